@@ -6,6 +6,7 @@ require (
 	github.com/charmbracelet/bubbletea v0.25.0
 	github.com/charmbracelet/lipgloss v0.9.1
 	gopkg.in/yaml.v3 v3.0.1
+	money v0.0.0
 )
 
 require (
@@ -25,3 +26,5 @@ require (
 	golang.org/x/term v0.6.0 // indirect
 	golang.org/x/text v0.3.8 // indirect
 )
+
+replace money => ../../PROGRAMS/money
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"money"
+)
 
 // APIResponse is the standard response wrapper from the API
 type APIResponse struct {
@@ -45,64 +49,78 @@ type TechTip struct {
 
 // Asset represents a financial asset
 type Asset struct {
-	ID            int       `json:"id"`
-	Name          string    `json:"name"`
-	Category      string    `json:"category"`
-	CurrentValue  float64   `json:"current_value"`
-	PurchasePrice float64   `json:"purchase_price"`
-	PurchaseDate  string    `json:"purchase_date"`
-	Notes         string    `json:"notes,omitempty"`
-	IsRemoved     bool      `json:"is_removed"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID            int         `json:"id"`
+	Name          string      `json:"name"`
+	Category      string      `json:"category"`
+	CurrentValue  money.Cents `json:"current_value"`
+	Currency      string      `json:"currency"`
+	PurchasePrice money.Cents `json:"purchase_price"`
+	PurchaseDate  string      `json:"purchase_date"`
+	Notes         string      `json:"notes,omitempty"`
+	IsRemoved     bool        `json:"is_removed"`
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
 }
 
 // Liability represents a financial liability
 type Liability struct {
-	ID             int       `json:"id"`
-	Name           string    `json:"name"`
-	LiabilityType  string    `json:"liability_type"`
-	CurrentBalance float64   `json:"current_balance"`
-	CreditLimit    float64   `json:"credit_limit,omitempty"`
-	InterestRate   float64   `json:"interest_rate,omitempty"`
-	MinimumPayment float64   `json:"minimum_payment,omitempty"`
-	CreditorName   string    `json:"creditor_name,omitempty"`
-	Notes          string    `json:"notes,omitempty"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	ID             int         `json:"id"`
+	Name           string      `json:"name"`
+	LiabilityType  string      `json:"liability_type"`
+	CurrentBalance money.Cents `json:"current_balance"`
+	Currency       string      `json:"currency"`
+	CreditLimit    money.Cents `json:"credit_limit,omitempty"`
+	InterestRate   float64     `json:"interest_rate,omitempty"`
+	MinimumPayment money.Cents `json:"minimum_payment,omitempty"`
+	CreditorName   string      `json:"creditor_name,omitempty"`
+	Notes          string      `json:"notes,omitempty"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
 }
 
 // FinancialOverview represents the complete financial snapshot
 type FinancialOverview struct {
-	TotalAssets      float64   `json:"total_assets"`
-	TotalLiabilities float64   `json:"total_liabilities"`
-	NetWorth         float64   `json:"net_worth"`
-	AssetCount       int       `json:"asset_count"`
-	LiabilityCount   int       `json:"liability_count"`
-	Timestamp        time.Time `json:"timestamp"`
+	TotalAssets      money.Cents `json:"total_assets"`
+	TotalLiabilities money.Cents `json:"total_liabilities"`
+	NetWorth         money.Cents `json:"net_worth"`
+	AssetCount       int         `json:"asset_count"`
+	LiabilityCount   int         `json:"liability_count"`
+	Timestamp        time.Time   `json:"timestamp"`
+
+	// Totals are in BaseCurrency; amounts without an exchange rate are listed separately
+	BaseCurrency           string              `json:"base_currency"`
+	UnconvertedAssets      []UnconvertedAmount `json:"unconverted_assets,omitempty"`
+	UnconvertedLiabilities []UnconvertedAmount `json:"unconverted_liabilities,omitempty"`
+}
+
+// UnconvertedAmount represents amounts in a currency the gateway had no exchange rate for
+type UnconvertedAmount struct {
+	Currency string      `json:"currency"`
+	Amount   money.Cents `json:"amount"`
+	Count    int         `json:"count"`
 }
 
 // Transaction represents a bank transaction
 type Transaction struct {
-	ID          int       `json:"id"`
-	Date        string    `json:"date"`
-	Description string    `json:"description"`
-	Amount      float64   `json:"amount"`
-	Category    string    `json:"category"`
-	Merchant    string    `json:"merchant,omitempty"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID          int         `json:"id"`
+	Date        string      `json:"date"`
+	Description string      `json:"description"`
+	Amount      money.Cents `json:"amount"`
+	Category    string      `json:"category"`
+	Merchant    string      `json:"merchant,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
 }
 
 // AssetSummary represents asset summary by category
 type AssetSummary struct {
 	Category     string  `json:"category"`
-	TotalValue   float64 `json:"total_value"`
+	TotalValue   money.Cents `json:"total_value"`
 	AssetCount   int     `json:"asset_count"`
 }
 
 // LiabilitySummary represents liability summary by type
 type LiabilitySummary struct {
-	LiabilityType  string  `json:"liability_type"`
-	TotalBalance   float64 `json:"total_balance"`
-	LiabilityCount int     `json:"liability_count"`
+	LiabilityType  string      `json:"liability_type"`
+	TotalBalance   money.Cents `json:"total_balance"`
+	LiabilityCount int         `json:"liability_count"`
 }
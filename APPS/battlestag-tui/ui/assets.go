@@ -130,13 +130,13 @@ func (m *AssetsModel) View() string {
 		}
 
 		gain := asset.CurrentValue - asset.PurchasePrice
-		gainStr := FormatCurrency(gain)
+		gainStr := FormatCurrencyIn(gain, asset.Currency)
 
 		row := fmt.Sprintf("%-20s %-15s %15s %15s %12s",
 			truncate(asset.Name, 20),
 			truncate(asset.Category, 15),
-			fmt.Sprintf("$%.2f", asset.CurrentValue),
-			fmt.Sprintf("$%.2f", asset.PurchasePrice),
+			FormatAmount(asset.CurrentValue, asset.Currency),
+			FormatAmount(asset.PurchasePrice, asset.Currency),
 			gainStr,
 		)
 
@@ -149,20 +149,20 @@ func (m *AssetsModel) View() string {
 
 	table := strings.Join(rows, "\n")
 
-	// Calculate totals
-	var totalValue, totalCost float64
+	// Calculate totals, per currency since values aren't converted here
+	totalValue, totalCost, totalGain := newCurrencyTotals(), newCurrencyTotals(), newCurrencyTotals()
 	for _, asset := range m.assets {
 		if !asset.IsRemoved {
-			totalValue += asset.CurrentValue
-			totalCost += asset.PurchasePrice
+			totalValue.add(asset.Currency, asset.CurrentValue)
+			totalCost.add(asset.Currency, asset.PurchasePrice)
+			totalGain.add(asset.Currency, asset.CurrentValue-asset.PurchasePrice)
 		}
 	}
-	totalGain := totalValue - totalCost
 
 	summary := fmt.Sprintf("\nTotal Value: %s | Total Cost: %s | Total Gain: %s",
-		FormatCurrency(totalValue),
-		FormatCurrency(totalCost),
-		FormatCurrency(totalGain),
+		totalValue.format(FormatCurrencyIn),
+		totalCost.format(FormatCurrencyIn),
+		totalGain.format(FormatCurrencyIn),
 	)
 
 	help := helpStyle.Render("\n[↑/↓] Navigate • [d] Delete • [r] Refresh")
@@ -143,13 +143,21 @@ Net Worth:         %s
 Asset Count:       %d
 Liability Count:   %d
 `,
-		FormatCurrency(m.overview.TotalAssets),
-		FormatCurrency(m.overview.TotalLiabilities),
-		netWorthColor.Render(fmt.Sprintf("$%.2f", netWorth)),
+		FormatCurrencyIn(m.overview.TotalAssets, m.overview.BaseCurrency),
+		FormatCurrencyIn(m.overview.TotalLiabilities, m.overview.BaseCurrency),
+		netWorthColor.Render(FormatAmount(netWorth, m.overview.BaseCurrency)),
 		m.overview.AssetCount,
 		m.overview.LiabilityCount,
 	)
 
+	// Amounts the gateway had no exchange rate for aren't in the totals above
+	for _, u := range m.overview.UnconvertedAssets {
+		content += fmt.Sprintf("Not converted:     %s of assets (%d)\n", FormatAmount(u.Amount, u.Currency), u.Count)
+	}
+	for _, u := range m.overview.UnconvertedLiabilities {
+		content += fmt.Sprintf("Not converted:     %s of liabilities (%d)\n", FormatAmount(u.Amount, u.Currency), u.Count)
+	}
+
 	return boxStyle.Render(title + content)
 }
 
@@ -126,7 +126,7 @@ func (m *LiabilitiesModel) View() string {
 	for i, liability := range m.liabilities {
 		limitStr := "-"
 		if liability.CreditLimit > 0 {
-			limitStr = fmt.Sprintf("$%.2f", liability.CreditLimit)
+			limitStr = FormatAmount(liability.CreditLimit, liability.Currency)
 		}
 
 		rateStr := "-"
@@ -137,7 +137,7 @@ func (m *LiabilitiesModel) View() string {
 		row := fmt.Sprintf("%-20s %-15s %15s %15s %10s",
 			truncate(liability.Name, 20),
 			truncate(liability.LiabilityType, 15),
-			fmt.Sprintf("$%.2f", liability.CurrentBalance),
+			FormatAmount(liability.CurrentBalance, liability.Currency),
 			limitStr,
 			rateStr,
 		)
@@ -151,25 +151,30 @@ func (m *LiabilitiesModel) View() string {
 
 	table := strings.Join(rows, "\n")
 
-	// Calculate totals
-	var totalBalance float64
-	var totalLimit float64
+	// Calculate totals, per currency since balances aren't converted here
+	totalBalance, totalLimit := newCurrencyTotals(), newCurrencyTotals()
 	for _, liability := range m.liabilities {
-		totalBalance += liability.CurrentBalance
+		totalBalance.add(liability.Currency, liability.CurrentBalance)
 		if liability.CreditLimit > 0 {
-			totalLimit += liability.CreditLimit
+			totalLimit.add(liability.Currency, liability.CreditLimit)
 		}
 	}
 
-	utilization := 0.0
-	if totalLimit > 0 {
-		utilization = (totalBalance / totalLimit) * 100
+	// Utilization compares balances with limits, so only when they are in a single currency
+	utilizationStr := "-"
+	if len(totalBalance.currencies) == 1 && len(totalLimit.currencies) == 1 {
+		currency := totalBalance.currencies[0]
+		if limit := totalLimit.sums[currency]; limit > 0 {
+			utilizationStr = FormatPercent(float64(totalBalance.sums[currency]) / float64(limit) * 100)
+		}
+	} else if len(totalLimit.currencies) == 0 {
+		utilizationStr = FormatPercent(0)
 	}
 
 	summary := fmt.Sprintf("\nTotal Balance: %s | Total Limit: %s | Utilization: %s",
-		negativeStyle.Render(fmt.Sprintf("$%.2f", totalBalance)),
-		fmt.Sprintf("$%.2f", totalLimit),
-		FormatPercent(utilization),
+		negativeStyle.Render(totalBalance.format(FormatAmount)),
+		totalLimit.format(FormatAmount),
+		utilizationStr,
 	)
 
 	help := helpStyle.Render("\n[↑/↓] Navigate • [d] Delete • [r] Refresh")
@@ -2,8 +2,11 @@ package ui
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+
+	"money"
 )
 
 var (
@@ -131,12 +134,59 @@ var (
 				Padding(0, 1)
 )
 
-// FormatCurrency formats a float as currency
-func FormatCurrency(amount float64) string {
+// FormatCurrency formats an amount as currency
+func FormatCurrency(amount money.Cents) string {
+	return FormatCurrencyIn(amount, "USD")
+}
+
+// FormatCurrencyIn formats an amount in the given currency with color
+func FormatCurrencyIn(amount money.Cents, currency string) string {
 	if amount >= 0 {
-		return positiveStyle.Render(fmt.Sprintf("$%.2f", amount))
+		return positiveStyle.Render(FormatAmount(amount, currency))
+	}
+	return negativeStyle.Render(FormatAmount(amount, currency))
+}
+
+// FormatAmount formats an amount in the given currency without color
+// US dollars keep the $ sign; other currencies are followed by their code
+func FormatAmount(amount money.Cents, currency string) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+	}
+	if currency == "" || currency == "USD" {
+		return fmt.Sprintf("%s$%s", sign, amount.Abs())
+	}
+	return fmt.Sprintf("%s%s %s", sign, amount.Abs(), currency)
+}
+
+// currencyTotals sums amounts per currency in the order the currencies first appear
+type currencyTotals struct {
+	currencies []string
+	sums       map[string]money.Cents
+}
+
+func newCurrencyTotals() *currencyTotals {
+	return &currencyTotals{sums: make(map[string]money.Cents)}
+}
+
+func (t *currencyTotals) add(currency string, amount money.Cents) {
+	if _, ok := t.sums[currency]; !ok {
+		t.currencies = append(t.currencies, currency)
+	}
+	t.sums[currency] += amount
+}
+
+// format joins the totals, one per currency, formatted by the given function
+func (t *currencyTotals) format(formatAmount func(money.Cents, string) string) string {
+	if len(t.currencies) == 0 {
+		return formatAmount(0, "")
+	}
+	parts := make([]string, len(t.currencies))
+	for i, currency := range t.currencies {
+		parts[i] = formatAmount(t.sums[currency], currency)
 	}
-	return negativeStyle.Render(fmt.Sprintf("-$%.2f", -amount))
+	return strings.Join(parts, " + ")
 }
 
 // FormatPercent formats a float as percentage with color
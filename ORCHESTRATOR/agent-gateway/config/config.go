@@ -3,17 +3,22 @@ package config
 import (
 	"fmt"
 	"os"
+	"regexp"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
 // Config represents the application configuration
 type Config struct {
-	Agents  AgentsConfig  `yaml:"agents"`
-	Server  ServerConfig  `yaml:"server"`
-	Auth    AuthConfig    `yaml:"auth"`
-	Logging LoggingConfig `yaml:"logging"`
-	LLM     LLMConfig     `yaml:"llm"`
+	Agents    AgentsConfig    `yaml:"agents"`
+	Server    ServerConfig    `yaml:"server"`
+	Auth      AuthConfig      `yaml:"auth"`
+	Logging   LoggingConfig   `yaml:"logging"`
+	LLM       LLMConfig       `yaml:"llm"`
+	Financial FinancialConfig `yaml:"financial"`
 }
 
 // AgentsConfig contains database paths for all agents
@@ -80,6 +85,13 @@ type LLMConfig struct {
 	SystemPrompt string `yaml:"system_prompt"` // System prompt for the assistant
 }
 
+// FinancialConfig contains settings shared by the financial endpoints
+type FinancialConfig struct {
+	// BaseCurrency is the currency totals are converted into with the exchange rates stored by
+	// the financial statement processor
+	BaseCurrency string `yaml:"base_currency"`
+}
+
 // Load reads configuration from file and environment variables
 func Load(configPath string) (*Config, error) {
 	cfg := &Config{
@@ -97,6 +109,9 @@ func Load(configPath string) (*Config, error) {
 			Timeout:      30,
 			SystemPrompt: "You are a helpful financial assistant helping manage personal finances.",
 		},
+		Financial: FinancialConfig{
+			BaseCurrency: "USD",
+		},
 	}
 
 	// Read config file if it exists
@@ -168,6 +183,10 @@ func Load(configPath string) (*Config, error) {
 	if llmPrompt := os.Getenv("LLM_SYSTEM_PROMPT"); llmPrompt != "" {
 		cfg.LLM.SystemPrompt = llmPrompt
 	}
+	if baseCurrency := os.Getenv("BASE_CURRENCY"); baseCurrency != "" {
+		cfg.Financial.BaseCurrency = baseCurrency
+	}
+	cfg.Financial.BaseCurrency = strings.ToUpper(strings.TrimSpace(cfg.Financial.BaseCurrency))
 
 	// Validate required fields
 	if cfg.Auth.APIKey == "" {
@@ -188,6 +207,9 @@ func Load(configPath string) (*Config, error) {
 	if cfg.Agents.FinancialLiability.ExecutablePath == "" {
 		return nil, fmt.Errorf("Financial Liability executable path is required")
 	}
+	if !currencyCode.MatchString(cfg.Financial.BaseCurrency) {
+		return nil, fmt.Errorf("base currency must be a 3-letter currency code, got: %s", cfg.Financial.BaseCurrency)
+	}
 
 	return cfg, nil
 }
@@ -3,12 +3,15 @@ package db
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 
 	"agent-gateway/config"
 	"agent-gateway/models"
+
+	"money"
 )
 
 // Manager manages connections to all agent databases
@@ -379,7 +382,9 @@ func (m *Manager) GetTechAll(page, pageSize int) (*models.PaginatedResponse, err
 // Financial Database Operations
 
 // GetTransactionSummary gets aggregated transaction data
-func (m *Manager) GetTransactionSummary(startDate, endDate string) (*models.TransactionSummary, error) {
+// With excludeTransfers set, both sides of linked transfers between accounts are left out
+// Amounts are converted into the base currency with the rate of their transaction date
+func (m *Manager) GetTransactionSummary(startDate, endDate string, excludeTransfers bool) (*models.TransactionSummary, error) {
 	if m.financialStatementDB == nil {
 		return nil, fmt.Errorf("financial statement database not available")
 	}
@@ -388,143 +393,402 @@ func (m *Manager) GetTransactionSummary(startDate, endDate string) (*models.Tran
 		StartDate:    startDate,
 		EndDate:      endDate,
 		CountByType:  make(map[string]int),
-		AmountByType: make(map[string]float64),
+		AmountByType: make(map[string]money.Cents),
+	}
+
+	where := `transaction_date >= ? AND transaction_date <= ?`
+	if excludeTransfers {
+		hasTransfers, err := hasTable(m.financialStatementDB, "transfers")
+		if err != nil {
+			return nil, err
+		}
+		if hasTransfers {
+			where += ` AND id NOT IN (
+				SELECT from_transaction_id FROM transfers WHERE status = 'linked'
+				UNION SELECT to_transaction_id FROM transfers WHERE status = 'linked')`
+		}
 	}
 
-	// Get total amount and count
-	query := `SELECT COALESCE(SUM(amount), 0), COUNT(*) FROM transactions WHERE date >= ? AND date <= ?`
-	err := m.financialStatementDB.QueryRow(query, startDate, endDate).Scan(&summary.TotalAmount, &summary.TotalCount)
+	// Databases from before merchant normalization and currencies have neither column
+	hasMerchants, err := hasColumn(m.financialStatementDB, "transactions", "merchant_name")
 	if err != nil {
-		return nil, fmt.Errorf("failed to get transaction totals: %w", err)
+		return nil, err
+	}
+	merchant := `''`
+	if hasMerchants {
+		merchant = `COALESCE(NULLIF(merchant_name, ''), description)`
+		summary.CountByMerchant = make(map[string]int)
+		summary.AmountByMerchant = make(map[string]money.Cents)
+	}
+	hasCurrency, err := hasColumn(m.financialStatementDB, "transactions", "currency")
+	if err != nil {
+		return nil, err
+	}
+	amount, err := centsColumn(m.financialStatementDB, "transactions", "amount", "")
+	if err != nil {
+		return nil, err
+	}
+
+	converter, err := m.currencyConverter()
+	if err != nil {
+		return nil, err
 	}
+	summary.BaseCurrency = converter.base
 
-	// Get counts by type
 	rows, err := m.financialStatementDB.Query(
-		`SELECT transaction_type, COUNT(*), SUM(amount) FROM transactions
-		 WHERE date >= ? AND date <= ?
-		 GROUP BY transaction_type`,
+		`SELECT transaction_type, `+merchant+`, `+currencyColumn(hasCurrency)+`, substr(transaction_date, 1, 10), `+amount+`
+		 FROM transactions
+		 WHERE `+where,
 		startDate, endDate,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get transaction breakdown: %w", err)
+		return nil, fmt.Errorf("failed to get transaction totals: %w", err)
 	}
 	defer rows.Close()
 
+	// Every transaction is counted; amounts without an exchange rate are left out of the totals
+	unconverted := make(unconvertedAmounts)
 	for rows.Next() {
-		var txnType string
-		var count int
-		var amount float64
-		if err := rows.Scan(&txnType, &count, &amount); err != nil {
-			return nil, fmt.Errorf("failed to scan transaction breakdown: %w", err)
+		var txnType, merchantName, currency, date string
+		var amount money.Cents
+		if err := rows.Scan(&txnType, &merchantName, &currency, &date, &amount); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
 		}
-		summary.CountByType[txnType] = count
-		summary.AmountByType[txnType] = amount
+		summary.TotalCount++
+		summary.CountByType[txnType]++
+		if hasMerchants {
+			summary.CountByMerchant[merchantName]++
+		}
+
+		converted, ok := converter.convert(amount, currency, date)
+		if !ok {
+			unconverted.add(currency, amount, 1)
+			continue
+		}
+		summary.TotalAmount += converted
+		summary.AmountByType[txnType] += converted
+		if hasMerchants {
+			summary.AmountByMerchant[merchantName] += converted
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
+	summary.Unconverted = unconverted.list()
+
 	return summary, nil
 }
 
+// GetRecurringSeries gets the recurring charges found by the statement processor
+// status is "active", "stopped" or "all"; flag optionally limits to series flagged "new" or "price_changed"
+func (m *Manager) GetRecurringSeries(status, flag string) ([]models.RecurringSeries, error) {
+	if m.financialStatementDB == nil {
+		return nil, fmt.Errorf("financial statement database not available")
+	}
+
+	series := []models.RecurringSeries{}
+	hasSeries, err := hasTable(m.financialStatementDB, "recurring_series")
+	if err != nil || !hasSeries {
+		return series, err
+	}
+
+	amounts := make([]string, 3)
+	for i, column := range []string{"typical_amount", "last_amount", "amount_drift"} {
+		if amounts[i], err = centsColumn(m.financialStatementDB, "recurring_series", column, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	query := `SELECT id, merchant_key, description, account_name, account_last4, cadence, interval_days,
+		occurrences, first_date, last_date, next_expected_date, ` + strings.Join(amounts, ", ") + `,
+		status, flags, detected_at
+		FROM recurring_series WHERE 1 = 1`
+	var args []interface{}
+	if status != "all" {
+		query += ` AND status = ?`
+		args = append(args, status)
+	}
+	if flag != "" {
+		query += ` AND (',' || flags || ',') LIKE ?`
+		args = append(args, "%,"+flag+",%")
+	}
+	query += ` ORDER BY next_expected_date, merchant_key`
+
+	rows, err := m.financialStatementDB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recurring series: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s models.RecurringSeries
+		var flags string
+		if err := rows.Scan(&s.ID, &s.MerchantKey, &s.Description, &s.AccountName, &s.AccountLast4, &s.Cadence,
+			&s.IntervalDays, &s.Occurrences, &s.FirstDate, &s.LastDate, &s.NextExpectedDate, &s.TypicalAmount,
+			&s.LastAmount, &s.AmountDrift, &s.Status, &flags, &s.DetectedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan recurring series: %w", err)
+		}
+		s.Flags = []string{}
+		if flags != "" {
+			s.Flags = strings.Split(flags, ",")
+		}
+		series = append(series, s)
+	}
+
+	return series, rows.Err()
+}
+
+// GetAccounts gets the accounts registered by the statement processor, with their aliases
+func (m *Manager) GetAccounts() ([]models.Account, error) {
+	if m.financialStatementDB == nil {
+		return nil, fmt.Errorf("financial statement database not available")
+	}
+
+	accounts := []models.Account{}
+	hasAccounts, err := hasTable(m.financialStatementDB, "accounts")
+	if err != nil || !hasAccounts {
+		return accounts, err
+	}
+
+	rows, err := m.financialStatementDB.Query(`SELECT id, COALESCE(institution, ''), nickname,
+		COALESCE(account_type, ''), last4, currency, opened_on, closed_on, created_at, updated_at,
+		(SELECT COUNT(*) FROM transactions WHERE transactions.account_id = accounts.id)
+		FROM accounts
+		ORDER BY closed_on IS NOT NULL, nickname, last4`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query accounts: %w", err)
+	}
+	defer rows.Close()
+
+	index := make(map[int64]int)
+	for rows.Next() {
+		a := models.Account{Aliases: []models.AccountAlias{}}
+		var openedOn, closedOn sql.NullTime
+		if err := rows.Scan(&a.ID, &a.Institution, &a.Nickname, &a.AccountType, &a.Last4, &a.Currency,
+			&openedOn, &closedOn, &a.CreatedAt, &a.UpdatedAt, &a.Transactions); err != nil {
+			return nil, fmt.Errorf("failed to scan account: %w", err)
+		}
+		if openedOn.Valid {
+			a.OpenedOn = &openedOn.Time
+		}
+		if closedOn.Valid {
+			a.ClosedOn = &closedOn.Time
+		}
+		index[a.ID] = len(accounts)
+		accounts = append(accounts, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	aliasRows, err := m.financialStatementDB.Query(`SELECT id, account_id, name, last4 FROM account_aliases ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query account aliases: %w", err)
+	}
+	defer aliasRows.Close()
+
+	for aliasRows.Next() {
+		var alias models.AccountAlias
+		var accountID int64
+		if err := aliasRows.Scan(&alias.ID, &accountID, &alias.Name, &alias.Last4); err != nil {
+			return nil, fmt.Errorf("failed to scan account alias: %w", err)
+		}
+		if i, ok := index[accountID]; ok {
+			accounts[i].Aliases = append(accounts[i].Aliases, alias)
+		}
+	}
+
+	return accounts, aliasRows.Err()
+}
+
+// hasTable reports whether a table exists, for features older databases may not have yet
+func hasTable(conn *sql.DB, name string) (bool, error) {
+	var count int
+	err := conn.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, name).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check for %s table: %w", name, err)
+	}
+	return count > 0, nil
+}
+
+// hasColumn reports whether a table has a column, for columns older databases may not have yet
+func hasColumn(conn *sql.DB, table, column string) (bool, error) {
+	var count int
+	err := conn.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check for %s.%s column: %w", table, column, err)
+	}
+	return count > 0, nil
+}
+
+// centsColumn returns the expression selecting a money column in cents: databases from before the
+// programs stored integer cents have the amount in currency units in a REAL column
+func centsColumn(conn *sql.DB, table, column, prefix string) (string, error) {
+	var columnType string
+	err := conn.QueryRow(`SELECT type FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&columnType)
+	if err != nil {
+		return "", fmt.Errorf("failed to check the type of %s.%s column: %w", table, column, err)
+	}
+	if strings.EqualFold(columnType, "INTEGER") {
+		return prefix + column, nil
+	}
+	return "CAST(ROUND(" + prefix + column + " * 100) AS INTEGER)", nil
+}
+
 // GetAssetSummary gets aggregated asset data
+// Values are converted into the base currency with the latest exchange rate
 func (m *Manager) GetAssetSummary() (*models.AssetSummary, error) {
 	if m.financialAssetDB == nil {
 		return nil, fmt.Errorf("financial asset database not available")
 	}
 
-	summary := &models.AssetSummary{
-		CountByCategory: make(map[string]int),
-		ValueByCategory: make(map[string]float64),
+	converter, err := m.currencyConverter()
+	if err != nil {
+		return nil, err
 	}
-
-	// Get total value and count (excluding removed assets)
-	query := `SELECT COALESCE(SUM(current_value), 0), COUNT(*) FROM assets WHERE is_removed = 0`
-	err := m.financialAssetDB.QueryRow(query).Scan(&summary.TotalValue, &summary.TotalCount)
+	hasCurrency, err := hasColumn(m.financialAssetDB, "assets", "currency")
 	if err != nil {
-		return nil, fmt.Errorf("failed to get asset totals: %w", err)
+		return nil, err
+	}
+	value, err := centsColumn(m.financialAssetDB, "assets", "current_value", "")
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.AssetSummary{
+		CountByCategory: make(map[string]int),
+		ValueByCategory: make(map[string]money.Cents),
+		BaseCurrency:    converter.base,
 	}
 
-	// Get breakdown by category
+	// Get breakdown by category and currency (excluding removed assets)
 	rows, err := m.financialAssetDB.Query(
-		`SELECT category, COUNT(*), SUM(current_value) FROM assets
+		`SELECT category, ` + currencyColumn(hasCurrency) + `, COUNT(*), SUM(` + value + `) FROM assets
 		 WHERE is_removed = 0
-		 GROUP BY category`,
+		 GROUP BY category, ` + currencyColumn(hasCurrency),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get asset breakdown: %w", err)
 	}
 	defer rows.Close()
 
+	unconverted := make(unconvertedAmounts)
 	for rows.Next() {
-		var category string
+		var category, currency string
 		var count int
-		var value float64
-		if err := rows.Scan(&category, &count, &value); err != nil {
+		var value money.Cents
+		if err := rows.Scan(&category, &currency, &count, &value); err != nil {
 			return nil, fmt.Errorf("failed to scan asset breakdown: %w", err)
 		}
-		summary.CountByCategory[category] = count
-		summary.ValueByCategory[category] = value
+		summary.TotalCount += count
+		summary.CountByCategory[category] += count
+
+		converted, ok := converter.convert(value, currency, "")
+		if !ok {
+			unconverted.add(currency, value, count)
+			continue
+		}
+		summary.TotalValue += converted
+		summary.ValueByCategory[category] += converted
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	summary.Unconverted = unconverted.list()
 
 	return summary, nil
 }
 
 // GetLiabilitySummary gets aggregated liability data
+// Balances are converted into the base currency with the latest exchange rate
 func (m *Manager) GetLiabilitySummary() (*models.LiabilitySummary, error) {
 	if m.financialLiabilityDB == nil {
 		return nil, fmt.Errorf("financial liability database not available")
 	}
 
-	summary := &models.LiabilitySummary{
-		CountByType:   make(map[string]int),
-		BalanceByType: make(map[string]float64),
+	converter, err := m.currencyConverter()
+	if err != nil {
+		return nil, err
 	}
-
-	// Get total balance and count
-	query := `SELECT COALESCE(SUM(current_balance), 0), COUNT(*) FROM liabilities`
-	err := m.financialLiabilityDB.QueryRow(query).Scan(&summary.TotalBalance, &summary.TotalCount)
+	hasCurrency, err := hasColumn(m.financialLiabilityDB, "liabilities", "currency")
+	if err != nil {
+		return nil, err
+	}
+	balance, err := centsColumn(m.financialLiabilityDB, "liabilities", "current_balance", "")
 	if err != nil {
-		return nil, fmt.Errorf("failed to get liability totals: %w", err)
+		return nil, err
+	}
+
+	summary := &models.LiabilitySummary{
+		CountByType:   make(map[string]int),
+		BalanceByType: make(map[string]money.Cents),
+		BaseCurrency:  converter.base,
 	}
 
-	// Get breakdown by type
+	// Get breakdown by type and currency
 	rows, err := m.financialLiabilityDB.Query(
-		`SELECT liability_type, COUNT(*), SUM(current_balance) FROM liabilities
-		 GROUP BY liability_type`,
+		`SELECT liability_type, ` + currencyColumn(hasCurrency) + `, COUNT(*), SUM(` + balance + `) FROM liabilities
+		 GROUP BY liability_type, ` + currencyColumn(hasCurrency),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get liability breakdown: %w", err)
 	}
 	defer rows.Close()
 
+	unconverted := make(unconvertedAmounts)
 	for rows.Next() {
-		var liabilityType string
+		var liabilityType, currency string
 		var count int
-		var balance float64
-		if err := rows.Scan(&liabilityType, &count, &balance); err != nil {
+		var balance money.Cents
+		if err := rows.Scan(&liabilityType, &currency, &count, &balance); err != nil {
 			return nil, fmt.Errorf("failed to scan liability breakdown: %w", err)
 		}
-		summary.CountByType[liabilityType] = count
-		summary.BalanceByType[liabilityType] = balance
+		summary.TotalCount += count
+		summary.CountByType[liabilityType] += count
+
+		converted, ok := converter.convert(balance, currency, "")
+		if !ok {
+			unconverted.add(currency, balance, count)
+			continue
+		}
+		summary.TotalBalance += converted
+		summary.BalanceByType[liabilityType] += converted
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
+	summary.Unconverted = unconverted.list()
 
 	return summary, nil
 }
 
 // GetFinancialOverview gets a complete financial snapshot
+// Totals are in the base currency; assets and liabilities without an exchange rate are listed
+// separately and left out of the net worth
 func (m *Manager) GetFinancialOverview() (*models.FinancialOverview, error) {
 	overview := &models.FinancialOverview{
-		Timestamp: time.Now(),
+		Timestamp:    time.Now(),
+		BaseCurrency: m.BaseCurrency(),
 	}
 
 	// Get asset totals
 	if m.financialAssetDB != nil {
-		query := `SELECT COALESCE(SUM(current_value), 0), COUNT(*) FROM assets WHERE is_removed = 0`
-		m.financialAssetDB.QueryRow(query).Scan(&overview.TotalAssets, &overview.AssetCount)
+		if assets, err := m.GetAssetSummary(); err == nil {
+			overview.TotalAssets = assets.TotalValue
+			overview.AssetCount = assets.TotalCount
+			overview.UnconvertedAssets = assets.Unconverted
+		}
 	}
 
 	// Get liability totals
 	if m.financialLiabilityDB != nil {
-		query := `SELECT COALESCE(SUM(current_balance), 0), COUNT(*) FROM liabilities`
-		m.financialLiabilityDB.QueryRow(query).Scan(&overview.TotalLiabilities, &overview.LiabilityCount)
+		if liabilities, err := m.GetLiabilitySummary(); err == nil {
+			overview.TotalLiabilities = liabilities.TotalBalance
+			overview.LiabilityCount = liabilities.TotalCount
+			overview.UnconvertedLiabilities = liabilities.Unconverted
+		}
 	}
 
 	// Calculate net worth
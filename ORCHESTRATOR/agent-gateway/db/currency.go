@@ -0,0 +1,121 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+
+	"agent-gateway/models"
+
+	"money"
+)
+
+// defaultCurrency is the currency of amounts stored before the programs recorded one
+const defaultCurrency = "USD"
+
+// exchangeRate is the value of one unit of a currency in the base currency from a date on
+type exchangeRate struct {
+	date string // YYYY-MM-DD
+	rate float64
+}
+
+// currencyConverter converts amounts into the base currency with the exchange rates stored by
+// the financial statement processor
+type currencyConverter struct {
+	base  string
+	rates map[string][]exchangeRate // by currency, oldest first
+}
+
+// BaseCurrency returns the configured currency totals are reported in
+func (m *Manager) BaseCurrency() string {
+	if m.config == nil || m.config.Financial.BaseCurrency == "" {
+		return defaultCurrency
+	}
+	return m.config.Financial.BaseCurrency
+}
+
+// currencyConverter loads the exchange rates into the base currency
+// Without the financial statement database or its exchange_rates table only amounts already in
+// the base currency can be converted
+func (m *Manager) currencyConverter() (*currencyConverter, error) {
+	c := &currencyConverter{base: m.BaseCurrency(), rates: make(map[string][]exchangeRate)}
+	if m.financialStatementDB == nil {
+		return c, nil
+	}
+	hasRates, err := hasTable(m.financialStatementDB, "exchange_rates")
+	if err != nil || !hasRates {
+		return c, err
+	}
+
+	rows, err := m.financialStatementDB.Query(
+		`SELECT currency, substr(rate_date, 1, 10), rate FROM exchange_rates
+		 WHERE base_currency = ?
+		 ORDER BY currency, rate_date`,
+		c.base,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query exchange rates: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var currency string
+		var r exchangeRate
+		if err := rows.Scan(&currency, &r.date, &r.rate); err != nil {
+			return nil, fmt.Errorf("failed to scan exchange rate: %w", err)
+		}
+		c.rates[currency] = append(c.rates[currency], r)
+	}
+
+	return c, rows.Err()
+}
+
+// convert converts an amount into the base currency with the latest rate on or before date
+// (YYYY-MM-DD), or the latest rate of all when date is empty; ok is false without such a rate
+func (c *currencyConverter) convert(amount money.Cents, currency, date string) (money.Cents, bool) {
+	if currency == "" || currency == c.base {
+		return amount, true
+	}
+	rates := c.rates[currency]
+	i := len(rates)
+	if date != "" {
+		// Index of the first rate after the date
+		i = sort.Search(len(rates), func(i int) bool { return rates[i].date > date })
+	}
+	if i == 0 {
+		return 0, false
+	}
+	return amount.Mul(rates[i-1].rate), true
+}
+
+// unconvertedAmounts sums the amounts per currency that could not be converted
+type unconvertedAmounts map[string]*models.UnconvertedAmount
+
+func (u unconvertedAmounts) add(currency string, amount money.Cents, count int) {
+	if u[currency] == nil {
+		u[currency] = &models.UnconvertedAmount{Currency: currency}
+	}
+	u[currency].Amount += amount
+	u[currency].Count += count
+}
+
+// list returns the amounts ordered by currency; nil when everything was converted
+func (u unconvertedAmounts) list() []models.UnconvertedAmount {
+	if len(u) == 0 {
+		return nil
+	}
+	result := make([]models.UnconvertedAmount, 0, len(u))
+	for _, amount := range u {
+		result = append(result, *amount)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Currency < result[j].Currency })
+	return result
+}
+
+// currencyColumn returns the currency column of a table, or the default currency for databases
+// from before the programs recorded one
+func currencyColumn(hasCurrency bool) string {
+	if hasCurrency {
+		return "currency"
+	}
+	return "'" + defaultCurrency + "'"
+}
@@ -67,7 +67,7 @@ build_application() {
     go mod download
 
     # Build
-    go build -ldflags="-s -w" -o "$BINARY_NAME" .
+    go build -tags sqlite_fts5 -ldflags="-s -w" -o "$BINARY_NAME" .
 
     if [[ ! -f "$BINARY_NAME" ]]; then
         print_error "Build failed"
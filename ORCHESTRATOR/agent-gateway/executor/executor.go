@@ -5,10 +5,13 @@ import (
 	"fmt"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"agent-gateway/models"
+
+	"money"
 )
 
 // Executor runs agent programs and parses their output
@@ -170,7 +173,7 @@ func (e *Executor) ProcessPDF(pdfPath string, accountType string, force bool) (*
 }
 
 // QueryTransactions queries transactions with filters
-func (e *Executor) QueryTransactions(startDate, endDate, txnType, merchant string) ([]models.Transaction, error) {
+func (e *Executor) QueryTransactions(startDate, endDate, txnType, merchant, category string) ([]models.Transaction, error) {
 	args := []string{"query"}
 	if startDate != "" {
 		args = append(args, "--start-date", startDate)
@@ -184,6 +187,9 @@ func (e *Executor) QueryTransactions(startDate, endDate, txnType, merchant strin
 	if merchant != "" {
 		args = append(args, "--merchant", merchant)
 	}
+	if category != "" {
+		args = append(args, "--category", category)
+	}
 
 	cmd := exec.Command(e.financialStatementPath, args...)
 	cmd.Dir = filepath.Dir(e.financialStatementPath)
@@ -200,6 +206,674 @@ func (e *Executor) QueryTransactions(startDate, endDate, txnType, merchant strin
 	return queryOut.Transactions, nil
 }
 
+// SearchTransactions finds transactions whose description, merchant, account, source file or notes
+// contain every word of the query, best match first, with the statement processor's search
+// Returns the results and the search mode used ("fts5" or "like")
+func (e *Executor) SearchTransactions(query, startDate, endDate, txnType, account string, limit int) ([]models.SearchResult, string, error) {
+	args := []string{"search", "--limit", strconv.Itoa(limit)}
+	if startDate != "" {
+		args = append(args, "--start-date", startDate)
+	}
+	if endDate != "" {
+		args = append(args, "--end-date", endDate)
+	}
+	if txnType != "" {
+		args = append(args, "--type", txnType)
+	}
+	if account != "" {
+		args = append(args, "--account", account)
+	}
+	args = append(args, "--", query)
+
+	cmd := exec.Command(e.financialStatementPath, args...)
+	cmd.Dir = filepath.Dir(e.financialStatementPath)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to search transactions: %w (output: %s)", err, string(output))
+	}
+
+	var result struct {
+		Mode    string `json:"mode"`
+		Results []struct {
+			Transaction struct {
+				ID              int64       `json:"id"`
+				AccountName     string      `json:"account_name"`
+				AccountLast4    string      `json:"account_last4"`
+				TransactionDate time.Time   `json:"transaction_date"`
+				Description     string      `json:"description"`
+				Amount          money.Cents `json:"amount"`
+				TransactionType string      `json:"transaction_type"`
+				Category        string      `json:"category"`
+				MerchantName    string      `json:"merchant_name"`
+				Notes           string      `json:"notes"`
+			} `json:"transaction"`
+			Score   float64 `json:"score"`
+			Snippet string  `json:"snippet"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, "", fmt.Errorf("failed to parse search output: %w (output: %s)", err, string(output))
+	}
+
+	results := make([]models.SearchResult, 0, len(result.Results))
+	for _, r := range result.Results {
+		t := r.Transaction
+		results = append(results, models.SearchResult{
+			ID:              t.ID,
+			AccountName:     t.AccountName,
+			AccountLast4:    t.AccountLast4,
+			Date:            t.TransactionDate.Format("2006-01-02"),
+			Description:     t.Description,
+			Amount:          t.Amount,
+			TransactionType: t.TransactionType,
+			Category:        t.Category,
+			Merchant:        t.MerchantName,
+			Notes:           t.Notes,
+			Score:           r.Score,
+			Snippet:         r.Snippet,
+		})
+	}
+
+	return results, result.Mode, nil
+}
+
+// GetTransaction returns a stored transaction as the statement processor reports it
+func (e *Executor) GetTransaction(id int64) (json.RawMessage, error) {
+	args := []string{"transactions", "show", "--id", strconv.FormatInt(id, 10)}
+	return e.runTransactionCommand("get", args)
+}
+
+// AddTransaction stores a transaction entered by hand
+func (e *Executor) AddTransaction(req *models.AddTransactionRequest) (json.RawMessage, error) {
+	args := []string{"transactions", "add",
+		"--account", req.AccountName,
+		"--date", req.TransactionDate,
+		"--description", req.Description,
+		"--amount", req.Amount.String(),
+	}
+	if req.AccountLast4 != "" {
+		args = append(args, "--last4", req.AccountLast4)
+	}
+	if req.Currency != "" {
+		args = append(args, "--currency", req.Currency)
+	}
+	if req.TransactionType != "" {
+		args = append(args, "--type", req.TransactionType)
+	}
+	if req.Category != "" {
+		args = append(args, "--category", req.Category)
+	}
+	if req.Notes != "" {
+		args = append(args, "--notes", req.Notes)
+	}
+
+	return e.runTransactionCommand("add", args)
+}
+
+// EditTransaction corrects a stored transaction
+func (e *Executor) EditTransaction(id int64, req *models.EditTransactionRequest) (json.RawMessage, error) {
+	args := []string{"transactions", "edit", "--id", strconv.FormatInt(id, 10)}
+	if req.TransactionDate != nil {
+		args = append(args, "--date", *req.TransactionDate)
+	}
+	if req.Description != nil {
+		args = append(args, "--description", *req.Description)
+	}
+	if req.Amount != nil {
+		args = append(args, "--amount", req.Amount.String())
+	}
+	if req.Currency != nil {
+		args = append(args, "--currency", *req.Currency)
+	}
+	if req.TransactionType != nil {
+		args = append(args, "--type", *req.TransactionType)
+	}
+	if req.Category != nil {
+		args = append(args, "--category", *req.Category)
+	}
+	if req.MerchantName != nil {
+		args = append(args, "--merchant", *req.MerchantName)
+	}
+	if req.Notes != nil {
+		args = append(args, "--notes", *req.Notes)
+	}
+
+	return e.runTransactionCommand("edit", args)
+}
+
+// DeleteTransaction removes a stored transaction
+func (e *Executor) DeleteTransaction(id int64) error {
+	args := []string{"transactions", "delete", "--id", strconv.FormatInt(id, 10)}
+
+	cmd := exec.Command(e.financialStatementPath, args...)
+	cmd.Dir = filepath.Dir(e.financialStatementPath)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to delete transaction: %w (output: %s)", err, string(output))
+	}
+
+	var result struct {
+		Success bool `json:"success"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return fmt.Errorf("failed to parse transactions delete output: %w (output: %s)", err, string(output))
+	}
+
+	if !result.Success {
+		return fmt.Errorf("delete failed")
+	}
+
+	return nil
+}
+
+// SplitTransaction splits a transaction into lines that add up to it and returns the lines
+func (e *Executor) SplitTransaction(id int64, req *models.SplitTransactionRequest) ([]json.RawMessage, error) {
+	lines, err := json.Marshal(req.Lines)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode split lines: %w", err)
+	}
+	args := []string{"transactions", "split", "--id", strconv.FormatInt(id, 10), "--lines", string(lines)}
+
+	cmd := exec.Command(e.financialStatementPath, args...)
+	cmd.Dir = filepath.Dir(e.financialStatementPath)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to split transaction: %w (output: %s)", err, string(output))
+	}
+
+	var result struct {
+		Success      bool              `json:"success"`
+		Transactions []json.RawMessage `json:"transactions"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse transactions split output: %w (output: %s)", err, string(output))
+	}
+
+	return result.Transactions, nil
+}
+
+// runTransactionCommand runs a transactions show/add/edit command and returns the transaction
+func (e *Executor) runTransactionCommand(action string, args []string) (json.RawMessage, error) {
+	cmd := exec.Command(e.financialStatementPath, args...)
+	cmd.Dir = filepath.Dir(e.financialStatementPath)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to %s transaction: %w (output: %s)", action, err, string(output))
+	}
+
+	var result struct {
+		Transaction json.RawMessage `json:"transaction"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse transactions %s output: %w (output: %s)", action, err, string(output))
+	}
+
+	return result.Transaction, nil
+}
+
+// ListPendingTransactions lists transactions held in the review queue
+func (e *Executor) ListPendingTransactions(status, sourceFile string) ([]models.PendingTransaction, error) {
+	args := []string{"review", "list"}
+	if status != "" {
+		args = append(args, "--status", status)
+	}
+	if sourceFile != "" {
+		args = append(args, "--source", sourceFile)
+	}
+
+	cmd := exec.Command(e.financialStatementPath, args...)
+	cmd.Dir = filepath.Dir(e.financialStatementPath)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending transactions: %w (output: %s)", err, string(output))
+	}
+
+	var result struct {
+		PendingTransactions []models.PendingTransaction `json:"pending_transactions"`
+		Count               int                         `json:"count"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse review list output: %w (output: %s)", err, string(output))
+	}
+
+	return result.PendingTransactions, nil
+}
+
+// EditPendingTransaction corrects a transaction in the review queue
+func (e *Executor) EditPendingTransaction(id int64, req *models.EditPendingTransactionRequest) (*models.PendingTransaction, error) {
+	args := []string{"review", "edit", "--id", strconv.FormatInt(id, 10)}
+	if req.TransactionDate != nil {
+		args = append(args, "--date", *req.TransactionDate)
+	}
+	if req.Description != nil {
+		args = append(args, "--description", *req.Description)
+	}
+	if req.Amount != nil {
+		args = append(args, "--amount", req.Amount.String())
+	}
+	if req.TransactionType != nil {
+		args = append(args, "--type", *req.TransactionType)
+	}
+	if req.Category != nil {
+		args = append(args, "--category", *req.Category)
+	}
+
+	cmd := exec.Command(e.financialStatementPath, args...)
+	cmd.Dir = filepath.Dir(e.financialStatementPath)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to edit pending transaction: %w (output: %s)", err, string(output))
+	}
+
+	var result struct {
+		Success            bool                      `json:"success"`
+		PendingTransaction models.PendingTransaction `json:"pending_transaction"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse review edit output: %w (output: %s)", err, string(output))
+	}
+
+	return &result.PendingTransaction, nil
+}
+
+// ApprovePendingTransaction moves a transaction from the review queue into transactions
+// and returns the new transaction ID (nil if it was already stored)
+func (e *Executor) ApprovePendingTransaction(id int64) (*int64, error) {
+	args := []string{"review", "approve", "--id", strconv.FormatInt(id, 10)}
+
+	cmd := exec.Command(e.financialStatementPath, args...)
+	cmd.Dir = filepath.Dir(e.financialStatementPath)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to approve pending transaction: %w (output: %s)", err, string(output))
+	}
+
+	var result struct {
+		Success  bool `json:"success"`
+		Approved []struct {
+			ID            int64  `json:"id"`
+			TransactionID *int64 `json:"transaction_id"`
+		} `json:"approved"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse review approve output: %w (output: %s)", err, string(output))
+	}
+
+	if !result.Success || len(result.Approved) == 0 {
+		return nil, fmt.Errorf("approve failed")
+	}
+
+	return result.Approved[0].TransactionID, nil
+}
+
+// RejectPendingTransaction discards a transaction in the review queue
+func (e *Executor) RejectPendingTransaction(id int64, note string) error {
+	args := []string{"review", "reject", "--id", strconv.FormatInt(id, 10)}
+	if note != "" {
+		args = append(args, "--note", note)
+	}
+
+	cmd := exec.Command(e.financialStatementPath, args...)
+	cmd.Dir = filepath.Dir(e.financialStatementPath)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to reject pending transaction: %w (output: %s)", err, string(output))
+	}
+
+	var result struct {
+		Success bool `json:"success"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return fmt.Errorf("failed to parse review reject output: %w (output: %s)", err, string(output))
+	}
+
+	if !result.Success {
+		return fmt.Errorf("reject failed")
+	}
+
+	return nil
+}
+
+// GetBudgetStatus returns every budget's spent, remaining and projected amounts for a month (YYYY-MM)
+func (e *Executor) GetBudgetStatus(month string) ([]models.BudgetStatus, error) {
+	args := []string{"budgets", "status"}
+	if month != "" {
+		args = append(args, "--month", month)
+	}
+
+	cmd := exec.Command(e.financialStatementPath, args...)
+	cmd.Dir = filepath.Dir(e.financialStatementPath)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get budget status: %w (output: %s)", err, string(output))
+	}
+
+	var result struct {
+		Month   string                `json:"month"`
+		Budgets []models.BudgetStatus `json:"budgets"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse budget status output: %w (output: %s)", err, string(output))
+	}
+
+	return result.Budgets, nil
+}
+
+// AddAccount registers a bank or card account
+func (e *Executor) AddAccount(req *models.AddAccountRequest) (*models.Account, error) {
+	args := []string{"accounts", "add", "--nickname", req.Nickname}
+	if req.Institution != "" {
+		args = append(args, "--institution", req.Institution)
+	}
+	if req.AccountType != "" {
+		args = append(args, "--type", req.AccountType)
+	}
+	if req.Last4 != "" {
+		args = append(args, "--last4", req.Last4)
+	}
+	if req.Currency != "" {
+		args = append(args, "--currency", req.Currency)
+	}
+	if req.OpenedOn != "" {
+		args = append(args, "--opened", req.OpenedOn)
+	}
+	if req.ClosedOn != "" {
+		args = append(args, "--closed", req.ClosedOn)
+	}
+	for _, alias := range req.Aliases {
+		args = append(args, "--alias", alias)
+	}
+
+	return e.runAccountCommand("add", args)
+}
+
+// EditAccount changes an account
+func (e *Executor) EditAccount(id int64, req *models.EditAccountRequest) (*models.Account, error) {
+	args := []string{"accounts", "edit", "--id", strconv.FormatInt(id, 10)}
+	if req.Nickname != nil {
+		args = append(args, "--nickname", *req.Nickname)
+	}
+	if req.Institution != nil {
+		args = append(args, "--institution", *req.Institution)
+	}
+	if req.AccountType != nil {
+		args = append(args, "--type", *req.AccountType)
+	}
+	if req.Last4 != nil {
+		args = append(args, "--last4", *req.Last4)
+	}
+	if req.Currency != nil {
+		args = append(args, "--currency", *req.Currency)
+	}
+	if req.OpenedOn != nil {
+		args = append(args, "--opened", *req.OpenedOn)
+	}
+	if req.ClosedOn != nil {
+		args = append(args, "--closed", *req.ClosedOn)
+	}
+
+	return e.runAccountCommand("edit", args)
+}
+
+// AddAccountAlias records another spelling of an account's name on statements
+func (e *Executor) AddAccountAlias(id int64, req *models.AccountAliasRequest) (*models.Account, error) {
+	args := []string{"accounts", "alias", "--id", strconv.FormatInt(id, 10), "--name", req.Name}
+	if req.Last4 != "" {
+		args = append(args, "--last4", req.Last4)
+	}
+
+	return e.runAccountCommand("alias", args)
+}
+
+// MergeAccounts moves a duplicate account's transactions and aliases into another account
+func (e *Executor) MergeAccounts(intoID, fromID int64) (*models.Account, error) {
+	args := []string{"accounts", "merge",
+		"--into", strconv.FormatInt(intoID, 10),
+		"--from", strconv.FormatInt(fromID, 10),
+	}
+
+	return e.runAccountCommand("merge", args)
+}
+
+// DeleteAccount removes an account without transactions
+func (e *Executor) DeleteAccount(id int64) error {
+	args := []string{"accounts", "delete", "--id", strconv.FormatInt(id, 10)}
+
+	cmd := exec.Command(e.financialStatementPath, args...)
+	cmd.Dir = filepath.Dir(e.financialStatementPath)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to delete account: %w (output: %s)", err, string(output))
+	}
+
+	var result struct {
+		Success bool `json:"success"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return fmt.Errorf("failed to parse accounts delete output: %w (output: %s)", err, string(output))
+	}
+
+	if !result.Success {
+		return fmt.Errorf("delete failed")
+	}
+
+	return nil
+}
+
+// runAccountCommand runs an accounts add/edit/alias/merge command and returns the stored account
+func (e *Executor) runAccountCommand(action string, args []string) (*models.Account, error) {
+	cmd := exec.Command(e.financialStatementPath, args...)
+	cmd.Dir = filepath.Dir(e.financialStatementPath)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to %s account: %w (output: %s)", action, err, string(output))
+	}
+
+	var result struct {
+		Success bool           `json:"success"`
+		Account models.Account `json:"account"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse accounts %s output: %w (output: %s)", action, err, string(output))
+	}
+
+	return &result.Account, nil
+}
+
+// GetStatementCoverage gets the imported statements per account, with gaps and overdue statements
+// Returns the accounts and how many of them are overdue
+func (e *Executor) GetStatementCoverage(account string, overdueOnly bool, graceDays int) ([]models.StatementCoverage, int, error) {
+	args := []string{"coverage"}
+	if account != "" {
+		args = append(args, "--account", account)
+	}
+	if overdueOnly {
+		args = append(args, "--overdue")
+	}
+	if graceDays > 0 {
+		args = append(args, "--grace-days", strconv.Itoa(graceDays))
+	}
+
+	cmd := exec.Command(e.financialStatementPath, args...)
+	cmd.Dir = filepath.Dir(e.financialStatementPath)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get statement coverage: %w (output: %s)", err, string(output))
+	}
+
+	var result struct {
+		Accounts []models.StatementCoverage `json:"accounts"`
+		Overdue  int                        `json:"overdue"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse statement coverage output: %w (output: %s)", err, string(output))
+	}
+
+	return result.Accounts, result.Overdue, nil
+}
+
+// GetProcessingLog gets the processed statement files, newest first, with retry hints for failed imports
+// status is a processing status, "failed" for any error, or "all"; dates and file are optional
+func (e *Executor) GetProcessingLog(status, startDate, endDate, file string, limit int) ([]models.ProcessingLogEntry, *models.ProcessingLogSummary, error) {
+	args := []string{"log", "--status", status, "--limit", strconv.Itoa(limit)}
+	if startDate != "" {
+		args = append(args, "--start-date", startDate)
+	}
+	if endDate != "" {
+		args = append(args, "--end-date", endDate)
+	}
+	if file != "" {
+		args = append(args, "--file", file)
+	}
+
+	cmd := exec.Command(e.financialStatementPath, args...)
+	cmd.Dir = filepath.Dir(e.financialStatementPath)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get processing log: %w (output: %s)", err, string(output))
+	}
+
+	var result struct {
+		Entries []models.ProcessingLogEntry `json:"entries"`
+		Summary models.ProcessingLogSummary `json:"summary"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse processing log output: %w (output: %s)", err, string(output))
+	}
+
+	return result.Entries, &result.Summary, nil
+}
+
+// GetCashFlow gets income, expenses, net and savings rate for each of the last months, ending with
+// the current one, with the top categories and merchants and the change from the previous month
+// Amounts are converted into baseCurrency; account is optional (name substring or last 4 digits)
+func (e *Executor) GetCashFlow(months, top int, account, baseCurrency string) (*models.CashFlowReport, error) {
+	args := []string{"cash-flow", "--months", strconv.Itoa(months), "--top", strconv.Itoa(top), "--base", baseCurrency}
+	if account != "" {
+		args = append(args, "--account", account)
+	}
+
+	cmd := exec.Command(e.financialStatementPath, args...)
+	cmd.Dir = filepath.Dir(e.financialStatementPath)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cash flow: %w (output: %s)", err, string(output))
+	}
+
+	var report models.CashFlowReport
+	if err := json.Unmarshal(output, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse cash flow output: %w (output: %s)", err, string(output))
+	}
+
+	return &report, nil
+}
+
+// AddBudget adds a monthly budget
+func (e *Executor) AddBudget(req *models.AddBudgetRequest) (*models.Budget, error) {
+	args := []string{"budgets", "add",
+		"--name", req.Name,
+		"--limit", req.MonthlyLimit.String(),
+	}
+	if req.Category != "" {
+		args = append(args, "--category", req.Category)
+	}
+	if req.Rollover != "" {
+		args = append(args, "--rollover", req.Rollover)
+	}
+	if req.StartMonth != "" {
+		args = append(args, "--start", req.StartMonth)
+	}
+	args = append(args, budgetPatternArgs(req.Patterns)...)
+
+	return e.runBudgetCommand("add", args)
+}
+
+// EditBudget changes a budget
+func (e *Executor) EditBudget(id int64, req *models.EditBudgetRequest) (*models.Budget, error) {
+	args := []string{"budgets", "edit", "--id", strconv.FormatInt(id, 10)}
+	if req.Name != nil {
+		args = append(args, "--name", *req.Name)
+	}
+	if req.Category != nil {
+		args = append(args, "--category", *req.Category)
+	}
+	if req.MonthlyLimit != nil {
+		args = append(args, "--limit", req.MonthlyLimit.String())
+	}
+	if req.Rollover != nil {
+		args = append(args, "--rollover", *req.Rollover)
+	}
+	if req.StartMonth != nil {
+		args = append(args, "--start", *req.StartMonth)
+	}
+	if req.Patterns != nil {
+		if len(*req.Patterns) == 0 {
+			args = append(args, "--clear-patterns")
+		}
+		args = append(args, budgetPatternArgs(*req.Patterns)...)
+	}
+
+	return e.runBudgetCommand("edit", args)
+}
+
+// DeleteBudget removes a budget
+func (e *Executor) DeleteBudget(id int64) error {
+	args := []string{"budgets", "delete", "--id", strconv.FormatInt(id, 10)}
+
+	cmd := exec.Command(e.financialStatementPath, args...)
+	cmd.Dir = filepath.Dir(e.financialStatementPath)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to delete budget: %w (output: %s)", err, string(output))
+	}
+
+	var result struct {
+		Success bool `json:"success"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return fmt.Errorf("failed to parse budgets delete output: %w (output: %s)", err, string(output))
+	}
+
+	if !result.Success {
+		return fmt.Errorf("delete failed")
+	}
+
+	return nil
+}
+
+// runBudgetCommand runs a budgets add/edit command and returns the stored budget
+func (e *Executor) runBudgetCommand(action string, args []string) (*models.Budget, error) {
+	cmd := exec.Command(e.financialStatementPath, args...)
+	cmd.Dir = filepath.Dir(e.financialStatementPath)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to %s budget: %w (output: %s)", action, err, string(output))
+	}
+
+	var result struct {
+		Success bool          `json:"success"`
+		Budget  models.Budget `json:"budget"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse budgets %s output: %w (output: %s)", action, err, string(output))
+	}
+
+	return &result.Budget, nil
+}
+
+// budgetPatternArgs converts budget patterns into --pattern and --regex-pattern flags
+func budgetPatternArgs(patterns []models.BudgetPatternRequest) []string {
+	var args []string
+	for _, p := range patterns {
+		if p.MatchType == "regex" {
+			args = append(args, "--regex-pattern", p.Pattern)
+		} else {
+			args = append(args, "--pattern", p.Pattern)
+		}
+	}
+	return args
+}
+
 // Financial Asset Tracker Methods
 
 // AssetListOutput represents the JSON output from list command
@@ -209,10 +883,13 @@ type AssetListOutput struct {
 }
 
 // AddAsset adds a new asset
-func (e *Executor) AddAsset(name, category string, currentValue float64, purchasePrice *float64, purchaseDate, notes string) (*models.Asset, error) {
-	args := []string{"add", "--name", name, "--category", category, "--current-value", fmt.Sprintf("%.2f", currentValue)}
+func (e *Executor) AddAsset(name, category string, currentValue money.Cents, currency string, purchasePrice *money.Cents, purchaseDate, notes string) (*models.Asset, error) {
+	args := []string{"add", "--name", name, "--category", category, "--current-value", currentValue.String()}
+	if currency != "" {
+		args = append(args, "--currency", currency)
+	}
 	if purchasePrice != nil {
-		args = append(args, "--purchase-price", fmt.Sprintf("%.2f", *purchasePrice))
+		args = append(args, "--purchase-price", purchasePrice.String())
 	}
 	if purchaseDate != "" {
 		args = append(args, "--purchase-date", purchaseDate)
@@ -240,8 +917,8 @@ func (e *Executor) AddAsset(name, category string, currentValue float64, purchas
 }
 
 // UpdateAssetValue updates an asset's value
-func (e *Executor) UpdateAssetValue(name string, newValue float64, notes string) error {
-	args := []string{"update", name, "--current-value", fmt.Sprintf("%.2f", newValue)}
+func (e *Executor) UpdateAssetValue(name string, newValue money.Cents, notes string) error {
+	args := []string{"update", name, "--current-value", newValue.String()}
 	if notes != "" {
 		args = append(args, "--notes", notes)
 	}
@@ -365,18 +1042,21 @@ type LiabilityListOutput struct {
 
 // AddLiability adds a new liability
 func (e *Executor) AddLiability(req *models.AddLiabilityRequest) (*models.Liability, error) {
-	args := []string{"add", "--name", req.Name, "--type", req.Type, "--balance", fmt.Sprintf("%.2f", req.Balance)}
+	args := []string{"add", "--name", req.Name, "--type", req.Type, "--balance", req.Balance.String()}
+	if req.Currency != "" {
+		args = append(args, "--currency", req.Currency)
+	}
 	if req.OriginalAmount != nil {
-		args = append(args, "--original", fmt.Sprintf("%.2f", *req.OriginalAmount))
+		args = append(args, "--original", req.OriginalAmount.String())
 	}
 	if req.CreditLimit != nil {
-		args = append(args, "--limit", fmt.Sprintf("%.2f", *req.CreditLimit))
+		args = append(args, "--limit", req.CreditLimit.String())
 	}
 	if req.InterestRate != nil {
 		args = append(args, "--rate", fmt.Sprintf("%.2f", *req.InterestRate))
 	}
 	if req.MinimumPayment != nil {
-		args = append(args, "--min-payment", fmt.Sprintf("%.2f", *req.MinimumPayment))
+		args = append(args, "--min-payment", req.MinimumPayment.String())
 	}
 	if req.CreditorName != "" {
 		args = append(args, "--creditor", req.CreditorName)
@@ -410,8 +1090,8 @@ func (e *Executor) AddLiability(req *models.AddLiabilityRequest) (*models.Liabil
 }
 
 // UpdateLiability updates a liability's balance
-func (e *Executor) UpdateLiability(name string, newBalance float64, notes string) error {
-	args := []string{"update", name, "--balance", fmt.Sprintf("%.2f", newBalance)}
+func (e *Executor) UpdateLiability(name string, newBalance money.Cents, notes string) error {
+	args := []string{"update", name, "--balance", newBalance.String()}
 	if notes != "" {
 		args = append(args, "--notes", notes)
 	}
@@ -502,7 +1182,7 @@ func (e *Executor) GetLiability(name string, includeHistory bool) (*models.Liabi
 }
 
 // GetTotalLiabilities gets total liability balance
-func (e *Executor) GetTotalLiabilities() (float64, error) {
+func (e *Executor) GetTotalLiabilities() (money.Cents, error) {
 	args := []string{"total"}
 
 	cmd := exec.Command(e.financialLiabilityPath, args...)
@@ -513,7 +1193,7 @@ func (e *Executor) GetTotalLiabilities() (float64, error) {
 	}
 
 	var result struct {
-		TotalBalance float64 `json:"total_balance"`
+		TotalBalance money.Cents `json:"total_balance"`
 	}
 	if err := json.Unmarshal(output, &result); err != nil {
 		return 0, fmt.Errorf("failed to parse total output: %w (output: %s)", err, string(output))
@@ -6,4 +6,7 @@ require (
 	github.com/gorilla/mux v1.8.1
 	github.com/mattn/go-sqlite3 v1.14.32
 	gopkg.in/yaml.v3 v3.0.1
+	money v0.0.0
 )
+
+replace money => ../../PROGRAMS/money
@@ -49,6 +49,7 @@ func (h *FinancialAssetHandler) AddAsset(w http.ResponseWriter, r *http.Request)
 		req.Name,
 		req.Category,
 		req.CurrentValue,
+		req.Currency,
 		req.PurchasePrice,
 		purchaseDate,
 		req.Notes,
@@ -4,17 +4,20 @@ import (
 	"net/http"
 
 	"agent-gateway/db"
+	"agent-gateway/executor"
 	"agent-gateway/models"
 )
 
 // FinancialOverviewHandler handles financial overview endpoints
 type FinancialOverviewHandler struct {
+	executor  *executor.Executor
 	dbManager *db.Manager
 }
 
 // NewFinancialOverviewHandler creates a new financial overview handler
-func NewFinancialOverviewHandler(dbManager *db.Manager) *FinancialOverviewHandler {
+func NewFinancialOverviewHandler(exec *executor.Executor, dbManager *db.Manager) *FinancialOverviewHandler {
 	return &FinancialOverviewHandler{
+		executor:  exec,
 		dbManager: dbManager,
 	}
 }
@@ -32,6 +35,7 @@ func (h *FinancialOverviewHandler) GetNetWorth(w http.ResponseWriter, r *http.Re
 		"net_worth":        overview.NetWorth,
 		"total_assets":     overview.TotalAssets,
 		"total_liabilities": overview.TotalLiabilities,
+		"base_currency":    overview.BaseCurrency,
 		"timestamp":        overview.Timestamp,
 	})
 }
@@ -47,3 +51,29 @@ func (h *FinancialOverviewHandler) GetSummary(w http.ResponseWriter, r *http.Req
 
 	models.WriteSuccess(w, overview)
 }
+
+// GetCashFlow returns monthly income, expenses, net and savings rate with the top categories and
+// merchants, ending with the current month
+// GET /api/financial/cash-flow?months=12&top=5&account=1234
+func (h *FinancialOverviewHandler) GetCashFlow(w http.ResponseWriter, r *http.Request) {
+	account := models.GetQueryParam(r, "account", "")
+
+	months, err := models.GetQueryParamInt(r, "months", 12, 60)
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	top, err := models.GetQueryParamInt(r, "top", 5, 20)
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	report, err := h.executor.GetCashFlow(months, top, account, h.dbManager.BaseCurrency())
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	models.WriteSuccess(w, report)
+}
@@ -2,11 +2,14 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
 	"time"
 
 	"agent-gateway/db"
 	"agent-gateway/executor"
 	"agent-gateway/models"
+
+	"github.com/gorilla/mux"
 )
 
 // FinancialStatementHandler handles financial statement processor endpoints
@@ -68,12 +71,13 @@ func (h *FinancialStatementHandler) ProcessPDF(w http.ResponseWriter, r *http.Re
 }
 
 // QueryTransactions queries transactions with filters
-// GET /api/financial-statement/transactions?start_date=2024-01-01&end_date=2024-12-31&type=debit&merchant=amazon
+// GET /api/financial-statement/transactions?start_date=2024-01-01&end_date=2024-12-31&type=debit&merchant=amazon&category=groceries
 func (h *FinancialStatementHandler) QueryTransactions(w http.ResponseWriter, r *http.Request) {
 	startDate := models.GetQueryParam(r, "start_date", "")
 	endDate := models.GetQueryParam(r, "end_date", "")
 	txnType := models.GetQueryParam(r, "type", "")
 	merchant := models.GetQueryParam(r, "merchant", "")
+	category := models.GetQueryParam(r, "category", "")
 
 	// Validate dates if provided
 	if startDate != "" {
@@ -90,7 +94,173 @@ func (h *FinancialStatementHandler) QueryTransactions(w http.ResponseWriter, r *
 	}
 
 	// Query transactions via executor
-	transactions, err := h.executor.QueryTransactions(startDate, endDate, txnType, merchant)
+	transactions, err := h.executor.QueryTransactions(startDate, endDate, txnType, merchant, category)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	models.WriteSuccess(w, map[string]interface{}{
+		"transactions": transactions,
+		"count":        len(transactions),
+	})
+}
+
+// SearchTransactions finds transactions by words in their description, merchant, account or notes
+// GET /api/financial-statement/search?q=amazon+refund&start_date=2024-03-01&end_date=2024-05-31&type=credit
+func (h *FinancialStatementHandler) SearchTransactions(w http.ResponseWriter, r *http.Request) {
+	query := models.GetQueryParam(r, "q", "")
+	startDate := models.GetQueryParam(r, "start_date", "")
+	endDate := models.GetQueryParam(r, "end_date", "")
+	txnType := models.GetQueryParam(r, "type", "")
+	account := models.GetQueryParam(r, "account", "")
+
+	if err := models.ValidateNonEmpty(query, "q"); err != nil {
+		models.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := models.ValidateDate(startDate); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "invalid start_date: "+err.Error())
+		return
+	}
+	if err := models.ValidateDate(endDate); err != nil {
+		models.WriteError(w, http.StatusBadRequest, "invalid end_date: "+err.Error())
+		return
+	}
+	if txnType != "" && txnType != "debit" && txnType != "credit" {
+		models.WriteError(w, http.StatusBadRequest, "invalid type, must be one of: debit, credit")
+		return
+	}
+	limit, err := models.GetQueryParamInt(r, "limit", 50, 500)
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	results, mode, err := h.executor.SearchTransactions(query, startDate, endDate, txnType, account, limit)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	models.WriteSuccess(w, map[string]interface{}{
+		"query":   query,
+		"mode":    mode,
+		"results": results,
+		"count":   len(results),
+	})
+}
+
+// GetTransaction returns a single stored transaction with its notes and manual change audit trail
+// GET /api/financial-statement/transactions/{id}
+func (h *FinancialStatementHandler) GetTransaction(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	transaction, err := h.executor.GetTransaction(id)
+	if err != nil {
+		models.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	models.WriteSuccess(w, transaction)
+}
+
+// AddTransaction stores a transaction entered by hand, such as a cash purchase
+// POST /api/financial-statement/transactions
+func (h *FinancialStatementHandler) AddTransaction(w http.ResponseWriter, r *http.Request) {
+	var req models.AddTransactionRequest
+	if err := models.ParseJSONBody(r, &req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Validate request
+	if err := req.Validate(); err != nil {
+		models.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	transaction, err := h.executor.AddTransaction(&req)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	models.WriteSuccess(w, transaction)
+}
+
+// EditTransaction corrects a stored transaction
+// PUT /api/financial-statement/transactions/{id}
+func (h *FinancialStatementHandler) EditTransaction(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.EditTransactionRequest
+	if err := models.ParseJSONBody(r, &req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Validate request
+	if err := req.Validate(); err != nil {
+		models.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	transaction, err := h.executor.EditTransaction(id, &req)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	models.WriteSuccess(w, transaction)
+}
+
+// DeleteTransaction removes a stored transaction
+// DELETE /api/financial-statement/transactions/{id}
+func (h *FinancialStatementHandler) DeleteTransaction(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.executor.DeleteTransaction(id); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	models.WriteSuccess(w, map[string]interface{}{
+		"message": "Transaction deleted",
+		"id":      id,
+	})
+}
+
+// SplitTransaction splits a transaction into lines that add up to the original amount
+// POST /api/financial-statement/transactions/{id}/split
+func (h *FinancialStatementHandler) SplitTransaction(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.SplitTransactionRequest
+	if err := models.ParseJSONBody(r, &req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Validate request
+	if err := req.Validate(); err != nil {
+		models.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	transactions, err := h.executor.SplitTransaction(id, &req)
 	if err != nil {
 		models.WriteError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -103,10 +273,11 @@ func (h *FinancialStatementHandler) QueryTransactions(w http.ResponseWriter, r *
 }
 
 // GetSummary returns transaction summary statistics
-// GET /api/financial-statement/summary?start_date=2024-01-01&end_date=2024-12-31&type=debit
+// GET /api/financial-statement/summary?start_date=2024-01-01&end_date=2024-12-31&exclude_transfers=true
 func (h *FinancialStatementHandler) GetSummary(w http.ResponseWriter, r *http.Request) {
 	startDate := models.GetQueryParam(r, "start_date", "")
 	endDate := models.GetQueryParam(r, "end_date", "")
+	excludeTransfers := models.GetQueryParamBool(r, "exclude_transfers", false)
 
 	// Set defaults if not provided (last 30 days)
 	if startDate == "" {
@@ -127,7 +298,7 @@ func (h *FinancialStatementHandler) GetSummary(w http.ResponseWriter, r *http.Re
 	}
 
 	// Get summary from database
-	summary, err := h.dbManager.GetTransactionSummary(startDate, endDate)
+	summary, err := h.dbManager.GetTransactionSummary(startDate, endDate, excludeTransfers)
 	if err != nil {
 		models.WriteError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -135,3 +306,464 @@ func (h *FinancialStatementHandler) GetSummary(w http.ResponseWriter, r *http.Re
 
 	models.WriteSuccess(w, summary)
 }
+
+// GetRecurring lists recurring charges detected by the statement processor
+// GET /api/financial-statement/recurring?status=active&flag=price_changed
+func (h *FinancialStatementHandler) GetRecurring(w http.ResponseWriter, r *http.Request) {
+	status := models.GetQueryParam(r, "status", "all")
+	flag := models.GetQueryParam(r, "flag", "")
+
+	switch status {
+	case "active", "stopped", "all":
+	default:
+		models.WriteError(w, http.StatusBadRequest, "invalid status, must be one of: active, stopped, all")
+		return
+	}
+	switch flag {
+	case "", "new", "price_changed":
+	default:
+		models.WriteError(w, http.StatusBadRequest, "invalid flag, must be one of: new, price_changed")
+		return
+	}
+
+	series, err := h.dbManager.GetRecurringSeries(status, flag)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	models.WriteSuccess(w, map[string]interface{}{
+		"recurring_series": series,
+		"count":            len(series),
+	})
+}
+
+// GetBudgets returns every budget's spending health for a month
+// GET /api/financial-statement/budgets?month=2024-10
+func (h *FinancialStatementHandler) GetBudgets(w http.ResponseWriter, r *http.Request) {
+	month := models.GetQueryParam(r, "month", "")
+
+	if err := models.ValidateMonth(month); err != nil {
+		models.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	budgets, err := h.executor.GetBudgetStatus(month)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	models.WriteSuccess(w, map[string]interface{}{
+		"budgets": budgets,
+		"count":   len(budgets),
+	})
+}
+
+// GetAccounts lists the accounts statements are attached to, with their aliases
+// GET /api/financial-statement/accounts
+func (h *FinancialStatementHandler) GetAccounts(w http.ResponseWriter, r *http.Request) {
+	accounts, err := h.dbManager.GetAccounts()
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	models.WriteSuccess(w, map[string]interface{}{
+		"accounts": accounts,
+		"count":    len(accounts),
+	})
+}
+
+// AddAccount registers a bank or card account
+// POST /api/financial-statement/accounts
+func (h *FinancialStatementHandler) AddAccount(w http.ResponseWriter, r *http.Request) {
+	var req models.AddAccountRequest
+	if err := models.ParseJSONBody(r, &req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Validate request
+	if err := req.Validate(); err != nil {
+		models.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	account, err := h.executor.AddAccount(&req)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	models.WriteSuccess(w, account)
+}
+
+// EditAccount changes an account; a new nickname also renames its transactions
+// PUT /api/financial-statement/accounts/{id}
+func (h *FinancialStatementHandler) EditAccount(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.EditAccountRequest
+	if err := models.ParseJSONBody(r, &req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Validate request
+	if err := req.Validate(); err != nil {
+		models.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	account, err := h.executor.EditAccount(id, &req)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	models.WriteSuccess(w, account)
+}
+
+// DeleteAccount removes an account without transactions
+// DELETE /api/financial-statement/accounts/{id}
+func (h *FinancialStatementHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.executor.DeleteAccount(id); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	models.WriteSuccess(w, map[string]interface{}{
+		"message": "Account deleted",
+		"id":      id,
+	})
+}
+
+// AddAccountAlias records another spelling of an account's name on statements
+// POST /api/financial-statement/accounts/{id}/aliases
+func (h *FinancialStatementHandler) AddAccountAlias(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.AccountAliasRequest
+	if err := models.ParseJSONBody(r, &req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Validate request
+	if err := req.Validate(); err != nil {
+		models.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	account, err := h.executor.AddAccountAlias(id, &req)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	models.WriteSuccess(w, account)
+}
+
+// MergeAccounts moves a duplicate account's transactions and aliases into the account in the path
+// POST /api/financial-statement/accounts/{id}/merge
+func (h *FinancialStatementHandler) MergeAccounts(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.MergeAccountsRequest
+	if err := models.ParseJSONBody(r, &req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Validate request
+	if err := req.Validate(); err != nil {
+		models.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.FromID == id {
+		models.WriteError(w, http.StatusBadRequest, "cannot merge an account into itself")
+		return
+	}
+
+	account, err := h.executor.MergeAccounts(id, req.FromID)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	models.WriteSuccess(w, account)
+}
+
+// GetCoverage reports which statements have been imported per account, the gaps between them
+// and accounts whose latest statement is overdue
+// GET /api/financial-statement/coverage?account=2222&overdue=true&grace_days=15
+func (h *FinancialStatementHandler) GetCoverage(w http.ResponseWriter, r *http.Request) {
+	account := models.GetQueryParam(r, "account", "")
+	overdueOnly := models.GetQueryParamBool(r, "overdue", false)
+
+	graceDays, err := models.GetQueryParamInt(r, "grace_days", 10, 90)
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	accounts, overdue, err := h.executor.GetStatementCoverage(account, overdueOnly, graceDays)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	models.WriteSuccess(w, map[string]interface{}{
+		"accounts": accounts,
+		"count":    len(accounts),
+		"overdue":  overdue,
+	})
+}
+
+// GetProcessingLog lists processed statement files, newest first, with the error and a retry hint
+// for failed imports
+// GET /api/financial-statement/processing-log?status=failed&start_date=2024-10-07&file=visa
+func (h *FinancialStatementHandler) GetProcessingLog(w http.ResponseWriter, r *http.Request) {
+	status := models.GetQueryParam(r, "status", "all")
+	startDate := models.GetQueryParam(r, "start_date", "")
+	endDate := models.GetQueryParam(r, "end_date", "")
+	file := models.GetQueryParam(r, "file", "")
+
+	// Validate filters
+	switch status {
+	case "all", "success", "parse_error", "db_error", "reconcile_error", "failed":
+	default:
+		models.WriteError(w, http.StatusBadRequest, "invalid status, must be one of: success, parse_error, db_error, reconcile_error, failed, all")
+		return
+	}
+	if startDate != "" {
+		if err := models.ValidateDate(startDate); err != nil {
+			models.WriteError(w, http.StatusBadRequest, "invalid start_date: "+err.Error())
+			return
+		}
+	}
+	if endDate != "" {
+		if err := models.ValidateDate(endDate); err != nil {
+			models.WriteError(w, http.StatusBadRequest, "invalid end_date: "+err.Error())
+			return
+		}
+	}
+
+	limit, err := models.GetQueryParamInt(r, "limit", 50, 500)
+	if err != nil {
+		models.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	entries, summary, err := h.executor.GetProcessingLog(status, startDate, endDate, file, limit)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	models.WriteSuccess(w, map[string]interface{}{
+		"entries": entries,
+		"count":   len(entries),
+		"summary": summary,
+	})
+}
+
+// AddBudget adds a monthly budget
+// POST /api/financial-statement/budgets
+func (h *FinancialStatementHandler) AddBudget(w http.ResponseWriter, r *http.Request) {
+	var req models.AddBudgetRequest
+	if err := models.ParseJSONBody(r, &req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Validate request
+	if err := req.Validate(); err != nil {
+		models.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	budget, err := h.executor.AddBudget(&req)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	models.WriteSuccess(w, budget)
+}
+
+// EditBudget changes a budget's limit, rollover, category or patterns
+// PUT /api/financial-statement/budgets/{id}
+func (h *FinancialStatementHandler) EditBudget(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.EditBudgetRequest
+	if err := models.ParseJSONBody(r, &req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Validate request
+	if err := req.Validate(); err != nil {
+		models.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	budget, err := h.executor.EditBudget(id, &req)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	models.WriteSuccess(w, budget)
+}
+
+// DeleteBudget removes a budget
+// DELETE /api/financial-statement/budgets/{id}
+func (h *FinancialStatementHandler) DeleteBudget(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.executor.DeleteBudget(id); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	models.WriteSuccess(w, map[string]interface{}{
+		"message": "Budget deleted",
+		"id":      id,
+	})
+}
+
+// ListPendingTransactions lists parsed transactions held for review
+// GET /api/financial-statement/review?status=pending&source_file=statement.pdf
+func (h *FinancialStatementHandler) ListPendingTransactions(w http.ResponseWriter, r *http.Request) {
+	status := models.GetQueryParam(r, "status", "pending")
+	sourceFile := models.GetQueryParam(r, "source_file", "")
+
+	switch status {
+	case "pending", "approved", "rejected", "all":
+	default:
+		models.WriteError(w, http.StatusBadRequest, "invalid status, must be one of: pending, approved, rejected, all")
+		return
+	}
+
+	pending, err := h.executor.ListPendingTransactions(status, sourceFile)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	models.WriteSuccess(w, map[string]interface{}{
+		"pending_transactions": pending,
+		"count":                len(pending),
+	})
+}
+
+// EditPendingTransaction corrects a transaction before it is approved
+// PUT /api/financial-statement/review/{id}
+func (h *FinancialStatementHandler) EditPendingTransaction(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.EditPendingTransactionRequest
+	if err := models.ParseJSONBody(r, &req); err != nil {
+		models.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Validate request
+	if err := req.Validate(); err != nil {
+		models.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	pending, err := h.executor.EditPendingTransaction(id, &req)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	models.WriteSuccess(w, pending)
+}
+
+// ApprovePendingTransaction moves a reviewed transaction into the transactions table
+// POST /api/financial-statement/review/{id}/approve
+func (h *FinancialStatementHandler) ApprovePendingTransaction(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	transactionID, err := h.executor.ApprovePendingTransaction(id)
+	if err != nil {
+		models.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	models.WriteSuccess(w, map[string]interface{}{
+		"message":        "Transaction approved",
+		"id":             id,
+		"transaction_id": transactionID,
+	})
+}
+
+// RejectPendingTransaction discards a transaction from the review queue
+// POST /api/financial-statement/review/{id}/reject
+func (h *FinancialStatementHandler) RejectPendingTransaction(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	// The body is optional; it only carries a note
+	var req models.RejectPendingTransactionRequest
+	if r.ContentLength > 0 {
+		if err := models.ParseJSONBody(r, &req); err != nil {
+			models.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	if err := h.executor.RejectPendingTransaction(id, req.Note); err != nil {
+		models.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	models.WriteSuccess(w, map[string]interface{}{
+		"message": "Transaction rejected",
+		"id":      id,
+	})
+}
+
+// pathID reads the {id} path variable, writing a 400 response if it is invalid
+func pathID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id <= 0 {
+		models.WriteError(w, http.StatusBadRequest, "invalid id: must be a positive integer")
+		return 0, false
+	}
+	return id, true
+}
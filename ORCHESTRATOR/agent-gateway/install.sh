@@ -20,7 +20,7 @@ BINARY_NAME="agent-gateway"
 
 # Build the binary
 echo "Building agent-gateway..."
-go build -o "$BINARY_NAME"
+go build -tags sqlite_fts5 -o "$BINARY_NAME"
 
 if [ ! -f "$BINARY_NAME" ]; then
     echo "Error: Build failed"
@@ -86,7 +86,7 @@ func main() {
 	financialStatementHandler := handlers.NewFinancialStatementHandler(exec, dbManager)
 	financialAssetHandler := handlers.NewFinancialAssetHandler(exec, dbManager)
 	financialLiabilityHandler := handlers.NewFinancialLiabilityHandler(exec, dbManager)
-	financialOverviewHandler := handlers.NewFinancialOverviewHandler(dbManager)
+	financialOverviewHandler := handlers.NewFinancialOverviewHandler(exec, dbManager)
 
 	// Initialize LLM client and handler (optional)
 	llmClient := llm.NewClient(
@@ -136,7 +136,30 @@ func main() {
 	// Financial Statement endpoints (require auth)
 	router.HandleFunc("/api/financial-statement/process", logMiddleware(auth.Authenticate(financialStatementHandler.ProcessPDF))).Methods("POST", "OPTIONS")
 	router.HandleFunc("/api/financial-statement/transactions", logMiddleware(auth.Authenticate(financialStatementHandler.QueryTransactions))).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/financial-statement/transactions", logMiddleware(auth.Authenticate(financialStatementHandler.AddTransaction))).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/financial-statement/transactions/{id}", logMiddleware(auth.Authenticate(financialStatementHandler.GetTransaction))).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/financial-statement/transactions/{id}", logMiddleware(auth.Authenticate(financialStatementHandler.EditTransaction))).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/api/financial-statement/transactions/{id}", logMiddleware(auth.Authenticate(financialStatementHandler.DeleteTransaction))).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/api/financial-statement/transactions/{id}/split", logMiddleware(auth.Authenticate(financialStatementHandler.SplitTransaction))).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/financial-statement/search", logMiddleware(auth.Authenticate(financialStatementHandler.SearchTransactions))).Methods("GET", "OPTIONS")
 	router.HandleFunc("/api/financial-statement/summary", logMiddleware(auth.Authenticate(financialStatementHandler.GetSummary))).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/financial-statement/recurring", logMiddleware(auth.Authenticate(financialStatementHandler.GetRecurring))).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/financial-statement/accounts", logMiddleware(auth.Authenticate(financialStatementHandler.GetAccounts))).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/financial-statement/accounts", logMiddleware(auth.Authenticate(financialStatementHandler.AddAccount))).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/financial-statement/accounts/{id}", logMiddleware(auth.Authenticate(financialStatementHandler.EditAccount))).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/api/financial-statement/accounts/{id}", logMiddleware(auth.Authenticate(financialStatementHandler.DeleteAccount))).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/api/financial-statement/accounts/{id}/aliases", logMiddleware(auth.Authenticate(financialStatementHandler.AddAccountAlias))).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/financial-statement/accounts/{id}/merge", logMiddleware(auth.Authenticate(financialStatementHandler.MergeAccounts))).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/financial-statement/coverage", logMiddleware(auth.Authenticate(financialStatementHandler.GetCoverage))).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/financial-statement/processing-log", logMiddleware(auth.Authenticate(financialStatementHandler.GetProcessingLog))).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/financial-statement/budgets", logMiddleware(auth.Authenticate(financialStatementHandler.GetBudgets))).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/financial-statement/budgets", logMiddleware(auth.Authenticate(financialStatementHandler.AddBudget))).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/financial-statement/budgets/{id}", logMiddleware(auth.Authenticate(financialStatementHandler.EditBudget))).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/api/financial-statement/budgets/{id}", logMiddleware(auth.Authenticate(financialStatementHandler.DeleteBudget))).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/api/financial-statement/review", logMiddleware(auth.Authenticate(financialStatementHandler.ListPendingTransactions))).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/financial-statement/review/{id}", logMiddleware(auth.Authenticate(financialStatementHandler.EditPendingTransaction))).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/api/financial-statement/review/{id}/approve", logMiddleware(auth.Authenticate(financialStatementHandler.ApprovePendingTransaction))).Methods("POST", "OPTIONS")
+	router.HandleFunc("/api/financial-statement/review/{id}/reject", logMiddleware(auth.Authenticate(financialStatementHandler.RejectPendingTransaction))).Methods("POST", "OPTIONS")
 
 	// Financial Asset endpoints (require auth)
 	router.HandleFunc("/api/financial-asset", logMiddleware(auth.Authenticate(financialAssetHandler.AddAsset))).Methods("POST", "OPTIONS")
@@ -159,6 +182,7 @@ func main() {
 	// Financial Overview endpoints (require auth)
 	router.HandleFunc("/api/financial/net-worth", logMiddleware(auth.Authenticate(financialOverviewHandler.GetNetWorth))).Methods("GET", "OPTIONS")
 	router.HandleFunc("/api/financial/summary", logMiddleware(auth.Authenticate(financialOverviewHandler.GetSummary))).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/financial/cash-flow", logMiddleware(auth.Authenticate(financialOverviewHandler.GetCashFlow))).Methods("GET", "OPTIONS")
 
 	// LLM endpoints (require auth)
 	router.HandleFunc("/api/llm/chat", logMiddleware(auth.Authenticate(llmHandler.Chat))).Methods("POST", "OPTIONS")
@@ -8,48 +8,169 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
+
+	"money"
 )
 
 // Request body structs for financial operations
 
 // AddAssetRequest represents a request to add a new asset
 type AddAssetRequest struct {
-	Name          string   `json:"name"`
-	Category      string   `json:"category"`
-	CurrentValue  float64  `json:"current_value"`
-	PurchasePrice *float64 `json:"purchase_price,omitempty"`
-	PurchaseDate  *string  `json:"purchase_date,omitempty"`
-	Notes         string   `json:"notes,omitempty"`
+	Name          string       `json:"name"`
+	Category      string       `json:"category"`
+	CurrentValue  money.Cents  `json:"current_value"`
+	Currency      string       `json:"currency,omitempty"` // defaults to USD
+	PurchasePrice *money.Cents `json:"purchase_price,omitempty"`
+	PurchaseDate  *string      `json:"purchase_date,omitempty"`
+	Notes         string       `json:"notes,omitempty"`
 }
 
 // UpdateAssetRequest represents a request to update an asset's value
 type UpdateAssetRequest struct {
-	CurrentValue float64 `json:"current_value"`
-	Notes        string  `json:"notes,omitempty"`
+	CurrentValue money.Cents `json:"current_value"`
+	Notes        string      `json:"notes,omitempty"`
 }
 
 // AddLiabilityRequest represents a request to add a new liability
 type AddLiabilityRequest struct {
-	Name           string   `json:"name"`
-	Type           string   `json:"type"`
-	Balance        float64  `json:"balance"`
-	OriginalAmount *float64 `json:"original_amount,omitempty"`
-	CreditLimit    *float64 `json:"credit_limit,omitempty"`
-	InterestRate   *float64 `json:"interest_rate,omitempty"`
-	MinimumPayment *float64 `json:"minimum_payment,omitempty"`
-	CreditorName   string   `json:"creditor_name,omitempty"`
-	AccountLast4   string   `json:"account_last4,omitempty"`
-	OpenedDate     *string  `json:"opened_date,omitempty"`
-	Notes          string   `json:"notes,omitempty"`
+	Name           string       `json:"name"`
+	Type           string       `json:"type"`
+	Balance        money.Cents  `json:"balance"`
+	Currency       string       `json:"currency,omitempty"` // defaults to USD
+	OriginalAmount *money.Cents `json:"original_amount,omitempty"`
+	CreditLimit    *money.Cents `json:"credit_limit,omitempty"`
+	InterestRate   *float64     `json:"interest_rate,omitempty"`
+	MinimumPayment *money.Cents `json:"minimum_payment,omitempty"`
+	CreditorName   string       `json:"creditor_name,omitempty"`
+	AccountLast4   string       `json:"account_last4,omitempty"`
+	OpenedDate     *string      `json:"opened_date,omitempty"`
+	Notes          string       `json:"notes,omitempty"`
 }
 
 // UpdateLiabilityRequest represents a request to update a liability's balance
 type UpdateLiabilityRequest struct {
-	Balance float64 `json:"balance"`
-	Notes   string  `json:"notes,omitempty"`
+	Balance money.Cents `json:"balance"`
+	Notes   string      `json:"notes,omitempty"`
+}
+
+// EditPendingTransactionRequest represents corrections to a transaction in the review queue
+// Omitted fields are left unchanged
+type EditPendingTransactionRequest struct {
+	TransactionDate *string      `json:"transaction_date,omitempty"`
+	Description     *string      `json:"description,omitempty"`
+	Amount          *money.Cents `json:"amount,omitempty"`
+	TransactionType *string      `json:"transaction_type,omitempty"`
+	Category        *string      `json:"category,omitempty"`
+}
+
+// RejectPendingTransactionRequest represents a request to discard a transaction in the review queue
+type RejectPendingTransactionRequest struct {
+	Note string `json:"note,omitempty"`
+}
+
+// AddTransactionRequest represents a transaction entered by hand, such as a cash purchase
+type AddTransactionRequest struct {
+	AccountName     string      `json:"account_name"`
+	AccountLast4    string      `json:"account_last4,omitempty"`
+	TransactionDate string      `json:"transaction_date"`
+	Description     string      `json:"description"`
+	Amount          money.Cents `json:"amount"`
+	Currency        string      `json:"currency,omitempty"` // defaults to the account's currency
+	TransactionType string      `json:"transaction_type,omitempty"`
+	Category        string      `json:"category,omitempty"`
+	Notes           string      `json:"notes,omitempty"`
+}
+
+// EditTransactionRequest represents corrections to a stored transaction
+// Omitted fields are left unchanged
+type EditTransactionRequest struct {
+	TransactionDate *string      `json:"transaction_date,omitempty"`
+	Description     *string      `json:"description,omitempty"`
+	Amount          *money.Cents `json:"amount,omitempty"`
+	Currency        *string      `json:"currency,omitempty"`
+	TransactionType *string      `json:"transaction_type,omitempty"`
+	Category        *string      `json:"category,omitempty"`
+	MerchantName    *string      `json:"merchant_name,omitempty"`
+	Notes           *string      `json:"notes,omitempty"`
+}
+
+// SplitTransactionRequest represents a request to split a transaction into lines that add up to it
+type SplitTransactionRequest struct {
+	Lines []SplitLine `json:"lines"`
+}
+
+// SplitLine is one part of a split transaction; empty fields are copied from the original
+type SplitLine struct {
+	Amount      money.Cents `json:"amount"`
+	Description string      `json:"description,omitempty"`
+	Category    string      `json:"category,omitempty"`
+	Notes       string      `json:"notes,omitempty"`
+}
+
+// AddAccountRequest represents a request to register a bank or card account
+type AddAccountRequest struct {
+	Nickname    string   `json:"nickname"`
+	Institution string   `json:"institution,omitempty"`
+	AccountType string   `json:"account_type,omitempty"`
+	Last4       string   `json:"last4,omitempty"`
+	Currency    string   `json:"currency,omitempty"`
+	OpenedOn    string   `json:"opened_on,omitempty"`
+	ClosedOn    string   `json:"closed_on,omitempty"`
+	Aliases     []string `json:"aliases,omitempty"`
+}
+
+// EditAccountRequest represents changes to an account
+// Omitted fields are left unchanged; an empty opened_on or closed_on clears the date
+type EditAccountRequest struct {
+	Nickname    *string `json:"nickname,omitempty"`
+	Institution *string `json:"institution,omitempty"`
+	AccountType *string `json:"account_type,omitempty"`
+	Last4       *string `json:"last4,omitempty"`
+	Currency    *string `json:"currency,omitempty"`
+	OpenedOn    *string `json:"opened_on,omitempty"`
+	ClosedOn    *string `json:"closed_on,omitempty"`
+}
+
+// AccountAliasRequest represents another spelling of an account's name on statements
+type AccountAliasRequest struct {
+	Name  string `json:"name"`
+	Last4 string `json:"last4,omitempty"`
+}
+
+// MergeAccountsRequest represents a request to merge a duplicate account into another
+type MergeAccountsRequest struct {
+	FromID int64 `json:"from_id"`
+}
+
+// BudgetPatternRequest is a description or merchant pattern that counts towards a budget
+type BudgetPatternRequest struct {
+	MatchType string `json:"match_type"` // "substring" (default) or "regex"
+	Pattern   string `json:"pattern"`
+}
+
+// AddBudgetRequest represents a request to add a monthly budget
+type AddBudgetRequest struct {
+	Name         string                 `json:"name"`
+	Category     string                 `json:"category,omitempty"`
+	MonthlyLimit money.Cents            `json:"monthly_limit"`
+	Rollover     string                 `json:"rollover,omitempty"`
+	StartMonth   string                 `json:"start_month,omitempty"`
+	Patterns     []BudgetPatternRequest `json:"patterns,omitempty"`
+}
+
+// EditBudgetRequest represents changes to a budget
+// Omitted fields are left unchanged; patterns, when present, replace all existing patterns
+type EditBudgetRequest struct {
+	Name         *string                 `json:"name,omitempty"`
+	Category     *string                 `json:"category,omitempty"`
+	MonthlyLimit *money.Cents            `json:"monthly_limit,omitempty"`
+	Rollover     *string                 `json:"rollover,omitempty"`
+	StartMonth   *string                 `json:"start_month,omitempty"`
+	Patterns     *[]BudgetPatternRequest `json:"patterns,omitempty"`
 }
 
 // Validation functions
@@ -66,8 +187,21 @@ func ValidateDate(dateStr string) error {
 	return nil
 }
 
-// ValidatePositiveFloat validates that a number is positive
-func ValidatePositiveFloat(value float64, fieldName string) error {
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// ValidateCurrency validates an optional 3-letter currency code
+func ValidateCurrency(currency string) error {
+	if currency == "" {
+		return nil
+	}
+	if !currencyCode.MatchString(currency) {
+		return fmt.Errorf("invalid currency, expected a 3-letter code such as USD")
+	}
+	return nil
+}
+
+// ValidatePositiveAmount validates that an amount of money is positive
+func ValidatePositiveAmount(value money.Cents, fieldName string) error {
 	if value <= 0 {
 		return fmt.Errorf("%s must be positive", fieldName)
 	}
@@ -221,7 +355,11 @@ func (r *AddAssetRequest) Validate() error {
 	if err := ValidateNonEmpty(r.Category, "category"); err != nil {
 		return err
 	}
-	if err := ValidatePositiveFloat(r.CurrentValue, "current_value"); err != nil {
+	if err := ValidatePositiveAmount(r.CurrentValue, "current_value"); err != nil {
+		return err
+	}
+	r.Currency = strings.ToUpper(strings.TrimSpace(r.Currency))
+	if err := ValidateCurrency(r.Currency); err != nil {
 		return err
 	}
 	if r.PurchaseDate != nil {
@@ -234,7 +372,7 @@ func (r *AddAssetRequest) Validate() error {
 
 // Validate validates an UpdateAssetRequest
 func (r *UpdateAssetRequest) Validate() error {
-	if err := ValidatePositiveFloat(r.CurrentValue, "current_value"); err != nil {
+	if err := ValidatePositiveAmount(r.CurrentValue, "current_value"); err != nil {
 		return err
 	}
 	return nil
@@ -251,7 +389,11 @@ func (r *AddLiabilityRequest) Validate() error {
 	if err := ValidateLiabilityType(r.Type); err != nil {
 		return err
 	}
-	if err := ValidatePositiveFloat(r.Balance, "balance"); err != nil {
+	if err := ValidatePositiveAmount(r.Balance, "balance"); err != nil {
+		return err
+	}
+	r.Currency = strings.ToUpper(strings.TrimSpace(r.Currency))
+	if err := ValidateCurrency(r.Currency); err != nil {
 		return err
 	}
 	if r.OpenedDate != nil {
@@ -264,8 +406,265 @@ func (r *AddLiabilityRequest) Validate() error {
 
 // Validate validates an UpdateLiabilityRequest
 func (r *UpdateLiabilityRequest) Validate() error {
-	if err := ValidatePositiveFloat(r.Balance, "balance"); err != nil {
+	if err := ValidatePositiveAmount(r.Balance, "balance"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Validate validates an EditPendingTransactionRequest
+func (r *EditPendingTransactionRequest) Validate() error {
+	if r.TransactionDate == nil && r.Description == nil && r.Amount == nil && r.TransactionType == nil && r.Category == nil {
+		return fmt.Errorf("at least one field to edit is required")
+	}
+	if r.TransactionDate != nil {
+		if err := ValidateNonEmpty(*r.TransactionDate, "transaction_date"); err != nil {
+			return err
+		}
+		if err := ValidateDate(*r.TransactionDate); err != nil {
+			return err
+		}
+	}
+	if r.Description != nil {
+		if err := ValidateNonEmpty(*r.Description, "description"); err != nil {
+			return err
+		}
+	}
+	if r.TransactionType != nil && *r.TransactionType != "debit" && *r.TransactionType != "credit" {
+		return fmt.Errorf("invalid transaction_type, must be one of: debit, credit")
+	}
+	return nil
+}
+
+// Validate validates an AddBudgetRequest
+func (r *AddBudgetRequest) Validate() error {
+	if err := ValidateNonEmpty(r.Name, "name"); err != nil {
+		return err
+	}
+	if err := ValidatePositiveAmount(r.MonthlyLimit, "monthly_limit"); err != nil {
+		return err
+	}
+	if r.Category == "" && len(r.Patterns) == 0 {
+		return fmt.Errorf("category or patterns is required")
+	}
+	if r.Rollover != "" {
+		if err := validateBudgetRollover(r.Rollover); err != nil {
+			return err
+		}
+	}
+	if err := ValidateMonth(r.StartMonth); err != nil {
+		return err
+	}
+	return validateBudgetPatterns(r.Patterns)
+}
+
+// Validate validates an EditBudgetRequest
+func (r *EditBudgetRequest) Validate() error {
+	if r.Name == nil && r.Category == nil && r.MonthlyLimit == nil && r.Rollover == nil && r.StartMonth == nil && r.Patterns == nil {
+		return fmt.Errorf("at least one field to edit is required")
+	}
+	if r.Name != nil {
+		if err := ValidateNonEmpty(*r.Name, "name"); err != nil {
+			return err
+		}
+	}
+	if r.MonthlyLimit != nil {
+		if err := ValidatePositiveAmount(*r.MonthlyLimit, "monthly_limit"); err != nil {
+			return err
+		}
+	}
+	if r.Rollover != nil {
+		if err := validateBudgetRollover(*r.Rollover); err != nil {
+			return err
+		}
+	}
+	if r.StartMonth != nil {
+		if err := ValidateNonEmpty(*r.StartMonth, "start_month"); err != nil {
+			return err
+		}
+		if err := ValidateMonth(*r.StartMonth); err != nil {
+			return err
+		}
+	}
+	if r.Patterns != nil {
+		return validateBudgetPatterns(*r.Patterns)
+	}
+	return nil
+}
+
+// ValidateMonth validates a month string in YYYY-MM format
+func ValidateMonth(monthStr string) error {
+	if monthStr == "" {
+		return nil // Optional months are allowed
+	}
+	_, err := time.Parse("2006-01", monthStr)
+	if err != nil {
+		return fmt.Errorf("invalid month format, expected YYYY-MM: %w", err)
+	}
+	return nil
+}
+
+func validateBudgetRollover(rollover string) error {
+	switch rollover {
+	case "none", "surplus", "full":
+		return nil
+	}
+	return fmt.Errorf("invalid rollover, must be one of: none, surplus, full")
+}
+
+func validateBudgetPatterns(patterns []BudgetPatternRequest) error {
+	for _, p := range patterns {
+		if err := ValidateNonEmpty(p.Pattern, "pattern"); err != nil {
+			return err
+		}
+		if p.MatchType != "" && p.MatchType != "substring" && p.MatchType != "regex" {
+			return fmt.Errorf("invalid match_type, must be one of: substring, regex")
+		}
+	}
+	return nil
+}
+
+// Validate validates an AddTransactionRequest
+func (r *AddTransactionRequest) Validate() error {
+	if err := ValidateNonEmpty(r.AccountName, "account_name"); err != nil {
+		return err
+	}
+	if err := ValidateNonEmpty(r.TransactionDate, "transaction_date"); err != nil {
+		return err
+	}
+	if err := ValidateDate(r.TransactionDate); err != nil {
 		return err
 	}
+	if err := ValidateNonEmpty(r.Description, "description"); err != nil {
+		return err
+	}
+	if r.Amount == 0 {
+		return fmt.Errorf("amount must be non-zero")
+	}
+	r.Currency = strings.ToUpper(strings.TrimSpace(r.Currency))
+	if err := ValidateCurrency(r.Currency); err != nil {
+		return err
+	}
+	if r.TransactionType != "" && r.TransactionType != "debit" && r.TransactionType != "credit" {
+		return fmt.Errorf("invalid transaction_type, must be one of: debit, credit")
+	}
+	return nil
+}
+
+// Validate validates an EditTransactionRequest
+func (r *EditTransactionRequest) Validate() error {
+	if r.TransactionDate == nil && r.Description == nil && r.Amount == nil && r.Currency == nil &&
+		r.TransactionType == nil && r.Category == nil && r.MerchantName == nil && r.Notes == nil {
+		return fmt.Errorf("at least one field to edit is required")
+	}
+	if r.TransactionDate != nil {
+		if err := ValidateNonEmpty(*r.TransactionDate, "transaction_date"); err != nil {
+			return err
+		}
+		if err := ValidateDate(*r.TransactionDate); err != nil {
+			return err
+		}
+	}
+	if r.Description != nil {
+		if err := ValidateNonEmpty(*r.Description, "description"); err != nil {
+			return err
+		}
+	}
+	if r.Amount != nil && *r.Amount == 0 {
+		return fmt.Errorf("amount must be non-zero")
+	}
+	if r.Currency != nil {
+		currency := strings.ToUpper(strings.TrimSpace(*r.Currency))
+		r.Currency = &currency
+		if err := ValidateNonEmpty(currency, "currency"); err != nil {
+			return err
+		}
+		if err := ValidateCurrency(currency); err != nil {
+			return err
+		}
+	}
+	if r.TransactionType != nil && *r.TransactionType != "debit" && *r.TransactionType != "credit" {
+		return fmt.Errorf("invalid transaction_type, must be one of: debit, credit")
+	}
+	return nil
+}
+
+// Validate validates an AddAccountRequest
+func (r *AddAccountRequest) Validate() error {
+	if err := ValidateNonEmpty(r.Nickname, "nickname"); err != nil {
+		return err
+	}
+	if err := validateAccountType(r.AccountType); err != nil {
+		return err
+	}
+	if err := ValidateDate(r.OpenedOn); err != nil {
+		return fmt.Errorf("invalid opened_on: %w", err)
+	}
+	if err := ValidateDate(r.ClosedOn); err != nil {
+		return fmt.Errorf("invalid closed_on: %w", err)
+	}
+	return nil
+}
+
+// Validate validates an EditAccountRequest
+func (r *EditAccountRequest) Validate() error {
+	if r.Nickname == nil && r.Institution == nil && r.AccountType == nil && r.Last4 == nil &&
+		r.Currency == nil && r.OpenedOn == nil && r.ClosedOn == nil {
+		return fmt.Errorf("at least one field to edit is required")
+	}
+	if r.Nickname != nil {
+		if err := ValidateNonEmpty(*r.Nickname, "nickname"); err != nil {
+			return err
+		}
+	}
+	if r.AccountType != nil {
+		if err := validateAccountType(*r.AccountType); err != nil {
+			return err
+		}
+	}
+	if r.OpenedOn != nil {
+		if err := ValidateDate(*r.OpenedOn); err != nil {
+			return fmt.Errorf("invalid opened_on: %w", err)
+		}
+	}
+	if r.ClosedOn != nil {
+		if err := ValidateDate(*r.ClosedOn); err != nil {
+			return fmt.Errorf("invalid closed_on: %w", err)
+		}
+	}
+	return nil
+}
+
+// Validate validates an AccountAliasRequest
+func (r *AccountAliasRequest) Validate() error {
+	return ValidateNonEmpty(r.Name, "name")
+}
+
+// Validate validates a MergeAccountsRequest
+func (r *MergeAccountsRequest) Validate() error {
+	if r.FromID <= 0 {
+		return fmt.Errorf("from_id is required")
+	}
+	return nil
+}
+
+func validateAccountType(accountType string) error {
+	switch accountType {
+	case "", "checking", "savings", "credit":
+		return nil
+	}
+	return fmt.Errorf("invalid account_type, must be one of: checking, savings, credit")
+}
+
+// Validate validates a SplitTransactionRequest
+func (r *SplitTransactionRequest) Validate() error {
+	if len(r.Lines) < 2 {
+		return fmt.Errorf("at least two lines are required")
+	}
+	for i, line := range r.Lines {
+		if line.Amount == 0 {
+			return fmt.Errorf("line %d: amount must be non-zero", i+1)
+		}
+	}
 	return nil
 }
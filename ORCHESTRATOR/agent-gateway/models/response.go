@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"net/http"
 	"time"
+
+	"money"
 )
 
 // Response is the standard API response wrapper
@@ -70,22 +72,238 @@ type Transaction struct {
 	ID            int       `json:"id"`
 	Date          string    `json:"date"`
 	Description   string    `json:"description"`
-	Amount        float64   `json:"amount"`
+	Amount        money.Cents `json:"amount"`
 	TransactionType string  `json:"transaction_type"`
 	Category      string    `json:"category"`
+	CategorySource string   `json:"category_source,omitempty"`
 	Merchant      string    `json:"merchant,omitempty"`
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
 }
 
+// PendingTransaction represents a parsed transaction held in the review queue
+type PendingTransaction struct {
+	ID            int64           `json:"id"`
+	Transaction   json.RawMessage `json:"transaction"`
+	RawDate       string          `json:"raw_date,omitempty"`
+	Flags         []string        `json:"flags"`
+	DuplicateOf   *int64          `json:"duplicate_of,omitempty"`
+	Status        string          `json:"status"`
+	Note          string          `json:"note,omitempty"`
+	TransactionID *int64          `json:"transaction_id,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	ReviewedAt    *time.Time      `json:"reviewed_at,omitempty"`
+}
+
 // TransactionSummary represents aggregated transaction data
 type TransactionSummary struct {
-	TotalAmount    float64          `json:"total_amount"`
-	TotalCount     int              `json:"total_count"`
-	CountByType    map[string]int   `json:"count_by_type"`
-	AmountByType   map[string]float64 `json:"amount_by_type"`
-	StartDate      string           `json:"start_date"`
-	EndDate        string           `json:"end_date"`
+	TotalAmount      money.Cents            `json:"total_amount"`
+	TotalCount       int                    `json:"total_count"`
+	CountByType      map[string]int         `json:"count_by_type"`
+	AmountByType     map[string]money.Cents `json:"amount_by_type"`
+	CountByMerchant  map[string]int         `json:"count_by_merchant,omitempty"`
+	AmountByMerchant map[string]money.Cents `json:"amount_by_merchant,omitempty"`
+	StartDate        string                 `json:"start_date"`
+	EndDate          string                 `json:"end_date"`
+	BaseCurrency     string                 `json:"base_currency"`
+	Unconverted      []UnconvertedAmount    `json:"unconverted,omitempty"`
+}
+
+// CashFlowReport represents monthly income against expenses over a range of months
+type CashFlowReport struct {
+	StartMonth   string              `json:"start_month"`
+	EndMonth     string              `json:"end_month"`
+	BaseCurrency string              `json:"base_currency"`
+	Months       []CashFlowMonth     `json:"months"`
+	Totals       CashFlowTotals      `json:"totals"`
+	Unconverted  []UnconvertedAmount `json:"unconverted,omitempty"`
+}
+
+// CashFlowMonth represents one month's income, expenses and savings rate
+type CashFlowMonth struct {
+	Month            string          `json:"month"`
+	Income           money.Cents     `json:"income"`
+	Expenses         money.Cents     `json:"expenses"`
+	Net              money.Cents     `json:"net"`
+	SavingsRate      *float64        `json:"savings_rate,omitempty"`
+	TransactionCount int             `json:"transaction_count"`
+	TopCategories    []CashFlowItem  `json:"top_categories"`
+	TopMerchants     []CashFlowItem  `json:"top_merchants"`
+	Change           *CashFlowChange `json:"change,omitempty"`
+}
+
+// CashFlowChange represents the difference from the previous month
+type CashFlowChange struct {
+	Income          money.Cents `json:"income"`
+	Expenses        money.Cents `json:"expenses"`
+	Net             money.Cents `json:"net"`
+	IncomePercent   *float64    `json:"income_percent,omitempty"`
+	ExpensesPercent *float64    `json:"expenses_percent,omitempty"`
+}
+
+// CashFlowTotals represents a cash-flow report summed over all its months
+type CashFlowTotals struct {
+	Income          money.Cents    `json:"income"`
+	Expenses        money.Cents    `json:"expenses"`
+	Net             money.Cents    `json:"net"`
+	SavingsRate     *float64       `json:"savings_rate,omitempty"`
+	AverageIncome   money.Cents    `json:"average_income"`
+	AverageExpenses money.Cents    `json:"average_expenses"`
+	TopCategories   []CashFlowItem `json:"top_categories"`
+	TopMerchants    []CashFlowItem `json:"top_merchants"`
+}
+
+// CashFlowItem represents the spending (debits minus refunds) of a category or merchant
+type CashFlowItem struct {
+	Name             string      `json:"name"`
+	Amount           money.Cents `json:"amount"`
+	TransactionCount int         `json:"transaction_count"`
+}
+
+// RecurringSeries represents a merchant that charges an account on a schedule
+type RecurringSeries struct {
+	ID               int64       `json:"id"`
+	MerchantKey      string      `json:"merchant_key"`
+	Description      string      `json:"description"`
+	AccountName      string      `json:"account_name"`
+	AccountLast4     string      `json:"account_last4"`
+	Cadence          string      `json:"cadence"`
+	IntervalDays     float64     `json:"interval_days"`
+	Occurrences      int         `json:"occurrences"`
+	FirstDate        time.Time   `json:"first_date"`
+	LastDate         time.Time   `json:"last_date"`
+	NextExpectedDate time.Time   `json:"next_expected_date"`
+	TypicalAmount    money.Cents `json:"typical_amount"`
+	LastAmount       money.Cents `json:"last_amount"`
+	AmountDrift      money.Cents `json:"amount_drift"`
+	Status           string      `json:"status"`
+	Flags            []string    `json:"flags"`
+	DetectedAt       time.Time   `json:"detected_at"`
+}
+
+// StatementCoverage represents which statements of an account have been imported
+type StatementCoverage struct {
+	AccountName               string        `json:"account_name"`
+	AccountLast4              string        `json:"account_last4"`
+	Cadence                   string        `json:"cadence"`
+	Statements                int           `json:"statements"`
+	FirstStatementDate        time.Time     `json:"first_statement_date"`
+	LastStatementDate         time.Time     `json:"last_statement_date"`
+	NextExpectedDate          time.Time     `json:"next_expected_date"`
+	MonthsPresent             []string      `json:"months_present"`
+	Gaps                      []CoverageGap `json:"gaps"`
+	MissingStatements         int           `json:"missing_statements"`
+	LastSuccessfulImport      *time.Time    `json:"last_successful_import,omitempty"`
+	LastImportedFile          string        `json:"last_imported_file,omitempty"`
+	FailedImportsSinceSuccess int           `json:"failed_imports_since_success"`
+	Status                    string        `json:"status"`
+	DaysOverdue               int           `json:"days_overdue"`
+}
+
+// CoverageGap represents a run of missing statements between two imported ones
+type CoverageGap struct {
+	After          time.Time `json:"after"`
+	Before         time.Time `json:"before"`
+	Missing        int       `json:"missing"`
+	ExpectedMonths []string  `json:"expected_months"`
+}
+
+// ProcessingLogEntry represents one processed statement file, successful or not
+type ProcessingLogEntry struct {
+	ID                   int64      `json:"id"`
+	SourceFile           string     `json:"source_file"`
+	StatementDate        *time.Time `json:"statement_date,omitempty"`
+	AccountName          string     `json:"account_name,omitempty"`
+	TransactionsInserted int        `json:"transactions_inserted"`
+	TransactionsSkipped  int        `json:"transactions_skipped"`
+	Status               string     `json:"status"`
+	ErrorMessage         string     `json:"error_message,omitempty"`
+	ProcessedAt          time.Time  `json:"processed_at"`
+	Resolved             bool       `json:"resolved"`
+	RetryHint            string     `json:"retry_hint,omitempty"`
+}
+
+// ProcessingLogSummary counts the processing log entries matching a query
+type ProcessingLogSummary struct {
+	Total      int            `json:"total"`
+	ByStatus   map[string]int `json:"by_status"`
+	Failed     int            `json:"failed"`
+	Unresolved int            `json:"unresolved"`
+}
+
+// SearchResult represents a transaction matching a full-text search, best match first
+type SearchResult struct {
+	ID              int64       `json:"id"`
+	AccountName     string      `json:"account_name"`
+	AccountLast4    string      `json:"account_last4"`
+	Date            string      `json:"date"`
+	Description     string      `json:"description"`
+	Amount          money.Cents `json:"amount"`
+	TransactionType string      `json:"transaction_type"`
+	Category        string      `json:"category,omitempty"`
+	Merchant        string      `json:"merchant,omitempty"`
+	Notes           string      `json:"notes,omitempty"`
+	Score           float64     `json:"score"`
+	Snippet         string      `json:"snippet"`
+}
+
+// Account represents a bank or card account that statements are attached to
+type Account struct {
+	ID           int64          `json:"id"`
+	Institution  string         `json:"institution,omitempty"`
+	Nickname     string         `json:"nickname"`
+	AccountType  string         `json:"account_type,omitempty"`
+	Last4        string         `json:"last4"`
+	Currency     string         `json:"currency"`
+	OpenedOn     *time.Time     `json:"opened_on,omitempty"`
+	ClosedOn     *time.Time     `json:"closed_on,omitempty"`
+	Aliases      []AccountAlias `json:"aliases"`
+	Transactions int            `json:"transactions"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+}
+
+// AccountAlias represents an account name as spelled on statements
+type AccountAlias struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Last4 string `json:"last4"`
+}
+
+// Budget represents a monthly spending limit for a category and/or description patterns
+type Budget struct {
+	ID           int64           `json:"id"`
+	Name         string          `json:"name"`
+	Category     string          `json:"category,omitempty"`
+	MonthlyLimit money.Cents     `json:"monthly_limit"`
+	Rollover     string          `json:"rollover"`
+	StartMonth   string          `json:"start_month"`
+	Patterns     []BudgetPattern `json:"patterns"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+// BudgetPattern matches transactions towards a budget by description or merchant name
+type BudgetPattern struct {
+	MatchType string `json:"match_type"`
+	Pattern   string `json:"pattern"`
+}
+
+// BudgetStatus represents a budget's health for one month
+type BudgetStatus struct {
+	Budget           Budget              `json:"budget"`
+	Month            string              `json:"month"`
+	Limit            money.Cents         `json:"limit"`
+	Carryover        money.Cents         `json:"carryover"`
+	Available        money.Cents         `json:"available"`
+	Spent            money.Cents         `json:"spent"`
+	Remaining        money.Cents         `json:"remaining"`
+	Projected        money.Cents         `json:"projected"`
+	TransactionCount int                 `json:"transaction_count"`
+	DaysElapsed      int                 `json:"days_elapsed"`
+	DaysInMonth      int                 `json:"days_in_month"`
+	Status           string              `json:"status"`
+	Currency         string              `json:"currency"`
+	Unconverted      []UnconvertedAmount `json:"unconverted,omitempty"`
 }
 
 // ProcessPDFResponse represents the response from processing a PDF
@@ -100,8 +318,9 @@ type Asset struct {
 	ID             int        `json:"id"`
 	Name           string     `json:"name"`
 	Category       string     `json:"category"`
-	CurrentValue   float64    `json:"current_value"`
-	PurchasePrice  *float64   `json:"purchase_price,omitempty"`
+	CurrentValue   money.Cents `json:"current_value"`
+	Currency       string     `json:"currency,omitempty"`
+	PurchasePrice  *money.Cents `json:"purchase_price,omitempty"`
 	PurchaseDate   *string    `json:"purchase_date,omitempty"`
 	Notes          string     `json:"notes,omitempty"`
 	IsRemoved      bool       `json:"is_removed"`
@@ -114,62 +333,80 @@ type Asset struct {
 type ValueHistory struct {
 	ID          int       `json:"id"`
 	AssetID     int       `json:"asset_id"`
-	Value       float64   `json:"value"`
+	Value       money.Cents `json:"value"`
 	RecordedAt  time.Time `json:"recorded_at"`
 	Notes       string    `json:"notes,omitempty"`
 }
 
 // AssetSummary represents aggregated asset data
 type AssetSummary struct {
-	TotalValue     float64            `json:"total_value"`
+	TotalValue     money.Cents            `json:"total_value"`
 	TotalCount     int                `json:"total_count"`
 	CountByCategory map[string]int    `json:"count_by_category"`
-	ValueByCategory map[string]float64 `json:"value_by_category"`
+	ValueByCategory map[string]money.Cents `json:"value_by_category"`
+	BaseCurrency    string              `json:"base_currency"`
+	Unconverted     []UnconvertedAmount `json:"unconverted,omitempty"`
 }
 
 // Liability represents a financial liability
 type Liability struct {
-	ID             int        `json:"id"`
-	Name           string     `json:"name"`
-	LiabilityType  string     `json:"liability_type"`
-	CurrentBalance float64    `json:"current_balance"`
-	OriginalAmount *float64   `json:"original_amount,omitempty"`
-	CreditLimit    *float64   `json:"credit_limit,omitempty"`
-	InterestRate   *float64   `json:"interest_rate,omitempty"`
-	MinimumPayment *float64   `json:"minimum_payment,omitempty"`
-	CreditorName   string     `json:"creditor_name,omitempty"`
-	AccountLast4   string     `json:"account_last4,omitempty"`
-	OpenedDate     *time.Time `json:"opened_date,omitempty"`
-	Notes          string     `json:"notes,omitempty"`
-	CreatedAt      time.Time  `json:"created_at"`
-	UpdatedAt      time.Time  `json:"updated_at"`
+	ID             int          `json:"id"`
+	Name           string       `json:"name"`
+	LiabilityType  string       `json:"liability_type"`
+	CurrentBalance money.Cents  `json:"current_balance"`
+	Currency       string       `json:"currency,omitempty"`
+	OriginalAmount *money.Cents `json:"original_amount,omitempty"`
+	CreditLimit    *money.Cents `json:"credit_limit,omitempty"`
+	InterestRate   *float64     `json:"interest_rate,omitempty"`
+	MinimumPayment *money.Cents `json:"minimum_payment,omitempty"`
+	CreditorName   string       `json:"creditor_name,omitempty"`
+	AccountLast4   string       `json:"account_last4,omitempty"`
+	OpenedDate     *time.Time   `json:"opened_date,omitempty"`
+	Notes          string       `json:"notes,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
 }
 
 // BalanceHistory represents historical balance data for a liability
 type BalanceHistory struct {
-	ID          int       `json:"id"`
-	LiabilityID int       `json:"liability_id"`
-	Balance     float64   `json:"balance"`
-	RecordedAt  time.Time `json:"recorded_at"`
-	Notes       string    `json:"notes,omitempty"`
+	ID          int         `json:"id"`
+	LiabilityID int         `json:"liability_id"`
+	Balance     money.Cents `json:"balance"`
+	RecordedAt  time.Time   `json:"recorded_at"`
+	Notes       string      `json:"notes,omitempty"`
 }
 
 // LiabilitySummary represents aggregated liability data
 type LiabilitySummary struct {
-	TotalBalance   float64            `json:"total_balance"`
+	TotalBalance   money.Cents            `json:"total_balance"`
 	TotalCount     int                `json:"total_count"`
 	CountByType    map[string]int     `json:"count_by_type"`
-	BalanceByType  map[string]float64 `json:"balance_by_type"`
+	BalanceByType  map[string]money.Cents `json:"balance_by_type"`
+	BaseCurrency   string              `json:"base_currency"`
+	Unconverted    []UnconvertedAmount `json:"unconverted,omitempty"`
 }
 
 // FinancialOverview represents a complete financial snapshot
 type FinancialOverview struct {
-	TotalAssets      float64   `json:"total_assets"`
-	TotalLiabilities float64   `json:"total_liabilities"`
-	NetWorth         float64   `json:"net_worth"`
-	AssetCount       int       `json:"asset_count"`
-	LiabilityCount   int       `json:"liability_count"`
-	Timestamp        time.Time `json:"timestamp"`
+	TotalAssets      money.Cents `json:"total_assets"`
+	TotalLiabilities money.Cents `json:"total_liabilities"`
+	NetWorth         money.Cents `json:"net_worth"`
+	AssetCount       int         `json:"asset_count"`
+	LiabilityCount   int         `json:"liability_count"`
+	Timestamp        time.Time   `json:"timestamp"`
+
+	// Totals are in BaseCurrency; values without an exchange rate are left out and listed here
+	BaseCurrency           string              `json:"base_currency"`
+	UnconvertedAssets      []UnconvertedAmount `json:"unconverted_assets,omitempty"`
+	UnconvertedLiabilities []UnconvertedAmount `json:"unconverted_liabilities,omitempty"`
+}
+
+// UnconvertedAmount represents amounts in a currency that could not be converted into the base
+// currency because there is no exchange rate for them
+type UnconvertedAmount struct {
+	Currency string      `json:"currency"`
+	Amount   money.Cents `json:"amount"`
+	Count    int         `json:"count"`
 }
 
 // WriteJSON writes a JSON response
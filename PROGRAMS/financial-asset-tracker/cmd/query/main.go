@@ -231,6 +231,7 @@ func formatCSV(assets []*db.Asset, staleAssets map[int64]int) {
 		"purchase_price",
 		"purchase_date",
 		"current_value",
+		"currency",
 		"date_added",
 		"last_updated",
 		"is_removed",
@@ -244,7 +245,7 @@ func formatCSV(assets []*db.Asset, staleAssets map[int64]int) {
 	for _, asset := range assets {
 		purchasePrice := ""
 		if asset.PurchasePrice != nil {
-			purchasePrice = fmt.Sprintf("%.2f", *asset.PurchasePrice)
+			purchasePrice = asset.PurchasePrice.String()
 		}
 
 		purchaseDate := ""
@@ -268,7 +269,8 @@ func formatCSV(assets []*db.Asset, staleAssets map[int64]int) {
 			asset.Category,
 			purchasePrice,
 			purchaseDate,
-			fmt.Sprintf("%.2f", asset.CurrentValue),
+			asset.CurrentValue.String(),
+			asset.Currency,
 			asset.DateAdded.Format("2006-01-02"),
 			asset.LastUpdated.Format("2006-01-02"),
 			fmt.Sprintf("%t", asset.IsRemoved),
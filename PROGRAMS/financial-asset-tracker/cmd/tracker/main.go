@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -9,6 +10,8 @@ import (
 	"financial-asset-tracker/db"
 	"financial-asset-tracker/pkg/app"
 	"financial-asset-tracker/pkg/exitcodes"
+	"money"
+	"sqlite-migrate/migrate"
 )
 
 func main() {
@@ -29,6 +32,8 @@ func main() {
 		handleRemove(args)
 	case "restore":
 		handleRestore(args)
+	case "migrate":
+		handleMigrate(args)
 	case "help", "--help", "-h":
 		printUsage()
 		os.Exit(exitcodes.Success)
@@ -47,6 +52,7 @@ Commands:
   update   Update an asset's value
   remove   Remove an asset (soft delete)
   restore  Restore a removed asset
+  migrate  Show (status) or apply (up) database schema migrations
   help     Show this help message
 
 Examples:
@@ -56,6 +62,9 @@ Examples:
   # Add property without purchase info
   financial-asset-tracker add --name "Main Residence" --category property --current-value 450000
 
+  # Add an asset held in another currency
+  financial-asset-tracker add --name "Lisbon Savings" --category investment --current-value 12000 --currency EUR
+
   # Update asset value
   financial-asset-tracker update --id 1 --value 17500
 
@@ -68,6 +77,9 @@ Examples:
   # Restore asset
   financial-asset-tracker restore --id 1
 
+  # Apply pending schema migrations (the database is backed up first)
+  financial-asset-tracker migrate up
+
 Environment Variables:
   DB_PATH    SQLite database file path (default: ~/.local/share/financial-asset-tracker/assets.db)
 
@@ -83,9 +95,10 @@ func handleAdd(args []string) {
 	fs := flag.NewFlagSet("add", flag.ExitOnError)
 	name := fs.String("name", "", "Asset name (required)")
 	category := fs.String("category", "other", "Asset category (vehicle, property, investment, other)")
-	purchasePrice := fs.Float64("purchase-price", 0, "Purchase price (optional)")
+	purchasePrice := fs.String("purchase-price", "", "Purchase price (optional)")
 	purchaseDate := fs.String("purchase-date", "", "Purchase date YYYY-MM-DD (optional)")
-	currentValue := fs.Float64("current-value", 0, "Current value (required)")
+	currentValue := fs.String("current-value", "", "Current value (required)")
+	currency := fs.String("currency", db.DefaultCurrency, "Currency code of the purchase price and values")
 	notes := fs.String("notes", "", "Additional notes")
 
 	fs.Parse(args)
@@ -95,7 +108,7 @@ func handleAdd(args []string) {
 		os.Exit(exitcodes.ArgsError)
 	}
 
-	if *currentValue == 0 {
+	if *currentValue == "" {
 		fmt.Fprintf(os.Stderr, "Error: --current-value is required\n")
 		os.Exit(exitcodes.ArgsError)
 	}
@@ -103,12 +116,15 @@ func handleAdd(args []string) {
 	asset := &db.Asset{
 		Name:         *name,
 		Category:     *category,
-		CurrentValue: *currentValue,
+		CurrentValue: parseAmount("current-value", *currentValue),
+		Currency:     *currency,
 		Notes:        *notes,
 	}
 
-	if *purchasePrice > 0 {
-		asset.PurchasePrice = purchasePrice
+	if *purchasePrice != "" {
+		if price := parseAmount("purchase-price", *purchasePrice); price > 0 {
+			asset.PurchasePrice = &price
+		}
 	}
 
 	if *purchaseDate != "" {
@@ -140,7 +156,7 @@ func handleAdd(args []string) {
 func handleUpdate(args []string) {
 	fs := flag.NewFlagSet("update", flag.ExitOnError)
 	id := fs.Int64("id", 0, "Asset ID (required)")
-	value := fs.Float64("value", 0, "New value (required)")
+	value := fs.String("value", "", "New value (required)")
 	notes := fs.String("notes", "", "Update notes")
 
 	fs.Parse(args)
@@ -150,10 +166,11 @@ func handleUpdate(args []string) {
 		os.Exit(exitcodes.ArgsError)
 	}
 
-	if *value == 0 {
+	if *value == "" {
 		fmt.Fprintf(os.Stderr, "Error: --value is required\n")
 		os.Exit(exitcodes.ArgsError)
 	}
+	newValue := parseAmount("value", *value)
 
 	database, err := app.InitDatabase()
 	if err != nil {
@@ -162,7 +179,7 @@ func handleUpdate(args []string) {
 	}
 	defer database.Close()
 
-	err = database.UpdateAssetValue(*id, *value, *notes)
+	err = database.UpdateAssetValue(*id, newValue, *notes)
 	if err != nil {
 		if err.Error() == "asset not found" {
 			fmt.Fprintf(os.Stderr, "Error: Asset ID %d not found\n", *id)
@@ -250,3 +267,31 @@ func handleRestore(args []string) {
 	fmt.Printf("Asset %d restored successfully\n", *id)
 	os.Exit(exitcodes.Success)
 }
+
+func handleMigrate(args []string) {
+	database, err := app.OpenDatabase()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Database error: %v\n", err)
+		os.Exit(exitcodes.DBError)
+	}
+	defer database.Close()
+
+	if err := database.RunMigrateCLI(args); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to migrate database: %v\n", err)
+		if errors.Is(err, migrate.ErrUsage) {
+			os.Exit(exitcodes.ArgsError)
+		}
+		os.Exit(exitcodes.DBError)
+	}
+	os.Exit(exitcodes.Success)
+}
+
+// parseAmount reads a decimal amount flag such as 18000 or 1234.56, exiting on anything else
+func parseAmount(name, value string) money.Cents {
+	amount, err := money.Parse(value)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid --%s: %v\n", name, err)
+		os.Exit(exitcodes.ArgsError)
+	}
+	return amount
+}
@@ -0,0 +1,32 @@
+-- Assets table
+CREATE TABLE IF NOT EXISTS assets (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    category TEXT NOT NULL,
+    purchase_price REAL,
+    purchase_date DATE,
+    current_value REAL NOT NULL,
+    date_added DATETIME DEFAULT CURRENT_TIMESTAMP,
+    last_updated DATETIME DEFAULT CURRENT_TIMESTAMP,
+    is_removed BOOLEAN DEFAULT 0,
+    removed_date DATE,
+    notes TEXT
+);
+
+-- Value history table
+CREATE TABLE IF NOT EXISTS asset_value_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    asset_id INTEGER NOT NULL,
+    value REAL NOT NULL,
+    recorded_date DATE NOT NULL,
+    notes TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (asset_id) REFERENCES assets(id)
+);
+
+-- Indexes
+CREATE INDEX IF NOT EXISTS idx_assets_category ON assets(category);
+CREATE INDEX IF NOT EXISTS idx_assets_is_removed ON assets(is_removed);
+CREATE INDEX IF NOT EXISTS idx_assets_last_updated ON assets(last_updated);
+CREATE INDEX IF NOT EXISTS idx_value_history_asset_id ON asset_value_history(asset_id);
+CREATE INDEX IF NOT EXISTS idx_value_history_recorded_date ON asset_value_history(recorded_date);
@@ -0,0 +1,2 @@
+-- Currency of each asset's values; existing assets were all entered in US dollars
+ALTER TABLE assets ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
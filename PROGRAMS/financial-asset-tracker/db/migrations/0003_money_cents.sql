@@ -0,0 +1,60 @@
+-- Values as integer cents (money.Cents in Go) instead of REAL, so totals don't drift by fractions
+-- of a cent; SQLite can't change a column's type, so both tables are rebuilt
+--
+-- asset_value_history references assets, so dropping the old assets table would fail the
+-- foreign key check
+-- migrate:foreign-keys-off
+
+-- Assets table (purchase_price and current_value in cents of currency)
+CREATE TABLE assets_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    category TEXT NOT NULL,
+    purchase_price INTEGER,
+    purchase_date DATE,
+    current_value INTEGER NOT NULL,
+    date_added DATETIME DEFAULT CURRENT_TIMESTAMP,
+    last_updated DATETIME DEFAULT CURRENT_TIMESTAMP,
+    is_removed BOOLEAN DEFAULT 0,
+    removed_date DATE,
+    notes TEXT,
+    currency TEXT NOT NULL DEFAULT 'USD'
+);
+
+INSERT INTO assets_new (
+    id, name, category, purchase_price, purchase_date, current_value,
+    date_added, last_updated, is_removed, removed_date, notes, currency
+)
+SELECT
+    id, name, category, CAST(ROUND(purchase_price * 100) AS INTEGER), purchase_date,
+    CAST(ROUND(current_value * 100) AS INTEGER),
+    date_added, last_updated, is_removed, removed_date, notes, currency
+FROM assets;
+
+DROP TABLE assets;
+ALTER TABLE assets_new RENAME TO assets;
+
+CREATE INDEX idx_assets_category ON assets(category);
+CREATE INDEX idx_assets_is_removed ON assets(is_removed);
+CREATE INDEX idx_assets_last_updated ON assets(last_updated);
+
+-- Value history table (value in cents)
+CREATE TABLE asset_value_history_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    asset_id INTEGER NOT NULL,
+    value INTEGER NOT NULL,
+    recorded_date DATE NOT NULL,
+    notes TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (asset_id) REFERENCES assets(id)
+);
+
+INSERT INTO asset_value_history_new (id, asset_id, value, recorded_date, notes, created_at)
+SELECT id, asset_id, CAST(ROUND(value * 100) AS INTEGER), recorded_date, notes, created_at
+FROM asset_value_history;
+
+DROP TABLE asset_value_history;
+ALTER TABLE asset_value_history_new RENAME TO asset_value_history;
+
+CREATE INDEX idx_value_history_asset_id ON asset_value_history(asset_id);
+CREATE INDEX idx_value_history_recorded_date ON asset_value_history(recorded_date);
@@ -3,43 +3,68 @@ package db
 import (
 	"database/sql"
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+	"money"
 )
 
+// DefaultCurrency is the currency of assets added without one
+const DefaultCurrency = "USD"
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
 // DB wraps the SQLite database connection
 type DB struct {
 	conn *sql.DB
+	path string
 }
 
 // Asset represents a tracked asset
 type Asset struct {
-	ID            int64      `json:"id"`
-	Name          string     `json:"name"`
-	Category      string     `json:"category"`
-	PurchasePrice *float64   `json:"purchase_price,omitempty"`
-	PurchaseDate  *time.Time `json:"purchase_date,omitempty"`
-	CurrentValue  float64    `json:"current_value"`
-	DateAdded     time.Time  `json:"date_added"`
-	LastUpdated   time.Time  `json:"last_updated"`
-	IsRemoved     bool       `json:"is_removed"`
-	RemovedDate   *time.Time `json:"removed_date,omitempty"`
-	Notes         string     `json:"notes,omitempty"`
+	ID            int64        `json:"id"`
+	Name          string       `json:"name"`
+	Category      string       `json:"category"`
+	PurchasePrice *money.Cents `json:"purchase_price,omitempty"`
+	PurchaseDate  *time.Time   `json:"purchase_date,omitempty"`
+	CurrentValue  money.Cents  `json:"current_value"`
+	Currency      string       `json:"currency"` // currency code of the purchase price and values
+	DateAdded     time.Time    `json:"date_added"`
+	LastUpdated   time.Time    `json:"last_updated"`
+	IsRemoved     bool         `json:"is_removed"`
+	RemovedDate   *time.Time   `json:"removed_date,omitempty"`
+	Notes         string       `json:"notes,omitempty"`
 }
 
 // ValueHistory represents a historical value record
 type ValueHistory struct {
-	ID           int64     `json:"id"`
-	AssetID      int64     `json:"asset_id"`
-	Value        float64   `json:"value"`
-	RecordedDate time.Time `json:"recorded_date"`
-	Notes        string    `json:"notes,omitempty"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID           int64       `json:"id"`
+	AssetID      int64       `json:"asset_id"`
+	Value        money.Cents `json:"value"`
+	RecordedDate time.Time   `json:"recorded_date"`
+	Notes        string      `json:"notes,omitempty"`
+	CreatedAt    time.Time   `json:"created_at"`
 }
 
-// New creates a new database connection and initializes schema
+// New creates a new database connection and applies pending schema migrations
 func New(dbPath string) (*DB, error) {
+	db, err := Open(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.Migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// Open creates a new database connection without touching the schema
+func Open(dbPath string) (*DB, error) {
 	conn, err := sql.Open("sqlite3", dbPath)
 	if err != nil {
 		return nil, fmt.Errorf("open database: %w", err)
@@ -58,15 +83,7 @@ func New(dbPath string) (*DB, error) {
 		return nil, fmt.Errorf("enable foreign keys: %w", err)
 	}
 
-	db := &DB{conn: conn}
-
-	// Initialize schema
-	if err := db.initSchema(); err != nil {
-		conn.Close()
-		return nil, fmt.Errorf("initialize schema: %w", err)
-	}
-
-	return db, nil
+	return &DB{conn: conn, path: dbPath}, nil
 }
 
 // Close closes the database connection
@@ -74,53 +91,16 @@ func (db *DB) Close() error {
 	return db.conn.Close()
 }
 
-// initSchema creates the necessary tables if they don't exist
-func (db *DB) initSchema() error {
-	schema := `
-	-- Assets table
-	CREATE TABLE IF NOT EXISTS assets (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		name TEXT NOT NULL,
-		category TEXT NOT NULL,
-		purchase_price REAL,
-		purchase_date DATE,
-		current_value REAL NOT NULL,
-		date_added DATETIME DEFAULT CURRENT_TIMESTAMP,
-		last_updated DATETIME DEFAULT CURRENT_TIMESTAMP,
-		is_removed BOOLEAN DEFAULT 0,
-		removed_date DATE,
-		notes TEXT
-	);
-
-	-- Value history table
-	CREATE TABLE IF NOT EXISTS asset_value_history (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		asset_id INTEGER NOT NULL,
-		value REAL NOT NULL,
-		recorded_date DATE NOT NULL,
-		notes TEXT,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (asset_id) REFERENCES assets(id)
-	);
-
-	-- Indexes
-	CREATE INDEX IF NOT EXISTS idx_assets_category ON assets(category);
-	CREATE INDEX IF NOT EXISTS idx_assets_is_removed ON assets(is_removed);
-	CREATE INDEX IF NOT EXISTS idx_assets_last_updated ON assets(last_updated);
-	CREATE INDEX IF NOT EXISTS idx_value_history_asset_id ON asset_value_history(asset_id);
-	CREATE INDEX IF NOT EXISTS idx_value_history_recorded_date ON asset_value_history(recorded_date);
-	`
-
-	_, err := db.conn.Exec(schema)
-	if err != nil {
-		return fmt.Errorf("create schema: %w", err)
-	}
-
-	return nil
-}
-
 // AddAsset inserts a new asset into the database
 func (db *DB) AddAsset(asset *Asset) (int64, error) {
+	asset.Currency = strings.ToUpper(strings.TrimSpace(asset.Currency))
+	if asset.Currency == "" {
+		asset.Currency = DefaultCurrency
+	}
+	if !currencyCode.MatchString(asset.Currency) {
+		return 0, fmt.Errorf("invalid currency %q (use a 3-letter code such as USD)", asset.Currency)
+	}
+
 	now := time.Now()
 	asset.DateAdded = now
 	asset.LastUpdated = now
@@ -128,8 +108,8 @@ func (db *DB) AddAsset(asset *Asset) (int64, error) {
 	query := `
 		INSERT INTO assets (
 			name, category, purchase_price, purchase_date,
-			current_value, date_added, last_updated, notes
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			current_value, currency, date_added, last_updated, notes
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	result, err := db.conn.Exec(
@@ -139,6 +119,7 @@ func (db *DB) AddAsset(asset *Asset) (int64, error) {
 		asset.PurchasePrice,
 		asset.PurchaseDate,
 		asset.CurrentValue,
+		asset.Currency,
 		asset.DateAdded,
 		asset.LastUpdated,
 		asset.Notes,
@@ -169,7 +150,7 @@ func (db *DB) AddAsset(asset *Asset) (int64, error) {
 }
 
 // UpdateAssetValue updates an asset's current value and creates history entry
-func (db *DB) UpdateAssetValue(id int64, value float64, notes string) error {
+func (db *DB) UpdateAssetValue(id int64, value money.Cents, notes string) error {
 	// Check if asset exists and is not removed
 	var isRemoved bool
 	err := db.conn.QueryRow("SELECT is_removed FROM assets WHERE id = ?", id).Scan(&isRemoved)
@@ -254,7 +235,7 @@ func (db *DB) RestoreAsset(id int64) error {
 func (db *DB) GetAsset(id int64) (*Asset, error) {
 	query := `
 		SELECT id, name, category, purchase_price, purchase_date,
-		       current_value, date_added, last_updated,
+		       current_value, currency, date_added, last_updated,
 		       is_removed, removed_date, notes
 		FROM assets WHERE id = ?
 	`
@@ -267,6 +248,7 @@ func (db *DB) GetAsset(id int64) (*Asset, error) {
 		&asset.PurchasePrice,
 		&asset.PurchaseDate,
 		&asset.CurrentValue,
+		&asset.Currency,
 		&asset.DateAdded,
 		&asset.LastUpdated,
 		&asset.IsRemoved,
@@ -288,7 +270,7 @@ func (db *DB) GetAsset(id int64) (*Asset, error) {
 func (db *DB) ListAssets(includeRemoved bool, category string) ([]*Asset, error) {
 	query := `
 		SELECT id, name, category, purchase_price, purchase_date,
-		       current_value, date_added, last_updated,
+		       current_value, currency, date_added, last_updated,
 		       is_removed, removed_date, notes
 		FROM assets
 		WHERE 1=1
@@ -323,6 +305,7 @@ func (db *DB) ListAssets(includeRemoved bool, category string) ([]*Asset, error)
 			&asset.PurchasePrice,
 			&asset.PurchaseDate,
 			&asset.CurrentValue,
+			&asset.Currency,
 			&asset.DateAdded,
 			&asset.LastUpdated,
 			&asset.IsRemoved,
@@ -404,11 +387,13 @@ func (db *DB) AddValueHistory(history *ValueHistory) error {
 }
 
 // GetSummary returns summary statistics
+// Values are not converted between currencies: total_value adds up all values as entered, and
+// by_currency and the category breakdown keep each currency apart
 func (db *DB) GetSummary() (map[string]interface{}, error) {
 	summary := make(map[string]interface{})
 
 	// Total active assets value
-	var totalValue float64
+	var totalValue money.Cents
 	err := db.conn.QueryRow(`
 		SELECT COALESCE(SUM(current_value), 0)
 		FROM assets
@@ -419,13 +404,42 @@ func (db *DB) GetSummary() (map[string]interface{}, error) {
 	}
 	summary["total_value"] = totalValue
 
+	// Totals by currency
+	currencyRows, err := db.conn.Query(`
+		SELECT currency, COUNT(*), SUM(current_value)
+		FROM assets
+		WHERE is_removed = 0
+		GROUP BY currency
+		ORDER BY currency
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("get currency breakdown: %w", err)
+	}
+	defer currencyRows.Close()
+
+	currencies := make([]map[string]interface{}, 0)
+	for currencyRows.Next() {
+		var currency string
+		var count int
+		var value money.Cents
+		if err := currencyRows.Scan(&currency, &count, &value); err != nil {
+			return nil, fmt.Errorf("scan currency: %w", err)
+		}
+		currencies = append(currencies, map[string]interface{}{
+			"currency": currency,
+			"count":    count,
+			"value":    value,
+		})
+	}
+	summary["by_currency"] = currencies
+
 	// Count by category
 	rows, err := db.conn.Query(`
-		SELECT category, COUNT(*), SUM(current_value)
+		SELECT category, currency, COUNT(*), SUM(current_value)
 		FROM assets
 		WHERE is_removed = 0
-		GROUP BY category
-		ORDER BY category
+		GROUP BY category, currency
+		ORDER BY category, currency
 	`)
 	if err != nil {
 		return nil, fmt.Errorf("get category breakdown: %w", err)
@@ -434,14 +448,15 @@ func (db *DB) GetSummary() (map[string]interface{}, error) {
 
 	categories := make([]map[string]interface{}, 0)
 	for rows.Next() {
-		var category string
+		var category, currency string
 		var count int
-		var value float64
-		if err := rows.Scan(&category, &count, &value); err != nil {
+		var value money.Cents
+		if err := rows.Scan(&category, &currency, &count, &value); err != nil {
 			return nil, fmt.Errorf("scan category: %w", err)
 		}
 		categories = append(categories, map[string]interface{}{
 			"category": category,
+			"currency": currency,
 			"count":    count,
 			"value":    value,
 		})
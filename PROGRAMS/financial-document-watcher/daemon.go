@@ -0,0 +1,179 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"financial-document-watcher/db"
+)
+
+// pendingFile is a file that appeared in a watch path and is processed once it has settled
+type pendingFile struct {
+	watch    WatchConfig
+	path     string
+	deadline time.Time // pushed back by every write, so half-written files wait
+}
+
+// runDaemon watches every watch path with inotify (through fsnotify) until SIGINT or SIGTERM and
+// processes each matching file once no write to it has been seen for the settle delay
+// Files that failed are queued again for the end of their retry backoff. At startup, every rescan
+// interval and whenever the kernel dropped events, all files matching a watch are queued too,
+// catching files that arrived unseen; watch paths that didn't exist yet are watched from the next
+// rescan on
+func runDaemon(watches []WatchConfig, database *db.DB, dryRun, force bool, settle, rescan time.Duration) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Fatalf("Failed to start file watcher: %v", err)
+	}
+	defer watcher.Close()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+
+	// Watch paths are watched once even when several watches share them
+	watched := make(map[string]bool)
+	addWatches := func() {
+		for _, watch := range watches {
+			dir := filepath.Clean(watch.WatchPath)
+			if watched[dir] {
+				continue
+			}
+			if err := watcher.Add(dir); err != nil {
+				log.Printf("[%s] WARNING: Cannot watch %s (retried at the next rescan): %v", watch.WatchID, dir, err)
+				continue
+			}
+			watched[dir] = true
+			log.Printf("[%s] Watching %s for %s", watch.WatchID, dir, watch.FilePattern)
+		}
+	}
+
+	pending := make(map[string]*pendingFile) // by watch ID and path
+	queue := func(watch WatchConfig, path string) {
+		key := watch.WatchID + "\x00" + path
+		if pending[key] == nil {
+			pending[key] = &pendingFile{watch: watch, path: path}
+		}
+		pending[key].deadline = time.Now().Add(settle)
+	}
+	scan := func() {
+		for _, watch := range watches {
+			matches, err := filepath.Glob(filepath.Join(watch.WatchPath, watch.FilePattern))
+			if err != nil {
+				log.Printf("[%s] ERROR: Failed to glob pattern %s: %v", watch.WatchID, watch.FilePattern, err)
+				continue
+			}
+			for _, path := range matches {
+				queue(watch, path)
+			}
+		}
+	}
+
+	addWatches()
+	scan()
+	log.Printf("Daemon started (settle delay %s, rescan every %s)", settle, rescan)
+
+	var rescanTick <-chan time.Time
+	if rescan > 0 {
+		ticker := time.NewTicker(rescan)
+		defer ticker.Stop()
+		rescanTick = ticker.C
+	}
+	settleTicker := time.NewTicker(settleInterval(settle))
+	defer settleTicker.Stop()
+
+	for {
+		select {
+		case sig := <-stop:
+			log.Printf("Received %s, stopping daemon with %d file(s) still settling", sig, len(pending))
+			return
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if !event.Has(fsnotify.Create) && !event.Has(fsnotify.Write) {
+				continue
+			}
+			for _, watch := range matchingWatches(watches, event.Name) {
+				queue(watch, event.Name)
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				log.Printf("WARNING: File events were dropped; rescanning all watches")
+				scan()
+				continue
+			}
+			log.Printf("ERROR: File watcher: %v", err)
+
+		case now := <-settleTicker.C:
+			processed, failed := 0, 0
+			for key, file := range pending {
+				if now.Before(file.deadline) {
+					continue
+				}
+				delete(pending, key)
+				// Files renamed away or removed before they settled are left alone
+				if info, err := os.Stat(file.path); err != nil || !info.Mode().IsRegular() {
+					continue
+				}
+				p, e := processFile(file.watch, database, file.path, dryRun, force)
+				processed += p
+				failed += e
+
+				// Files left in place are tried again once their backoff is over
+				attempt, err := database.GetFileAttempt(file.watch.WatchID, file.path)
+				if err == nil && attempt != nil && attempt.Status == db.AttemptRetrying {
+					if _, err := os.Stat(file.path); err == nil {
+						file.deadline = attempt.NextAttemptAt
+						pending[key] = file
+					}
+				}
+			}
+			if processed+failed > 0 {
+				log.Printf("Processed: %d, Errors: %d", processed, failed)
+			}
+
+		case <-rescanTick:
+			addWatches()
+			scan()
+		}
+	}
+}
+
+// matchingWatches returns the watches whose path holds the file and whose pattern matches its name
+func matchingWatches(watches []WatchConfig, path string) []WatchConfig {
+	var result []WatchConfig
+	for _, watch := range watches {
+		if filepath.Clean(watch.WatchPath) != filepath.Dir(path) {
+			continue
+		}
+		if ok, _ := filepath.Match(watch.FilePattern, filepath.Base(path)); ok {
+			result = append(result, watch)
+		}
+	}
+	return result
+}
+
+// settleInterval is how often settling files are checked: a quarter of the settle delay, between
+// 100ms and a second
+func settleInterval(settle time.Duration) time.Duration {
+	interval := settle / 4
+	if interval < 100*time.Millisecond {
+		return 100 * time.Millisecond
+	}
+	if interval > time.Second {
+		return time.Second
+	}
+	return interval
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSettleInterval(t *testing.T) {
+	tests := []struct {
+		name   string
+		settle time.Duration
+		want   time.Duration
+	}{
+		{"quarter of the settle delay", 2 * time.Second, 500 * time.Millisecond},
+		{"at least 100ms", 200 * time.Millisecond, 100 * time.Millisecond},
+		{"zero settle delay", 0, 100 * time.Millisecond},
+		{"at most a second", 10 * time.Second, time.Second},
+		{"long settle delay", time.Hour, time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := settleInterval(tt.settle); got != tt.want {
+				t.Errorf("settleInterval(%s) = %s, want %s", tt.settle, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchingWatches(t *testing.T) {
+	watches := []WatchConfig{
+		{WatchID: "bank", WatchPath: "/in/bank/", FilePattern: "*.pdf"},
+		{WatchID: "bank_csv", WatchPath: "/in/bank", FilePattern: "*.csv"},
+		{WatchID: "invoices", WatchPath: "/in/invoices", FilePattern: "invoice_*.pdf"},
+		{WatchID: "archive", WatchPath: "/in/bank", FilePattern: "*"},
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want []string
+	}{
+		{"pattern and trailing slash", "/in/bank/statement.pdf", []string{"bank", "archive"}},
+		{"other pattern in the same path", "/in/bank/export.csv", []string{"bank_csv", "archive"}},
+		{"prefixed pattern", "/in/invoices/invoice_42.pdf", []string{"invoices"}},
+		{"pattern miss", "/in/invoices/receipt.pdf", nil},
+		{"subdirectory is not watched", "/in/bank/failed/statement.pdf", nil},
+		{"other directory", "/elsewhere/statement.pdf", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := matchingWatches(watches, tt.path)
+			if len(got) != len(tt.want) {
+				t.Fatalf("matchingWatches(%q) returned %d watches, want %v", tt.path, len(got), tt.want)
+			}
+			for i, watch := range got {
+				if watch.WatchID != tt.want[i] {
+					t.Errorf("matchingWatches(%q)[%d] = %s, want %s", tt.path, i, watch.WatchID, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+-- Files handed to a watch's executable, so they are only processed once
+CREATE TABLE IF NOT EXISTS processed_files (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    watch_id TEXT NOT NULL,
+    file_path TEXT NOT NULL,
+    processed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(watch_id, file_path)
+);
+
+CREATE INDEX IF NOT EXISTS idx_watch_id ON processed_files(watch_id);
+CREATE INDEX IF NOT EXISTS idx_file_path ON processed_files(file_path);
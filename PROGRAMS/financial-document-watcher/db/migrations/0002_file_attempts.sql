@@ -0,0 +1,16 @@
+-- Failed runs of a watch's executable per file, so retries back off and stop after the watch's
+-- max_attempts; 'failed' files were moved to the watch's failed_path
+CREATE TABLE IF NOT EXISTS file_attempts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    watch_id TEXT NOT NULL,
+    file_path TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_exit_code INTEGER,
+    last_attempt_at DATETIME,
+    next_attempt_at DATETIME,
+    status TEXT NOT NULL DEFAULT 'retrying' CHECK (status IN ('retrying', 'failed')),
+    failed_path TEXT,
+    UNIQUE(watch_id, file_path)
+);
+
+CREATE INDEX IF NOT EXISTS idx_file_attempts_status ON file_attempts(status);
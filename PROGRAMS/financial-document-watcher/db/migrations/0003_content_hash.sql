@@ -0,0 +1,26 @@
+-- Processed files are recognized by the SHA-256 of their content instead of their path, so a
+-- statement downloaded again as "statement (1).pdf" is skipped and a new file reusing an old name
+-- isn't. The same path can now be processed more than once, so the table is rebuilt without
+-- UNIQUE(watch_id, file_path). Rows from before have no hash and are still matched by path
+CREATE TABLE processed_files_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    watch_id TEXT NOT NULL,
+    file_path TEXT NOT NULL,      -- path the file was found at
+    original_name TEXT,           -- its name there
+    content_hash TEXT,            -- hex SHA-256 of the content
+    processed_path TEXT,          -- where it was moved, renamed if the name was taken
+    processed_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO processed_files_new (id, watch_id, file_path, original_name, processed_at)
+SELECT id, watch_id, file_path,
+       replace(file_path, rtrim(file_path, replace(file_path, '/', '')), ''),
+       processed_at
+FROM processed_files;
+
+DROP TABLE processed_files;
+ALTER TABLE processed_files_new RENAME TO processed_files;
+
+CREATE INDEX idx_watch_id ON processed_files(watch_id);
+CREATE INDEX idx_file_path ON processed_files(file_path);
+CREATE INDEX idx_processed_files_hash ON processed_files(watch_id, content_hash);
@@ -0,0 +1,4 @@
+-- Files with the content of an already processed file are moved out of the watch path instead of
+-- being skipped on every run. They are recorded here too, pointing at the row they duplicate;
+-- only rows without duplicate_of count as processed
+ALTER TABLE processed_files ADD COLUMN duplicate_of INTEGER;
@@ -3,6 +3,7 @@ package db
 import (
 	"database/sql"
 	"fmt"
+	"path/filepath"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -11,18 +12,73 @@ import (
 // DB wraps the SQLite database connection
 type DB struct {
 	conn *sql.DB
+	path string
 }
 
 // ProcessedFile represents a file that has been processed
 type ProcessedFile struct {
-	ID          int64
-	WatchID     string
-	FilePath    string
-	ProcessedAt time.Time
+	ID            int64
+	WatchID       string
+	FilePath      string
+	OriginalName  string
+	ContentHash   string // hex SHA-256; empty for files processed before hashing
+	ProcessedPath string
+	ProcessedAt   time.Time
+	DuplicateOf   *int64 // set for a duplicate moved aside unprocessed: the ID of the file it repeats
 }
 
-// New creates a new database connection and initializes the schema
+// processedFileColumns are the processed_files columns read by scanProcessedFile
+const processedFileColumns = `id, watch_id, file_path, COALESCE(original_name, ''), COALESCE(content_hash, ''),
+	COALESCE(processed_path, ''), processed_at, duplicate_of`
+
+func scanProcessedFile(row interface{ Scan(...interface{}) error }) (*ProcessedFile, error) {
+	var pf ProcessedFile
+	var duplicateOf sql.NullInt64
+	err := row.Scan(&pf.ID, &pf.WatchID, &pf.FilePath, &pf.OriginalName, &pf.ContentHash, &pf.ProcessedPath, &pf.ProcessedAt, &duplicateOf)
+	if err != nil {
+		return nil, err
+	}
+	if duplicateOf.Valid {
+		pf.DuplicateOf = &duplicateOf.Int64
+	}
+	return &pf, nil
+}
+
+// File attempt statuses
+const (
+	AttemptRetrying = "retrying"
+	AttemptFailed   = "failed"
+)
+
+// FileAttempt tracks the failed runs of a watch's executable on a file
+type FileAttempt struct {
+	WatchID       string
+	FilePath      string
+	Attempts      int
+	LastExitCode  int
+	LastAttemptAt time.Time
+	NextAttemptAt time.Time // retrying files wait until then
+	Status        string
+	FailedPath    string // where a failed file was moved
+}
+
+// New creates a new database connection and applies pending schema migrations
 func New(dbPath string) (*DB, error) {
+	db, err := Open(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.Migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// Open creates a new database connection without touching the schema
+func Open(dbPath string) (*DB, error) {
 	conn, err := sql.Open("sqlite3", dbPath)
 	if err != nil {
 		return nil, fmt.Errorf("open database: %w", err)
@@ -34,15 +90,7 @@ func New(dbPath string) (*DB, error) {
 		return nil, fmt.Errorf("ping database: %w", err)
 	}
 
-	db := &DB{conn: conn}
-
-	// Initialize schema
-	if err := db.initSchema(); err != nil {
-		conn.Close()
-		return nil, fmt.Errorf("initialize schema: %w", err)
-	}
-
-	return db, nil
+	return &DB{conn: conn, path: dbPath}, nil
 }
 
 // Close closes the database connection
@@ -50,49 +98,51 @@ func (db *DB) Close() error {
 	return db.conn.Close()
 }
 
-// initSchema creates the necessary tables if they don't exist
-func (db *DB) initSchema() error {
-	schema := `
-	CREATE TABLE IF NOT EXISTS processed_files (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		watch_id TEXT NOT NULL,
-		file_path TEXT NOT NULL,
-		processed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		UNIQUE(watch_id, file_path)
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_watch_id ON processed_files(watch_id);
-	CREATE INDEX IF NOT EXISTS idx_file_path ON processed_files(file_path);
+// FindProcessedFile returns the latest file processed for a watch with the given content, or nil
+// Files processed before content hashing have no hash and are matched by their path instead;
+// duplicates moved aside are never returned
+func (db *DB) FindProcessedFile(watchID, contentHash, filePath string) (*ProcessedFile, error) {
+	query := `
+		SELECT ` + processedFileColumns + `
+		FROM processed_files
+		WHERE watch_id = ? AND duplicate_of IS NULL
+			AND (content_hash = ? OR (content_hash IS NULL AND file_path = ?))
+		ORDER BY processed_at DESC, id DESC
+		LIMIT 1
 	`
 
-	_, err := db.conn.Exec(schema)
+	pf, err := scanProcessedFile(db.conn.QueryRow(query, watchID, contentHash, filePath))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
 	if err != nil {
-		return fmt.Errorf("create schema: %w", err)
+		return nil, fmt.Errorf("query processed file: %w", err)
 	}
 
-	return nil
+	return pf, nil
 }
 
-// IsFileProcessed checks if a file has already been processed for a given watch
-func (db *DB) IsFileProcessed(watchID, filePath string) (bool, error) {
-	query := `SELECT COUNT(*) FROM processed_files WHERE watch_id = ? AND file_path = ?`
+// RecordProcessedFile records that a file has been processed and where it was moved to
+func (db *DB) RecordProcessedFile(watchID, filePath, contentHash, processedPath string) error {
+	query := `INSERT INTO processed_files (watch_id, file_path, original_name, content_hash, processed_path) VALUES (?, ?, ?, ?, ?)`
 
-	var count int
-	err := db.conn.QueryRow(query, watchID, filePath).Scan(&count)
+	_, err := db.conn.Exec(query, watchID, filePath, filepath.Base(filePath), contentHash, processedPath)
 	if err != nil {
-		return false, fmt.Errorf("query processed file: %w", err)
+		return fmt.Errorf("insert processed file: %w", err)
 	}
 
-	return count > 0, nil
+	return nil
 }
 
-// RecordProcessedFile records that a file has been processed
-func (db *DB) RecordProcessedFile(watchID, filePath string) error {
-	query := `INSERT INTO processed_files (watch_id, file_path) VALUES (?, ?)`
+// RecordDuplicateFile records that a file with the content of processed file duplicateOf was moved
+// to movedPath without being processed
+func (db *DB) RecordDuplicateFile(watchID, filePath, contentHash, movedPath string, duplicateOf int64) error {
+	query := `INSERT INTO processed_files (watch_id, file_path, original_name, content_hash, processed_path, duplicate_of) VALUES (?, ?, ?, ?, ?, ?)`
 
-	_, err := db.conn.Exec(query, watchID, filePath)
+	_, err := db.conn.Exec(query, watchID, filePath, filepath.Base(filePath), contentHash, movedPath, duplicateOf)
 	if err != nil {
-		return fmt.Errorf("insert processed file: %w", err)
+		return fmt.Errorf("insert duplicate file: %w", err)
 	}
 
 	return nil
@@ -101,21 +151,14 @@ func (db *DB) RecordProcessedFile(watchID, filePath string) error {
 // GetLastProcessedFile returns the most recently processed file for a watch
 func (db *DB) GetLastProcessedFile(watchID string) (*ProcessedFile, error) {
 	query := `
-		SELECT id, watch_id, file_path, processed_at
+		SELECT ` + processedFileColumns + `
 		FROM processed_files
-		WHERE watch_id = ?
-		ORDER BY processed_at DESC
+		WHERE watch_id = ? AND duplicate_of IS NULL
+		ORDER BY processed_at DESC, id DESC
 		LIMIT 1
 	`
 
-	var pf ProcessedFile
-	err := db.conn.QueryRow(query, watchID).Scan(
-		&pf.ID,
-		&pf.WatchID,
-		&pf.FilePath,
-		&pf.ProcessedAt,
-	)
-
+	pf, err := scanProcessedFile(db.conn.QueryRow(query, watchID))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -124,19 +167,25 @@ func (db *DB) GetLastProcessedFile(watchID string) (*ProcessedFile, error) {
 		return nil, fmt.Errorf("query last processed file: %w", err)
 	}
 
-	return &pf, nil
+	return pf, nil
 }
 
-// GetProcessedFiles returns all processed files for a watch
-func (db *DB) GetProcessedFiles(watchID string) ([]ProcessedFile, error) {
+// GetProcessedFiles returns the processed files for a watch, or for every watch when watchID is
+// empty, newest first, including duplicates moved aside; limit 0 returns them all
+func (db *DB) GetProcessedFiles(watchID string, limit int) ([]ProcessedFile, error) {
 	query := `
-		SELECT id, watch_id, file_path, processed_at
+		SELECT ` + processedFileColumns + `
 		FROM processed_files
-		WHERE watch_id = ?
-		ORDER BY processed_at DESC
+		WHERE ? = '' OR watch_id = ?
+		ORDER BY processed_at DESC, id DESC
 	`
+	args := []interface{}{watchID, watchID}
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
 
-	rows, err := db.conn.Query(query, watchID)
+	rows, err := db.conn.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("query processed files: %w", err)
 	}
@@ -144,11 +193,11 @@ func (db *DB) GetProcessedFiles(watchID string) ([]ProcessedFile, error) {
 
 	var files []ProcessedFile
 	for rows.Next() {
-		var pf ProcessedFile
-		if err := rows.Scan(&pf.ID, &pf.WatchID, &pf.FilePath, &pf.ProcessedAt); err != nil {
+		pf, err := scanProcessedFile(rows)
+		if err != nil {
 			return nil, fmt.Errorf("scan processed file: %w", err)
 		}
-		files = append(files, pf)
+		files = append(files, *pf)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -178,3 +227,93 @@ func (db *DB) DeleteProcessedFile(watchID, filePath string) error {
 
 	return nil
 }
+
+// GetFileAttempt returns the failed attempts at a file for a watch, or nil if there were none
+func (db *DB) GetFileAttempt(watchID, filePath string) (*FileAttempt, error) {
+	query := `
+		SELECT watch_id, file_path, attempts, COALESCE(last_exit_code, 0), last_attempt_at,
+			next_attempt_at, status, COALESCE(failed_path, '')
+		FROM file_attempts
+		WHERE watch_id = ? AND file_path = ?
+	`
+
+	var fa FileAttempt
+	var lastAttempt, nextAttempt sql.NullTime
+	err := db.conn.QueryRow(query, watchID, filePath).Scan(
+		&fa.WatchID,
+		&fa.FilePath,
+		&fa.Attempts,
+		&fa.LastExitCode,
+		&lastAttempt,
+		&nextAttempt,
+		&fa.Status,
+		&fa.FailedPath,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("query file attempt: %w", err)
+	}
+
+	fa.LastAttemptAt = lastAttempt.Time
+	fa.NextAttemptAt = nextAttempt.Time
+	return &fa, nil
+}
+
+// RecordFailedAttempt records the latest failed attempt at a file and when to try again
+func (db *DB) RecordFailedAttempt(watchID, filePath string, attempts, exitCode int, nextAttempt time.Time) error {
+	query := `
+		INSERT INTO file_attempts (watch_id, file_path, attempts, last_exit_code, last_attempt_at, next_attempt_at, status, failed_path)
+		VALUES (?, ?, ?, ?, ?, ?, ?, NULL)
+		ON CONFLICT(watch_id, file_path) DO UPDATE SET
+			attempts = excluded.attempts,
+			last_exit_code = excluded.last_exit_code,
+			last_attempt_at = excluded.last_attempt_at,
+			next_attempt_at = excluded.next_attempt_at,
+			status = excluded.status,
+			failed_path = NULL
+	`
+
+	_, err := db.conn.Exec(query, watchID, filePath, attempts, exitCode, time.Now().UTC(), nextAttempt.UTC(), AttemptRetrying)
+	if err != nil {
+		return fmt.Errorf("record failed attempt: %w", err)
+	}
+
+	return nil
+}
+
+// MarkFileFailed records that a file ran out of attempts and was moved to failedPath
+func (db *DB) MarkFileFailed(watchID, filePath string, attempts, exitCode int, failedPath string) error {
+	query := `
+		INSERT INTO file_attempts (watch_id, file_path, attempts, last_exit_code, last_attempt_at, next_attempt_at, status, failed_path)
+		VALUES (?, ?, ?, ?, ?, NULL, ?, ?)
+		ON CONFLICT(watch_id, file_path) DO UPDATE SET
+			attempts = excluded.attempts,
+			last_exit_code = excluded.last_exit_code,
+			last_attempt_at = excluded.last_attempt_at,
+			next_attempt_at = NULL,
+			status = excluded.status,
+			failed_path = excluded.failed_path
+	`
+
+	_, err := db.conn.Exec(query, watchID, filePath, attempts, exitCode, time.Now().UTC(), AttemptFailed, failedPath)
+	if err != nil {
+		return fmt.Errorf("mark file failed: %w", err)
+	}
+
+	return nil
+}
+
+// ClearFileAttempts forgets the failed attempts at a file, after it was processed or to start over
+func (db *DB) ClearFileAttempts(watchID, filePath string) error {
+	query := `DELETE FROM file_attempts WHERE watch_id = ? AND file_path = ?`
+
+	if _, err := db.conn.Exec(query, watchID, filePath); err != nil {
+		return fmt.Errorf("clear file attempts: %w", err)
+	}
+
+	return nil
+}
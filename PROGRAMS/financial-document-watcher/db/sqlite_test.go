@@ -0,0 +1,82 @@
+package db
+
+import (
+	"os"
+	"testing"
+)
+
+func TestFindProcessedFile(t *testing.T) {
+	dbPath := "./test_processed.db"
+	defer os.Remove(dbPath)
+
+	db, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.RecordProcessedFile("bank", "/in/statement.pdf", "aaaa", "/done/statement.pdf"); err != nil {
+		t.Fatalf("Failed to record processed file: %v", err)
+	}
+	// Processed before content hashing, so only its path is known
+	if _, err := db.conn.Exec(`INSERT INTO processed_files (watch_id, file_path, original_name, processed_path)
+		VALUES ('bank', '/in/old.pdf', 'old.pdf', '/done/old.pdf')`); err != nil {
+		t.Fatalf("Failed to insert unhashed file: %v", err)
+	}
+	original, err := db.FindProcessedFile("bank", "aaaa", "/in/statement.pdf")
+	if err != nil || original == nil {
+		t.Fatalf("Failed to find processed file: %v", err)
+	}
+	if err := db.RecordDuplicateFile("bank", "/in/copy.pdf", "bbbb", "/in/duplicates/copy.pdf", original.ID); err != nil {
+		t.Fatalf("Failed to record duplicate file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		watchID string
+		hash    string
+		path    string
+		want    string // original name of the file found, empty for none
+	}{
+		{"same content", "bank", "aaaa", "/in/statement.pdf", "statement.pdf"},
+		{"same content under another name", "bank", "aaaa", "/in/statement (1).pdf", "statement.pdf"},
+		{"new content under a processed name", "bank", "cccc", "/in/statement.pdf", ""},
+		{"other watch", "cards", "aaaa", "/in/statement.pdf", ""},
+		{"unhashed file by path", "bank", "dddd", "/in/old.pdf", "old.pdf"},
+		{"unhashed path elsewhere", "bank", "dddd", "/in/new.pdf", ""},
+		{"duplicates are not processed files", "bank", "bbbb", "/in/copy.pdf", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pf, err := db.FindProcessedFile(tt.watchID, tt.hash, tt.path)
+			if err != nil {
+				t.Fatalf("FindProcessedFile() error: %v", err)
+			}
+			got := ""
+			if pf != nil {
+				got = pf.OriginalName
+			}
+			if got != tt.want {
+				t.Errorf("FindProcessedFile(%s, %s, %s) found %q, want %q", tt.watchID, tt.hash, tt.path, got, tt.want)
+			}
+		})
+	}
+
+	files, err := db.GetProcessedFiles("bank", 0)
+	if err != nil {
+		t.Fatalf("Failed to get processed files: %v", err)
+	}
+	duplicates := 0
+	for _, f := range files {
+		if f.DuplicateOf != nil {
+			duplicates++
+			if *f.DuplicateOf != original.ID || f.ProcessedPath != "/in/duplicates/copy.pdf" {
+				t.Errorf("Unexpected duplicate record: %+v", f)
+			}
+		}
+	}
+	if len(files) != 3 || duplicates != 1 {
+		t.Errorf("Expected 3 files with 1 duplicate in the history, got %d with %d", len(files), duplicates)
+	}
+}
@@ -2,4 +2,12 @@ module financial-document-watcher
 
 go 1.25.4
 
-require github.com/mattn/go-sqlite3 v1.14.32
+require (
+	github.com/fsnotify/fsnotify v1.10.1
+	github.com/mattn/go-sqlite3 v1.14.32
+	sqlite-migrate v0.0.0
+)
+
+require golang.org/x/sys v0.13.0 // indirect
+
+replace sqlite-migrate => ../sqlite-migrate
@@ -45,14 +45,21 @@ read -p "Log directory [~/.local/log]: " LOG_DIR
 LOG_DIR=${LOG_DIR:-~/.local/log}
 LOG_DIR=$(eval echo "$LOG_DIR")  # Expand ~
 
-# Prompt for cron schedule
+# Prompt for daemon mode (systemd user service) or cron
 echo ""
-echo -e "${YELLOW}Cron schedule (leave empty to skip cron setup):${NC}"
-echo "Examples:"
-echo "  0 9 * * *       - Daily at 9 AM"
-echo "  0 * * * *       - Every hour"
-echo "  */30 * * * *    - Every 30 minutes"
-read -p "Cron schedule: " CRON_SCHEDULE
+echo -e "${YELLOW}Run continuously as a systemd user service, processing files as they appear?${NC}"
+read -p "Install systemd service? (y/N): " INSTALL_SERVICE
+
+CRON_SCHEDULE=""
+if [[ ! $INSTALL_SERVICE =~ ^[Yy]$ ]]; then
+    echo ""
+    echo -e "${YELLOW}Cron schedule (leave empty to skip cron setup):${NC}"
+    echo "Examples:"
+    echo "  0 9 * * *       - Daily at 9 AM"
+    echo "  0 * * * *       - Every hour"
+    echo "  */30 * * * *    - Every 30 minutes"
+    read -p "Cron schedule: " CRON_SCHEDULE
+fi
 
 echo ""
 echo -e "${GREEN}Summary:${NC}"
@@ -60,7 +67,9 @@ echo "  Binary: $INSTALL_DIR/financial-document-watcher"
 echo "  Config: $CONFIG_DIR/watches.json"
 echo "  Database: $DB_DIR/watcher.db"
 echo "  Logs: $LOG_DIR/financial-watcher.log"
-if [ -n "$CRON_SCHEDULE" ]; then
+if [[ $INSTALL_SERVICE =~ ^[Yy]$ ]]; then
+    echo "  Service: ~/.config/systemd/user/financial-document-watcher.service"
+elif [ -n "$CRON_SCHEDULE" ]; then
     echo "  Cron: $CRON_SCHEDULE"
 else
     echo "  Cron: Not configured"
@@ -130,6 +139,23 @@ else
     EXEC_COMMAND="$INSTALL_DIR/financial-document-watcher"
 fi
 
+# Set up the systemd user service if requested
+if [[ $INSTALL_SERVICE =~ ^[Yy]$ ]]; then
+    echo -e "${GREEN}Setting up systemd user service...${NC}"
+
+    SERVICE_DIR="$HOME/.config/systemd/user"
+    mkdir -p "$SERVICE_DIR"
+    sed "s|@EXEC_COMMAND@|$EXEC_COMMAND|" financial-document-watcher.service > "$SERVICE_DIR/financial-document-watcher.service"
+
+    systemctl --user daemon-reload
+    systemctl --user enable --now financial-document-watcher
+    echo -e "${GREEN}Service started!${NC}"
+    echo -e "${YELLOW}To keep it running while you're logged out: loginctl enable-linger $USER${NC}"
+
+    echo ""
+    echo "Check the service with: systemctl --user status financial-document-watcher"
+fi
+
 # Set up cron if requested
 if [ -n "$CRON_SCHEDULE" ]; then
     echo -e "${GREEN}Setting up cron job...${NC}"
@@ -171,8 +197,15 @@ echo "4. Run manually:"
 echo "   ${YELLOW}$EXEC_COMMAND${NC}"
 echo ""
 echo "5. View logs:"
-echo "   ${YELLOW}tail -f $LOG_DIR/financial-watcher.log${NC}"
+if [[ $INSTALL_SERVICE =~ ^[Yy]$ ]]; then
+    echo "   ${YELLOW}journalctl --user -u financial-document-watcher -f${NC}"
+else
+    echo "   ${YELLOW}tail -f $LOG_DIR/financial-watcher.log${NC}"
+fi
 echo ""
+if [[ $INSTALL_SERVICE =~ ^[Yy]$ ]]; then
+    echo "The service picks up watches.json changes after: systemctl --user restart financial-document-watcher"
+fi
 if [ -n "$CRON_SCHEDULE" ]; then
     echo "Cron is configured to run: $CRON_SCHEDULE"
     echo "Next cron run: $(date -d \"now + 1 hour\" '+%Y-%m-%d %H:00:00')"
@@ -1,13 +1,17 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"financial-document-watcher/db"
@@ -16,6 +20,20 @@ import (
 const (
 	defaultConfigPath = "./watches.json"
 	defaultDBPath     = "./watcher.db"
+
+	// Daemon mode waits for new files to settle and still scans every watch periodically
+	defaultSettle = 10 * time.Second
+	defaultRescan = time.Hour
+
+	// Failed files are retried with a doubling backoff, then moved to the watch's failed path
+	defaultMaxAttempts  = 5
+	defaultRetryBackoff = 15 * time.Minute
+	maxRetryBackoff     = 24 * time.Hour
+
+	// reconcileErrorExitCode is returned by the statement processor when the parsed transactions
+	// don't add up to the statement balances. It fails the same way on every run, so the file is
+	// moved to the failed path without retries
+	reconcileErrorExitCode = 4
 )
 
 // WatchConfig represents a single watch configuration
@@ -25,13 +43,39 @@ type WatchConfig struct {
 	FilePattern    string `json:"file_pattern"`
 	ExecutablePath string `json:"executable_path"`
 	ProcessedPath  string `json:"processed_path"`
+
+	// Retry policy; loadConfig fills in the defaults
+	MaxAttempts  int    `json:"max_attempts"`  // runs of the executable before a file is given up on
+	RetryBackoff string `json:"retry_backoff"` // wait after the first failure, doubled after each one
+	FailedPath   string `json:"failed_path"`   // where files are moved once out of attempts
+
+	// DuplicatesPath is where files with already processed content are moved; loadConfig fills in
+	// the default
+	DuplicatesPath string `json:"duplicates_path"`
+
+	retryBackoff time.Duration
 }
 
 func main() {
+	// Schema migrations are managed with a subcommand: financial-document-watcher migrate [status|up] [--db path]
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+	// Processed files are listed with: financial-document-watcher history [--watch id] [--limit n] [--db path]
+	if len(os.Args) > 1 && os.Args[1] == "history" {
+		runHistory(os.Args[2:])
+		return
+	}
+
 	// CLI flags
 	configPath := flag.String("config", defaultConfigPath, "Path to watches.json config file")
 	dbPath := flag.String("db", defaultDBPath, "Path to SQLite database")
 	dryRun := flag.Bool("dry-run", false, "Show what would be processed without executing")
+	force := flag.Bool("force", false, "Process files even if a file with the same content was processed before")
+	daemon := flag.Bool("daemon", false, "Keep running and process files as they appear instead of scanning once")
+	settle := flag.Duration("settle", defaultSettle, "Daemon mode: time without writes before a new file is processed")
+	rescan := flag.Duration("rescan", defaultRescan, "Daemon mode: interval between full scans that retry failed files (0 to disable)")
 	flag.Parse()
 
 	log.Printf("Financial Document Watcher started at %s", time.Now().Format(time.RFC3339))
@@ -50,12 +94,17 @@ func main() {
 	}
 	defer database.Close()
 
+	if *daemon {
+		runDaemon(watches, database, *dryRun, *force, *settle, *rescan)
+		return
+	}
+
 	// Process each watch
 	totalProcessed := 0
 	totalErrors := 0
 
 	for _, watch := range watches {
-		processed, errors := processWatch(watch, database, *dryRun)
+		processed, errors := processWatch(watch, database, *dryRun, *force)
 		totalProcessed += processed
 		totalErrors += errors
 	}
@@ -63,6 +112,64 @@ func main() {
 	log.Printf("Watcher run completed. Processed: %d, Errors: %d", totalProcessed, totalErrors)
 }
 
+// runMigrate shows or applies the database schema migrations
+func runMigrate(args []string) {
+	action := "status"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		action = args[0]
+		args = args[1:]
+	}
+
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dbPath := fs.String("db", defaultDBPath, "Path to SQLite database")
+	fs.Parse(args)
+
+	database, err := db.Open(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	if err := database.RunMigrateCLI(append([]string{action}, fs.Args()...)); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+}
+
+// runHistory lists processed files, newest first, with their content hash and original name
+func runHistory(args []string) {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	dbPath := fs.String("db", defaultDBPath, "Path to SQLite database")
+	watchID := fs.String("watch", "", "Only list files processed for this watch ID")
+	limit := fs.Int("limit", 50, "Maximum number of files to list (0 for all)")
+	fs.Parse(args)
+
+	database, err := db.New(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer database.Close()
+
+	files, err := database.GetProcessedFiles(*watchID, *limit)
+	if err != nil {
+		log.Fatalf("Failed to get processed files: %v", err)
+	}
+
+	for _, f := range files {
+		hash := f.ContentHash
+		if hash == "" {
+			hash = "(processed before content hashing)"
+		}
+		name := f.OriginalName
+		if stored := filepath.Base(f.ProcessedPath); f.ProcessedPath != "" && stored != name {
+			name += " (stored as " + stored + ")"
+		}
+		if f.DuplicateOf != nil {
+			name += " [duplicate, not processed]"
+		}
+		fmt.Printf("%s  %-20s %-64s  %s\n", f.ProcessedAt.Local().Format("2006-01-02 15:04:05"), f.WatchID, hash, name)
+	}
+}
+
 // loadConfig reads and parses the watches.json configuration file
 func loadConfig(path string) ([]WatchConfig, error) {
 	data, err := os.ReadFile(path)
@@ -92,13 +199,34 @@ func loadConfig(path string) ([]WatchConfig, error) {
 		if w.ProcessedPath == "" {
 			return nil, fmt.Errorf("watch %d: processed_path is required", i)
 		}
+
+		if w.MaxAttempts < 0 {
+			return nil, fmt.Errorf("watch %d: max_attempts must not be negative", i)
+		}
+		if w.MaxAttempts == 0 {
+			watches[i].MaxAttempts = defaultMaxAttempts
+		}
+		watches[i].retryBackoff = defaultRetryBackoff
+		if w.RetryBackoff != "" {
+			backoff, err := time.ParseDuration(w.RetryBackoff)
+			if err != nil || backoff < 0 {
+				return nil, fmt.Errorf("watch %d: retry_backoff must be a duration such as 15m or 2h", i)
+			}
+			watches[i].retryBackoff = backoff
+		}
+		if w.FailedPath == "" {
+			watches[i].FailedPath = filepath.Join(w.WatchPath, "failed")
+		}
+		if w.DuplicatesPath == "" {
+			watches[i].DuplicatesPath = filepath.Join(w.WatchPath, "duplicates")
+		}
 	}
 
 	return watches, nil
 }
 
 // processWatch handles a single watch configuration
-func processWatch(watch WatchConfig, database *db.DB, dryRun bool) (processed, errors int) {
+func processWatch(watch WatchConfig, database *db.DB, dryRun, force bool) (processed, errors int) {
 	log.Printf("[%s] Checking watch path: %s", watch.WatchID, watch.WatchPath)
 
 	// Check if watch path exists
@@ -124,62 +252,177 @@ func processWatch(watch WatchConfig, database *db.DB, dryRun bool) (processed, e
 
 	// Process each matching file
 	for _, filePath := range matches {
-		// Check if already processed
-		alreadyProcessed, err := database.IsFileProcessed(watch.WatchID, filePath)
-		if err != nil {
-			log.Printf("[%s] ERROR: Failed to check if file processed: %s: %v", watch.WatchID, filePath, err)
-			errors++
-			continue
+		p, e := processFile(watch, database, filePath, dryRun, force)
+		processed += p
+		errors += e
+	}
+
+	return processed, errors
+}
+
+// processFile hands one file matching a watch to its executable, counting it as processed or as an
+// error (0 or 1 each)
+// Files are recognized by the SHA-256 of their content, so one processed before under any name is
+// moved to the duplicates path unprocessed unless force is set
+func processFile(watch WatchConfig, database *db.DB, filePath string, dryRun, force bool) (processed, errors int) {
+	contentHash, err := hashFile(filePath)
+	if err != nil {
+		log.Printf("[%s] ERROR: Failed to hash file: %s: %v", watch.WatchID, filePath, err)
+		return 0, 1
+	}
+
+	// Check if already processed
+	previous, err := database.FindProcessedFile(watch.WatchID, contentHash, filePath)
+	if err != nil {
+		log.Printf("[%s] ERROR: Failed to check if file processed: %s: %v", watch.WatchID, filePath, err)
+		return 0, 1
+	}
+
+	if previous != nil {
+		if !force {
+			if dryRun {
+				log.Printf("[%s] DRY-RUN: Would move duplicate of %s: %s", watch.WatchID, previous.OriginalName, filePath)
+				return 0, 0
+			}
+			duplicatePath, err := moveDuplicate(watch, filePath, previous)
+			if err != nil {
+				log.Printf("[%s] ERROR: Failed to move duplicate: %v", watch.WatchID, err)
+				return 0, 1
+			}
+			if err := database.RecordDuplicateFile(watch.WatchID, filePath, contentHash, duplicatePath, previous.ID); err != nil {
+				log.Printf("[%s] ERROR: Failed to record duplicate file: %v", watch.WatchID, err)
+				return 0, 1
+			}
+			log.Printf("[%s] DUPLICATE: Same content as %s, processed %s; %s moved to: %s", watch.WatchID, previous.OriginalName,
+				previous.ProcessedAt.Local().Format("2006-01-02 15:04"), filepath.Base(filePath), duplicatePath)
+			return 0, 0
 		}
+		log.Printf("[%s] FORCE: Processing again, same content as %s: %s", watch.WatchID, previous.OriginalName, filepath.Base(filePath))
+	}
 
-		if alreadyProcessed {
-			log.Printf("[%s] SKIP: File already processed: %s", watch.WatchID, filepath.Base(filePath))
-			continue
+	// Files that failed before wait out their backoff
+	attempt, err := database.GetFileAttempt(watch.WatchID, filePath)
+	if err != nil {
+		log.Printf("[%s] ERROR: Failed to check previous attempts: %s: %v", watch.WatchID, filePath, err)
+		return 0, 1
+	}
+	if attempt != nil && attempt.Status == db.AttemptFailed {
+		// Moved back from the failed path by hand, so it gets all its attempts again
+		log.Printf("[%s] File is back after failing %d times, starting over: %s", watch.WatchID, attempt.Attempts, filepath.Base(filePath))
+		attempt = nil
+	}
+	if attempt != nil && time.Now().Before(attempt.NextAttemptAt) {
+		log.Printf("[%s] WAIT: Attempt %d of %d for %s not before %s", watch.WatchID, attempt.Attempts+1,
+			watch.MaxAttempts, filepath.Base(filePath), attempt.NextAttemptAt.Local().Format(time.RFC3339))
+		return 0, 0
+	}
+
+	if dryRun {
+		log.Printf("[%s] DRY-RUN: Would process file: %s", watch.WatchID, filePath)
+		return 1, 0
+	}
+
+	// Execute the processor
+	log.Printf("[%s] Processing file: %s", watch.WatchID, filepath.Base(filePath))
+	success, output, exitCode := executeProcessor(watch.ExecutablePath, filePath)
+
+	if success {
+		log.Printf("[%s] SUCCESS: Processor completed (exit code: %d)", watch.WatchID, exitCode)
+		if output != "" {
+			log.Printf("[%s] Output: %s", watch.WatchID, output)
 		}
 
-		if dryRun {
-			log.Printf("[%s] DRY-RUN: Would process file: %s", watch.WatchID, filePath)
-			processed++
-			continue
+		// Move file to processed directory
+		processedPath, err := moveFile(filePath, watch.ProcessedPath)
+		if err != nil {
+			log.Printf("[%s] ERROR: Failed to move file to processed: %v", watch.WatchID, err)
+			return 0, 1
 		}
 
-		// Execute the processor
-		log.Printf("[%s] Processing file: %s", watch.WatchID, filepath.Base(filePath))
-		success, output, exitCode := executeProcessor(watch.ExecutablePath, filePath)
+		// Record as processed
+		if err := database.RecordProcessedFile(watch.WatchID, filePath, contentHash, processedPath); err != nil {
+			log.Printf("[%s] ERROR: Failed to record processed file: %v", watch.WatchID, err)
+			return 0, 1
+		}
+		if err := database.ClearFileAttempts(watch.WatchID, filePath); err != nil {
+			log.Printf("[%s] ERROR: Failed to clear previous attempts: %v", watch.WatchID, err)
+		}
 
-		if success {
-			log.Printf("[%s] SUCCESS: Processor completed (exit code: %d)", watch.WatchID, exitCode)
-			if output != "" {
-				log.Printf("[%s] Output: %s", watch.WatchID, output)
-			}
+		log.Printf("[%s] File moved to: %s", watch.WatchID, watch.ProcessedPath)
+		return 1, 0
+	}
 
-			// Move file to processed directory
-			if err := moveToProcessed(filePath, watch.ProcessedPath); err != nil {
-				log.Printf("[%s] ERROR: Failed to move file to processed: %v", watch.WatchID, err)
-				errors++
-				continue
-			}
+	if exitCode == reconcileErrorExitCode {
+		log.Printf("[%s] RECONCILE ERROR: Transactions don't match the statement balances (exit code: %d)", watch.WatchID, exitCode)
+		if output != "" {
+			log.Printf("[%s] Output: %s", watch.WatchID, output)
+		}
+	} else {
+		log.Printf("[%s] FAILED: Processor failed (exit code: %d)", watch.WatchID, exitCode)
+		if output != "" {
+			log.Printf("[%s] Error output: %s", watch.WatchID, output)
+		}
+	}
 
-			// Record as processed
-			if err := database.RecordProcessedFile(watch.WatchID, filePath); err != nil {
-				log.Printf("[%s] ERROR: Failed to record processed file: %v", watch.WatchID, err)
-				errors++
-				continue
-			}
+	attempts := 1
+	if attempt != nil {
+		attempts = attempt.Attempts + 1
+	}
 
-			log.Printf("[%s] File moved to: %s", watch.WatchID, watch.ProcessedPath)
-			processed++
-		} else {
-			log.Printf("[%s] FAILED: Processor failed (exit code: %d)", watch.WatchID, exitCode)
-			if output != "" {
-				log.Printf("[%s] Error output: %s", watch.WatchID, output)
-			}
-			log.Printf("[%s] File left in place for retry: %s", watch.WatchID, filePath)
-			errors++
+	if attempts < watch.MaxAttempts && exitCode != reconcileErrorExitCode {
+		next := time.Now().Add(retryDelay(watch.retryBackoff, attempts))
+		if err := database.RecordFailedAttempt(watch.WatchID, filePath, attempts, exitCode, next); err != nil {
+			log.Printf("[%s] ERROR: Failed to record failed attempt: %v", watch.WatchID, err)
 		}
+		log.Printf("[%s] File left in place for retry (attempt %d of %d, next at %s): %s", watch.WatchID, attempts,
+			watch.MaxAttempts, next.Format(time.RFC3339), filePath)
+		return 0, 1
 	}
 
-	return processed, errors
+	// Out of attempts, or a reconcile error that no retry fixes: dead-letter the file with the reason
+	// next to it
+	failedPath, err := moveToFailed(watch, filePath, attempts, exitCode, output)
+	if err != nil {
+		log.Printf("[%s] ERROR: Failed to move file to failed: %v", watch.WatchID, err)
+		return 0, 1
+	}
+	if err := database.MarkFileFailed(watch.WatchID, filePath, attempts, exitCode, failedPath); err != nil {
+		log.Printf("[%s] ERROR: Failed to record failed file: %v", watch.WatchID, err)
+	}
+	if exitCode == reconcileErrorExitCode {
+		log.Printf("[%s] NOT RETRIED: Reconcile errors fail the same way every run, file moved to: %s", watch.WatchID, failedPath)
+	} else {
+		log.Printf("[%s] GAVE UP after %d attempts, file moved to: %s", watch.WatchID, attempts, failedPath)
+	}
+	return 0, 1
+}
+
+// hashFile returns the hex SHA-256 of a file's content
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// retryDelay is the wait after a file's nth failed attempt: the backoff, doubled after each
+// further failure, up to a day
+func retryDelay(backoff time.Duration, attempts int) time.Duration {
+	delay := backoff
+	for i := 1; i < attempts && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryBackoff {
+		return maxRetryBackoff
+	}
+	return delay
 }
 
 // executeProcessor runs the external executable with the file path as argument
@@ -201,18 +444,58 @@ func executeProcessor(executablePath, filePath string) (success bool, output str
 	return true, output, 0
 }
 
-// moveToProcessed moves a file from the watch path to the processed path
-func moveToProcessed(filePath, processedPath string) error {
-	// Ensure processed directory exists
-	if err := os.MkdirAll(processedPath, 0755); err != nil {
-		return fmt.Errorf("create processed directory: %w", err)
+// moveToFailed moves a file that ran out of attempts, or failed to reconcile, to the watch's failed
+// path and writes the last attempt's exit code and output next to it as <name>.error.txt; returns
+// the file's new path
+func moveToFailed(watch WatchConfig, filePath string, attempts, exitCode int, output string) (string, error) {
+	destPath, err := moveFile(filePath, watch.FailedPath)
+	if err != nil {
+		return "", err
+	}
+
+	report := fmt.Sprintf("Watch: %s\nFile: %s\nAttempts: %d\nExit code: %d\nFailed at: %s\n\nOutput:\n%s",
+		watch.WatchID, filePath, attempts, exitCode, time.Now().Format(time.RFC3339), output)
+	if err := os.WriteFile(destPath+".error.txt", []byte(report), 0644); err != nil {
+		return destPath, fmt.Errorf("write error file: %w", err)
+	}
+
+	return destPath, nil
+}
+
+// moveDuplicate moves a file whose content was already processed to the watch's duplicates path and
+// writes which file it duplicates next to it as <name>.duplicate.txt; returns the file's new path
+func moveDuplicate(watch WatchConfig, filePath string, previous *db.ProcessedFile) (string, error) {
+	destPath, err := moveFile(filePath, watch.DuplicatesPath)
+	if err != nil {
+		return "", err
+	}
+
+	original := previous.ProcessedPath
+	if original == "" {
+		original = previous.FilePath
+	}
+	note := fmt.Sprintf("Watch: %s\nFile: %s\nDuplicate of: %s\nProcessed at: %s\nStored as: %s\nContent hash: %s\n",
+		watch.WatchID, filePath, previous.OriginalName, previous.ProcessedAt.Format(time.RFC3339), original, previous.ContentHash)
+	if err := os.WriteFile(destPath+".duplicate.txt", []byte(note), 0644); err != nil {
+		return destPath, fmt.Errorf("write duplicate note: %w", err)
+	}
+
+	return destPath, nil
+}
+
+// moveFile moves a file from the watch path into a directory such as the processed path and
+// returns its new path
+func moveFile(filePath, destDir string) (string, error) {
+	// Ensure destination directory exists
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return "", fmt.Errorf("create directory: %w", err)
 	}
 
 	// Get the filename
 	filename := filepath.Base(filePath)
 
 	// Construct destination path
-	destPath := filepath.Join(processedPath, filename)
+	destPath := filepath.Join(destDir, filename)
 
 	// Handle file name collision by adding timestamp
 	if _, err := os.Stat(destPath); err == nil {
@@ -220,13 +503,13 @@ func moveToProcessed(filePath, processedPath string) error {
 		ext := filepath.Ext(filename)
 		nameWithoutExt := filename[:len(filename)-len(ext)]
 		filename = fmt.Sprintf("%s_%s%s", nameWithoutExt, timestamp, ext)
-		destPath = filepath.Join(processedPath, filename)
+		destPath = filepath.Join(destDir, filename)
 	}
 
 	// Move the file
 	if err := os.Rename(filePath, destPath); err != nil {
-		return fmt.Errorf("move file: %w", err)
+		return "", fmt.Errorf("move file: %w", err)
 	}
 
-	return nil
+	return destPath, nil
 }
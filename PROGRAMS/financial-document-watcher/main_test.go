@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"financial-document-watcher/db"
+)
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		backoff  time.Duration
+		attempts int
+		want     time.Duration
+	}{
+		{"first failure waits the backoff", 15 * time.Minute, 1, 15 * time.Minute},
+		{"second failure doubles it", 15 * time.Minute, 2, 30 * time.Minute},
+		{"fourth failure", 15 * time.Minute, 4, 2 * time.Hour},
+		{"capped at a day", 15 * time.Minute, 8, 24 * time.Hour},
+		{"cap holds for many attempts", 15 * time.Minute, 1000, 24 * time.Hour},
+		{"backoff above the cap", 48 * time.Hour, 1, 24 * time.Hour},
+		{"zero backoff retries at once", 0, 3, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryDelay(tt.backoff, tt.attempts); got != tt.want {
+				t.Errorf("retryDelay(%s, %d) = %s, want %s", tt.backoff, tt.attempts, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		return path
+	}
+
+	path := write("defaults.json", `[
+		{"watch_id": "bank", "watch_path": "/in/bank", "file_pattern": "*.pdf", "executable_path": "/bin/true", "processed_path": "/done/bank"},
+		{"watch_id": "cards", "watch_path": "/in/cards", "file_pattern": "*.csv", "executable_path": "/bin/true", "processed_path": "/done/cards",
+		 "max_attempts": 2, "retry_backoff": "1h", "failed_path": "/failed/cards", "duplicates_path": "/dup/cards"}
+	]`)
+	watches, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if len(watches) != 2 {
+		t.Fatalf("Expected 2 watches, got %d", len(watches))
+	}
+
+	bank := watches[0]
+	if bank.MaxAttempts != defaultMaxAttempts || bank.retryBackoff != defaultRetryBackoff {
+		t.Errorf("Expected the default retry policy, got %d attempts and %s", bank.MaxAttempts, bank.retryBackoff)
+	}
+	if bank.FailedPath != filepath.Join("/in/bank", "failed") || bank.DuplicatesPath != filepath.Join("/in/bank", "duplicates") {
+		t.Errorf("Expected the default paths under the watch path, got %q and %q", bank.FailedPath, bank.DuplicatesPath)
+	}
+
+	cards := watches[1]
+	if cards.MaxAttempts != 2 || cards.retryBackoff != time.Hour {
+		t.Errorf("Expected the configured retry policy, got %d attempts and %s", cards.MaxAttempts, cards.retryBackoff)
+	}
+	if cards.FailedPath != "/failed/cards" || cards.DuplicatesPath != "/dup/cards" {
+		t.Errorf("Expected the configured paths, got %q and %q", cards.FailedPath, cards.DuplicatesPath)
+	}
+
+	invalid := []struct {
+		name   string
+		config string
+	}{
+		{"missing watch_id", `[{"watch_path": "/in", "file_pattern": "*.pdf", "executable_path": "/bin/true", "processed_path": "/done"}]`},
+		{"missing processed_path", `[{"watch_id": "bank", "watch_path": "/in", "file_pattern": "*.pdf", "executable_path": "/bin/true"}]`},
+		{"negative max_attempts", `[{"watch_id": "bank", "watch_path": "/in", "file_pattern": "*.pdf", "executable_path": "/bin/true", "processed_path": "/done", "max_attempts": -1}]`},
+		{"unparsable retry_backoff", `[{"watch_id": "bank", "watch_path": "/in", "file_pattern": "*.pdf", "executable_path": "/bin/true", "processed_path": "/done", "retry_backoff": "soon"}]`},
+		{"negative retry_backoff", `[{"watch_id": "bank", "watch_path": "/in", "file_pattern": "*.pdf", "executable_path": "/bin/true", "processed_path": "/done", "retry_backoff": "-1h"}]`},
+	}
+
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := loadConfig(write("invalid.json", tt.config)); err == nil {
+				t.Errorf("Expected an error for %s", tt.name)
+			}
+		})
+	}
+}
+
+func TestProcessFileFailures(t *testing.T) {
+	dir := t.TempDir()
+	database, err := db.New(filepath.Join(dir, "watcher.db"))
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer database.Close()
+
+	tests := []struct {
+		name       string
+		exitCode   int
+		wantFailed bool // moved to the failed path on the first run
+	}{
+		{"reconcile error is not retried", reconcileErrorExitCode, true},
+		{"other failures are retried", 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			watchPath := t.TempDir()
+			script := filepath.Join(t.TempDir(), "processor.sh")
+			if err := os.WriteFile(script, []byte(fmt.Sprintf("#!/bin/sh\necho totals differ\nexit %d\n", tt.exitCode)), 0755); err != nil {
+				t.Fatalf("Failed to write processor: %v", err)
+			}
+			filePath := filepath.Join(watchPath, "statement.pdf")
+			if err := os.WriteFile(filePath, []byte(tt.name), 0644); err != nil {
+				t.Fatalf("Failed to write statement: %v", err)
+			}
+			watch := WatchConfig{
+				WatchID:        "bank",
+				WatchPath:      watchPath,
+				ExecutablePath: script,
+				ProcessedPath:  filepath.Join(watchPath, "processed"),
+				FailedPath:     filepath.Join(watchPath, "failed"),
+				DuplicatesPath: filepath.Join(watchPath, "duplicates"),
+				MaxAttempts:    defaultMaxAttempts,
+				retryBackoff:   defaultRetryBackoff,
+			}
+
+			if processed, errors := processFile(watch, database, filePath, false, false); processed != 0 || errors != 1 {
+				t.Fatalf("Expected 1 error, got %d processed and %d errors", processed, errors)
+			}
+
+			failedPath := filepath.Join(watch.FailedPath, "statement.pdf")
+			_, statErr := os.Stat(failedPath)
+			if moved := statErr == nil; moved != tt.wantFailed {
+				t.Fatalf("Expected moved to failed path = %v, got %v", tt.wantFailed, moved)
+			}
+			if !tt.wantFailed {
+				if _, err := os.Stat(filePath); err != nil {
+					t.Errorf("Expected the file to be left in place for retry: %v", err)
+				}
+				return
+			}
+			report, err := os.ReadFile(failedPath + ".error.txt")
+			if err != nil {
+				t.Fatalf("Failed to read error file: %v", err)
+			}
+			if !strings.Contains(string(report), "Attempts: 1\nExit code: 4") || !strings.Contains(string(report), "totals differ") {
+				t.Errorf("Unexpected error file: %s", report)
+			}
+		})
+	}
+}
@@ -55,6 +55,14 @@ if [ -f "$INSTALL_DIR/financial-document-watcher-run" ]; then
     FOUND_ITEMS="${FOUND_ITEMS}wrapper "
 fi
 
+SERVICE_FILE="$HOME/.config/systemd/user/financial-document-watcher.service"
+if [ -f "$SERVICE_FILE" ]; then
+    FOUND_ITEMS="${FOUND_ITEMS}service "
+    echo "  Service: $SERVICE_FILE (will be stopped and removed)"
+else
+    echo "  Service: Not found"
+fi
+
 if crontab -l 2>/dev/null | grep -q "financial-document-watcher\|financial-watcher"; then
     FOUND_ITEMS="${FOUND_ITEMS}cron "
     echo "  Cron job: Found (will be removed)"
@@ -93,6 +101,15 @@ fi
 
 echo ""
 
+# Stop and remove the systemd user service
+if [ -f "$SERVICE_FILE" ]; then
+    echo -e "${GREEN}Removing systemd service...${NC}"
+    systemctl --user disable --now financial-document-watcher 2>/dev/null || true
+    rm -f "$SERVICE_FILE"
+    systemctl --user daemon-reload
+    echo "  Removed: $SERVICE_FILE"
+fi
+
 # Remove binary
 if [ -f "$INSTALL_DIR/financial-document-watcher" ]; then
     echo -e "${GREEN}Removing binary...${NC}"
@@ -136,6 +136,13 @@ if [ "$UPDATE_CONFIG" = true ]; then
     echo "  ✓ Config updated: $CONFIG_DIR/watches.json"
 fi
 
+# A running daemon keeps the old binary and config until it's restarted
+if systemctl --user is-active --quiet financial-document-watcher 2>/dev/null; then
+    echo ""
+    systemctl --user restart financial-document-watcher
+    echo "  ✓ Service restarted"
+fi
+
 echo ""
 echo -e "${GREEN}================================================${NC}"
 echo -e "${GREEN}  Update Complete!${NC}"
@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
@@ -10,6 +11,8 @@ import (
 	"financial-liability-tracker/db"
 	"financial-liability-tracker/pkg/app"
 	"financial-liability-tracker/pkg/exitcodes"
+	"money"
+	"sqlite-migrate/migrate"
 )
 
 func main() {
@@ -34,6 +37,8 @@ func main() {
 		handleGet(args)
 	case "total":
 		handleTotal(args)
+	case "migrate":
+		handleMigrate(args)
 	case "help", "--help", "-h":
 		printUsage()
 		os.Exit(exitcodes.Success)
@@ -56,6 +61,7 @@ Commands:
   list     List all liabilities
   get      Get liability details
   total    Calculate total of all balances
+  migrate  Show (status) or apply (up) database schema migrations
   help     Show this help message
 
 Examples:
@@ -67,6 +73,10 @@ Examples:
   financial-liability-tracker add --type auto-loan --name "Honda Civic Loan" \\
     --balance 15000 --original 25000 --rate 4.5 --min-payment 350
 
+  # Add a loan in another currency (balances are in that currency)
+  financial-liability-tracker add --type personal-loan --name "Lisbon Car Loan" \\
+    --balance 8000 --currency EUR
+
   # Update balance
   financial-liability-tracker update chase-sapphire --balance 2100
 
@@ -82,6 +92,9 @@ Examples:
   # Get total balance
   financial-liability-tracker total
 
+  # Apply pending schema migrations (the database is backed up first)
+  financial-liability-tracker migrate up
+
 Environment Variables:
   POSTGRES_HOST      PostgreSQL host (default: localhost)
   POSTGRES_PORT      PostgreSQL port (default: 5432)
@@ -103,11 +116,12 @@ func handleAdd(args []string) {
 	fs := flag.NewFlagSet("add", flag.ExitOnError)
 	name := fs.String("name", "", "Liability name (required, unique identifier)")
 	liabilityType := fs.String("type", "", "Liability type (required: credit-card, auto-loan, mortgage, student-loan, personal-loan, medical-debt)")
-	balance := fs.Float64("balance", 0, "Current balance (required)")
-	original := fs.Float64("original", 0, "Original amount (optional)")
-	limit := fs.Float64("limit", 0, "Credit limit for credit cards (optional)")
+	balance := fs.String("balance", "", "Current balance (required)")
+	currency := fs.String("currency", db.DefaultCurrency, "Currency code of the balance and amounts")
+	original := fs.String("original", "", "Original amount (optional)")
+	limit := fs.String("limit", "", "Credit limit for credit cards (optional)")
 	rate := fs.Float64("rate", 0, "Interest rate percentage (optional)")
-	minPayment := fs.Float64("min-payment", 0, "Minimum payment (optional)")
+	minPayment := fs.String("min-payment", "", "Minimum payment (optional)")
 	creditor := fs.String("creditor", "", "Creditor name (optional)")
 	last4 := fs.String("last4", "", "Last 4 digits of account (optional)")
 	opened := fs.String("opened", "", "Opened date YYYY-MM-DD (optional)")
@@ -115,7 +129,7 @@ func handleAdd(args []string) {
 
 	fs.Parse(args)
 
-	if *name == "" || *liabilityType == "" || *balance == 0 {
+	if *name == "" || *liabilityType == "" || *balance == "" {
 		fmt.Fprintf(os.Stderr, `{"error": "name, type, and balance are required"}`+"\n")
 		os.Exit(exitcodes.ArgsError)
 	}
@@ -137,24 +151,19 @@ func handleAdd(args []string) {
 	liability := &db.Liability{
 		Name:           *name,
 		LiabilityType:  *liabilityType,
-		CurrentBalance: *balance,
+		CurrentBalance: parseAmount("balance", *balance),
+		Currency:       *currency,
 		CreditorName:   *creditor,
 		AccountLast4:   *last4,
 		Notes:          *notes,
 	}
 
-	if *original > 0 {
-		liability.OriginalAmount = original
-	}
-	if *limit > 0 {
-		liability.CreditLimit = limit
-	}
+	liability.OriginalAmount = parseOptionalAmount("original", *original)
+	liability.CreditLimit = parseOptionalAmount("limit", *limit)
 	if *rate > 0 {
 		liability.InterestRate = rate
 	}
-	if *minPayment > 0 {
-		liability.MinimumPayment = minPayment
-	}
+	liability.MinimumPayment = parseOptionalAmount("min-payment", *minPayment)
 	if *opened != "" {
 		openedDate, err := time.Parse("2006-01-02", *opened)
 		if err != nil {
@@ -192,15 +201,16 @@ func handleUpdate(args []string) {
 
 	name := args[0]
 	fs := flag.NewFlagSet("update", flag.ExitOnError)
-	balance := fs.Float64("balance", 0, "New balance")
+	balance := fs.String("balance", "", "New balance")
 	notes := fs.String("notes", "", "Update notes")
 
 	fs.Parse(args[1:])
 
-	if *balance == 0 {
+	if *balance == "" {
 		fmt.Fprintf(os.Stderr, `{"error": "balance is required"}`+"\n")
 		os.Exit(exitcodes.ArgsError)
 	}
+	newBalance := parseAmount("balance", *balance)
 
 	database, err := app.InitDatabase()
 	if err != nil {
@@ -209,7 +219,7 @@ func handleUpdate(args []string) {
 	}
 	defer database.Close()
 
-	if err := database.UpdateLiability(name, balance, *notes); err != nil {
+	if err := database.UpdateLiability(name, &newBalance, *notes); err != nil {
 		if err.Error() == fmt.Sprintf("liability not found: %s", name) {
 			fmt.Fprintf(os.Stderr, `{"error": "liability not found: %s"}`+"\n", name)
 			os.Exit(exitcodes.NotFound)
@@ -344,9 +354,56 @@ func handleTotal(args []string) {
 		os.Exit(exitcodes.DBError)
 	}
 
+	byCurrency, err := database.GetTotalsByCurrency()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, `{"error": "failed to calculate total: %v"}`+"\n", err)
+		os.Exit(exitcodes.DBError)
+	}
+
 	output, _ := json.Marshal(map[string]interface{}{
 		"total_balance": total,
+		"by_currency":   byCurrency,
 	})
 	fmt.Println(string(output))
 	os.Exit(exitcodes.Success)
 }
+
+func handleMigrate(args []string) {
+	database, err := app.OpenDatabase()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, `{"error": "database initialization failed: %v"}`+"\n", err)
+		os.Exit(exitcodes.DBError)
+	}
+	defer database.Close()
+
+	if err := database.RunMigrateCLI(args); err != nil {
+		fmt.Fprintf(os.Stderr, `{"error": "failed to migrate database: %v"}`+"\n", err)
+		if errors.Is(err, migrate.ErrUsage) {
+			os.Exit(exitcodes.ArgsError)
+		}
+		os.Exit(exitcodes.DBError)
+	}
+	os.Exit(exitcodes.Success)
+}
+
+// parseAmount reads a decimal amount flag such as 1500 or 249.99, exiting on anything else
+func parseAmount(name, value string) money.Cents {
+	amount, err := money.Parse(value)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, `{"error": "invalid --%s: %v"}`+"\n", name, err)
+		os.Exit(exitcodes.ArgsError)
+	}
+	return amount
+}
+
+// parseOptionalAmount reads an optional amount flag; unset or non-positive amounts are left out
+func parseOptionalAmount(name, value string) *money.Cents {
+	if value == "" {
+		return nil
+	}
+	amount := parseAmount(name, value)
+	if amount <= 0 {
+		return nil
+	}
+	return &amount
+}
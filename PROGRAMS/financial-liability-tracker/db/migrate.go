@@ -0,0 +1,20 @@
+package db
+
+import (
+	"embed"
+
+	"sqlite-migrate/migrate"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Migrate applies pending schema migrations, backing up the database file first
+func (db *DB) Migrate() (*migrate.Result, error) {
+	return migrate.Apply(db.conn, db.path, migrations, "migrations")
+}
+
+// RunMigrateCLI runs the migrate subcommand (status or up) on this database
+func (db *DB) RunMigrateCLI(args []string) error {
+	return migrate.RunCLI(args, db.conn, db.path, migrations, "migrations", true)
+}
@@ -0,0 +1,2 @@
+-- Currency of each liability's balances and amounts; existing liabilities were all in US dollars
+ALTER TABLE liabilities ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
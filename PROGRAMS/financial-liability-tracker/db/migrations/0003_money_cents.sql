@@ -0,0 +1,71 @@
+-- Balances and amounts as integer cents (money.Cents in Go) instead of REAL, so totals don't drift
+-- by fractions of a cent; SQLite can't change a column's type, so both tables are rebuilt.
+-- interest_rate stays REAL
+--
+-- Dropping the old liabilities table would cascade into liability_balance_history
+-- migrate:foreign-keys-off
+
+-- Liabilities table (current_balance, original_amount, credit_limit and minimum_payment in cents)
+CREATE TABLE liabilities_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT UNIQUE NOT NULL,
+    liability_type TEXT NOT NULL CHECK (liability_type IN (
+        'credit-card', 'auto-loan', 'mortgage',
+        'student-loan', 'personal-loan', 'medical-debt'
+    )),
+    current_balance INTEGER NOT NULL,
+    original_amount INTEGER,
+    credit_limit INTEGER,  -- For credit cards
+    interest_rate REAL,
+    minimum_payment INTEGER,
+    creditor_name TEXT,
+    account_last4 TEXT,
+    opened_date TEXT,  -- ISO8601 format: YYYY-MM-DD
+    notes TEXT,
+    created_at TEXT DEFAULT CURRENT_TIMESTAMP,
+    updated_at TEXT DEFAULT CURRENT_TIMESTAMP,
+    currency TEXT NOT NULL DEFAULT 'USD'
+);
+
+INSERT INTO liabilities_new (
+    id, name, liability_type, current_balance, original_amount, credit_limit, interest_rate,
+    minimum_payment, creditor_name, account_last4, opened_date, notes, created_at, updated_at, currency
+)
+SELECT
+    id, name, liability_type, CAST(ROUND(current_balance * 100) AS INTEGER),
+    CAST(ROUND(original_amount * 100) AS INTEGER), CAST(ROUND(credit_limit * 100) AS INTEGER),
+    interest_rate, CAST(ROUND(minimum_payment * 100) AS INTEGER),
+    creditor_name, account_last4, opened_date, notes, created_at, updated_at, currency
+FROM liabilities;
+
+DROP TABLE liabilities;
+ALTER TABLE liabilities_new RENAME TO liabilities;
+
+CREATE INDEX idx_liabilities_type ON liabilities(liability_type);
+CREATE INDEX idx_liabilities_name ON liabilities(name);
+
+CREATE TRIGGER update_liabilities_updated_at
+AFTER UPDATE ON liabilities
+FOR EACH ROW
+BEGIN
+    UPDATE liabilities SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+END;
+
+-- Balance history table (balance in cents)
+CREATE TABLE liability_balance_history_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    liability_id INTEGER NOT NULL REFERENCES liabilities(id) ON DELETE CASCADE,
+    balance INTEGER NOT NULL,
+    recorded_at TEXT DEFAULT CURRENT_TIMESTAMP,
+    notes TEXT
+);
+
+INSERT INTO liability_balance_history_new (id, liability_id, balance, recorded_at, notes)
+SELECT id, liability_id, CAST(ROUND(balance * 100) AS INTEGER), recorded_at, notes
+FROM liability_balance_history;
+
+DROP TABLE liability_balance_history;
+ALTER TABLE liability_balance_history_new RENAME TO liability_balance_history;
+
+CREATE INDEX idx_balance_history_liability_id ON liability_balance_history(liability_id);
+CREATE INDEX idx_balance_history_recorded_at ON liability_balance_history(recorded_at);
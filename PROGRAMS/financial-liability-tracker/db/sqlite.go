@@ -5,93 +5,70 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+	"money"
 )
 
-var schema = `-- Financial Liability Tracker Schema
--- SQLite
-
--- Liabilities table
-CREATE TABLE IF NOT EXISTS liabilities (
-    id INTEGER PRIMARY KEY AUTOINCREMENT,
-    name TEXT UNIQUE NOT NULL,
-    liability_type TEXT NOT NULL CHECK (liability_type IN (
-        'credit-card', 'auto-loan', 'mortgage',
-        'student-loan', 'personal-loan', 'medical-debt'
-    )),
-    current_balance REAL NOT NULL,
-    original_amount REAL,
-    credit_limit REAL,  -- For credit cards
-    interest_rate REAL,
-    minimum_payment REAL,
-    creditor_name TEXT,
-    account_last4 TEXT,
-    opened_date TEXT,  -- ISO8601 format: YYYY-MM-DD
-    notes TEXT,
-    created_at TEXT DEFAULT CURRENT_TIMESTAMP,
-    updated_at TEXT DEFAULT CURRENT_TIMESTAMP
-);
-
--- Balance history table
-CREATE TABLE IF NOT EXISTS liability_balance_history (
-    id INTEGER PRIMARY KEY AUTOINCREMENT,
-    liability_id INTEGER NOT NULL REFERENCES liabilities(id) ON DELETE CASCADE,
-    balance REAL NOT NULL,
-    recorded_at TEXT DEFAULT CURRENT_TIMESTAMP,
-    notes TEXT
-);
-
--- Indexes
-CREATE INDEX IF NOT EXISTS idx_liabilities_type ON liabilities(liability_type);
-CREATE INDEX IF NOT EXISTS idx_liabilities_name ON liabilities(name);
-CREATE INDEX IF NOT EXISTS idx_balance_history_liability_id ON liability_balance_history(liability_id);
-CREATE INDEX IF NOT EXISTS idx_balance_history_recorded_at ON liability_balance_history(recorded_at);
-
--- Trigger to update updated_at timestamp
-CREATE TRIGGER IF NOT EXISTS update_liabilities_updated_at
-AFTER UPDATE ON liabilities
-FOR EACH ROW
-BEGIN
-    UPDATE liabilities SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
-END;
-`
+// DefaultCurrency is the currency of liabilities added without one
+const DefaultCurrency = "USD"
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
 
 // DB wraps the SQLite database connection
 type DB struct {
 	conn *sql.DB
+	path string
 }
 
 // Liability represents a financial liability
 type Liability struct {
-	ID             int        `json:"id"`
-	Name           string     `json:"name"`
-	LiabilityType  string     `json:"liability_type"`
-	CurrentBalance float64    `json:"current_balance"`
-	OriginalAmount *float64   `json:"original_amount,omitempty"`
-	CreditLimit    *float64   `json:"credit_limit,omitempty"`
-	InterestRate   *float64   `json:"interest_rate,omitempty"`
-	MinimumPayment *float64   `json:"minimum_payment,omitempty"`
-	CreditorName   string     `json:"creditor_name,omitempty"`
-	AccountLast4   string     `json:"account_last4,omitempty"`
-	OpenedDate     *time.Time `json:"opened_date,omitempty"`
-	Notes          string     `json:"notes,omitempty"`
-	CreatedAt      time.Time  `json:"created_at"`
-	UpdatedAt      time.Time  `json:"updated_at"`
+	ID             int          `json:"id"`
+	Name           string       `json:"name"`
+	LiabilityType  string       `json:"liability_type"`
+	CurrentBalance money.Cents  `json:"current_balance"`
+	Currency       string       `json:"currency"` // currency code of the balances and amounts
+	OriginalAmount *money.Cents `json:"original_amount,omitempty"`
+	CreditLimit    *money.Cents `json:"credit_limit,omitempty"`
+	InterestRate   *float64     `json:"interest_rate,omitempty"`
+	MinimumPayment *money.Cents `json:"minimum_payment,omitempty"`
+	CreditorName   string       `json:"creditor_name,omitempty"`
+	AccountLast4   string       `json:"account_last4,omitempty"`
+	OpenedDate     *time.Time   `json:"opened_date,omitempty"`
+	Notes          string       `json:"notes,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
 }
 
 // BalanceHistory represents a historical balance record
 type BalanceHistory struct {
-	ID          int       `json:"id"`
-	LiabilityID int       `json:"liability_id"`
-	Balance     float64   `json:"balance"`
-	RecordedAt  time.Time `json:"recorded_at"`
-	Notes       string    `json:"notes,omitempty"`
+	ID          int         `json:"id"`
+	LiabilityID int         `json:"liability_id"`
+	Balance     money.Cents `json:"balance"`
+	RecordedAt  time.Time   `json:"recorded_at"`
+	Notes       string      `json:"notes,omitempty"`
 }
 
-// New creates a new database connection
+// New creates a new database connection and applies pending schema migrations
 func New(dbPath string) (*DB, error) {
+	db, err := Open(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.Migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// Open creates a new database connection without touching the schema
+func Open(dbPath string) (*DB, error) {
 	// Ensure directory exists
 	dir := filepath.Dir(dbPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -109,13 +86,7 @@ func New(dbPath string) (*DB, error) {
 		return nil, fmt.Errorf("enable foreign keys: %w", err)
 	}
 
-	// Initialize schema
-	if _, err := conn.Exec(schema); err != nil {
-		conn.Close()
-		return nil, fmt.Errorf("initialize schema: %w", err)
-	}
-
-	return &DB{conn: conn}, nil
+	return &DB{conn: conn, path: dbPath}, nil
 }
 
 // Close closes the database connection
@@ -125,12 +96,20 @@ func (db *DB) Close() error {
 
 // AddLiability adds a new liability to the database
 func (db *DB) AddLiability(l *Liability) error {
+	l.Currency = strings.ToUpper(strings.TrimSpace(l.Currency))
+	if l.Currency == "" {
+		l.Currency = DefaultCurrency
+	}
+	if !currencyCode.MatchString(l.Currency) {
+		return fmt.Errorf("invalid currency %q (use a 3-letter code such as USD)", l.Currency)
+	}
+
 	query := `
 		INSERT INTO liabilities (
-			name, liability_type, current_balance, original_amount,
+			name, liability_type, current_balance, currency, original_amount,
 			credit_limit, interest_rate, minimum_payment, creditor_name,
 			account_last4, opened_date, notes
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	result, err := db.conn.Exec(
@@ -138,6 +117,7 @@ func (db *DB) AddLiability(l *Liability) error {
 		l.Name,
 		l.LiabilityType,
 		l.CurrentBalance,
+		l.Currency,
 		l.OriginalAmount,
 		l.CreditLimit,
 		l.InterestRate,
@@ -187,9 +167,9 @@ func (db *DB) AddLiability(l *Liability) error {
 }
 
 // UpdateLiability updates an existing liability
-func (db *DB) UpdateLiability(name string, newBalance *float64, notes string) error {
+func (db *DB) UpdateLiability(name string, newBalance *money.Cents, notes string) error {
 	// Get current liability
-	var currentBalance float64
+	var currentBalance money.Cents
 	var liabilityID int
 	err := db.conn.QueryRow(
 		"SELECT id, current_balance FROM liabilities WHERE name = ?",
@@ -250,7 +230,7 @@ func (db *DB) DeleteLiability(name string) error {
 // GetLiability retrieves a liability by name
 func (db *DB) GetLiability(name string) (*Liability, error) {
 	query := `
-		SELECT id, name, liability_type, current_balance, original_amount,
+		SELECT id, name, liability_type, current_balance, currency, original_amount,
 		       credit_limit, interest_rate, minimum_payment, creditor_name,
 		       account_last4, opened_date, notes, created_at, updated_at
 		FROM liabilities
@@ -266,6 +246,7 @@ func (db *DB) GetLiability(name string) (*Liability, error) {
 		&l.Name,
 		&l.LiabilityType,
 		&l.CurrentBalance,
+		&l.Currency,
 		&l.OriginalAmount,
 		&l.CreditLimit,
 		&l.InterestRate,
@@ -303,7 +284,7 @@ func (db *DB) GetLiability(name string) (*Liability, error) {
 // ListLiabilities retrieves all liabilities, optionally filtered by type
 func (db *DB) ListLiabilities(liabilityType string) ([]*Liability, error) {
 	query := `
-		SELECT id, name, liability_type, current_balance, original_amount,
+		SELECT id, name, liability_type, current_balance, currency, original_amount,
 		       credit_limit, interest_rate, minimum_payment, creditor_name,
 		       account_last4, opened_date, notes, created_at, updated_at
 		FROM liabilities
@@ -334,6 +315,7 @@ func (db *DB) ListLiabilities(liabilityType string) ([]*Liability, error) {
 			&l.Name,
 			&l.LiabilityType,
 			&l.CurrentBalance,
+			&l.Currency,
 			&l.OriginalAmount,
 			&l.CreditLimit,
 			&l.InterestRate,
@@ -425,12 +407,47 @@ func (db *DB) GetBalanceHistory(name string) ([]*BalanceHistory, error) {
 	return history, nil
 }
 
-// GetTotalBalance calculates the sum of all current balances
-func (db *DB) GetTotalBalance() (float64, error) {
-	var total float64
+// GetTotalBalance calculates the sum of all current balances, whatever their currency
+func (db *DB) GetTotalBalance() (money.Cents, error) {
+	var total money.Cents
 	err := db.conn.QueryRow("SELECT COALESCE(SUM(current_balance), 0) FROM liabilities").Scan(&total)
 	if err != nil {
 		return 0, fmt.Errorf("calculate total balance: %w", err)
 	}
 	return total, nil
 }
+
+// CurrencyTotal is the sum of the current balances in one currency
+type CurrencyTotal struct {
+	Currency string      `json:"currency"`
+	Balance  money.Cents `json:"balance"`
+	Count    int         `json:"count"`
+}
+
+// GetTotalsByCurrency sums the current balances per currency
+func (db *DB) GetTotalsByCurrency() ([]*CurrencyTotal, error) {
+	rows, err := db.conn.Query(`
+		SELECT currency, COALESCE(SUM(current_balance), 0), COUNT(*)
+		FROM liabilities
+		GROUP BY currency
+		ORDER BY currency
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("calculate totals by currency: %w", err)
+	}
+	defer rows.Close()
+
+	totals := make([]*CurrencyTotal, 0)
+	for rows.Next() {
+		t := &CurrencyTotal{}
+		if err := rows.Scan(&t.Currency, &t.Balance, &t.Count); err != nil {
+			return nil, fmt.Errorf("scan currency total: %w", err)
+		}
+		totals = append(totals, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate currency totals: %w", err)
+	}
+
+	return totals, nil
+}
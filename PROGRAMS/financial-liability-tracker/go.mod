@@ -2,4 +2,12 @@ module financial-liability-tracker
 
 go 1.25.4
 
-require github.com/mattn/go-sqlite3 v1.14.32
+require (
+	github.com/mattn/go-sqlite3 v1.14.32
+	money v0.0.0
+	sqlite-migrate v0.0.0
+)
+
+replace sqlite-migrate => ../sqlite-migrate
+
+replace money => ../money
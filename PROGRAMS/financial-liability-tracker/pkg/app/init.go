@@ -23,3 +23,14 @@ func InitDatabase() (*db.DB, error) {
 
 	return database, nil
 }
+
+// OpenDatabase loads config and opens the database without applying schema migrations
+// Used by the migrate command; caller is responsible for calling db.Close()
+func OpenDatabase() (*db.DB, error) {
+	cfg, err := config.LoadFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	return db.Open(cfg.DatabasePath())
+}
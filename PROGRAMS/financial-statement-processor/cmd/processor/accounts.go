@@ -0,0 +1,231 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"financial-statement-processor/db"
+	"financial-statement-processor/pkg/exitcodes"
+)
+
+// handleAccounts manages the accounts statements are attached to
+func handleAccounts(args []string) {
+	if len(args) < 1 {
+		printAccountsUsage()
+		os.Exit(exitcodes.ArgsError)
+	}
+
+	action := args[0]
+	args = args[1:]
+
+	switch action {
+	case "list":
+		withDatabase(func(database *db.DB) {
+			accounts, err := database.ListAccounts()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to list accounts: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			if accounts == nil {
+				accounts = []*db.Account{}
+			}
+			printJSON(map[string]interface{}{"accounts": accounts, "count": len(accounts)})
+		})
+	case "show":
+		fs := flag.NewFlagSet("accounts show", flag.ExitOnError)
+		id := fs.Int64("id", 0, "Account ID (required)")
+		fs.Parse(args)
+
+		requireID(*id)
+
+		withDatabase(func(database *db.DB) {
+			account, err := database.GetAccount(*id)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to get account: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			printJSON(map[string]interface{}{"account": account})
+		})
+	case "add":
+		fs := flag.NewFlagSet("accounts add", flag.ExitOnError)
+		nickname := fs.String("nickname", "", "Account nickname, stored as the account name of its transactions (required)")
+		institution := fs.String("institution", "", "Bank or card issuer")
+		accountType := fs.String("type", "", "Account type: checking, savings or credit")
+		last4 := fs.String("last4", "", "Last 4 digits of the account number")
+		currency := fs.String("currency", "USD", "Currency code")
+		opened := fs.String("opened", "", "Date the account was opened (YYYY-MM-DD)")
+		closed := fs.String("closed", "", "Date the account was closed (YYYY-MM-DD)")
+		var aliases patternList
+		fs.Var(&aliases, "alias", "Account name as spelled on statements (repeatable)")
+		fs.Parse(args)
+
+		account := &db.Account{
+			Institution: *institution,
+			Nickname:    *nickname,
+			AccountType: *accountType,
+			Last4:       *last4,
+			Currency:    *currency,
+		}
+		if *opened != "" {
+			d := parseDateFlag("opened", *opened)
+			account.OpenedOn = &d
+		}
+		if *closed != "" {
+			d := parseDateFlag("closed", *closed)
+			account.ClosedOn = &d
+		}
+
+		withDatabase(func(database *db.DB) {
+			id, err := database.AddAccount(account, aliases)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to add account: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			stored, err := database.GetAccount(id)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to get account: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			printJSON(map[string]interface{}{"success": true, "account": stored})
+		})
+	case "edit":
+		fs := flag.NewFlagSet("accounts edit", flag.ExitOnError)
+		id := fs.Int64("id", 0, "Account ID (required)")
+		nickname := fs.String("nickname", "", "Account nickname")
+		institution := fs.String("institution", "", "Bank or card issuer")
+		accountType := fs.String("type", "", "Account type: checking, savings or credit")
+		last4 := fs.String("last4", "", "Last 4 digits of the account number")
+		currency := fs.String("currency", "", "Currency code")
+		opened := fs.String("opened", "", "Date the account was opened (YYYY-MM-DD, empty to clear)")
+		closed := fs.String("closed", "", "Date the account was closed (YYYY-MM-DD, empty to reopen)")
+		fs.Parse(args)
+
+		requireID(*id)
+
+		var edit db.AccountEdit
+		fs.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "nickname":
+				edit.Nickname = nickname
+			case "institution":
+				edit.Institution = institution
+			case "type":
+				edit.AccountType = accountType
+			case "last4":
+				edit.Last4 = last4
+			case "currency":
+				edit.Currency = currency
+			case "opened":
+				edit.OpenedOn = optionalDateFlag("opened", *opened)
+			case "closed":
+				edit.ClosedOn = optionalDateFlag("closed", *closed)
+			}
+		})
+
+		withDatabase(func(database *db.DB) {
+			account, err := database.EditAccount(*id, edit)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to edit account: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			printJSON(map[string]interface{}{"success": true, "account": account})
+		})
+	case "alias":
+		fs := flag.NewFlagSet("accounts alias", flag.ExitOnError)
+		id := fs.Int64("id", 0, "Account ID (required)")
+		name := fs.String("name", "", "Account name as spelled on statements (required)")
+		last4 := fs.String("last4", "", "Last 4 digits shown with that name")
+		fs.Parse(args)
+
+		requireID(*id)
+
+		withDatabase(func(database *db.DB) {
+			if err := database.AddAccountAlias(*id, *name, *last4); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to add alias: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			account, err := database.GetAccount(*id)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to get account: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			printJSON(map[string]interface{}{"success": true, "account": account})
+		})
+	case "merge":
+		fs := flag.NewFlagSet("accounts merge", flag.ExitOnError)
+		into := fs.Int64("into", 0, "Account to keep (required)")
+		from := fs.Int64("from", 0, "Duplicate account to merge into it and delete (required)")
+		fs.Parse(args)
+
+		if *into == 0 || *from == 0 {
+			fmt.Fprintf(os.Stderr, "Error: --into and --from are required\n")
+			os.Exit(exitcodes.ArgsError)
+		}
+
+		withDatabase(func(database *db.DB) {
+			account, err := database.MergeAccounts(*into, *from)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to merge accounts: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			printJSON(map[string]interface{}{"success": true, "account": account, "merged": *from})
+		})
+	case "delete":
+		fs := flag.NewFlagSet("accounts delete", flag.ExitOnError)
+		id := fs.Int64("id", 0, "Account ID (required)")
+		fs.Parse(args)
+
+		requireID(*id)
+
+		withDatabase(func(database *db.DB) {
+			if err := database.DeleteAccount(*id); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to delete account: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			printJSON(map[string]interface{}{"success": true, "deleted": *id})
+		})
+	case "help", "--help", "-h":
+		printAccountsUsage()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown accounts action: %s\n\n", action)
+		printAccountsUsage()
+		os.Exit(exitcodes.ArgsError)
+	}
+
+	os.Exit(exitcodes.Success)
+}
+
+// optionalDateFlag parses a YYYY-MM-DD flag value where an empty value clears the date
+func optionalDateFlag(name, value string) *time.Time {
+	if value == "" {
+		return &time.Time{}
+	}
+	d := parseDateFlag(name, value)
+	return &d
+}
+
+func printAccountsUsage() {
+	fmt.Fprintf(os.Stderr, `Usage: %s accounts <action> [options]
+
+Every statement is attached to an account by the account name and last 4 digits printed on it.
+A statement matches an alias with the same name and last 4 digits, then the only open account
+with those last 4 digits, then (without last 4 digits) the only account with that name; otherwise
+a new account is created. Transactions take the account's nickname as their account name.
+
+Actions:
+  list     List accounts with their aliases and transaction counts
+  show     Show one account (--id)
+  add      Add an account (--nickname, --institution, --type, --last4, --currency, --opened, --closed, --alias)
+  edit     Change an account (--id and any of the add options except --alias)
+  alias    Add another spelling of an account's name on statements (--id, --name, --last4)
+  merge    Move a duplicate account's transactions and aliases into another (--into, --from)
+  delete   Delete an account without transactions (--id)
+
+Examples:
+  %s accounts add --nickname "Chase Sapphire" --institution Chase --type credit --last4 4821 --alias "CHASE SAPPHIRE PREFERRED"
+  %s accounts merge --into 1 --from 3
+  %s accounts edit --id 2 --closed 2024-06-30
+`, os.Args[0], os.Args[0], os.Args[0], os.Args[0])
+}
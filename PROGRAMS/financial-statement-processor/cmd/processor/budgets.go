@@ -0,0 +1,233 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"financial-statement-processor/db"
+	"financial-statement-processor/pkg/app"
+	"financial-statement-processor/pkg/exitcodes"
+)
+
+// patternList collects a repeatable --pattern flag
+type patternList []string
+
+func (p *patternList) String() string { return strings.Join(*p, ", ") }
+
+func (p *patternList) Set(value string) error {
+	*p = append(*p, value)
+	return nil
+}
+
+// handleBudgets manages monthly budgets and reports their status
+func handleBudgets(args []string) {
+	if len(args) < 1 {
+		printBudgetsUsage()
+		os.Exit(exitcodes.ArgsError)
+	}
+
+	action := args[0]
+	args = args[1:]
+
+	switch action {
+	case "list":
+		withDatabase(func(database *db.DB) {
+			budgets, err := database.ListBudgets()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to list budgets: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			if budgets == nil {
+				budgets = []*db.Budget{}
+			}
+			printJSON(map[string]interface{}{"budgets": budgets, "count": len(budgets)})
+		})
+	case "add":
+		fs := flag.NewFlagSet("budgets add", flag.ExitOnError)
+		name := fs.String("name", "", "Budget name (required)")
+		category := fs.String("category", "", "Category whose transactions count towards the budget")
+		limit := fs.String("limit", "", "Monthly limit (required)")
+		rollover := fs.String("rollover", db.BudgetRolloverNone, "Rollover: none, surplus (carry unspent money) or full (carry overspending too)")
+		start := fs.String("start", time.Now().Format("2006-01"), "First month of the budget (YYYY-MM)")
+		var patterns, regexPatterns patternList
+		fs.Var(&patterns, "pattern", "Description or merchant substring that counts towards the budget (repeatable)")
+		fs.Var(&regexPatterns, "regex-pattern", "Description or merchant regex that counts towards the budget (repeatable)")
+		fs.Parse(args)
+
+		budget := &db.Budget{
+			Name:       *name,
+			Category:   *category,
+			Rollover:   *rollover,
+			StartMonth: *start,
+			Patterns:   budgetPatterns(patterns, regexPatterns),
+		}
+		if amount := parseOptionalAmount("limit", *limit); amount != nil {
+			budget.MonthlyLimit = *amount
+		}
+
+		if err := budget.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitcodes.ArgsError)
+		}
+
+		withDatabase(func(database *db.DB) {
+			id, err := database.AddBudget(budget)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to add budget: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			stored, err := database.GetBudget(id)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to load budget: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			printJSON(map[string]interface{}{"success": true, "budget": stored})
+		})
+	case "edit":
+		fs := flag.NewFlagSet("budgets edit", flag.ExitOnError)
+		id := fs.Int64("id", 0, "Budget ID (required)")
+		name := fs.String("name", "", "Budget name")
+		category := fs.String("category", "", "Category (empty to count only patterns)")
+		limit := fs.String("limit", "", "Monthly limit")
+		rollover := fs.String("rollover", "", "Rollover: none, surplus or full")
+		start := fs.String("start", "", "First month of the budget (YYYY-MM)")
+		clearPatterns := fs.Bool("clear-patterns", false, "Remove all patterns")
+		var patterns, regexPatterns patternList
+		fs.Var(&patterns, "pattern", "Replace the patterns with these substrings (repeatable)")
+		fs.Var(&regexPatterns, "regex-pattern", "Replace the patterns with these regexes (repeatable)")
+		fs.Parse(args)
+
+		if *id == 0 {
+			fmt.Fprintf(os.Stderr, "Error: --id is required\n")
+			os.Exit(exitcodes.ArgsError)
+		}
+
+		var edit db.BudgetEdit
+		fs.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "name":
+				edit.Name = name
+			case "category":
+				edit.Category = category
+			case "limit":
+				edit.MonthlyLimit = parseOptionalAmount("limit", *limit)
+			case "rollover":
+				edit.Rollover = rollover
+			case "start":
+				edit.StartMonth = start
+			}
+		})
+		if *clearPatterns || len(patterns) > 0 || len(regexPatterns) > 0 {
+			edit.Patterns = budgetPatterns(patterns, regexPatterns)
+		}
+
+		withDatabase(func(database *db.DB) {
+			budget, err := database.EditBudget(*id, edit)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to edit budget: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			printJSON(map[string]interface{}{"success": true, "budget": budget})
+		})
+	case "delete":
+		fs := flag.NewFlagSet("budgets delete", flag.ExitOnError)
+		id := fs.Int64("id", 0, "Budget ID (required)")
+		fs.Parse(args)
+
+		if *id == 0 {
+			fmt.Fprintf(os.Stderr, "Error: --id is required\n")
+			os.Exit(exitcodes.ArgsError)
+		}
+
+		withDatabase(func(database *db.DB) {
+			if err := database.DeleteBudget(*id); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to delete budget: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			printJSON(map[string]interface{}{"success": true, "deleted": *id})
+		})
+	case "status":
+		fs := flag.NewFlagSet("budgets status", flag.ExitOnError)
+		monthFlag := fs.String("month", "", "Month to report (YYYY-MM, default: current month)")
+		fs.Parse(args)
+
+		now := time.Now()
+		month := now
+		if *monthFlag != "" {
+			m, err := time.Parse("2006-01", *monthFlag)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: invalid --month (use YYYY-MM): %v\n", err)
+				os.Exit(exitcodes.ArgsError)
+			}
+			month = m
+		}
+		asOf := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+		cfg, err := app.InitConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
+			os.Exit(exitcodes.ConfigError)
+		}
+
+		withDatabase(func(database *db.DB) {
+			statuses, err := database.BudgetStatuses(month, asOf, cfg.BaseCurrency)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to compute budget status: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			printJSON(map[string]interface{}{
+				"month":   month.Format("2006-01"),
+				"budgets": statuses,
+				"count":   len(statuses),
+			})
+		})
+	case "help", "--help", "-h":
+		printBudgetsUsage()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown budgets action: %s\n\n", action)
+		printBudgetsUsage()
+		os.Exit(exitcodes.ArgsError)
+	}
+
+	os.Exit(exitcodes.Success)
+}
+
+// budgetPatterns converts --pattern and --regex-pattern values into budget patterns
+func budgetPatterns(substrings, regexes []string) []*db.BudgetPattern {
+	patterns := make([]*db.BudgetPattern, 0, len(substrings)+len(regexes))
+	for _, v := range substrings {
+		patterns = append(patterns, &db.BudgetPattern{MatchType: "substring", Pattern: v})
+	}
+	for _, v := range regexes {
+		patterns = append(patterns, &db.BudgetPattern{MatchType: "regex", Pattern: v})
+	}
+	return patterns
+}
+
+func printBudgetsUsage() {
+	fmt.Fprintf(os.Stderr, `Usage: %s budgets <action> [options]
+
+A budget is a monthly limit for a category and/or description patterns. Spending is debits minus
+refunds that count towards the budget; linked transfers between accounts never count.
+
+Actions:
+  list     List budgets
+  add      Add a budget (--name, --limit, --category, --pattern, --regex-pattern, --rollover, --start)
+  edit     Change a budget (--id, then any add option; patterns replace all, --clear-patterns removes)
+  delete   Delete a budget (--id)
+  status   Spent, remaining and projected month-end spending per budget (--month YYYY-MM)
+
+Rollover:
+  none     Every month starts from the limit
+  surplus  Unspent money carries into the next month
+  full     Unspent money and overspending both carry over
+
+Examples:
+  %s budgets add --name Groceries --category groceries --limit 600
+  %s budgets add --name Coffee --pattern "COFFEE" --pattern "STARBUCKS" --limit 60 --rollover surplus
+  %s budgets status --month 2024-10
+`, os.Args[0], os.Args[0], os.Args[0], os.Args[0])
+}
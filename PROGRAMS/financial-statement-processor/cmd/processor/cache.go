@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"financial-statement-processor/db"
+	"financial-statement-processor/parser"
+	"financial-statement-processor/pkg/exitcodes"
+)
+
+// handleCache lists and purges the parse cache of raw LLM responses
+func handleCache(args []string) {
+	if len(args) < 1 {
+		printCacheUsage()
+		os.Exit(exitcodes.ArgsError)
+	}
+
+	action := args[0]
+	args = args[1:]
+
+	switch action {
+	case "list":
+		fs := flag.NewFlagSet("cache list", flag.ExitOnError)
+		filter := cacheFilterFlags(fs)
+		responses := fs.Bool("responses", false, "Include the raw LLM responses")
+		fs.Parse(args)
+
+		f := filter()
+		withDatabase(func(database *db.DB) {
+			entries, err := database.ListParseCache(f, *responses)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to list parse cache: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			printJSON(map[string]interface{}{"entries": entries, "count": len(entries)})
+		})
+	case "purge":
+		fs := flag.NewFlagSet("cache purge", flag.ExitOnError)
+		filter := cacheFilterFlags(fs)
+		olderThan := fs.Int("older-than", 0, "Only purge entries older than this many days")
+		all := fs.Bool("all", false, "Purge every entry (required when no other filter is given)")
+		fs.Parse(args)
+
+		f := filter()
+		if *olderThan > 0 {
+			cutoff := time.Now().AddDate(0, 0, -*olderThan)
+			f.OlderThan = &cutoff
+		}
+
+		if f == (db.ParseCacheFilter{}) && !*all {
+			fmt.Fprintf(os.Stderr, "Error: pass a filter (--path, --source, --hash, --model, --older-than) or --all\n")
+			os.Exit(exitcodes.ArgsError)
+		}
+
+		withDatabase(func(database *db.DB) {
+			purged, err := database.PurgeParseCache(f)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to purge parse cache: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			fmt.Printf("Purged %d cache entries\n", purged)
+		})
+	case "help", "--help", "-h":
+		printCacheUsage()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown cache action: %s\n\n", action)
+		printCacheUsage()
+		os.Exit(exitcodes.ArgsError)
+	}
+
+	os.Exit(exitcodes.Success)
+}
+
+// cacheFilterFlags registers the shared cache filter flags and returns a function building the filter
+func cacheFilterFlags(fs *flag.FlagSet) func() db.ParseCacheFilter {
+	path := fs.String("path", "", "Statement file on disk (matched by content hash)")
+	source := fs.String("source", "", "Source file name as recorded when it was processed")
+	hash := fs.String("hash", "", "SHA-256 of the statement file")
+	model := fs.String("model", "", "LLM model name")
+
+	return func() db.ParseCacheFilter {
+		filter := db.ParseCacheFilter{
+			FileHash:   *hash,
+			SourceFile: *source,
+			Model:      *model,
+		}
+
+		if *path != "" {
+			h, err := parser.FileSHA256(*path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitcodes.ArgsError)
+			}
+			filter.FileHash = h
+		}
+
+		return filter
+	}
+}
+
+func printCacheUsage() {
+	fmt.Fprintf(os.Stderr, `Usage: %s cache <action> [options]
+
+The parse cache keeps the raw LLM response for every statement page, keyed by the file's
+SHA-256, page number, model and prompt version. Re-processing a file reuses cached pages.
+
+Actions:
+  list    List cache entries (--path, --source, --hash, --model, --responses)
+  purge   Delete cache entries (--path, --source, --hash, --model, --older-than, --all)
+
+Examples:
+  # Show exactly what the model returned for a statement
+  %s cache list --path /path/to/statement.pdf --responses
+
+  # Re-parse a statement from scratch
+  %s cache purge --path /path/to/statement.pdf
+
+  # Drop responses from a model you no longer use
+  %s cache purge --model mistral
+`, os.Args[0], os.Args[0], os.Args[0], os.Args[0])
+}
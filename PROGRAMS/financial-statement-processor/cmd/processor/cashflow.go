@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"financial-statement-processor/db"
+	"financial-statement-processor/pkg/app"
+	"financial-statement-processor/pkg/exitcodes"
+)
+
+// handleCashFlow reports monthly income against expenses as JSON
+func handleCashFlow(args []string) {
+	cfg, err := app.InitConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
+		os.Exit(exitcodes.ConfigError)
+	}
+
+	fs := flag.NewFlagSet("cash-flow", flag.ExitOnError)
+	months := fs.Int("months", 12, "Number of months, ending with the month of --end-date")
+	endDate := fs.String("end-date", "", "Any day of the last month (YYYY-MM-DD, default: today)")
+	account := fs.String("account", "", "Account name substring or last 4 digits")
+	top := fs.Int("top", 5, "Categories and merchants listed per month")
+	base := fs.String("base", cfg.BaseCurrency, "Currency code amounts are converted into")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s cash-flow [OPTIONS]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Show monthly income, expenses, net and savings rate with the top categories and merchants.\n")
+		fmt.Fprintf(os.Stderr, "Amounts are converted into --base with the stored exchange rates.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	opts := db.CashFlowOptions{Months: *months, Account: *account, Top: *top, BaseCurrency: *base}
+	if *endDate != "" {
+		if opts.End, err = time.Parse("2006-01-02", *endDate); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --end-date (use YYYY-MM-DD): %v\n", err)
+			os.Exit(exitcodes.ArgsError)
+		}
+	}
+
+	withDatabase(func(database *db.DB) {
+		report, err := database.CashFlow(opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to build cash flow report: %v\n", err)
+			os.Exit(exitcodes.DBError)
+		}
+		printJSON(report)
+	})
+
+	os.Exit(exitcodes.Success)
+}
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"financial-statement-processor/config"
+	"financial-statement-processor/db"
+	"financial-statement-processor/parser"
+	"financial-statement-processor/pkg/app"
+	"financial-statement-processor/pkg/exitcodes"
+	"money"
+)
+
+// categorizeTransactions applies category rules and, if enabled, the LLM fallback
+// Failures are logged but never abort processing - uncategorized rows can be back-filled later
+func categorizeTransactions(database *db.DB, cfg *config.Config, transactions []*db.Transaction) {
+	matched, err := database.ApplyCategoryRules(transactions)
+	if err != nil {
+		log.Printf("WARNING: Failed to apply category rules: %v", err)
+		return
+	}
+	log.Printf("Categorized by rules: %d/%d", matched, len(transactions))
+
+	if !cfg.CategorizeWithLLM || matched == len(transactions) {
+		return
+	}
+
+	categories, err := database.CategoryNames()
+	if err != nil {
+		log.Printf("WARNING: Failed to load categories: %v", err)
+		return
+	}
+
+	backend, err := newLLMBackend(cfg)
+	if err != nil {
+		log.Printf("WARNING: LLM categorization skipped: %v", err)
+		return
+	}
+
+	categorized, err := parser.CategorizeWithLLM(transactions, categories, backend)
+	if err != nil {
+		log.Printf("WARNING: LLM categorization failed: %v", err)
+	}
+	log.Printf("Categorized by LLM: %d", categorized)
+}
+
+// handleCategorize back-fills categories for transactions already in the database
+func handleCategorize(args []string) {
+	fs := flag.NewFlagSet("categorize", flag.ExitOnError)
+	useLLM := fs.Bool("llm", false, "Use the LLM for transactions no rule matches")
+	all := fs.Bool("all", false, "Re-categorize all transactions (manually categorized rows are always kept)")
+	dryRun := fs.Bool("dry-run", false, "Show what would change without updating the database")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s categorize [OPTIONS]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Back-fill categories for stored transactions using category rules.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	cfg, err := app.InitConfig()
+	if err != nil {
+		log.Printf("ERROR: Configuration error: %v", err)
+		os.Exit(exitcodes.ConfigError)
+	}
+
+	database, err := db.New(cfg.DatabasePath())
+	if err != nil {
+		log.Printf("ERROR: Failed to open database: %v", err)
+		os.Exit(exitcodes.DBError)
+	}
+	defer database.Close()
+
+	transactions, err := database.QueryTransactionsForCategorization(*all)
+	if err != nil {
+		log.Printf("ERROR: Failed to load transactions: %v", err)
+		os.Exit(exitcodes.DBError)
+	}
+	log.Printf("Transactions to categorize: %d", len(transactions))
+
+	// Remember existing categories so only real changes are written
+	previous := make(map[int64]string, len(transactions))
+	for _, tx := range transactions {
+		previous[tx.ID] = tx.Category
+		if *all {
+			tx.Category = ""
+			tx.CategorySource = ""
+		}
+	}
+
+	matched, err := database.ApplyCategoryRules(transactions)
+	if err != nil {
+		log.Printf("ERROR: Failed to apply category rules: %v", err)
+		os.Exit(exitcodes.DBError)
+	}
+	log.Printf("Categorized by rules: %d", matched)
+
+	if *useLLM {
+		categories, err := database.CategoryNames()
+		if err != nil {
+			log.Printf("ERROR: Failed to load categories: %v", err)
+			os.Exit(exitcodes.DBError)
+		}
+
+		backend, err := newLLMBackend(cfg)
+		if err != nil {
+			log.Printf("ERROR: Configuration error: %v", err)
+			os.Exit(exitcodes.ConfigError)
+		}
+
+		categorized, err := parser.CategorizeWithLLM(transactions, categories, backend)
+		if err != nil {
+			log.Printf("WARNING: LLM categorization failed: %v", err)
+		}
+		log.Printf("Categorized by LLM: %d", categorized)
+	}
+
+	updated := 0
+	for _, tx := range transactions {
+		if tx.Category == "" || tx.Category == previous[tx.ID] {
+			continue
+		}
+
+		if *dryRun {
+			log.Printf("DRY-RUN: %d %s %s -> %s (%s)", tx.ID, tx.TransactionDate.Format("2006-01-02"), tx.Description, tx.Category, tx.CategorySource)
+			updated++
+			continue
+		}
+
+		if err := database.SetTransactionCategory(tx.ID, tx.Category, tx.CategorySource); err != nil {
+			log.Printf("ERROR: Failed to update transaction %d: %v", tx.ID, err)
+			os.Exit(exitcodes.DBError)
+		}
+		updated++
+	}
+
+	log.Printf("Transactions updated: %d", updated)
+	os.Exit(exitcodes.Success)
+}
+
+// handleCategories manages categories and categorization rules
+func handleCategories(args []string) {
+	if len(args) < 1 {
+		printCategoriesUsage()
+		os.Exit(exitcodes.ArgsError)
+	}
+
+	action := args[0]
+	args = args[1:]
+
+	switch action {
+	case "list":
+		withDatabase(func(database *db.DB) {
+			categories, err := database.ListCategories()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to list categories: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			printJSON(map[string]interface{}{"categories": categories, "count": len(categories)})
+		})
+	case "add":
+		fs := flag.NewFlagSet("categories add", flag.ExitOnError)
+		name := fs.String("name", "", "Category name (required)")
+		description := fs.String("description", "", "Category description")
+		fs.Parse(args)
+
+		if *name == "" {
+			fmt.Fprintf(os.Stderr, "Error: --name is required\n")
+			os.Exit(exitcodes.ArgsError)
+		}
+
+		withDatabase(func(database *db.DB) {
+			id, err := database.AddCategory(*name, *description)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to add category: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			fmt.Printf("Category added successfully (ID: %d)\n", id)
+		})
+	case "rules":
+		withDatabase(func(database *db.DB) {
+			rules, err := database.ListCategoryRules()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to list rules: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			printJSON(map[string]interface{}{"rules": rules, "count": len(rules)})
+		})
+	case "add-rule":
+		fs := flag.NewFlagSet("categories add-rule", flag.ExitOnError)
+		category := fs.String("category", "", "Category to assign (required)")
+		pattern := fs.String("pattern", "", "Description substring (or regex with --regex)")
+		regex := fs.Bool("regex", false, "Treat --pattern as a regular expression")
+		minAmount := fs.String("min-amount", "", "Minimum absolute amount")
+		maxAmount := fs.String("max-amount", "", "Maximum absolute amount")
+		account := fs.String("account", "", "Account name substring or last 4 digits")
+		priority := fs.Int("priority", 0, "Rule priority (higher is evaluated first)")
+		fs.Parse(args)
+
+		rule := &db.CategoryRule{
+			Category:  *category,
+			MatchType: "substring",
+			Pattern:   *pattern,
+			Account:   *account,
+			Priority:  *priority,
+		}
+		if *regex {
+			rule.MatchType = "regex"
+		}
+		rule.MinAmount = parseOptionalAmount("min-amount", *minAmount)
+		rule.MaxAmount = parseOptionalAmount("max-amount", *maxAmount)
+
+		if err := rule.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitcodes.ArgsError)
+		}
+
+		withDatabase(func(database *db.DB) {
+			id, err := database.AddCategoryRule(rule)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to add rule: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			fmt.Printf("Rule added successfully (ID: %d)\n", id)
+		})
+	case "delete-rule":
+		fs := flag.NewFlagSet("categories delete-rule", flag.ExitOnError)
+		id := fs.Int64("id", 0, "Rule ID (required)")
+		fs.Parse(args)
+
+		if *id == 0 {
+			fmt.Fprintf(os.Stderr, "Error: --id is required\n")
+			os.Exit(exitcodes.ArgsError)
+		}
+
+		withDatabase(func(database *db.DB) {
+			if err := database.DeleteCategoryRule(*id); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to delete rule: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			fmt.Printf("Rule %d deleted successfully\n", *id)
+		})
+	case "help", "--help", "-h":
+		printCategoriesUsage()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown categories action: %s\n\n", action)
+		printCategoriesUsage()
+		os.Exit(exitcodes.ArgsError)
+	}
+
+	os.Exit(exitcodes.Success)
+}
+
+func printCategoriesUsage() {
+	fmt.Fprintf(os.Stderr, `Usage: %s categories <action> [options]
+
+Actions:
+  list         List categories
+  add          Add a category (--name, --description)
+  rules        List categorization rules in evaluation order
+  add-rule     Add a rule (--category, --pattern, --regex, --min-amount, --max-amount, --account, --priority)
+  delete-rule  Delete a rule (--id)
+
+Examples:
+  # Everything from the grocery store is groceries
+  %s categories add-rule --category groceries --pattern "WHOLE FOODS"
+
+  # Large transfers out of checking are savings transfers
+  %s categories add-rule --category transfer --pattern "^ONLINE TRANSFER" --regex --min-amount 500 --account 1234
+`, os.Args[0], os.Args[0], os.Args[0])
+}
+
+// newLLMBackend creates the LLM backend selected by LLM_BACKEND
+func newLLMBackend(cfg *config.Config) (parser.LLMBackend, error) {
+	return parser.NewLLMBackend(parser.LLMConfig{
+		Backend:  cfg.LLMBackend,
+		Host:     cfg.LLMHost,
+		Model:    cfg.LLMModel,
+		APIKey:   cfg.LLMAPIKey,
+		Fixtures: cfg.LLMFixtures,
+	})
+}
+
+// withDatabase opens the configured database, runs fn and closes it again
+func withDatabase(fn func(database *db.DB)) {
+	database, err := app.InitDatabase()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Database error: %v\n", err)
+		os.Exit(exitcodes.DBError)
+	}
+	defer database.Close()
+
+	fn(database)
+}
+
+// parseOptionalAmount parses an optional amount flag, exiting on invalid input
+func parseOptionalAmount(name, value string) *money.Cents {
+	if value == "" {
+		return nil
+	}
+
+	amount, err := money.Parse(value)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid --%s: %v\n", name, err)
+		os.Exit(exitcodes.ArgsError)
+	}
+	return &amount
+}
+
+// printJSON writes data as pretty-printed JSON to stdout
+func printJSON(data interface{}) {
+	out, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to format output: %v\n", err)
+		os.Exit(exitcodes.DBError)
+	}
+	fmt.Println(string(out))
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"financial-statement-processor/db"
+	"financial-statement-processor/pkg/exitcodes"
+)
+
+// handleCoverage reports which statements have been imported per account, with gaps and overdue statements
+func handleCoverage(args []string) {
+	fs := flag.NewFlagSet("coverage", flag.ExitOnError)
+	account := fs.String("account", "", "Account name substring or last 4 digits")
+	overdue := fs.Bool("overdue", false, "Only accounts whose latest statement is overdue")
+	graceDays := fs.Int("grace-days", 10, "Days past the expected statement date before it is overdue")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s coverage [OPTIONS]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Show imported statements per account, the gaps between them and overdue statements.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	withDatabase(func(database *db.DB) {
+		accounts, err := database.StatementCoverage(db.CoverageOptions{Account: *account, GraceDays: *graceDays})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to get statement coverage: %v\n", err)
+			os.Exit(exitcodes.DBError)
+		}
+
+		result := []*db.StatementCoverage{}
+		overdueCount := 0
+		for _, c := range accounts {
+			if c.Status == db.CoverageStatusOverdue {
+				overdueCount++
+			} else if *overdue {
+				continue
+			}
+			result = append(result, c)
+		}
+
+		printJSON(map[string]interface{}{"accounts": result, "count": len(result), "overdue": overdueCount})
+	})
+
+	os.Exit(exitcodes.Success)
+}
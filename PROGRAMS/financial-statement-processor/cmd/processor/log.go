@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"financial-statement-processor/db"
+	"financial-statement-processor/pkg/exitcodes"
+)
+
+// handleLog lists processing log entries, with retry hints for failed imports
+func handleLog(args []string) {
+	fs := flag.NewFlagSet("log", flag.ExitOnError)
+	status := fs.String("status", "all", "success, parse_error, db_error, reconcile_error, failed (any error) or all")
+	startDate := fs.String("start-date", "", "Only files processed on or after this date (YYYY-MM-DD)")
+	endDate := fs.String("end-date", "", "Only files processed on or before this date (YYYY-MM-DD)")
+	file := fs.String("file", "", "Source file name substring")
+	limit := fs.Int("limit", 50, "Maximum number of entries")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s log [OPTIONS]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Show processed statements, newest first, with the reason and a retry hint for failed imports.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	opts := db.ProcessingLogOptions{File: *file, Limit: *limit}
+	switch *status {
+	case "all":
+	case "success", "parse_error", "db_error", "reconcile_error", db.ProcessingStatusFailed:
+		opts.Status = *status
+	default:
+		fmt.Fprintf(os.Stderr, "Error: --status must be success, parse_error, db_error, reconcile_error, failed or all (got: %s)\n", *status)
+		os.Exit(exitcodes.ArgsError)
+	}
+	var err error
+	if *startDate != "" {
+		if opts.StartDate, err = time.Parse("2006-01-02", *startDate); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --start-date (use YYYY-MM-DD): %v\n", err)
+			os.Exit(exitcodes.ArgsError)
+		}
+	}
+	if *endDate != "" {
+		if opts.EndDate, err = time.Parse("2006-01-02", *endDate); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --end-date (use YYYY-MM-DD): %v\n", err)
+			os.Exit(exitcodes.ArgsError)
+		}
+	}
+
+	withDatabase(func(database *db.DB) {
+		entries, summary, err := database.QueryProcessingLog(opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to get processing log: %v\n", err)
+			os.Exit(exitcodes.DBError)
+		}
+
+		printJSON(map[string]interface{}{"entries": entries, "count": len(entries), "summary": summary})
+	})
+
+	os.Exit(exitcodes.Success)
+}
@@ -14,28 +14,127 @@ import (
 )
 
 func main() {
+	// Maintenance commands; anything else is treated as a statement file to process
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "categorize":
+			handleCategorize(os.Args[2:])
+			return
+		case "categories":
+			handleCategories(os.Args[2:])
+			return
+		case "merchants":
+			handleMerchants(os.Args[2:])
+			return
+		case "cache":
+			handleCache(os.Args[2:])
+			return
+		case "review":
+			handleReview(os.Args[2:])
+			return
+		case "transfers":
+			handleTransfers(os.Args[2:])
+			return
+		case "recurring":
+			handleRecurring(os.Args[2:])
+			return
+		case "budgets":
+			handleBudgets(os.Args[2:])
+			return
+		case "transactions":
+			handleTransactions(os.Args[2:])
+			return
+		case "coverage":
+			handleCoverage(os.Args[2:])
+			return
+		case "cash-flow":
+			handleCashFlow(os.Args[2:])
+			return
+		case "search":
+			handleSearch(os.Args[2:])
+			return
+		case "log":
+			handleLog(os.Args[2:])
+			return
+		case "accounts":
+			handleAccounts(os.Args[2:])
+			return
+		case "rates":
+			handleRates(os.Args[2:])
+			return
+		case "migrate":
+			handleMigrate(os.Args[2:])
+			return
+		}
+	}
+
 	// Parse command-line arguments
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: %s [OPTIONS] <file_path>\n\n", os.Args[0])
-		fmt.Fprintf(os.Stderr, "Process a bank statement PDF or image file and store transactions in SQLite.\n\n")
+		fmt.Fprintf(os.Stderr, "Usage: %s [OPTIONS] <file_path>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s <command> [OPTIONS]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Process a bank statement file and store transactions in SQLite.\n")
+		fmt.Fprintf(os.Stderr, "PDFs and images are parsed by the LLM; CSV, OFX/QFX and QIF downloads are imported directly.\n\n")
 		fmt.Fprintf(os.Stderr, "Arguments:\n")
-		fmt.Fprintf(os.Stderr, "  file_path    Path to the statement file (PDF, JPG, PNG, TIFF)\n\n")
+		fmt.Fprintf(os.Stderr, "  file_path    Path to the statement file (PDF, JPG, PNG, TIFF, CSV, OFX, QFX, QIF)\n\n")
+		fmt.Fprintf(os.Stderr, "Commands:\n")
+		fmt.Fprintf(os.Stderr, "  accounts     Manage accounts, their statement aliases, and merge duplicates\n")
+		fmt.Fprintf(os.Stderr, "  transactions Add, edit, delete or split transactions by hand\n")
+		fmt.Fprintf(os.Stderr, "  categorize   Back-fill categories for stored transactions\n")
+		fmt.Fprintf(os.Stderr, "  categories   Manage categories and categorization rules\n")
+		fmt.Fprintf(os.Stderr, "  merchants    Manage merchant rules and back-fill merchant names\n")
+		fmt.Fprintf(os.Stderr, "  cache        List or purge cached LLM responses\n")
+		fmt.Fprintf(os.Stderr, "  review       Review, approve or reject flagged transactions\n")
+		fmt.Fprintf(os.Stderr, "  transfers    Match, list, link or unlink transfers between accounts\n")
+		fmt.Fprintf(os.Stderr, "  recurring    Re-run recurring charge detection\n")
+		fmt.Fprintf(os.Stderr, "  budgets      Manage monthly budgets and show spending against them\n")
+		fmt.Fprintf(os.Stderr, "  coverage     Show imported statements per account, gaps and overdue statements\n")
+		fmt.Fprintf(os.Stderr, "  cash-flow    Show monthly income, expenses, net and savings rate\n")
+		fmt.Fprintf(os.Stderr, "  search       Search transactions by words, best match first\n")
+		fmt.Fprintf(os.Stderr, "  log          Show processed statements and why failed imports failed\n")
+		fmt.Fprintf(os.Stderr, "  rates        Add, import or list exchange rates into the base currency\n")
+		fmt.Fprintf(os.Stderr, "  migrate      Show or apply database schema migrations\n\n")
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		flag.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\nEnvironment Variables:\n")
 		fmt.Fprintf(os.Stderr, "  DB_PATH       SQLite database file path (default: ./transactions.db)\n")
 		fmt.Fprintf(os.Stderr, "  OLLAMA_HOST   Ollama server URL (default: http://localhost:11434)\n")
-		fmt.Fprintf(os.Stderr, "  OLLAMA_MODEL  LLM model for parsing (default: dolphin3)\n\n")
+		fmt.Fprintf(os.Stderr, "  OLLAMA_MODEL  LLM model for parsing (default: dolphin3)\n")
+		fmt.Fprintf(os.Stderr, "  LLM_BACKEND   ollama, ollama-chat, openai or fake (default: ollama)\n")
+		fmt.Fprintf(os.Stderr, "  LLM_HOST      LLM server URL (default: OLLAMA_HOST)\n")
+		fmt.Fprintf(os.Stderr, "  LLM_MODEL     LLM model (default: OLLAMA_MODEL)\n")
+		fmt.Fprintf(os.Stderr, "  LLM_API_KEY   Bearer token for OpenAI-compatible servers\n")
+		fmt.Fprintf(os.Stderr, "  LLM_FIXTURES  Fixture file for LLM_BACKEND=fake\n")
+		fmt.Fprintf(os.Stderr, "  LLM_WORKERS   Statement pages parsed concurrently (default: 2)\n")
+		fmt.Fprintf(os.Stderr, "  LLM_REPAIR_RETRIES   Times an invalid LLM response is sent back for repair (default: 2)\n")
+		fmt.Fprintf(os.Stderr, "  LLM_INVALID_ROWS     Rows still invalid after repair: skip or fail the page (default: skip)\n")
+		fmt.Fprintf(os.Stderr, "  LLM_PLAIN_JSON       Don't send the response schema, only request JSON (default: false)\n")
+		fmt.Fprintf(os.Stderr, "  CATEGORIZE_WITH_LLM  Use the LLM for transactions no rule matches (default: true)\n")
+		fmt.Fprintf(os.Stderr, "  CSV_PROFILES_PATH    JSON file with additional CSV column mapping profiles\n")
+		fmt.Fprintf(os.Stderr, "  TRANSFER_WINDOW_DAYS       Max days between the two sides of a transfer (default: 3)\n")
+		fmt.Fprintf(os.Stderr, "  TRANSFER_AMOUNT_TOLERANCE  Max amount difference between the two sides (default: 0)\n")
+		fmt.Fprintf(os.Stderr, "  BASE_CURRENCY        Currency reports convert amounts into (default: USD)\n")
+		fmt.Fprintf(os.Stderr, "  OCR_ENGINE           OCR for images/scanned pages: tesseract, ollama or none (default: tesseract)\n")
+		fmt.Fprintf(os.Stderr, "  OCR_LANGUAGE         Tesseract language (default: eng)\n")
+		fmt.Fprintf(os.Stderr, "  OCR_DPI              Rasterization/OCR resolution (default: 300)\n")
+		fmt.Fprintf(os.Stderr, "  OLLAMA_VISION_MODEL  Vision model for OCR_ENGINE=ollama (default: llava)\n\n")
 		fmt.Fprintf(os.Stderr, "Exit Codes:\n")
 		fmt.Fprintf(os.Stderr, "  0 - Success\n")
 		fmt.Fprintf(os.Stderr, "  1 - Parse error\n")
 		fmt.Fprintf(os.Stderr, "  2 - Database error\n")
-		fmt.Fprintf(os.Stderr, "  3 - Configuration error\n\n")
+		fmt.Fprintf(os.Stderr, "  3 - Configuration error\n")
+		fmt.Fprintf(os.Stderr, "  4 - Reconciliation error (balances don't match transactions; nothing inserted)\n\n")
 		fmt.Fprintf(os.Stderr, "Example:\n")
 		fmt.Fprintf(os.Stderr, "  %s /path/to/statement.pdf\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s --csv-profile chase-credit --account-name \"Chase Sapphire\" --account-last4 1234 /path/to/activity.csv\n", os.Args[0])
 	}
 
 	verbose := flag.Bool("verbose", false, "Enable verbose logging")
+	csvProfile := flag.String("csv-profile", "", "CSV column mapping profile (auto-detected when empty)")
+	accountName := flag.String("account-name", "", "Account name for files that don't include one (CSV/QIF)")
+	accountLast4 := flag.String("account-last4", "", "Account last 4 digits for files that don't include them (CSV/QIF)")
+	refreshCache := flag.Bool("refresh-cache", false, "Ignore cached LLM responses and re-parse every page")
+	noReview := flag.Bool("no-review", false, "Insert flagged transactions directly (rows with unparseable dates are still queued)")
+	skipReconcile := flag.Bool("skip-reconcile", false, "Insert transactions even if they don't reconcile with the statement balances")
 	flag.Parse()
 
 	if flag.NArg() < 1 {
@@ -79,8 +178,38 @@ func main() {
 
 	// Parse the statement file
 	log.Printf("Parsing statement file...")
-	log.Printf("Using Ollama at %s with model %s", cfg.OllamaHost, cfg.OllamaModel)
-	statementData, err := parser.ParseFile(filePath, cfg.OllamaHost, cfg.OllamaModel)
+	var backend parser.LLMBackend
+	if !parser.IsStructuredFile(filePath) {
+		backend, err = newLLMBackend(cfg)
+		if err != nil {
+			log.Printf("ERROR: Configuration error: %v", err)
+			os.Exit(exitcodes.ConfigError)
+		}
+		log.Printf("Using %s LLM backend at %s with model %s", cfg.LLMBackend, cfg.LLMHost, backend.Model())
+	}
+	statementData, err := parser.ParseFile(filePath, parser.Options{
+		OllamaHost:      cfg.OllamaHost,
+		OllamaModel:     cfg.OllamaModel,
+		LLM:             backend,
+		Workers:         cfg.LLMWorkers,
+		PlainJSON:       cfg.LLMPlainJSON,
+		RepairRetries:   cfg.LLMRepairRetries,
+		InvalidRows:     cfg.LLMInvalidRows,
+		Cache:           database,
+		RefreshCache:    *refreshCache,
+		CSVProfile:      *csvProfile,
+		CSVProfilesPath: cfg.CSVProfilesPath,
+		AccountName:     *accountName,
+		AccountLast4:    *accountLast4,
+		OCR: parser.OCROptions{
+			Engine:        cfg.OCREngine,
+			TesseractPath: cfg.TesseractPath,
+			Language:      cfg.OCRLanguage,
+			DPI:           cfg.OCRDPI,
+			PDFToPPMPath:  cfg.PDFToPPMPath,
+			VisionModel:   cfg.OllamaVisionModel,
+		},
+	})
 	if err != nil {
 		log.Printf("ERROR: Failed to parse file: %v", err)
 
@@ -119,9 +248,58 @@ func main() {
 	log.Printf("  Statement Date: %s", statementData.StatementDate.Format("2006-01-02"))
 	log.Printf("  Transactions Found: %d", len(statementData.Transactions))
 
+	// Check the transactions add up to the statement balances before touching the database
+	reconcile := parser.Reconcile(statementData)
+	if reconcile.OK() {
+		log.Printf("Reconciliation: %s", reconcile.Summary())
+	} else if *skipReconcile {
+		log.Printf("WARNING: Reconciliation failed, inserting anyway (--skip-reconcile): %s", reconcile.Summary())
+	} else {
+		log.Printf("ERROR: Reconciliation failed: %s", reconcile.Summary())
+
+		logErr := database.LogProcessing(&db.ProcessingLog{
+			SourceFile:    filepath.Base(filePath),
+			StatementDate: &statementData.StatementDate,
+			AccountName:   statementData.AccountName,
+			Status:        "reconcile_error",
+			ErrorMessage:  reconcile.Summary(),
+		})
+		if logErr != nil {
+			log.Printf("WARNING: Failed to log processing error: %v", logErr)
+		}
+
+		log.Printf("Review the statement and re-run with --skip-reconcile to import it as parsed")
+		os.Exit(exitcodes.ReconcileError)
+	}
+
+	// Categorize transactions (rules first, then LLM fallback for anything unmatched)
+	categorizeTransactions(database, cfg, statementData.Transactions)
+
+	// Hold flagged transactions in the review queue
+	ready, queued, err := holdForReview(database, statementData.Transactions, *noReview)
+	if err != nil {
+		log.Printf("ERROR: Failed to queue transactions for review: %v", err)
+
+		logErr := database.LogProcessing(&db.ProcessingLog{
+			SourceFile:    filepath.Base(filePath),
+			StatementDate: &statementData.StatementDate,
+			AccountName:   statementData.AccountName,
+			Status:        "db_error",
+			ErrorMessage:  err.Error(),
+		})
+		if logErr != nil {
+			log.Printf("WARNING: Failed to log processing error: %v", logErr)
+		}
+
+		os.Exit(exitcodes.DBError)
+	}
+	if queued > 0 {
+		log.Printf("Transactions held for review: %d (see '%s review list')", queued, filepath.Base(os.Args[0]))
+	}
+
 	// Insert transactions
 	log.Printf("Inserting transactions into database...")
-	inserted, skipped, err := database.InsertTransactions(statementData.Transactions)
+	inserted, skipped, err := database.InsertTransactions(ready)
 	if err != nil {
 		log.Printf("ERROR: Failed to insert transactions: %v", err)
 
@@ -145,6 +323,13 @@ func main() {
 	log.Printf("Transactions inserted: %d", inserted)
 	log.Printf("Transactions skipped (duplicates): %d", skipped)
 
+	// Pair transfers between accounts so they can be excluded from spending and income,
+	// then refresh recurring charges (transfers are left out of those)
+	if inserted > 0 {
+		matchTransfers(database, cfg)
+		detectRecurring(database)
+	}
+
 	// Log successful processing
 	err = database.LogProcessing(&db.ProcessingLog{
 		SourceFile:           filepath.Base(filePath),
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"financial-statement-processor/db"
+	"financial-statement-processor/pkg/exitcodes"
+)
+
+// handleMerchants manages merchant normalization rules and back-fills merchant names
+func handleMerchants(args []string) {
+	if len(args) < 1 {
+		printMerchantsUsage()
+		os.Exit(exitcodes.ArgsError)
+	}
+
+	action := args[0]
+	args = args[1:]
+
+	switch action {
+	case "rules":
+		withDatabase(func(database *db.DB) {
+			rules, err := database.ListMerchantRules()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to list rules: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			printJSON(map[string]interface{}{"rules": rules, "count": len(rules)})
+		})
+	case "add-rule":
+		fs := flag.NewFlagSet("merchants add-rule", flag.ExitOnError)
+		merchant := fs.String("merchant", "", "Canonical merchant name (required)")
+		pattern := fs.String("pattern", "", "Description substring (or regex with --regex) (required)")
+		regex := fs.Bool("regex", false, "Treat --pattern as a regular expression")
+		priority := fs.Int("priority", 0, "Rule priority (higher is evaluated first)")
+		fs.Parse(args)
+
+		rule := &db.MerchantRule{
+			Merchant:  *merchant,
+			MatchType: "substring",
+			Pattern:   *pattern,
+			Priority:  *priority,
+		}
+		if *regex {
+			rule.MatchType = "regex"
+		}
+
+		if err := rule.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitcodes.ArgsError)
+		}
+
+		withDatabase(func(database *db.DB) {
+			id, err := database.AddMerchantRule(rule)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to add rule: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			fmt.Printf("Rule added successfully (ID: %d)\n", id)
+		})
+	case "delete-rule":
+		fs := flag.NewFlagSet("merchants delete-rule", flag.ExitOnError)
+		id := fs.Int64("id", 0, "Rule ID (required)")
+		fs.Parse(args)
+
+		if *id == 0 {
+			fmt.Fprintf(os.Stderr, "Error: --id is required\n")
+			os.Exit(exitcodes.ArgsError)
+		}
+
+		withDatabase(func(database *db.DB) {
+			if err := database.DeleteMerchantRule(*id); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to delete rule: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			fmt.Printf("Rule %d deleted successfully\n", *id)
+		})
+	case "normalize":
+		fs := flag.NewFlagSet("merchants normalize", flag.ExitOnError)
+		all := fs.Bool("all", false, "Recompute merchant names for all transactions, not just those without one")
+		dryRun := fs.Bool("dry-run", false, "Show what would change without updating the database")
+		fs.Parse(args)
+
+		withDatabase(func(database *db.DB) {
+			normalizeMerchants(database, *all, *dryRun)
+		})
+	case "help", "--help", "-h":
+		printMerchantsUsage()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown merchants action: %s\n\n", action)
+		printMerchantsUsage()
+		os.Exit(exitcodes.ArgsError)
+	}
+
+	os.Exit(exitcodes.Success)
+}
+
+// normalizeMerchants back-fills merchant names for stored transactions
+func normalizeMerchants(database *db.DB, all, dryRun bool) {
+	transactions, err := database.QueryTransactionsForMerchants(all)
+	if err != nil {
+		log.Printf("ERROR: Failed to load transactions: %v", err)
+		os.Exit(exitcodes.DBError)
+	}
+	log.Printf("Transactions to normalize: %d", len(transactions))
+
+	// Remember existing names so only real changes are written
+	previous := make(map[int64]string, len(transactions))
+	for _, tx := range transactions {
+		previous[tx.ID] = tx.MerchantName
+		tx.MerchantName = ""
+	}
+
+	matched, err := database.ApplyMerchantRules(transactions)
+	if err != nil {
+		log.Printf("ERROR: Failed to apply merchant rules: %v", err)
+		os.Exit(exitcodes.DBError)
+	}
+	log.Printf("Matched by rules: %d", matched)
+
+	updated := 0
+	for _, tx := range transactions {
+		if tx.MerchantName == previous[tx.ID] {
+			continue
+		}
+
+		if dryRun {
+			log.Printf("DRY-RUN: %d %s %s -> %s", tx.ID, tx.TransactionDate.Format("2006-01-02"), tx.Description, tx.MerchantName)
+			updated++
+			continue
+		}
+
+		if err := database.SetTransactionMerchant(tx.ID, tx.MerchantName); err != nil {
+			log.Printf("ERROR: Failed to update transaction %d: %v", tx.ID, err)
+			os.Exit(exitcodes.DBError)
+		}
+		updated++
+	}
+
+	log.Printf("Transactions updated: %d", updated)
+}
+
+func printMerchantsUsage() {
+	fmt.Fprintf(os.Stderr, `Usage: %s merchants <action> [options]
+
+Every transaction gets a canonical merchant_name when it is inserted: the first matching rule
+wins, otherwise card processor prefixes (SQ *, TST*, PAYPAL *), store numbers and a trailing
+city and state are stripped from the description.
+
+Actions:
+  rules        List merchant rules in evaluation order
+  add-rule     Add a rule (--merchant, --pattern, --regex, --priority)
+  delete-rule  Delete a rule (--id)
+  normalize    Back-fill merchant names (--all to recompute every row, --dry-run)
+
+Examples:
+  %s merchants add-rule --merchant Amazon --pattern "^(AMZN|AMAZON)" --regex
+  %s merchants normalize --all --dry-run
+`, os.Args[0], os.Args[0], os.Args[0])
+}
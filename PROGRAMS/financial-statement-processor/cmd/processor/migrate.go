@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"financial-statement-processor/pkg/app"
+	"financial-statement-processor/pkg/exitcodes"
+	"sqlite-migrate/migrate"
+)
+
+// handleMigrate shows or applies the database schema migrations
+// Every other command applies pending migrations when it opens the database; this one opens it
+// without migrating so the status can be checked first
+func handleMigrate(args []string) {
+	if len(args) > 0 {
+		switch args[0] {
+		case "help", "--help", "-h":
+			printMigrateUsage()
+			os.Exit(exitcodes.Success)
+		}
+	}
+
+	database, err := app.OpenDatabase()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Database error: %v\n", err)
+		os.Exit(exitcodes.DBError)
+	}
+	defer database.Close()
+
+	if err := database.RunMigrateCLI(args); err != nil {
+		if errors.Is(err, migrate.ErrUsage) {
+			fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
+			printMigrateUsage()
+			os.Exit(exitcodes.ArgsError)
+		}
+		fmt.Fprintf(os.Stderr, "Failed to migrate database: %v\n", err)
+		os.Exit(exitcodes.DBError)
+	}
+}
+
+func printMigrateUsage() {
+	fmt.Fprintf(os.Stderr, `Usage: %s migrate <action>
+
+Schema changes are numbered SQL migrations built into the binary and recorded in the
+schema_migrations table. Before applying any to a database that already holds data, a copy is
+written next to it as <DB_PATH>.v<version>-<timestamp>.bak. Databases created before versioned
+migrations are upgraded in place to the first migration.
+
+Actions:
+  status   List migrations and whether each has been applied (default)
+  up       Apply pending migrations
+
+Examples:
+  %s migrate status
+  %s migrate up
+`, os.Args[0], os.Args[0], os.Args[0])
+}
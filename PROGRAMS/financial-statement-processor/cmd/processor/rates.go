@@ -0,0 +1,151 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"financial-statement-processor/db"
+	"financial-statement-processor/pkg/app"
+	"financial-statement-processor/pkg/exitcodes"
+)
+
+// handleRates manages the exchange rates used to convert amounts into the base currency
+func handleRates(args []string) {
+	if len(args) < 1 {
+		printRatesUsage()
+		os.Exit(exitcodes.ArgsError)
+	}
+
+	cfg, err := app.InitConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
+		os.Exit(exitcodes.ConfigError)
+	}
+
+	action := args[0]
+	args = args[1:]
+
+	switch action {
+	case "list":
+		fs := flag.NewFlagSet("rates list", flag.ExitOnError)
+		currency := fs.String("currency", "", "Only rates for this currency")
+		base := fs.String("base", "", "Only rates into this base currency")
+		fs.Parse(args)
+
+		withDatabase(func(database *db.DB) {
+			rates, err := database.ListExchangeRates(*currency, *base)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to list exchange rates: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			if rates == nil {
+				rates = []*db.ExchangeRate{}
+			}
+			printJSON(map[string]interface{}{"rates": rates, "count": len(rates)})
+		})
+	case "add":
+		fs := flag.NewFlagSet("rates add", flag.ExitOnError)
+		currency := fs.String("currency", "", "Currency code the rate converts from (required)")
+		base := fs.String("base", cfg.BaseCurrency, "Currency code the rate converts into")
+		date := fs.String("date", "", "Date of the rate, YYYY-MM-DD (required)")
+		rate := fs.String("rate", "", "Value of one unit of --currency in --base (required)")
+		source := fs.String("source", "manual", "Where the rate came from")
+		fs.Parse(args)
+
+		if *date == "" || *rate == "" {
+			fmt.Fprintf(os.Stderr, "Error: --date and --rate are required\n")
+			os.Exit(exitcodes.ArgsError)
+		}
+		value, err := strconv.ParseFloat(*rate, 64)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --rate: %v\n", err)
+			os.Exit(exitcodes.ArgsError)
+		}
+
+		r := &db.ExchangeRate{
+			Currency:     *currency,
+			BaseCurrency: *base,
+			RateDate:     parseDateFlag("date", *date),
+			Rate:         value,
+			Source:       *source,
+		}
+		withDatabase(func(database *db.DB) {
+			if _, err := database.SetExchangeRate(r); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to set exchange rate: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			printJSON(map[string]interface{}{"success": true, "rate": r})
+		})
+	case "import":
+		fs := flag.NewFlagSet("rates import", flag.ExitOnError)
+		base := fs.String("base", cfg.BaseCurrency, "Base currency of rows without a base_currency column")
+		source := fs.String("source", "", "Where the rates came from (default: the file name)")
+		fs.Parse(args)
+
+		if fs.NArg() < 1 {
+			fmt.Fprintf(os.Stderr, "Error: CSV file path is required\n")
+			os.Exit(exitcodes.ArgsError)
+		}
+		path := fs.Arg(0)
+		if *source == "" {
+			*source = filepath.Base(path)
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open rates file: %v\n", err)
+			os.Exit(exitcodes.ParseError)
+		}
+		defer f.Close()
+
+		withDatabase(func(database *db.DB) {
+			imported, err := database.ImportExchangeRates(f, *base, *source)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to import exchange rates: %v\n", err)
+				os.Exit(exitcodes.ParseError)
+			}
+			printJSON(map[string]interface{}{"success": true, "imported": imported, "source": *source})
+		})
+	case "delete":
+		fs := flag.NewFlagSet("rates delete", flag.ExitOnError)
+		id := fs.Int64("id", 0, "Exchange rate ID (required)")
+		fs.Parse(args)
+
+		requireID(*id)
+
+		withDatabase(func(database *db.DB) {
+			if err := database.DeleteExchangeRate(*id); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to delete exchange rate: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			printJSON(map[string]interface{}{"success": true, "deleted": *id})
+		})
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown rates action: %s\n\n", action)
+		printRatesUsage()
+		os.Exit(exitcodes.ArgsError)
+	}
+}
+
+func printRatesUsage() {
+	fmt.Fprintf(os.Stderr, `Usage: %s rates <action> [options]
+
+Every transaction is in its account's currency. Reports convert amounts into the base currency
+(BASE_CURRENCY, default USD) with the latest rate on or before the transaction's date; amounts
+without such a rate are listed as unconverted instead of being counted.
+A rate is the value of one unit of the currency in the base currency (EUR 1.08 means 1 EUR = 1.08 USD).
+
+Actions:
+  list     List stored rates, newest first (--currency, --base)
+  add      Add or replace the rate for a date (--currency, --date, --rate, --base, --source)
+  import   Import rates from a CSV file with date, currency, rate and optional base_currency columns (--base, --source)
+  delete   Delete a rate (--id)
+
+Examples:
+  %s rates add --currency EUR --date 2024-06-01 --rate 1.08
+  %s rates import ~/Downloads/ecb-rates.csv
+`, os.Args[0], os.Args[0], os.Args[0])
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"financial-statement-processor/db"
+	"financial-statement-processor/pkg/exitcodes"
+)
+
+// detectRecurring refreshes the recurring_series table and logs series that need attention
+func detectRecurring(database *db.DB) {
+	series, err := database.DetectRecurring(db.RecurringOptions{})
+	if err != nil {
+		log.Printf("WARNING: Failed to detect recurring charges: %v", err)
+		return
+	}
+
+	for _, s := range series {
+		if len(s.Flags) > 0 {
+			log.Printf("  Recurring: %s ...%s %s %s %v", s.MerchantKey, s.AccountLast4, s.Cadence, s.LastAmount, s.Flags)
+		}
+	}
+	log.Printf("Recurring series: %d", len(series))
+}
+
+// handleRecurring re-runs recurring charge detection outside an import
+func handleRecurring(args []string) {
+	if len(args) < 1 {
+		printRecurringUsage()
+		os.Exit(exitcodes.ArgsError)
+	}
+
+	action := args[0]
+	args = args[1:]
+
+	switch action {
+	case "detect":
+		fs := flag.NewFlagSet("recurring detect", flag.ExitOnError)
+		fs.Parse(args)
+
+		withDatabase(func(database *db.DB) {
+			series, err := database.DetectRecurring(db.RecurringOptions{})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to detect recurring charges: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			if series == nil {
+				series = []*db.RecurringSeries{}
+			}
+			printJSON(map[string]interface{}{"success": true, "recurring_series": series, "count": len(series)})
+		})
+	case "help", "--help", "-h":
+		printRecurringUsage()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown recurring action: %s\n\n", action)
+		printRecurringUsage()
+		os.Exit(exitcodes.ArgsError)
+	}
+
+	os.Exit(exitcodes.Success)
+}
+
+func printRecurringUsage() {
+	fmt.Fprintf(os.Stderr, `Usage: %s recurring <action>
+
+Recurring charges are detected after every import and stored in the recurring_series table,
+which financial-statement-query --recurring lists. Re-run detection after editing, deleting or
+linking transactions by hand, or on a database filled before detection existed.
+
+Actions:
+  detect   Rebuild the recurring series from the stored transactions
+
+Examples:
+  %s recurring detect
+`, os.Args[0], os.Args[0])
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"financial-statement-processor/db"
+	"financial-statement-processor/pkg/exitcodes"
+)
+
+// holdForReview moves flagged transactions into the review queue and returns the rest for insertion
+// With skipFlags set only rows that can't be stored at all (unparseable dates) are queued
+func holdForReview(database *db.DB, transactions []*db.Transaction, skipFlags bool) ([]*db.Transaction, int, error) {
+	if !skipFlags {
+		flagged, err := database.FlagSuspectedDuplicates(transactions)
+		if err != nil {
+			log.Printf("WARNING: Failed to check for suspected duplicates: %v", err)
+		} else if flagged > 0 {
+			log.Printf("Suspected duplicates of stored transactions: %d", flagged)
+		}
+	}
+
+	var ready, held []*db.Transaction
+	for _, tx := range transactions {
+		if tx.HasReviewFlag(db.ReviewFlagUnparseableDate) || (!skipFlags && tx.NeedsReview()) {
+			held = append(held, tx)
+			continue
+		}
+		ready = append(ready, tx)
+	}
+
+	if len(held) == 0 {
+		return ready, 0, nil
+	}
+
+	queued, err := database.QueuePendingTransactions(held)
+	if err != nil {
+		return nil, 0, err
+	}
+	if queued < len(held) {
+		log.Printf("Already in the review queue: %d", len(held)-queued)
+	}
+
+	for _, tx := range held {
+		log.Printf("  Held for review: %s %s %s [%s]", reviewDate(tx), tx.Description, tx.Amount, strings.Join(tx.ReviewFlags, ", "))
+	}
+
+	return ready, queued, nil
+}
+
+// reviewDate formats a transaction date, falling back to the raw text for unparseable dates
+func reviewDate(tx *db.Transaction) string {
+	if tx.TransactionDate.IsZero() {
+		return fmt.Sprintf("%q", tx.RawDate)
+	}
+	return tx.TransactionDate.Format("2006-01-02")
+}
+
+// handleReview manages the review queue of flagged transactions
+func handleReview(args []string) {
+	if len(args) < 1 {
+		printReviewUsage()
+		os.Exit(exitcodes.ArgsError)
+	}
+
+	action := args[0]
+	args = args[1:]
+
+	switch action {
+	case "list":
+		fs := flag.NewFlagSet("review list", flag.ExitOnError)
+		status := fs.String("status", db.ReviewStatusPending, "Status to list: pending, approved, rejected or all")
+		source := fs.String("source", "", "Only rows from this source file")
+		fs.Parse(args)
+
+		withDatabase(func(database *db.DB) {
+			pending, err := database.ListPendingTransactions(*status, *source)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to list pending transactions: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			if pending == nil {
+				pending = []*db.PendingTransaction{}
+			}
+			printJSON(map[string]interface{}{"pending_transactions": pending, "count": len(pending)})
+		})
+	case "approve":
+		fs := flag.NewFlagSet("review approve", flag.ExitOnError)
+		ids := fs.String("id", "", "Pending transaction ID(s), comma-separated")
+		source := fs.String("source", "", "Approve every pending row from this source file")
+		fs.Parse(args)
+
+		if *ids == "" && *source == "" {
+			fmt.Fprintf(os.Stderr, "Error: --id or --source is required\n")
+			os.Exit(exitcodes.ArgsError)
+		}
+
+		withDatabase(func(database *db.DB) {
+			toApprove := parseIDList(*ids)
+			if *source != "" {
+				pending, err := database.ListPendingTransactions(db.ReviewStatusPending, *source)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to list pending transactions: %v\n", err)
+					os.Exit(exitcodes.DBError)
+				}
+				for _, p := range pending {
+					toApprove = append(toApprove, p.ID)
+				}
+			}
+
+			approved := make([]map[string]interface{}, 0, len(toApprove))
+			errors := []string{}
+			for _, id := range toApprove {
+				transactionID, err := database.ApprovePendingTransaction(id)
+				if err != nil {
+					errors = append(errors, err.Error())
+					continue
+				}
+				approved = append(approved, map[string]interface{}{"id": id, "transaction_id": transactionID})
+			}
+
+			printJSON(map[string]interface{}{
+				"success":  len(errors) == 0,
+				"approved": approved,
+				"errors":   errors,
+			})
+			if len(errors) > 0 {
+				os.Exit(exitcodes.DBError)
+			}
+		})
+	case "edit":
+		fs := flag.NewFlagSet("review edit", flag.ExitOnError)
+		id := fs.Int64("id", 0, "Pending transaction ID (required)")
+		date := fs.String("date", "", "Transaction date (YYYY-MM-DD)")
+		description := fs.String("description", "", "Description")
+		amount := fs.String("amount", "", "Amount (negative for debits)")
+		txType := fs.String("type", "", "Transaction type: debit or credit")
+		category := fs.String("category", "", "Category")
+		fs.Parse(args)
+
+		if *id == 0 {
+			fmt.Fprintf(os.Stderr, "Error: --id is required\n")
+			os.Exit(exitcodes.ArgsError)
+		}
+
+		var edit db.PendingEdit
+		fs.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "date":
+				d, err := time.Parse("2006-01-02", *date)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: invalid --date (use YYYY-MM-DD): %v\n", err)
+					os.Exit(exitcodes.ArgsError)
+				}
+				edit.TransactionDate = &d
+			case "description":
+				edit.Description = description
+			case "amount":
+				edit.Amount = parseOptionalAmount("amount", *amount)
+			case "type":
+				edit.TransactionType = txType
+			case "category":
+				edit.Category = category
+			}
+		})
+
+		withDatabase(func(database *db.DB) {
+			pending, err := database.EditPendingTransaction(*id, edit)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to edit pending transaction: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			printJSON(map[string]interface{}{"success": true, "pending_transaction": pending})
+		})
+	case "reject":
+		fs := flag.NewFlagSet("review reject", flag.ExitOnError)
+		ids := fs.String("id", "", "Pending transaction ID(s), comma-separated (required)")
+		note := fs.String("note", "", "Reason for rejecting")
+		fs.Parse(args)
+
+		toReject := parseIDList(*ids)
+		if len(toReject) == 0 {
+			fmt.Fprintf(os.Stderr, "Error: --id is required\n")
+			os.Exit(exitcodes.ArgsError)
+		}
+
+		withDatabase(func(database *db.DB) {
+			for _, id := range toReject {
+				if err := database.RejectPendingTransaction(id, *note); err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to reject pending transaction: %v\n", err)
+					os.Exit(exitcodes.DBError)
+				}
+			}
+			printJSON(map[string]interface{}{"success": true, "rejected": toReject})
+		})
+	case "help", "--help", "-h":
+		printReviewUsage()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown review action: %s\n\n", action)
+		printReviewUsage()
+		os.Exit(exitcodes.ArgsError)
+	}
+
+	os.Exit(exitcodes.Success)
+}
+
+// parseIDList parses a comma-separated list of IDs, exiting on invalid input
+func parseIDList(value string) []int64 {
+	var ids []int64
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid ID %q\n", part)
+			os.Exit(exitcodes.ArgsError)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func printReviewUsage() {
+	fmt.Fprintf(os.Stderr, `Usage: %s review <action> [options]
+
+Parsed transactions with an unparseable date, an amount whose sign contradicts its type, or that
+look like a transaction already stored are held in a review queue instead of being inserted.
+
+Actions:
+  list      List queued rows (--status pending|approved|rejected|all, --source)
+  approve   Move rows into transactions (--id 1,2,3 or --source FILE)
+  edit      Correct a row before approving (--id, --date, --description, --amount, --type, --category)
+  reject    Discard rows (--id 1,2,3, --note)
+
+Examples:
+  %s review list
+  %s review edit --id 12 --date 2024-10-15
+  %s review approve --id 12,13
+  %s review reject --id 14 --note "duplicate of the pending charge"
+`, os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0])
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"financial-statement-processor/db"
+	"financial-statement-processor/pkg/exitcodes"
+)
+
+// handleSearch finds transactions by words in their description, merchant, account, source file
+// or notes and prints them as JSON, best match first
+func handleSearch(args []string) {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	startDate := fs.String("start-date", "", "Only transactions on or after this date (YYYY-MM-DD)")
+	endDate := fs.String("end-date", "", "Only transactions on or before this date (YYYY-MM-DD)")
+	account := fs.String("account", "", "Account name substring or last 4 digits")
+	txType := fs.String("type", "", "Transaction type: debit or credit")
+	category := fs.String("category", "", "Only transactions in this category")
+	limit := fs.Int("limit", 50, "Maximum number of results")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s search [OPTIONS] <words>\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Search transactions for every word, best match first (same as the query tool's --search).\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	query := strings.Join(fs.Args(), " ")
+	if strings.TrimSpace(query) == "" {
+		fs.Usage()
+		os.Exit(exitcodes.ArgsError)
+	}
+
+	opts := db.SearchOptions{Account: *account, Category: *category, Limit: *limit}
+	switch *txType {
+	case "", "debit", "credit":
+		opts.TransactionType = *txType
+	default:
+		fmt.Fprintf(os.Stderr, "Error: --type must be debit or credit (got: %s)\n", *txType)
+		os.Exit(exitcodes.ArgsError)
+	}
+	var err error
+	if *startDate != "" {
+		if opts.StartDate, err = time.Parse("2006-01-02", *startDate); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --start-date (use YYYY-MM-DD): %v\n", err)
+			os.Exit(exitcodes.ArgsError)
+		}
+	}
+	if *endDate != "" {
+		if opts.EndDate, err = time.Parse("2006-01-02", *endDate); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --end-date (use YYYY-MM-DD): %v\n", err)
+			os.Exit(exitcodes.ArgsError)
+		}
+	}
+
+	withDatabase(func(database *db.DB) {
+		results, err := database.SearchTransactions(query, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to search transactions: %v\n", err)
+			os.Exit(exitcodes.DBError)
+		}
+		if results == nil {
+			results = []*db.SearchResult{}
+		}
+
+		printJSON(map[string]interface{}{
+			"query":   query,
+			"mode":    database.SearchMode(),
+			"results": results,
+			"count":   len(results),
+		})
+	})
+
+	os.Exit(exitcodes.Success)
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"financial-statement-processor/db"
+	"financial-statement-processor/pkg/exitcodes"
+)
+
+// handleTransactions adds, corrects, deletes and splits stored transactions by hand
+func handleTransactions(args []string) {
+	if len(args) < 1 {
+		printTransactionsUsage()
+		os.Exit(exitcodes.ArgsError)
+	}
+
+	action := args[0]
+	args = args[1:]
+
+	switch action {
+	case "show":
+		fs := flag.NewFlagSet("transactions show", flag.ExitOnError)
+		id := fs.Int64("id", 0, "Transaction ID (required)")
+		fs.Parse(args)
+
+		requireID(*id)
+
+		withDatabase(func(database *db.DB) {
+			tx, err := database.GetTransaction(*id)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to get transaction: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			printJSON(map[string]interface{}{"transaction": tx})
+		})
+	case "add":
+		fs := flag.NewFlagSet("transactions add", flag.ExitOnError)
+		account := fs.String("account", "", "Account name, e.g. Cash (required)")
+		last4 := fs.String("last4", "", "Last 4 digits of the account number")
+		date := fs.String("date", time.Now().Format("2006-01-02"), "Transaction date (YYYY-MM-DD)")
+		description := fs.String("description", "", "Description (required)")
+		amount := fs.String("amount", "", "Amount, negative for debits (required)")
+		currency := fs.String("currency", "", "Currency code of the amount (default: the account's currency)")
+		txType := fs.String("type", "", "Transaction type: debit or credit (default: from the amount's sign)")
+		category := fs.String("category", "", "Category (default: from categorization rules)")
+		notes := fs.String("notes", "", "Free-form notes")
+		fs.Parse(args)
+
+		if *amount == "" {
+			fmt.Fprintf(os.Stderr, "Error: --amount is required\n")
+			os.Exit(exitcodes.ArgsError)
+		}
+
+		tx := &db.Transaction{
+			AccountName:     *account,
+			AccountLast4:    *last4,
+			TransactionDate: parseDateFlag("date", *date),
+			Description:     *description,
+			Amount:          *parseOptionalAmount("amount", *amount),
+			Currency:        *currency,
+			TransactionType: *txType,
+			Category:        *category,
+			Notes:           *notes,
+		}
+
+		withDatabase(func(database *db.DB) {
+			id, err := database.AddManualTransaction(tx)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to add transaction: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			stored, err := database.GetTransaction(id)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to get transaction: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			printJSON(map[string]interface{}{"success": true, "transaction": stored})
+		})
+	case "edit":
+		fs := flag.NewFlagSet("transactions edit", flag.ExitOnError)
+		id := fs.Int64("id", 0, "Transaction ID (required)")
+		date := fs.String("date", "", "Transaction date (YYYY-MM-DD)")
+		description := fs.String("description", "", "Description")
+		amount := fs.String("amount", "", "Amount (negative for debits)")
+		currency := fs.String("currency", "", "Currency code of the amount (an empty value restores the account's currency)")
+		txType := fs.String("type", "", "Transaction type: debit or credit")
+		category := fs.String("category", "", "Category (empty to clear)")
+		merchant := fs.String("merchant", "", "Canonical merchant name")
+		notes := fs.String("notes", "", "Free-form notes (empty to clear)")
+		fs.Parse(args)
+
+		requireID(*id)
+
+		var edit db.TransactionEdit
+		fs.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "date":
+				d := parseDateFlag("date", *date)
+				edit.TransactionDate = &d
+			case "description":
+				edit.Description = description
+			case "amount":
+				edit.Amount = parseOptionalAmount("amount", *amount)
+			case "currency":
+				edit.Currency = currency
+			case "type":
+				edit.TransactionType = txType
+			case "category":
+				edit.Category = category
+			case "merchant":
+				edit.MerchantName = merchant
+			case "notes":
+				edit.Notes = notes
+			}
+		})
+
+		withDatabase(func(database *db.DB) {
+			tx, err := database.EditTransaction(*id, edit)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to edit transaction: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			printJSON(map[string]interface{}{"success": true, "transaction": tx})
+		})
+	case "delete":
+		fs := flag.NewFlagSet("transactions delete", flag.ExitOnError)
+		id := fs.Int64("id", 0, "Transaction ID (required)")
+		fs.Parse(args)
+
+		requireID(*id)
+
+		withDatabase(func(database *db.DB) {
+			if err := database.DeleteTransaction(*id); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to delete transaction: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			printJSON(map[string]interface{}{"success": true, "deleted": *id})
+		})
+	case "split":
+		fs := flag.NewFlagSet("transactions split", flag.ExitOnError)
+		id := fs.Int64("id", 0, "Transaction ID (required)")
+		linesJSON := fs.String("lines", "", `Split lines as JSON: [{"amount":-30,"description":"...","category":"...","notes":"..."}, ...] (required)`)
+		fs.Parse(args)
+
+		requireID(*id)
+
+		var lines []*db.SplitLine
+		if err := json.Unmarshal([]byte(*linesJSON), &lines); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --lines JSON: %v\n", err)
+			os.Exit(exitcodes.ArgsError)
+		}
+
+		withDatabase(func(database *db.DB) {
+			split, err := database.SplitTransaction(*id, lines)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to split transaction: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			printJSON(map[string]interface{}{"success": true, "transactions": split, "count": len(split)})
+		})
+	case "help", "--help", "-h":
+		printTransactionsUsage()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown transactions action: %s\n\n", action)
+		printTransactionsUsage()
+		os.Exit(exitcodes.ArgsError)
+	}
+
+	os.Exit(exitcodes.Success)
+}
+
+// requireID exits with a usage error when --id wasn't given
+func requireID(id int64) {
+	if id == 0 {
+		fmt.Fprintf(os.Stderr, "Error: --id is required\n")
+		os.Exit(exitcodes.ArgsError)
+	}
+}
+
+// parseDateFlag parses a YYYY-MM-DD flag value, exiting on invalid input
+func parseDateFlag(name, value string) time.Time {
+	d, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid --%s (use YYYY-MM-DD): %v\n", name, err)
+		os.Exit(exitcodes.ArgsError)
+	}
+	return d
+}
+
+func printTransactionsUsage() {
+	fmt.Fprintf(os.Stderr, `Usage: %s transactions <action> [options]
+
+Enter transactions that aren't on any statement (cash purchases) and correct stored ones.
+Every manual add, edit and split is recorded in the transaction's manual_changes audit trail.
+
+Actions:
+  show     Show one transaction (--id)
+  add      Add a transaction (--account, --description, --amount, --currency, --date, --last4, --type, --category, --notes)
+  edit     Correct a transaction (--id, --date, --description, --amount, --currency, --type, --category, --merchant, --notes)
+  delete   Delete a transaction (--id); re-importing its statement adds it again
+  split    Split a transaction into lines that add up to it (--id, --lines JSON)
+
+Examples:
+  %s transactions add --account Cash --description "Farmers market" --amount -24.50 --category groceries
+  %s transactions edit --id 42 --amount -18.75 --notes "corrected from receipt"
+  %s transactions split --id 42 --lines '[{"amount":-12.75,"category":"groceries"},{"amount":-6,"description":"Flowers","category":"shopping"}]'
+  %s transactions delete --id 43
+`, os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0])
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"financial-statement-processor/config"
+	"financial-statement-processor/db"
+	"financial-statement-processor/pkg/app"
+	"financial-statement-processor/pkg/exitcodes"
+)
+
+// matchTransfers pairs newly inserted transactions with the other side of transfers between accounts
+// Only rows that both look like transfers are paired unattended; 'transfers match' pairs any
+// Failures are logged but never abort processing - matching can be re-run with 'transfers match'
+func matchTransfers(database *db.DB, cfg *config.Config) {
+	transfers, err := database.MatchTransfers(db.TransferMatchOptions{
+		WindowDays:   cfg.TransferWindowDays,
+		Tolerance:    cfg.TransferTolerance,
+		TransferLike: true,
+	}, false)
+	if err != nil {
+		log.Printf("WARNING: Failed to match transfers: %v", err)
+		return
+	}
+
+	for _, t := range transfers {
+		log.Printf("  Transfer: %s ...%s -> ...%s %s", t.From.TransactionDate.Format("2006-01-02"), t.From.AccountLast4, t.To.AccountLast4, t.Amount)
+	}
+	log.Printf("Transfers matched: %d", len(transfers))
+}
+
+// handleTransfers matches, lists, links and unlinks transfers between accounts
+func handleTransfers(args []string) {
+	if len(args) < 1 {
+		printTransfersUsage()
+		os.Exit(exitcodes.ArgsError)
+	}
+
+	action := args[0]
+	args = args[1:]
+
+	switch action {
+	case "match":
+		cfg, err := app.InitConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
+			os.Exit(exitcodes.ConfigError)
+		}
+
+		fs := flag.NewFlagSet("transfers match", flag.ExitOnError)
+		window := fs.Int("window", cfg.TransferWindowDays, "Maximum days between the two sides")
+		tolerance := fs.String("tolerance", cfg.TransferTolerance.String(), "Maximum difference between the two amounts")
+		transferLike := fs.Bool("transfer-like", false, "Only pair rows that both look like transfers, as after an import")
+		dryRun := fs.Bool("dry-run", false, "Show the pairs without storing them")
+		fs.Parse(args)
+
+		withDatabase(func(database *db.DB) {
+			opts := db.TransferMatchOptions{WindowDays: *window, Tolerance: *parseOptionalAmount("tolerance", *tolerance), TransferLike: *transferLike}
+			transfers, err := database.MatchTransfers(opts, *dryRun)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to match transfers: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			if transfers == nil {
+				transfers = []*db.Transfer{}
+			}
+			printJSON(map[string]interface{}{"success": true, "dry_run": *dryRun, "transfers": transfers, "count": len(transfers)})
+		})
+	case "list":
+		fs := flag.NewFlagSet("transfers list", flag.ExitOnError)
+		status := fs.String("status", db.TransferStatusLinked, "Status to list: linked, rejected or all")
+		fs.Parse(args)
+
+		withDatabase(func(database *db.DB) {
+			transfers, err := database.ListTransfers(*status)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to list transfers: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			if transfers == nil {
+				transfers = []*db.Transfer{}
+			}
+			printJSON(map[string]interface{}{"transfers": transfers, "count": len(transfers)})
+		})
+	case "link":
+		fs := flag.NewFlagSet("transfers link", flag.ExitOnError)
+		from := fs.Int64("from", 0, "Transaction ID of one side (required)")
+		to := fs.Int64("to", 0, "Transaction ID of the other side (required)")
+		fs.Parse(args)
+
+		if *from == 0 || *to == 0 {
+			fmt.Fprintf(os.Stderr, "Error: --from and --to are required\n")
+			os.Exit(exitcodes.ArgsError)
+		}
+
+		withDatabase(func(database *db.DB) {
+			transfer, err := database.LinkTransfer(*from, *to)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to link transfer: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			printJSON(map[string]interface{}{"success": true, "transfer": transfer})
+		})
+	case "unlink":
+		fs := flag.NewFlagSet("transfers unlink", flag.ExitOnError)
+		id := fs.Int64("id", 0, "Transfer ID (required)")
+		fs.Parse(args)
+
+		if *id == 0 {
+			fmt.Fprintf(os.Stderr, "Error: --id is required\n")
+			os.Exit(exitcodes.ArgsError)
+		}
+
+		withDatabase(func(database *db.DB) {
+			if err := database.UnlinkTransfer(*id); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to unlink transfer: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			printJSON(map[string]interface{}{"success": true, "id": *id})
+		})
+	case "help", "--help", "-h":
+		printTransfersUsage()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown transfers action: %s\n\n", action)
+		printTransfersUsage()
+		os.Exit(exitcodes.ArgsError)
+	}
+
+	os.Exit(exitcodes.Success)
+}
+
+func printTransfersUsage() {
+	fmt.Fprintf(os.Stderr, `Usage: %s transfers <action> [options]
+
+A transfer between two of your accounts (e.g. paying a credit card from checking) appears as a
+debit on one statement and a credit on the other. Linked transfers can be excluded from totals
+so they aren't counted as both spending and income.
+
+Actions:
+  match    Pair unlinked debits and credits on different accounts (--window, --tolerance,
+           --transfer-like, --dry-run)
+  list     List transfers (--status linked|rejected|all)
+  link     Link two transactions by hand (--from, --to)
+  unlink   Unlink a wrong match; the pair won't be matched again (--id)
+
+Examples:
+  %s transfers match --dry-run
+  %s transfers match --window 5 --tolerance 1.00
+  %s transfers link --from 120 --to 348
+  %s transfers unlink --id 7
+`, os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0])
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+
+	"financial-statement-processor/db"
+)
+
+// formatCashFlowCSV writes one row per month of a cash-flow report as CSV to stdout
+func formatCashFlowCSV(report *db.CashFlowReport) error {
+	w := csv.NewWriter(os.Stdout)
+	defer w.Flush()
+
+	header := []string{
+		"month",
+		"income",
+		"expenses",
+		"net",
+		"savings_rate",
+		"income_change",
+		"expenses_change",
+		"net_change",
+		"transaction_count",
+		"top_categories",
+		"top_merchants",
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	for _, m := range report.Months {
+		savingsRate := ""
+		if m.SavingsRate != nil {
+			savingsRate = fmt.Sprintf("%.1f", *m.SavingsRate)
+		}
+		incomeChange, expensesChange, netChange := "", "", ""
+		if m.Change != nil {
+			incomeChange = m.Change.Income.String()
+			expensesChange = m.Change.Expenses.String()
+			netChange = m.Change.Net.String()
+		}
+
+		row := []string{
+			m.Month,
+			m.Income.String(),
+			m.Expenses.String(),
+			m.Net.String(),
+			savingsRate,
+			incomeChange,
+			expensesChange,
+			netChange,
+			fmt.Sprintf("%d", m.TransactionCount),
+			formatCashFlowItems(m.TopCategories),
+			formatCashFlowItems(m.TopMerchants),
+		}
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// formatCashFlowMarkdown renders a cash-flow report as a markdown table with a totals row
+func formatCashFlowMarkdown(report *db.CashFlowReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Cash flow %s to %s (%s)\n\n", report.StartMonth, report.EndMonth, report.BaseCurrency)
+	b.WriteString("| Month | Income | Expenses | Net | Savings rate | Net vs prev. | Top categories |\n")
+	b.WriteString("|---|---:|---:|---:|---:|---:|---|\n")
+
+	for _, m := range report.Months {
+		netChange := ""
+		if m.Change != nil {
+			netChange = m.Change.Net.String()
+			if m.Change.Net >= 0 {
+				netChange = "+" + netChange
+			}
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s | %s |\n",
+			m.Month, m.Income, m.Expenses, m.Net, formatPercent(m.SavingsRate), netChange,
+			markdownEscape(formatCashFlowItems(m.TopCategories)))
+	}
+
+	t := report.Totals
+	fmt.Fprintf(&b, "| **Total** | **%s** | **%s** | **%s** | **%s** | | %s |\n",
+		t.Income, t.Expenses, t.Net, formatPercent(t.SavingsRate), markdownEscape(formatCashFlowItems(t.TopCategories)))
+
+	if len(t.TopMerchants) > 0 {
+		b.WriteString("\nTop merchants: ")
+		b.WriteString(markdownEscape(formatCashFlowItems(t.TopMerchants)))
+		b.WriteString("\n")
+	}
+
+	// Amounts without an exchange rate aren't in the table; say so rather than under-report
+	for _, u := range report.Unconverted {
+		fmt.Fprintf(&b, "\nNot converted (no %s rate): %d transactions totalling %s %s\n",
+			u.Currency, u.TransactionCount, u.Amount, u.Currency)
+	}
+
+	return b.String()
+}
+
+// formatCashFlowItems lists categories or merchants with their spending, largest first
+func formatCashFlowItems(items []*db.CashFlowItem) string {
+	parts := make([]string, len(items))
+	for i, item := range items {
+		parts[i] = fmt.Sprintf("%s %s", item.Name, item.Amount)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// formatPercent formats an optional percentage; empty when there is none
+func formatPercent(p *float64) string {
+	if p == nil {
+		return ""
+	}
+	return fmt.Sprintf("%.1f%%", *p)
+}
+
+// markdownEscape keeps merchant names from breaking table cells
+func markdownEscape(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
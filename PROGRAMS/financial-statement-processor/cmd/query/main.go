@@ -41,24 +41,66 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  %s --start-date 2024-10-01 --end-date 2024-10-31 --account 1234\n\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  # Query only debits (expenses) as CSV\n")
 		fmt.Fprintf(os.Stderr, "  %s --start-date 2024-10-01 --end-date 2024-10-31 --type debit --csv\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Query groceries, or transactions still missing a category\n")
+		fmt.Fprintf(os.Stderr, "  %s --start-date 2024-10-01 --end-date 2024-10-31 --category groceries\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s --start-date 2024-10-01 --end-date 2024-10-31 --category uncategorized\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Everything from one merchant, however the bank spelled it\n")
+		fmt.Fprintf(os.Stderr, "  %s --start-date 2024-10-01 --end-date 2024-10-31 --merchant \"Coffee Shop\"\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Spending without credit card payments and other transfers between accounts\n")
+		fmt.Fprintf(os.Stderr, "  %s --start-date 2024-10-01 --end-date 2024-10-31 --type debit --exclude-transfers\n\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  # Query with pretty-printed JSON\n")
 		fmt.Fprintf(os.Stderr, "  %s --start-date 2024-10-01 --end-date 2024-10-31 --pretty\n\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  # Show account summary\n")
 		fmt.Fprintf(os.Stderr, "  %s --summary --pretty\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s --summary --group-by merchant --exclude-transfers --pretty\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Recurring charges found at import; list those that stopped or changed price\n")
+		fmt.Fprintf(os.Stderr, "  %s --recurring --pretty\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s --recurring --status stopped\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s --recurring --flag price_changed\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Statement coverage per account: gaps, last import, overdue statements\n")
+		fmt.Fprintf(os.Stderr, "  %s --coverage --pretty\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s --coverage --overdue --grace-days 15\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Full-text search, best match first; dates and other filters are optional\n")
+		fmt.Fprintf(os.Stderr, "  %s --search \"amazon refund\" --start-date 2024-03-01 --end-date 2024-05-31 --pretty\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Statements that failed to import this week, with the reason and a retry hint\n")
+		fmt.Fprintf(os.Stderr, "  %s --log --status failed --start-date 2024-10-07 --pretty\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s --log --file visa_2024_09\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Monthly income vs expenses for the last 12 months, as JSON, CSV or a markdown table\n")
+		fmt.Fprintf(os.Stderr, "  %s --cash-flow --pretty\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s --cash-flow --months 6 --end-date 2024-09-30 --csv\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s --cash-flow --markdown\n", os.Args[0])
 	}
 
 	startDateStr := flag.String("start-date", "", "Start date (YYYY-MM-DD) - required")
 	endDateStr := flag.String("end-date", "", "End date (YYYY-MM-DD) - required")
 	account := flag.String("account", "", "Filter by account name or last 4 digits (optional)")
 	transactionType := flag.String("type", "all", "Filter by transaction type: debit, credit, or all (default: all)")
+	category := flag.String("category", "", "Filter by category, or 'uncategorized' (optional)")
+	merchant := flag.String("merchant", "", "Filter by canonical merchant name, ignoring case (optional)")
 	pretty := flag.Bool("pretty", false, "Pretty-print JSON output")
 	csvOutput := flag.Bool("csv", false, "Output as CSV instead of JSON")
 	summary := flag.Bool("summary", false, "Show account summary instead of transactions")
+	groupBy := flag.String("group-by", "account", "With --summary: account or merchant")
+	excludeTransfers := flag.Bool("exclude-transfers", false, "Leave out linked transfers between accounts")
+	recurring := flag.Bool("recurring", false, "Show the recurring charges detected at import instead of transactions")
+	recurringStatus := flag.String("status", "all", "With --recurring: active, stopped, or all; with --log: success, parse_error, db_error, reconcile_error, failed, or all")
+	recurringFlag := flag.String("flag", "", "With --recurring: only series flagged new or price_changed (optional)")
+	search := flag.String("search", "", "Search descriptions, merchants, accounts, source files and notes for these words")
+	limit := flag.Int("limit", 50, "With --search or --log: maximum number of results")
+	coverage := flag.Bool("coverage", false, "Show which statements have been imported per account, with gaps and overdue statements")
+	overdue := flag.Bool("overdue", false, "With --coverage: only accounts whose latest statement is overdue")
+	graceDays := flag.Int("grace-days", 10, "With --coverage: days past the expected statement date before it is overdue")
+	processingLog := flag.Bool("log", false, "Show the processing log of imported and failed statements instead of transactions")
+	file := flag.String("file", "", "With --log: filter by source file name (substring, optional)")
+	cashFlow := flag.Bool("cash-flow", false, "Show monthly income, expenses, net and savings rate instead of transactions")
+	months := flag.Int("months", 12, "With --cash-flow: number of months, ending with the month of --end-date (default: this month)")
+	top := flag.Int("top", 5, "With --cash-flow: categories and merchants listed per month")
+	markdownOutput := flag.Bool("markdown", false, "With --cash-flow: output a markdown table instead of JSON")
 	flag.Parse()
 
 	// Validate required flags
-	if !*summary && (*startDateStr == "" || *endDateStr == "") {
-		fmt.Fprintf(os.Stderr, "Error: --start-date and --end-date are required (unless using --summary)\n\n")
+	if !*summary && !*recurring && !*coverage && !*processingLog && !*cashFlow && *search == "" && (*startDateStr == "" || *endDateStr == "") {
+		fmt.Fprintf(os.Stderr, "Error: --start-date and --end-date are required (unless using --summary, --recurring, --coverage, --log, --cash-flow or --search)\n\n")
 		flag.Usage()
 		os.Exit(exitcodes.ArgsError)
 	}
@@ -70,6 +112,31 @@ func main() {
 		os.Exit(exitcodes.ArgsError)
 	}
 
+	// Validate summary grouping
+	if *groupBy != "account" && *groupBy != "merchant" {
+		fmt.Fprintf(os.Stderr, "Error: --group-by must be 'account' or 'merchant' (got: %s)\n\n", *groupBy)
+		flag.Usage()
+		os.Exit(exitcodes.ArgsError)
+	}
+
+	// Validate recurring filters (--log checks its own statuses)
+	if !*processingLog && *recurringStatus != "all" && *recurringStatus != db.RecurringStatusActive && *recurringStatus != db.RecurringStatusStopped {
+		fmt.Fprintf(os.Stderr, "Error: --status must be 'active', 'stopped', or 'all' (got: %s)\n\n", *recurringStatus)
+		flag.Usage()
+		os.Exit(exitcodes.ArgsError)
+	}
+
+	// Validate processing log filters
+	if *processingLog {
+		switch *recurringStatus {
+		case "all", "success", "parse_error", "db_error", "reconcile_error", db.ProcessingStatusFailed:
+		default:
+			fmt.Fprintf(os.Stderr, "Error: with --log, --status must be 'success', 'parse_error', 'db_error', 'reconcile_error', 'failed', or 'all' (got: %s)\n\n", *recurringStatus)
+			flag.Usage()
+			os.Exit(exitcodes.ArgsError)
+		}
+	}
+
 	// Initialize database
 	database, err := app.InitDatabase()
 	if err != nil {
@@ -80,7 +147,10 @@ func main() {
 
 	// Handle summary mode
 	if *summary {
-		summaries, err := database.GetAccountSummary()
+		summaries, err := database.GetAccountSummary(*excludeTransfers)
+		if *groupBy == "merchant" {
+			summaries, err = database.GetMerchantSummary(*excludeTransfers)
+		}
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Failed to retrieve account summary: %v\n", err)
 			os.Exit(exitcodes.DBError)
@@ -96,6 +166,190 @@ func main() {
 		os.Exit(exitcodes.Success)
 	}
 
+	// Handle recurring mode
+	if *recurring {
+		series, err := database.ListRecurringSeries(*recurringStatus, *recurringFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to retrieve recurring series: %v\n", err)
+			os.Exit(exitcodes.DBError)
+		}
+		if series == nil {
+			series = []*db.RecurringSeries{}
+		}
+
+		output, err := formatOutput(map[string]interface{}{"recurring_series": series, "count": len(series)}, *pretty)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to format output: %v\n", err)
+			os.Exit(exitcodes.DBError)
+		}
+
+		fmt.Println(output)
+		os.Exit(exitcodes.Success)
+	}
+
+	// Handle coverage mode
+	if *coverage {
+		accounts, err := database.StatementCoverage(db.CoverageOptions{Account: *account, GraceDays: *graceDays})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to retrieve statement coverage: %v\n", err)
+			os.Exit(exitcodes.DBError)
+		}
+
+		result := []*db.StatementCoverage{}
+		overdueCount := 0
+		for _, c := range accounts {
+			if c.Status == db.CoverageStatusOverdue {
+				overdueCount++
+			} else if *overdue {
+				continue
+			}
+			result = append(result, c)
+		}
+
+		output, err := formatOutput(map[string]interface{}{
+			"accounts": result,
+			"count":    len(result),
+			"overdue":  overdueCount,
+		}, *pretty)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to format output: %v\n", err)
+			os.Exit(exitcodes.DBError)
+		}
+
+		fmt.Println(output)
+		os.Exit(exitcodes.Success)
+	}
+
+	// Handle processing log mode
+	if *processingLog {
+		opts := db.ProcessingLogOptions{File: *file, Limit: *limit}
+		if *recurringStatus != "all" {
+			opts.Status = *recurringStatus
+		}
+		if *startDateStr != "" {
+			if opts.StartDate, err = time.Parse("2006-01-02", *startDateStr); err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid start date format (use YYYY-MM-DD): %v\n", err)
+				os.Exit(exitcodes.ArgsError)
+			}
+		}
+		if *endDateStr != "" {
+			if opts.EndDate, err = time.Parse("2006-01-02", *endDateStr); err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid end date format (use YYYY-MM-DD): %v\n", err)
+				os.Exit(exitcodes.ArgsError)
+			}
+		}
+
+		entries, logSummary, err := database.QueryProcessingLog(opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to query processing log: %v\n", err)
+			os.Exit(exitcodes.DBError)
+		}
+
+		output, err := formatOutput(map[string]interface{}{
+			"entries": entries,
+			"count":   len(entries),
+			"summary": logSummary,
+		}, *pretty)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to format output: %v\n", err)
+			os.Exit(exitcodes.DBError)
+		}
+
+		fmt.Println(output)
+		os.Exit(exitcodes.Success)
+	}
+
+	// Handle cash flow mode
+	if *cashFlow {
+		cfg, err := app.InitConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
+			os.Exit(exitcodes.ConfigError)
+		}
+
+		opts := db.CashFlowOptions{Months: *months, Account: *account, Top: *top, BaseCurrency: cfg.BaseCurrency}
+		if *endDateStr != "" {
+			if opts.End, err = time.Parse("2006-01-02", *endDateStr); err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid end date format (use YYYY-MM-DD): %v\n", err)
+				os.Exit(exitcodes.ArgsError)
+			}
+		}
+
+		report, err := database.CashFlow(opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to build cash flow report: %v\n", err)
+			os.Exit(exitcodes.DBError)
+		}
+
+		if *csvOutput {
+			if err := formatCashFlowCSV(report); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to format CSV output: %v\n", err)
+				os.Exit(exitcodes.DBError)
+			}
+			os.Exit(exitcodes.Success)
+		}
+		if *markdownOutput {
+			fmt.Print(formatCashFlowMarkdown(report))
+			os.Exit(exitcodes.Success)
+		}
+
+		output, err := formatOutput(report, *pretty)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to format output: %v\n", err)
+			os.Exit(exitcodes.DBError)
+		}
+
+		fmt.Println(output)
+		os.Exit(exitcodes.Success)
+	}
+
+	// Handle search mode
+	if *search != "" {
+		opts := db.SearchOptions{
+			Account:  *account,
+			Category: *category,
+			Limit:    *limit,
+		}
+		if *transactionType != "all" {
+			opts.TransactionType = *transactionType
+		}
+		if *startDateStr != "" {
+			if opts.StartDate, err = time.Parse("2006-01-02", *startDateStr); err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid start date format (use YYYY-MM-DD): %v\n", err)
+				os.Exit(exitcodes.ArgsError)
+			}
+		}
+		if *endDateStr != "" {
+			if opts.EndDate, err = time.Parse("2006-01-02", *endDateStr); err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid end date format (use YYYY-MM-DD): %v\n", err)
+				os.Exit(exitcodes.ArgsError)
+			}
+		}
+
+		results, err := database.SearchTransactions(*search, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to search transactions: %v\n", err)
+			os.Exit(exitcodes.DBError)
+		}
+		if results == nil {
+			results = []*db.SearchResult{}
+		}
+
+		output, err := formatOutput(map[string]interface{}{
+			"query":   *search,
+			"mode":    database.SearchMode(),
+			"results": results,
+			"count":   len(results),
+		}, *pretty)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to format output: %v\n", err)
+			os.Exit(exitcodes.DBError)
+		}
+
+		fmt.Println(output)
+		os.Exit(exitcodes.Success)
+	}
+
 	// Parse dates
 	startDate, err := time.Parse("2006-01-02", *startDateStr)
 	if err != nil {
@@ -116,7 +370,7 @@ func main() {
 	}
 
 	// Query transactions with type filter
-	transactions, err := database.QueryTransactionsWithType(startDate, endDate, *account, *transactionType)
+	transactions, err := database.QueryTransactionsWithType(startDate, endDate, *account, *transactionType, *category, *merchant, *excludeTransfers)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to query transactions: %v\n", err)
 		os.Exit(exitcodes.DBError)
@@ -189,10 +443,16 @@ func formatCSV(transactions []*db.Transaction) error {
 		"post_date",
 		"description",
 		"amount",
+		"currency",
 		"transaction_type",
 		"balance",
 		"statement_date",
 		"source_file",
+		"category",
+		"merchant_name",
+		"transfer_id",
+		"notes",
+		"split_from_id",
 	}
 	if err := w.Write(header); err != nil {
 		return err
@@ -207,7 +467,17 @@ func formatCSV(transactions []*db.Transaction) error {
 
 		balance := ""
 		if tx.Balance != nil {
-			balance = fmt.Sprintf("%.2f", *tx.Balance)
+			balance = tx.Balance.String()
+		}
+
+		transferID := ""
+		if tx.TransferID != nil {
+			transferID = fmt.Sprintf("%d", *tx.TransferID)
+		}
+
+		splitFromID := ""
+		if tx.SplitFromID != nil {
+			splitFromID = fmt.Sprintf("%d", *tx.SplitFromID)
 		}
 
 		row := []string{
@@ -217,11 +487,17 @@ func formatCSV(transactions []*db.Transaction) error {
 			tx.TransactionDate.Format("2006-01-02"),
 			postDate,
 			tx.Description,
-			fmt.Sprintf("%.2f", tx.Amount),
+			tx.Amount.String(),
+			tx.Currency,
 			tx.TransactionType,
 			balance,
 			tx.StatementDate.Format("2006-01-02"),
 			tx.SourceFile,
+			tx.Category,
+			tx.MerchantName,
+			transferID,
+			tx.Notes,
+			splitFromID,
 		}
 
 		if err := w.Write(row); err != nil {
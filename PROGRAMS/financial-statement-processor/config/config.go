@@ -4,6 +4,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"money"
 )
 
 // Config holds the application configuration
@@ -11,12 +16,64 @@ type Config struct {
 	DBPath      string
 	OllamaHost  string
 	OllamaModel string
+
+	// LLM backend used for parsing and categorization
+	// LLMHost and LLMModel default to OllamaHost and OllamaModel
+	LLMBackend  string
+	LLMHost     string
+	LLMModel    string
+	LLMAPIKey   string
+	LLMFixtures string
+
+	// LLMWorkers is how many statement pages are sent to the LLM concurrently
+	LLMWorkers int
+
+	// Validation of LLM responses: whether to send the response schema, how many times to ask
+	// the model to repair an invalid response, and whether rows still invalid are skipped or
+	// fail the page
+	LLMPlainJSON     bool
+	LLMRepairRetries int
+	LLMInvalidRows   string
+
+	// OCR settings for images and scanned PDF pages
+	OCREngine         string
+	TesseractPath     string
+	OCRLanguage       string
+	OCRDPI            int
+	PDFToPPMPath      string
+	OllamaVisionModel string
+
+	// CSVProfilesPath points to a JSON file with additional CSV column mapping profiles
+	CSVProfilesPath string
+
+	// CategorizeWithLLM enables the LLM fallback for transactions no category rule matched
+	CategorizeWithLLM bool
+
+	// Transfer matching: how many days apart and how far off in amount the two sides of a
+	// transfer between accounts may be
+	TransferWindowDays int
+	TransferTolerance  money.Cents
+
+	// BaseCurrency is the currency reports convert amounts into with the stored exchange rates
+	BaseCurrency string
 }
 
 const (
 	defaultDBPath      = "./transactions.db"
 	defaultOllamaHost  = "http://localhost:11434"
 	defaultOllamaModel = "dolphin3"
+	defaultLLMBackend  = "ollama"
+	defaultLLMWorkers  = 2
+	defaultLLMRetries  = 2
+
+	defaultTransferWindowDays = 3
+
+	defaultBaseCurrency = "USD"
+
+	defaultOCREngine   = "tesseract"
+	defaultOCRLanguage = "eng"
+	defaultOCRDPI      = 300
+	defaultVisionModel = "llava"
 )
 
 // LoadFromEnv loads configuration from environment variables
@@ -38,10 +95,75 @@ func LoadFromEnv() (*Config, error) {
 		return nil, fmt.Errorf("create database directory: %w", err)
 	}
 
+	ocrDPI, err := getEnvInt("OCR_DPI", defaultOCRDPI)
+	if err != nil {
+		return nil, err
+	}
+
+	llmWorkers, err := getEnvInt("LLM_WORKERS", defaultLLMWorkers)
+	if err != nil {
+		return nil, err
+	}
+
+	ollamaHost := getEnv("OLLAMA_HOST", defaultOllamaHost)
+	ollamaModel := getEnv("OLLAMA_MODEL", defaultOllamaModel)
+
+	repairRetries, err := getEnvCount("LLM_REPAIR_RETRIES", defaultLLMRetries)
+	if err != nil {
+		return nil, err
+	}
+
+	invalidRows := getEnv("LLM_INVALID_ROWS", "skip")
+	if invalidRows != "skip" && invalidRows != "fail" {
+		return nil, fmt.Errorf("LLM_INVALID_ROWS must be skip or fail, got: %s", invalidRows)
+	}
+
+	transferWindow, err := getEnvCount("TRANSFER_WINDOW_DAYS", defaultTransferWindowDays)
+	if err != nil {
+		return nil, err
+	}
+
+	transferTolerance, err := getEnvAmount("TRANSFER_AMOUNT_TOLERANCE", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	baseCurrency := strings.ToUpper(getEnv("BASE_CURRENCY", defaultBaseCurrency))
+	if !regexp.MustCompile(`^[A-Z]{3}$`).MatchString(baseCurrency) {
+		return nil, fmt.Errorf("BASE_CURRENCY must be a 3-letter currency code, got: %s", baseCurrency)
+	}
+
 	cfg := &Config{
 		DBPath:      dbPath,
-		OllamaHost:  getEnv("OLLAMA_HOST", defaultOllamaHost),
-		OllamaModel: getEnv("OLLAMA_MODEL", defaultOllamaModel),
+		OllamaHost:  ollamaHost,
+		OllamaModel: ollamaModel,
+
+		LLMBackend:  getEnv("LLM_BACKEND", defaultLLMBackend),
+		LLMHost:     getEnv("LLM_HOST", ollamaHost),
+		LLMModel:    getEnv("LLM_MODEL", ollamaModel),
+		LLMAPIKey:   getEnv("LLM_API_KEY", ""),
+		LLMFixtures: expandHome(getEnv("LLM_FIXTURES", "")),
+		LLMWorkers:  llmWorkers,
+
+		LLMPlainJSON:     getEnv("LLM_PLAIN_JSON", "false") == "true",
+		LLMRepairRetries: repairRetries,
+		LLMInvalidRows:   invalidRows,
+
+		OCREngine:         getEnv("OCR_ENGINE", defaultOCREngine),
+		TesseractPath:     getEnv("TESSERACT_PATH", "tesseract"),
+		OCRLanguage:       getEnv("OCR_LANGUAGE", defaultOCRLanguage),
+		OCRDPI:            ocrDPI,
+		PDFToPPMPath:      getEnv("PDFTOPPM_PATH", "pdftoppm"),
+		OllamaVisionModel: getEnv("OLLAMA_VISION_MODEL", defaultVisionModel),
+
+		CSVProfilesPath: expandHome(getEnv("CSV_PROFILES_PATH", "")),
+
+		CategorizeWithLLM: getEnv("CATEGORIZE_WITH_LLM", "true") == "true",
+
+		TransferWindowDays: transferWindow,
+		TransferTolerance:  transferTolerance,
+
+		BaseCurrency: baseCurrency,
 	}
 
 	return cfg, nil
@@ -52,6 +174,16 @@ func (c *Config) DatabasePath() string {
 	return c.DBPath
 }
 
+// expandHome expands a leading ~ to the user's home directory
+func expandHome(path string) string {
+	if len(path) > 0 && path[0] == '~' {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
+
 // getEnv retrieves an environment variable or returns a default value
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -59,3 +191,45 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvInt retrieves an integer environment variable or returns a default value
+func getEnvInt(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%s must be a positive integer, got: %s", key, value)
+	}
+	return n, nil
+}
+
+// getEnvCount retrieves a non-negative integer environment variable or returns a default value
+func getEnvCount(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer, got: %s", key, value)
+	}
+	return n, nil
+}
+
+// getEnvAmount retrieves a non-negative money amount environment variable or returns a default value
+func getEnvAmount(key string, defaultValue money.Cents) (money.Cents, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	amount, err := money.Parse(value)
+	if err != nil || amount < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative amount, got: %s", key, value)
+	}
+	return amount, nil
+}
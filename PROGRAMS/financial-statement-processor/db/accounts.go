@@ -0,0 +1,640 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Account types
+const (
+	AccountTypeChecking = "checking"
+	AccountTypeSavings  = "savings"
+	AccountTypeCredit   = "credit"
+)
+
+// defaultAccountCurrency is the currency of accounts created without one
+const defaultAccountCurrency = "USD"
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Account is a bank or card account that statements and transactions are attached to
+type Account struct {
+	ID           int64           `json:"id"`
+	Institution  string          `json:"institution,omitempty"`
+	Nickname     string          `json:"nickname"` // stored as account_name on the account's transactions
+	AccountType  string          `json:"account_type,omitempty"`
+	Last4        string          `json:"last4"`
+	Currency     string          `json:"currency"`
+	OpenedOn     *time.Time      `json:"opened_on,omitempty"`
+	ClosedOn     *time.Time      `json:"closed_on,omitempty"`
+	Aliases      []*AccountAlias `json:"aliases"`
+	Transactions int             `json:"transactions"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+// AccountAlias is an account name (and last 4 digits) as spelled on a statement
+type AccountAlias struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Last4 string `json:"last4"`
+}
+
+// AccountEdit holds the account fields to change; nil fields are left as they are
+// An empty OpenedOn/ClosedOn date (zero time) clears it
+type AccountEdit struct {
+	Institution *string
+	Nickname    *string
+	AccountType *string
+	Last4       *string
+	Currency    *string
+	OpenedOn    *time.Time
+	ClosedOn    *time.Time
+}
+
+// ListAccounts returns all accounts with their aliases and transaction counts, open accounts first
+func (db *DB) ListAccounts() ([]*Account, error) {
+	return db.queryAccounts("", nil)
+}
+
+// GetAccount returns a single account with its aliases
+func (db *DB) GetAccount(id int64) (*Account, error) {
+	accounts, err := db.queryAccounts("WHERE accounts.id = ?", []interface{}{id})
+	if err != nil {
+		return nil, err
+	}
+	if len(accounts) == 0 {
+		return nil, fmt.Errorf("account %d not found", id)
+	}
+	return accounts[0], nil
+}
+
+// AddAccount stores a new account; its nickname and any extra alias names are matched against
+// the account names on statements
+func (db *DB) AddAccount(a *Account, aliases []string) (int64, error) {
+	if err := normalizeAccount(a); err != nil {
+		return 0, err
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	id, err := insertAccount(tx, a)
+	if err != nil {
+		return 0, err
+	}
+	for _, name := range append([]string{a.Nickname}, aliases...) {
+		if err := insertAccountAlias(tx, id, name, a.Last4); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return id, nil
+}
+
+// EditAccount changes an account; a new nickname is also written to its transactions and kept as an alias
+func (db *DB) EditAccount(id int64, edit AccountEdit) (*Account, error) {
+	a, err := db.GetAccount(id)
+	if err != nil {
+		return nil, err
+	}
+	oldNickname, oldCurrency := a.Nickname, a.Currency
+
+	if edit.Institution != nil {
+		a.Institution = *edit.Institution
+	}
+	if edit.Nickname != nil {
+		a.Nickname = *edit.Nickname
+	}
+	if edit.AccountType != nil {
+		a.AccountType = *edit.AccountType
+	}
+	if edit.Last4 != nil {
+		a.Last4 = *edit.Last4
+	}
+	if edit.Currency != nil {
+		a.Currency = *edit.Currency
+	}
+	if edit.OpenedOn != nil {
+		a.OpenedOn = optionalDate(*edit.OpenedOn)
+	}
+	if edit.ClosedOn != nil {
+		a.ClosedOn = optionalDate(*edit.ClosedOn)
+	}
+	if err := normalizeAccount(a); err != nil {
+		return nil, err
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE accounts
+		SET institution = ?, nickname = ?, account_type = ?, last4 = ?, currency = ?,
+			opened_on = ?, closed_on = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, nullString(a.Institution), a.Nickname, nullString(a.AccountType), a.Last4, a.Currency,
+		a.OpenedOn, a.ClosedOn, id)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, fmt.Errorf("an account named %q with last 4 digits %q already exists; merge the two instead", a.Nickname, a.Last4)
+		}
+		return nil, fmt.Errorf("update account: %w", err)
+	}
+
+	if a.Nickname != oldNickname {
+		if _, err := tx.Exec(`UPDATE transactions SET account_name = ? WHERE account_id = ?`, a.Nickname, id); err != nil {
+			return nil, fmt.Errorf("rename account transactions: %w", err)
+		}
+		if err := insertAccountAlias(tx, id, a.Nickname, a.Last4); err != nil {
+			return nil, err
+		}
+	}
+	// A corrected currency applies to the amounts already imported in the old one too; amounts
+	// entered or imported with their own currency (charges abroad) keep it
+	if a.Currency != oldCurrency {
+		_, err := tx.Exec(`UPDATE transactions SET currency = ? WHERE account_id = ? AND currency = ?`, a.Currency, id, oldCurrency)
+		if err != nil {
+			return nil, fmt.Errorf("update account transactions currency: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return db.GetAccount(id)
+}
+
+// AddAccountAlias records another spelling of an account's name on statements
+func (db *DB) AddAccountAlias(id int64, name, last4 string) error {
+	if _, err := db.GetAccount(id); err != nil {
+		return err
+	}
+	if accountKey(name) == "" {
+		return fmt.Errorf("alias name is required")
+	}
+
+	var owner int64
+	err := db.conn.QueryRow(`SELECT account_id FROM account_aliases WHERE name_key = ? AND last4 = ?`,
+		accountKey(name), strings.TrimSpace(last4)).Scan(&owner)
+	if err == nil && owner != id {
+		return fmt.Errorf("alias %q already belongs to account %d; merge the accounts instead", name, owner)
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("look up alias: %w", err)
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertAccountAlias(tx, id, name, last4); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// MergeAccounts moves the transactions and aliases of account fromID to account intoID and deletes
+// fromID; fields the kept account is missing are taken from the merged one
+func (db *DB) MergeAccounts(intoID, fromID int64) (*Account, error) {
+	if intoID == fromID {
+		return nil, fmt.Errorf("cannot merge an account into itself")
+	}
+	into, err := db.GetAccount(intoID)
+	if err != nil {
+		return nil, err
+	}
+	from, err := db.GetAccount(fromID)
+	if err != nil {
+		return nil, err
+	}
+
+	if into.Institution == "" {
+		into.Institution = from.Institution
+	}
+	if into.AccountType == "" {
+		into.AccountType = from.AccountType
+	}
+	if into.Last4 == "" {
+		into.Last4 = from.Last4
+	}
+	if from.OpenedOn != nil && (into.OpenedOn == nil || from.OpenedOn.Before(*into.OpenedOn)) {
+		into.OpenedOn = from.OpenedOn
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE transactions SET account_id = ?, account_name = ? WHERE account_id = ?`,
+		intoID, into.Nickname, fromID); err != nil {
+		return nil, fmt.Errorf("move transactions: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE account_aliases SET account_id = ? WHERE account_id = ?`, intoID, fromID); err != nil {
+		return nil, fmt.Errorf("move aliases: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM accounts WHERE id = ?`, fromID); err != nil {
+		return nil, fmt.Errorf("delete merged account: %w", err)
+	}
+	_, err = tx.Exec(`
+		UPDATE accounts
+		SET institution = ?, account_type = ?, last4 = ?, opened_on = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, nullString(into.Institution), nullString(into.AccountType), into.Last4, into.OpenedOn, intoID)
+	if err != nil {
+		return nil, fmt.Errorf("update account: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return db.GetAccount(intoID)
+}
+
+// DeleteAccount removes an account that has no transactions
+func (db *DB) DeleteAccount(id int64) error {
+	a, err := db.GetAccount(id)
+	if err != nil {
+		return err
+	}
+	if a.Transactions > 0 {
+		return fmt.Errorf("account %d has %d transactions; merge it into another account instead", id, a.Transactions)
+	}
+
+	if _, err := db.conn.Exec(`DELETE FROM accounts WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("delete account: %w", err)
+	}
+	return nil
+}
+
+// AssignAccounts attaches transactions to accounts by the account name and last 4 digits on the
+// statement, creating accounts for names not seen before, and replaces each transaction's account
+// name with the account's nickname. Returns the number of accounts created
+//
+// A statement matches, in order: an alias with the same name and last 4 digits; the only open
+// account with those last 4 digits whose institution, nickname or aliases share a word with the
+// name; or, without last 4 digits, the only account with that name
+func (db *DB) AssignAccounts(transactions []*Transaction) (int, error) {
+	type key struct{ name, last4 string }
+	resolved := make(map[key]*Account)
+	created := 0
+
+	for _, t := range transactions {
+		t.Currency = strings.ToUpper(strings.TrimSpace(t.Currency))
+		k := key{accountKey(t.AccountName), strings.TrimSpace(t.AccountLast4)}
+		a, ok := resolved[k]
+		if !ok {
+			var isNew bool
+			var err error
+			a, isNew, err = db.resolveAccount(t.AccountName, t.AccountLast4, t.Currency)
+			if err != nil {
+				return created, err
+			}
+			if isNew {
+				created++
+			}
+			resolved[k] = a
+		}
+
+		id := a.ID
+		t.AccountID = &id
+		t.AccountName = a.Nickname
+		if t.Currency == "" {
+			t.Currency = a.Currency
+		}
+	}
+
+	return created, nil
+}
+
+// resolveAccount finds the account a statement's account name belongs to, creating one if none matches
+// A new account gets the statement's currency, when it has one
+func (db *DB) resolveAccount(name, last4, currency string) (*Account, bool, error) {
+	name = strings.TrimSpace(name)
+	last4 = strings.TrimSpace(last4)
+	nameKey := accountKey(name)
+
+	var id int64
+	err := db.conn.QueryRow(`SELECT account_id FROM account_aliases WHERE name_key = ? AND last4 = ?`, nameKey, last4).Scan(&id)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, false, fmt.Errorf("look up account alias: %w", err)
+	}
+
+	if err == sql.ErrNoRows {
+		var candidates []int64
+		if last4 != "" {
+			candidates, err = db.accountIDs(`SELECT id FROM accounts WHERE last4 = ? AND closed_on IS NULL`, last4)
+		} else {
+			candidates, err = db.accountIDs(`SELECT DISTINCT account_id FROM account_aliases WHERE name_key = ?`, nameKey)
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		// Cards from different issuers can share the last 4 digits, so the name has to agree too;
+		// otherwise the statement gets its own account, to be merged by hand if it is the same one
+		if last4 != "" && len(candidates) == 1 {
+			a, err := db.GetAccount(candidates[0])
+			if err != nil {
+				return nil, false, err
+			}
+			if !accountNameMatches(a, nameKey) {
+				candidates = nil
+			}
+		}
+
+		isNew := len(candidates) != 1
+		tx, err := db.conn.Begin()
+		if err != nil {
+			return nil, false, fmt.Errorf("begin transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		if isNew {
+			a := &Account{Nickname: name, Last4: last4, AccountType: guessAccountType(name), Currency: currency}
+			if err := normalizeAccount(a); err != nil {
+				return nil, false, err
+			}
+			if id, err = insertAccount(tx, a); err != nil {
+				return nil, false, err
+			}
+		} else {
+			id = candidates[0]
+		}
+		if err := insertAccountAlias(tx, id, name, last4); err != nil {
+			return nil, false, err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return nil, false, fmt.Errorf("commit transaction: %w", err)
+		}
+
+		a, err := db.GetAccount(id)
+		return a, isNew, err
+	}
+
+	a, err := db.GetAccount(id)
+	return a, false, err
+}
+
+// ensureTransactionAccounts attaches transactions stored before the accounts table existed
+func (db *DB) ensureTransactionAccounts() error {
+	rows, err := db.conn.Query(`SELECT DISTINCT account_name, account_last4 FROM transactions WHERE account_id IS NULL`)
+	if err != nil {
+		return fmt.Errorf("query unassigned accounts: %w", err)
+	}
+	defer rows.Close()
+
+	var unassigned []*Transaction
+	for rows.Next() {
+		t := &Transaction{}
+		if err := rows.Scan(&t.AccountName, &t.AccountLast4); err != nil {
+			return fmt.Errorf("scan unassigned account: %w", err)
+		}
+		unassigned = append(unassigned, t)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate unassigned accounts: %w", err)
+	}
+	rows.Close()
+
+	for _, t := range unassigned {
+		name := t.AccountName
+		if _, err := db.AssignAccounts([]*Transaction{t}); err != nil {
+			return err
+		}
+		_, err := db.conn.Exec(`
+			UPDATE transactions SET account_id = ?, account_name = ?
+			WHERE account_id IS NULL AND account_name = ? AND account_last4 = ?
+		`, *t.AccountID, t.AccountName, name, t.AccountLast4)
+		if err != nil {
+			return fmt.Errorf("assign transactions to account: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// queryAccounts loads accounts matching a WHERE clause with their aliases and transaction counts
+func (db *DB) queryAccounts(where string, args []interface{}) ([]*Account, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, COALESCE(institution, ''), nickname, COALESCE(account_type, ''), last4, currency,
+			opened_on, closed_on, created_at, updated_at,
+			(SELECT COUNT(*) FROM transactions WHERE transactions.account_id = accounts.id)
+		FROM accounts
+		`+where+`
+		ORDER BY closed_on IS NOT NULL, nickname, last4
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query accounts: %w", err)
+	}
+	defer rows.Close()
+
+	var accounts []*Account
+	byID := make(map[int64]*Account)
+	for rows.Next() {
+		a := &Account{Aliases: []*AccountAlias{}}
+		var openedOn, closedOn sql.NullTime
+		if err := rows.Scan(&a.ID, &a.Institution, &a.Nickname, &a.AccountType, &a.Last4, &a.Currency,
+			&openedOn, &closedOn, &a.CreatedAt, &a.UpdatedAt, &a.Transactions); err != nil {
+			return nil, fmt.Errorf("scan account: %w", err)
+		}
+		if openedOn.Valid {
+			a.OpenedOn = &openedOn.Time
+		}
+		if closedOn.Valid {
+			a.ClosedOn = &closedOn.Time
+		}
+		accounts = append(accounts, a)
+		byID[a.ID] = a
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate accounts: %w", err)
+	}
+	rows.Close()
+
+	aliasRows, err := db.conn.Query(`SELECT id, account_id, name, last4 FROM account_aliases ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("query account aliases: %w", err)
+	}
+	defer aliasRows.Close()
+
+	for aliasRows.Next() {
+		alias := &AccountAlias{}
+		var accountID int64
+		if err := aliasRows.Scan(&alias.ID, &accountID, &alias.Name, &alias.Last4); err != nil {
+			return nil, fmt.Errorf("scan account alias: %w", err)
+		}
+		if a, ok := byID[accountID]; ok {
+			a.Aliases = append(a.Aliases, alias)
+		}
+	}
+	if err := aliasRows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate account aliases: %w", err)
+	}
+
+	return accounts, nil
+}
+
+// accountIDs runs a query returning account IDs
+func (db *DB) accountIDs(query string, args ...interface{}) ([]int64, error) {
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query accounts: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan account: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// insertAccount inserts a normalized account
+func insertAccount(tx *sql.Tx, a *Account) (int64, error) {
+	result, err := tx.Exec(`
+		INSERT INTO accounts (institution, nickname, account_type, last4, currency, opened_on, closed_on)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, nullString(a.Institution), a.Nickname, nullString(a.AccountType), a.Last4, a.Currency, a.OpenedOn, a.ClosedOn)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return 0, fmt.Errorf("an account named %q with last 4 digits %q already exists", a.Nickname, a.Last4)
+		}
+		return 0, fmt.Errorf("insert account: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// insertAccountAlias records an alias for an account unless it is already recorded
+func insertAccountAlias(tx *sql.Tx, accountID int64, name, last4 string) error {
+	name = strings.TrimSpace(name)
+	if accountKey(name) == "" {
+		return nil
+	}
+	_, err := tx.Exec(`
+		INSERT OR IGNORE INTO account_aliases (account_id, name, name_key, last4) VALUES (?, ?, ?, ?)
+	`, accountID, name, accountKey(name), strings.TrimSpace(last4))
+	if err != nil {
+		return fmt.Errorf("insert account alias: %w", err)
+	}
+	return nil
+}
+
+// normalizeAccount validates an account and tidies its fields
+func normalizeAccount(a *Account) error {
+	a.Institution = strings.TrimSpace(a.Institution)
+	a.Nickname = strings.TrimSpace(a.Nickname)
+	a.AccountType = strings.ToLower(strings.TrimSpace(a.AccountType))
+	a.Last4 = strings.TrimSpace(a.Last4)
+	a.Currency = strings.ToUpper(strings.TrimSpace(a.Currency))
+
+	if a.Nickname == "" {
+		return fmt.Errorf("account nickname is required")
+	}
+	if a.AccountType != "" && !contains([]string{AccountTypeChecking, AccountTypeSavings, AccountTypeCredit}, a.AccountType) {
+		return fmt.Errorf("invalid account type %q (use checking, savings or credit)", a.AccountType)
+	}
+	if a.Currency == "" {
+		a.Currency = defaultAccountCurrency
+	}
+	if !currencyCode.MatchString(a.Currency) {
+		return fmt.Errorf("invalid currency %q (use a 3-letter code such as USD)", a.Currency)
+	}
+	if a.OpenedOn != nil && a.ClosedOn != nil && a.ClosedOn.Before(*a.OpenedOn) {
+		return fmt.Errorf("account can't be closed before it was opened")
+	}
+	return nil
+}
+
+// accountKey reduces an account name to uppercase words so spelling differences in punctuation,
+// case and spacing don't matter
+func accountKey(name string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToUpper(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// genericAccountWords describe the kind of account rather than whose it is, so names that only
+// share these don't match
+var genericAccountWords = map[string]bool{
+	"ACCOUNT": true, "BANK": true, "CARD": true, "CREDIT": true, "DEBIT": true, "CHECKING": true, "SAVINGS": true,
+	"VISA": true, "MASTERCARD": true, "THE": true, "OF": true,
+}
+
+// accountNameMatches reports whether a statement's account name (as an accountKey) shares a word
+// other than a generic one with the account's institution, nickname or aliases
+func accountNameMatches(a *Account, nameKey string) bool {
+	words := make(map[string]bool)
+	for _, word := range strings.Fields(nameKey) {
+		if !genericAccountWords[word] {
+			words[word] = true
+		}
+	}
+
+	names := []string{a.Institution, a.Nickname}
+	for _, alias := range a.Aliases {
+		names = append(names, alias.Name)
+	}
+	for _, name := range names {
+		for _, word := range strings.Fields(accountKey(name)) {
+			if words[word] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// guessAccountType infers the type of an account created from a statement by its name
+func guessAccountType(name string) string {
+	upper := strings.ToUpper(name)
+	switch {
+	case strings.Contains(upper, "SAVINGS"):
+		return AccountTypeSavings
+	case strings.Contains(upper, "CHECKING"):
+		return AccountTypeChecking
+	}
+	for _, word := range []string{"CREDIT", "CARD", "VISA", "MASTERCARD", "AMEX", "AMERICAN EXPRESS", "DISCOVER"} {
+		if strings.Contains(upper, word) {
+			return AccountTypeCredit
+		}
+	}
+	return ""
+}
+
+// optionalDate turns a zero date into nil
+func optionalDate(d time.Time) *time.Time {
+	if d.IsZero() {
+		return nil
+	}
+	return &d
+}
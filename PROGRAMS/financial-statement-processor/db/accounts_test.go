@@ -0,0 +1,164 @@
+package db
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestAccounts(t *testing.T) {
+	dbPath := "./test_accounts.db"
+	defer os.Remove(dbPath)
+
+	db, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.AddAccount(&Account{Nickname: "Chase Sapphire", AccountType: "debit"}, nil); err == nil {
+		t.Error("Expected an error for an invalid account type")
+	}
+	sapphire, err := db.AddAccount(&Account{Nickname: "Chase Sapphire", Institution: "Chase", AccountType: AccountTypeCredit, Last4: "4821"},
+		[]string{"CHASE SAPPHIRE PREFERRED"})
+	if err != nil {
+		t.Fatalf("Failed to add account: %v", err)
+	}
+
+	date := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	tx := func(account, last4, description string) *Transaction {
+		return &Transaction{AccountName: account, AccountLast4: last4, TransactionDate: date, Description: description,
+			Amount: -1000, TransactionType: "debit", StatementDate: date}
+	}
+	transactions := []*Transaction{
+		tx("Chase Sapphire Preferred", "4821", "COFFEE"),     // alias
+		tx("CHASE SAPPHIRE PREFERRED CARD", "4821", "LUNCH"), // same last 4 digits
+		tx("Checking Account", "0007", "RENT"),               // new account
+		tx("CHECKING ACCOUNT", "0007", "GAS"),                // matches the new account
+		tx("CITI DOUBLE CASH", "4821", "BOOKS"),              // another issuer, same last 4 digits
+	}
+	if _, _, err := db.InsertTransactions(transactions); err != nil {
+		t.Fatalf("Failed to insert transactions: %v", err)
+	}
+
+	for _, tr := range transactions[:2] {
+		if tr.AccountID == nil || *tr.AccountID != sapphire || tr.AccountName != "Chase Sapphire" {
+			t.Errorf("Expected %s to be attached to the Sapphire account, got %v %q", tr.Description, tr.AccountID, tr.AccountName)
+		}
+	}
+	if citi := transactions[4].AccountID; citi == nil || *citi == sapphire || transactions[4].AccountName != "CITI DOUBLE CASH" {
+		t.Errorf("Expected a card of another issuer with the same last 4 digits to get its own account, got %v %q", citi, transactions[4].AccountName)
+	}
+	checking := *transactions[2].AccountID
+	if *transactions[3].AccountID != checking || checking == sapphire {
+		t.Errorf("Expected the checking statements to share a new account")
+	}
+	created, err := db.GetAccount(checking)
+	if err != nil || created.AccountType != AccountTypeChecking || created.Nickname != "Checking Account" || created.Transactions != 2 {
+		t.Errorf("Unexpected created account: %+v (err=%v)", created, err)
+	}
+
+	// The same account imported under a different number, then merged
+	if _, _, err := db.InsertTransactions([]*Transaction{tx("Sapphire Reserve", "9999", "HOTEL")}); err != nil {
+		t.Fatalf("Failed to insert transaction: %v", err)
+	}
+	accounts, _ := db.ListAccounts()
+	if len(accounts) != 4 {
+		t.Fatalf("Expected 4 accounts before merging, got %d", len(accounts))
+	}
+	var duplicate int64
+	for _, a := range accounts {
+		if a.Last4 == "9999" {
+			duplicate = a.ID
+		}
+	}
+	merged, err := db.MergeAccounts(sapphire, duplicate)
+	if err != nil {
+		t.Fatalf("Failed to merge accounts: %v", err)
+	}
+	if merged.Transactions != 3 || len(merged.Aliases) != 4 {
+		t.Errorf("Expected 3 transactions and 4 aliases after merging, got %d and %d", merged.Transactions, len(merged.Aliases))
+	}
+	if _, err := db.GetAccount(duplicate); err == nil {
+		t.Error("Expected the merged account to be deleted")
+	}
+	hotel, _ := db.QueryTransactionsWithType(date, date, "9999", "all", "", "", false)
+	if len(hotel) != 1 || hotel[0].AccountName != "Chase Sapphire" {
+		t.Errorf("Expected the merged transaction to take the kept nickname, got %+v", hotel)
+	}
+
+	// Renaming rewrites the account name on transactions
+	nickname := "Sapphire"
+	if _, err := db.EditAccount(sapphire, AccountEdit{Nickname: &nickname}); err != nil {
+		t.Fatalf("Failed to edit account: %v", err)
+	}
+	hotel, _ = db.QueryTransactionsWithType(date, date, "9999", "all", "", "", false)
+	if len(hotel) != 1 || hotel[0].AccountName != "Sapphire" {
+		t.Errorf("Expected the renamed nickname on transactions, got %+v", hotel)
+	}
+
+	if err := db.AddAccountAlias(checking, "Chase Sapphire Preferred", "4821"); err == nil {
+		t.Error("Expected an error adding another account's alias")
+	}
+	if err := db.DeleteAccount(checking); err == nil {
+		t.Error("Expected an error deleting an account with transactions")
+	}
+
+	// Rows stored before accounts existed are attached when the database is opened
+	if _, err := db.conn.Exec(`INSERT INTO transactions (account_name, account_last4, transaction_date, description, amount,
+		transaction_type, statement_date) VALUES ('SAPPHIRE', '4821', ?, 'OLD', -1, 'debit', ?)`, date, date); err != nil {
+		t.Fatalf("Failed to insert legacy row: %v", err)
+	}
+	if err := db.ensureTransactionAccounts(); err != nil {
+		t.Fatalf("Failed to assign accounts: %v", err)
+	}
+	old, _ := db.QueryTransactionsWithType(date, date, "4821", "all", "", "", false)
+	for _, tr := range old {
+		if tr.Description == "BOOKS" {
+			continue // the other issuer's card
+		}
+		if tr.AccountID == nil || *tr.AccountID != sapphire || tr.AccountName != "Sapphire" {
+			t.Errorf("Expected %s to be attached to the Sapphire account, got %v %q", tr.Description, tr.AccountID, tr.AccountName)
+		}
+	}
+}
+
+func TestEditAccountCurrency(t *testing.T) {
+	dbPath := "./test_account_currency.db"
+	defer os.Remove(dbPath)
+
+	db, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	card, err := db.AddAccount(&Account{Nickname: "Travel Card", AccountType: AccountTypeCredit, Last4: "5555", Currency: "USD"}, nil)
+	if err != nil {
+		t.Fatalf("Failed to add account: %v", err)
+	}
+
+	date := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+	home := &Transaction{AccountName: "Travel Card", AccountLast4: "5555", TransactionDate: date, Description: "GROCERIES",
+		Amount: -4200, TransactionType: "debit", StatementDate: date}
+	abroad := &Transaction{AccountName: "Travel Card", AccountLast4: "5555", TransactionDate: date, Description: "HOTEL PARIS",
+		Amount: -18000, Currency: "EUR", TransactionType: "debit", StatementDate: date}
+	if _, _, err := db.InsertTransactions([]*Transaction{home, abroad}); err != nil {
+		t.Fatalf("Failed to insert transactions: %v", err)
+	}
+
+	// The account was set up in the wrong currency; the charge abroad keeps its own
+	currency := "CAD"
+	if _, err := db.EditAccount(card, AccountEdit{Currency: &currency}); err != nil {
+		t.Fatalf("Failed to edit account: %v", err)
+	}
+	for _, tt := range []struct {
+		id   int64
+		want string
+	}{{home.ID, "CAD"}, {abroad.ID, "EUR"}} {
+		stored, err := db.GetTransaction(tt.id)
+		if err != nil || stored.Currency != tt.want {
+			t.Errorf("Expected transaction %d in %s, got %+v (err=%v)", tt.id, tt.want, stored, err)
+		}
+	}
+}
@@ -0,0 +1,502 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"money"
+)
+
+// Budget rollover options
+const (
+	BudgetRolloverNone    = "none"    // every month starts from the limit
+	BudgetRolloverSurplus = "surplus" // unspent money carries into the next month
+	BudgetRolloverFull    = "full"    // unspent money and overspending both carry over
+)
+
+// Budget health statuses
+const (
+	BudgetStatusOK     = "ok"
+	BudgetStatusAtRisk = "at_risk" // on pace to exceed the available amount by month end
+	BudgetStatusOver   = "over"    // already spent more than is available
+)
+
+// monthLayout is how budget months are written
+const monthLayout = "2006-01"
+
+// Budget is a monthly spending limit for a category and/or description patterns
+type Budget struct {
+	ID           int64            `json:"id"`
+	Name         string           `json:"name"`
+	Category     string           `json:"category,omitempty"` // transactions in this category count towards the budget
+	MonthlyLimit money.Cents      `json:"monthly_limit"`
+	Rollover     string           `json:"rollover"`
+	StartMonth   string           `json:"start_month"` // YYYY-MM; rollover is accumulated from here
+	Patterns     []*BudgetPattern `json:"patterns"`    // transactions matching any pattern count too
+	CreatedAt    time.Time        `json:"created_at"`
+}
+
+// BudgetPattern matches transactions by description or merchant name
+type BudgetPattern struct {
+	MatchType string `json:"match_type"` // "substring" or "regex"
+	Pattern   string `json:"pattern"`
+
+	re *regexp.Regexp
+}
+
+// BudgetEdit holds the budget fields to change; nil fields are left as they are
+type BudgetEdit struct {
+	Name         *string
+	Category     *string
+	MonthlyLimit *money.Cents
+	Rollover     *string
+	StartMonth   *string
+	Patterns     []*BudgetPattern // replaces all patterns when non-nil
+}
+
+// BudgetStatus is a budget's health for one month
+type BudgetStatus struct {
+	Budget           *Budget     `json:"budget"`
+	Month            string      `json:"month"`
+	Limit            money.Cents `json:"limit"`
+	Carryover        money.Cents `json:"carryover"` // from previous months, per the rollover option
+	Available        money.Cents `json:"available"` // limit plus carryover
+	Spent            money.Cents `json:"spent"`     // debits minus refunds; transfers are excluded
+	Remaining        money.Cents `json:"remaining"`
+	Projected        money.Cents `json:"projected"` // spending at month end at the current pace
+	TransactionCount int         `json:"transaction_count"`
+	DaysElapsed      int         `json:"days_elapsed"`
+	DaysInMonth      int         `json:"days_in_month"`
+	Status           string      `json:"status"`
+
+	// Currency is the base currency the limit and spending are in
+	Currency string `json:"currency"`
+	// Unconverted lists this month's spending left out because there was no exchange rate for it
+	Unconverted []*UnconvertedAmount `json:"unconverted,omitempty"`
+}
+
+// Matches reports whether the pattern applies to a description or merchant name
+func (p *BudgetPattern) Matches(tx *Transaction) bool {
+	if p.MatchType == "regex" {
+		if p.re == nil {
+			re, err := regexp.Compile("(?i)" + p.Pattern)
+			if err != nil {
+				return false
+			}
+			p.re = re
+		}
+		return p.re.MatchString(tx.Description) || (tx.MerchantName != "" && p.re.MatchString(tx.MerchantName))
+	}
+
+	pattern := strings.ToLower(p.Pattern)
+	return strings.Contains(strings.ToLower(tx.Description), pattern) ||
+		strings.Contains(strings.ToLower(tx.MerchantName), pattern)
+}
+
+// Matches reports whether a transaction counts towards the budget
+func (b *Budget) Matches(tx *Transaction) bool {
+	if b.Category != "" && tx.Category == b.Category {
+		return true
+	}
+	for _, p := range b.Patterns {
+		if p.Matches(tx) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that a budget is well-formed before it is stored
+func (b *Budget) Validate() error {
+	if strings.TrimSpace(b.Name) == "" {
+		return fmt.Errorf("budget name is required")
+	}
+	if b.MonthlyLimit <= 0 {
+		return fmt.Errorf("monthly limit must be positive")
+	}
+	switch b.Rollover {
+	case BudgetRolloverNone, BudgetRolloverSurplus, BudgetRolloverFull:
+	default:
+		return fmt.Errorf("rollover must be 'none', 'surplus' or 'full', got: %s", b.Rollover)
+	}
+	if _, err := time.Parse(monthLayout, b.StartMonth); err != nil {
+		return fmt.Errorf("invalid start month (use YYYY-MM): %s", b.StartMonth)
+	}
+	if b.Category == "" && len(b.Patterns) == 0 {
+		return fmt.Errorf("budget needs a category or at least one pattern")
+	}
+	for _, p := range b.Patterns {
+		if p.Pattern == "" {
+			return fmt.Errorf("budget patterns must not be empty")
+		}
+		if p.MatchType != "substring" && p.MatchType != "regex" {
+			return fmt.Errorf("match type must be 'substring' or 'regex', got: %s", p.MatchType)
+		}
+		if p.MatchType == "regex" {
+			if _, err := regexp.Compile("(?i)" + p.Pattern); err != nil {
+				return fmt.Errorf("invalid regex pattern: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// AddBudget stores a new budget and its patterns
+func (db *DB) AddBudget(b *Budget) (int64, error) {
+	b.Name = strings.TrimSpace(b.Name)
+	b.Category = strings.ToLower(strings.TrimSpace(b.Category))
+	if err := b.Validate(); err != nil {
+		return 0, err
+	}
+	if err := db.checkCategoryExists(b.Category); err != nil {
+		return 0, err
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO budgets (name, category, monthly_limit, rollover, start_month)
+		VALUES (?, ?, ?, ?, ?)
+	`, b.Name, nullString(b.Category), b.MonthlyLimit, b.Rollover, b.StartMonth)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return 0, fmt.Errorf("budget already exists: %s", b.Name)
+		}
+		return 0, fmt.Errorf("insert budget: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("insert budget: %w", err)
+	}
+
+	if err := insertBudgetPatterns(tx, id, b.Patterns); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	b.ID = id
+	return id, nil
+}
+
+// EditBudget applies changes to a stored budget and returns the updated budget
+func (db *DB) EditBudget(id int64, edit BudgetEdit) (*Budget, error) {
+	b, err := db.GetBudget(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if edit.Name != nil {
+		b.Name = strings.TrimSpace(*edit.Name)
+	}
+	if edit.Category != nil {
+		b.Category = strings.ToLower(strings.TrimSpace(*edit.Category))
+	}
+	if edit.MonthlyLimit != nil {
+		b.MonthlyLimit = *edit.MonthlyLimit
+	}
+	if edit.Rollover != nil {
+		b.Rollover = *edit.Rollover
+	}
+	if edit.StartMonth != nil {
+		b.StartMonth = *edit.StartMonth
+	}
+	if edit.Patterns != nil {
+		b.Patterns = edit.Patterns
+	}
+
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+	if err := db.checkCategoryExists(b.Category); err != nil {
+		return nil, err
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE budgets SET name = ?, category = ?, monthly_limit = ?, rollover = ?, start_month = ?
+		WHERE id = ?
+	`, b.Name, nullString(b.Category), b.MonthlyLimit, b.Rollover, b.StartMonth, id)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, fmt.Errorf("budget already exists: %s", b.Name)
+		}
+		return nil, fmt.Errorf("update budget: %w", err)
+	}
+
+	if edit.Patterns != nil {
+		if _, err := tx.Exec(`DELETE FROM budget_patterns WHERE budget_id = ?`, id); err != nil {
+			return nil, fmt.Errorf("delete budget patterns: %w", err)
+		}
+		if err := insertBudgetPatterns(tx, id, b.Patterns); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return b, nil
+}
+
+// DeleteBudget removes a budget and its patterns
+func (db *DB) DeleteBudget(id int64) error {
+	result, err := db.conn.Exec(`DELETE FROM budgets WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("delete budget: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("budget not found")
+	}
+
+	return nil
+}
+
+// GetBudget returns a single budget with its patterns
+func (db *DB) GetBudget(id int64) (*Budget, error) {
+	budgets, err := db.queryBudgets(" WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(budgets) == 0 {
+		return nil, fmt.Errorf("budget %d not found", id)
+	}
+	return budgets[0], nil
+}
+
+// ListBudgets returns all budgets ordered by name
+func (db *DB) ListBudgets() ([]*Budget, error) {
+	return db.queryBudgets("")
+}
+
+// BudgetStatuses computes the health of every budget in effect for the month containing month
+// asOf is the current date, used to project spending for a month still in progress
+// Limits are in baseCurrency (default USD); spending in other currencies is converted into it
+func (db *DB) BudgetStatuses(month, asOf time.Time, baseCurrency string) ([]*BudgetStatus, error) {
+	budgets, err := db.ListBudgets()
+	if err != nil {
+		return nil, err
+	}
+	converter, err := db.CurrencyConverter(baseCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	monthStart := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	monthEnd := monthStart.AddDate(0, 1, 0)
+
+	// Rollover needs every month since the earliest budget started
+	from := monthStart
+	for _, b := range budgets {
+		start, _ := time.Parse(monthLayout, b.StartMonth)
+		if start.Before(from) {
+			from = start
+		}
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT `+transactionColumns+`
+		FROM transactions
+		WHERE transaction_date >= ? AND transaction_date < ? AND id NOT IN (`+linkedTransferIDs+`)
+		ORDER BY transaction_date, id
+	`, from, monthEnd)
+	if err != nil {
+		return nil, fmt.Errorf("query budget transactions: %w", err)
+	}
+	defer rows.Close()
+
+	transactions, err := scanTransactions(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]*BudgetStatus, 0, len(budgets))
+	for _, b := range budgets {
+		// Budgets that start later don't apply to this month
+		if b.StartMonth > monthStart.Format(monthLayout) {
+			continue
+		}
+		statuses = append(statuses, budgetStatus(b, transactions, converter, monthStart, asOf))
+	}
+
+	return statuses, nil
+}
+
+// budgetStatus computes one budget's status, carrying balances forward from its start month
+func budgetStatus(b *Budget, transactions []*Transaction, converter *CurrencyConverter, monthStart, asOf time.Time) *BudgetStatus {
+	spent := make(map[string]money.Cents)
+	counts := make(map[string]int)
+	unconverted := newUnconvertedAmounts()
+	for _, tx := range transactions {
+		if !b.Matches(tx) {
+			continue
+		}
+		key := tx.TransactionDate.Format(monthLayout)
+		amount, ok := converter.Convert(tx.Amount, tx.Currency, tx.TransactionDate)
+		if !ok {
+			if key == monthStart.Format(monthLayout) {
+				unconverted.add(tx.Currency, -tx.Amount)
+			}
+			continue
+		}
+		spent[key] -= amount
+		counts[key]++
+	}
+
+	var carry money.Cents
+	start, _ := time.Parse(monthLayout, b.StartMonth)
+	for m := start; m.Before(monthStart); m = m.AddDate(0, 1, 0) {
+		left := b.MonthlyLimit + carry - spent[m.Format(monthLayout)]
+		switch b.Rollover {
+		case BudgetRolloverSurplus:
+			carry = 0
+			if left > 0 {
+				carry = left
+			}
+		case BudgetRolloverFull:
+			carry = left
+		}
+	}
+
+	key := monthStart.Format(monthLayout)
+	s := &BudgetStatus{
+		Budget:           b,
+		Month:            key,
+		Limit:            b.MonthlyLimit,
+		Carryover:        carry,
+		Spent:            spent[key],
+		TransactionCount: counts[key],
+		DaysInMonth:      monthStart.AddDate(0, 1, -1).Day(),
+		Currency:         converter.Base(),
+		Unconverted:      unconverted.list(),
+	}
+	s.Available = s.Limit + s.Carryover
+	s.Remaining = s.Available - s.Spent
+
+	// Project the current pace to month end; finished months are what they are
+	switch {
+	case asOf.Before(monthStart):
+		s.DaysElapsed = 0
+		s.Projected = s.Spent
+	case asOf.Before(monthStart.AddDate(0, 1, 0)):
+		s.DaysElapsed = asOf.Day()
+		s.Projected = s.Spent.Mul(float64(s.DaysInMonth) / float64(s.DaysElapsed))
+	default:
+		s.DaysElapsed = s.DaysInMonth
+		s.Projected = s.Spent
+	}
+
+	switch {
+	case s.Spent > s.Available:
+		s.Status = BudgetStatusOver
+	case s.Projected > s.Available:
+		s.Status = BudgetStatusAtRisk
+	default:
+		s.Status = BudgetStatusOK
+	}
+
+	return s
+}
+
+// queryBudgets loads budgets matching a WHERE clause, with their patterns
+func (db *DB) queryBudgets(where string, args ...interface{}) ([]*Budget, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, name, COALESCE(category, ''), monthly_limit, rollover, start_month, created_at
+		FROM budgets`+where+`
+		ORDER BY name
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query budgets: %w", err)
+	}
+	defer rows.Close()
+
+	var budgets []*Budget
+	byID := make(map[int64]*Budget)
+	for rows.Next() {
+		b := &Budget{Patterns: []*BudgetPattern{}}
+		if err := rows.Scan(&b.ID, &b.Name, &b.Category, &b.MonthlyLimit, &b.Rollover, &b.StartMonth, &b.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan budget: %w", err)
+		}
+		budgets = append(budgets, b)
+		byID[b.ID] = b
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate budgets: %w", err)
+	}
+	rows.Close()
+
+	patternRows, err := db.conn.Query(`SELECT budget_id, match_type, pattern FROM budget_patterns ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("query budget patterns: %w", err)
+	}
+	defer patternRows.Close()
+
+	for patternRows.Next() {
+		var budgetID int64
+		p := &BudgetPattern{}
+		if err := patternRows.Scan(&budgetID, &p.MatchType, &p.Pattern); err != nil {
+			return nil, fmt.Errorf("scan budget pattern: %w", err)
+		}
+		if b, ok := byID[budgetID]; ok {
+			b.Patterns = append(b.Patterns, p)
+		}
+	}
+
+	if err := patternRows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate budget patterns: %w", err)
+	}
+
+	return budgets, nil
+}
+
+// insertBudgetPatterns stores a budget's patterns
+func insertBudgetPatterns(tx *sql.Tx, budgetID int64, patterns []*BudgetPattern) error {
+	for _, p := range patterns {
+		_, err := tx.Exec(`
+			INSERT INTO budget_patterns (budget_id, match_type, pattern) VALUES (?, ?, ?)
+		`, budgetID, p.MatchType, p.Pattern)
+		if err != nil {
+			return fmt.Errorf("insert budget pattern: %w", err)
+		}
+	}
+	return nil
+}
+
+// checkCategoryExists returns an error if a non-empty category isn't defined
+func (db *DB) checkCategoryExists(category string) error {
+	if category == "" {
+		return nil
+	}
+
+	var id int64
+	err := db.conn.QueryRow(`SELECT id FROM categories WHERE name = ?`, category).Scan(&id)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("category not found: %s", category)
+	}
+	if err != nil {
+		return fmt.Errorf("look up category: %w", err)
+	}
+	return nil
+}
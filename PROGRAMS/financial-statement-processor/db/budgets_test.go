@@ -0,0 +1,147 @@
+package db
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"money"
+)
+
+func TestBudgetStatuses(t *testing.T) {
+	dbPath := "./test_budgets.db"
+	defer os.Remove(dbPath)
+
+	db, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	date := func(m time.Month, d int) time.Time { return time.Date(2024, m, d, 0, 0, 0, 0, time.UTC) }
+	tx := func(account, last4 string, d time.Time, description, category string, amount float64) *Transaction {
+		txType := "debit"
+		if amount > 0 {
+			txType = "credit"
+		}
+		return &Transaction{AccountName: account, AccountLast4: last4, TransactionDate: d, Description: description,
+			Category: category, Amount: money.FromFloat(amount), TransactionType: txType, StatementDate: d}
+	}
+
+	transactions := []*Transaction{
+		tx("Checking", "1111", date(9, 3), "WHOLE FOODS", "groceries", -450),
+		tx("Checking", "1111", date(10, 2), "WHOLE FOODS", "groceries", -200),
+		tx("Checking", "1111", date(10, 6), "WHOLE FOODS REFUND", "groceries", 20),
+		tx("Checking", "1111", date(10, 8), "BLUE BOTTLE COFFEE", "dining", -30),
+		tx("Checking", "1111", date(10, 9), "STARBUCKS 1234", "dining", -25),
+		tx("Checking", "1111", date(10, 9), "TRANSFER TO SAVINGS", "groceries", -100), // linked transfer, never counts
+		tx("Savings", "2222", date(10, 9), "TRANSFER FROM CHECKING", "", 100),
+	}
+	if _, _, err := db.InsertTransactions(transactions); err != nil {
+		t.Fatalf("Failed to insert transactions: %v", err)
+	}
+	if _, err := db.MatchTransfers(TransferMatchOptions{WindowDays: 3}, false); err != nil {
+		t.Fatalf("Failed to match transfers: %v", err)
+	}
+
+	if _, err := db.AddBudget(&Budget{Name: "Pets", Category: "no-such-category", MonthlyLimit: 5000, Rollover: BudgetRolloverNone, StartMonth: "2024-09"}); err == nil {
+		t.Error("Expected an error for an unknown category")
+	}
+
+	groceries := &Budget{Name: "Groceries", Category: "Groceries", MonthlyLimit: 50000, Rollover: BudgetRolloverSurplus, StartMonth: "2024-09"}
+	if _, err := db.AddBudget(groceries); err != nil {
+		t.Fatalf("Failed to add budget: %v", err)
+	}
+	coffee := &Budget{Name: "Coffee", MonthlyLimit: 6000, Rollover: BudgetRolloverNone, StartMonth: "2024-10",
+		Patterns: []*BudgetPattern{{MatchType: "substring", Pattern: "coffee"}, {MatchType: "regex", Pattern: `^starbucks\b`}}}
+	if _, err := db.AddBudget(coffee); err != nil {
+		t.Fatalf("Failed to add budget: %v", err)
+	}
+
+	statusOf := func(month time.Month, asOf time.Time) map[string]*BudgetStatus {
+		statuses, err := db.BudgetStatuses(date(month, 1), asOf, "USD")
+		if err != nil {
+			t.Fatalf("Failed to compute budget status: %v", err)
+		}
+		byName := map[string]*BudgetStatus{}
+		for _, s := range statuses {
+			byName[s.Budget.Name] = s
+		}
+		return byName
+	}
+
+	// Halfway through October: groceries carry September's $50 surplus, refund reduces spending
+	october := statusOf(10, date(10, 10))
+	g := october["Groceries"]
+	if g.Carryover != 5000 || g.Available != 55000 || g.Spent != 18000 || g.Remaining != 37000 || g.TransactionCount != 2 {
+		t.Errorf("Unexpected groceries status: %+v", g)
+	}
+	if g.Projected != 55800 || g.Status != BudgetStatusAtRisk {
+		t.Errorf("Expected groceries projected 558 and at risk, got %s %s", g.Projected, g.Status)
+	}
+	c := october["Coffee"]
+	if c.Spent != 5500 || c.Carryover != 0 || c.TransactionCount != 2 || c.Status != BudgetStatusAtRisk {
+		t.Errorf("Unexpected coffee status: %+v", c)
+	}
+
+	// Budgets don't apply before their start month
+	if _, ok := statusOf(9, date(10, 10))["Coffee"]; ok {
+		t.Error("Expected no coffee status before its start month")
+	}
+
+	// Once the month is over the projection is the actual spending
+	if c := statusOf(10, date(11, 5))["Coffee"]; c.Projected != 5500 || c.Status != BudgetStatusOK {
+		t.Errorf("Expected finished month to be ok, got %+v", c)
+	}
+
+	// Full rollover carries overspending into the next month
+	limit := money.Cents(4000)
+	rollover := BudgetRolloverFull
+	if _, err := db.EditBudget(coffee.ID, BudgetEdit{MonthlyLimit: &limit, Rollover: &rollover}); err != nil {
+		t.Fatalf("Failed to edit budget: %v", err)
+	}
+	if c := statusOf(10, date(11, 5))["Coffee"]; c.Status != BudgetStatusOver || c.Remaining != -1500 {
+		t.Errorf("Expected coffee over budget by 15, got %+v", c)
+	}
+	if c := statusOf(11, date(11, 5))["Coffee"]; c.Carryover != -1500 || c.Available != 2500 {
+		t.Errorf("Expected November to start 15 short, got %+v", c)
+	}
+
+	// Replacing the patterns changes what counts
+	if _, err := db.EditBudget(coffee.ID, BudgetEdit{Patterns: []*BudgetPattern{{MatchType: "substring", Pattern: "blue bottle"}}}); err != nil {
+		t.Fatalf("Failed to edit budget patterns: %v", err)
+	}
+	c = statusOf(10, date(11, 5))["Coffee"]
+	if c.Spent != 3000 {
+		t.Errorf("Expected only Blue Bottle to count, got %s", c.Spent)
+	}
+
+	// Spending in other currencies is converted into the base currency, or listed when it can't be
+	abroad := []*Transaction{
+		tx("Wise", "4444", date(10, 20), "BLUE BOTTLE PARIS", "dining", -10),
+		tx("Wise", "4444", date(10, 21), "BLUE BOTTLE LONDON", "dining", -8),
+	}
+	abroad[0].Currency = "EUR"
+	abroad[1].Currency = "GBP"
+	if _, _, err := db.InsertTransactions(abroad); err != nil {
+		t.Fatalf("Failed to insert transactions: %v", err)
+	}
+	if _, err := db.SetExchangeRate(&ExchangeRate{Currency: "EUR", BaseCurrency: "USD", RateDate: date(10, 1), Rate: 1.1}); err != nil {
+		t.Fatalf("Failed to set exchange rate: %v", err)
+	}
+	c = statusOf(10, date(11, 5))["Coffee"]
+	if c.Spent != 4100 || c.TransactionCount != 2 || c.Currency != "USD" ||
+		len(c.Unconverted) != 1 || c.Unconverted[0].Currency != "GBP" || c.Unconverted[0].Amount != 800 {
+		t.Errorf("Expected the euro coffee converted and the pound one unconverted, got %+v", c)
+	}
+
+	if err := db.DeleteBudget(coffee.ID); err != nil {
+		t.Fatalf("Failed to delete budget: %v", err)
+	}
+	if budgets, _ := db.ListBudgets(); len(budgets) != 1 {
+		t.Errorf("Expected 1 budget after delete, got %d", len(budgets))
+	}
+	if err := db.DeleteBudget(coffee.ID); err == nil {
+		t.Error("Expected an error deleting a missing budget")
+	}
+}
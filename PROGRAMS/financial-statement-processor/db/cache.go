@@ -0,0 +1,148 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ParseCacheKey identifies the LLM response for one page of a statement file
+type ParseCacheKey struct {
+	FileHash      string // SHA-256 of the statement file
+	PageNumber    int
+	Backend       string // LLM backend name (ollama, ollama-chat, openai or fake)
+	Model         string
+	PromptVersion int
+	TextHash      string // SHA-256 of the page text sent to the LLM, which changes with the OCR engine and DPI
+}
+
+// ParseCacheEntry is a cached raw LLM response
+type ParseCacheEntry struct {
+	FileHash      string    `json:"file_hash"`
+	PageNumber    int       `json:"page_number"`
+	Backend       string    `json:"backend"`
+	Model         string    `json:"model"`
+	PromptVersion int       `json:"prompt_version"`
+	TextHash      string    `json:"text_hash"`
+	SourceFile    string    `json:"source_file,omitempty"`
+	Response      string    `json:"response,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ParseCacheFilter selects cache entries to list or purge; empty fields match everything
+type ParseCacheFilter struct {
+	FileHash   string
+	SourceFile string
+	Model      string
+	OlderThan  *time.Time
+}
+
+// GetParseCache returns the cached LLM response for a page, if any
+func (db *DB) GetParseCache(key ParseCacheKey) (string, bool, error) {
+	var response string
+	err := db.conn.QueryRow(`
+		SELECT response FROM parse_cache
+		WHERE file_hash = ? AND page_number = ? AND backend = ? AND model = ? AND prompt_version = ? AND text_hash = ?
+	`, key.FileHash, key.PageNumber, key.Backend, key.Model, key.PromptVersion, key.TextHash).Scan(&response)
+
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("query parse cache: %w", err)
+	}
+
+	return response, true, nil
+}
+
+// PutParseCache stores the raw LLM response for a page, replacing any previous entry
+func (db *DB) PutParseCache(key ParseCacheKey, sourceFile, response string) error {
+	_, err := db.conn.Exec(`
+		INSERT OR REPLACE INTO parse_cache (
+			file_hash, page_number, backend, model, prompt_version, text_hash, source_file, response
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, key.FileHash, key.PageNumber, key.Backend, key.Model, key.PromptVersion, key.TextHash, nullString(sourceFile), response)
+
+	if err != nil {
+		return fmt.Errorf("insert parse cache: %w", err)
+	}
+
+	return nil
+}
+
+// ListParseCache returns cache entries matching the filter, newest first
+// Responses are only included when withResponses is set
+func (db *DB) ListParseCache(filter ParseCacheFilter, withResponses bool) ([]*ParseCacheEntry, error) {
+	where, args := filter.where()
+
+	response := "''"
+	if withResponses {
+		response = "response"
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT file_hash, page_number, backend, model, prompt_version, text_hash, COALESCE(source_file, ''), `+response+`, created_at
+		FROM parse_cache`+where+`
+		ORDER BY created_at DESC, file_hash, page_number
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query parse cache: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*ParseCacheEntry
+	for rows.Next() {
+		e := &ParseCacheEntry{}
+		if err := rows.Scan(&e.FileHash, &e.PageNumber, &e.Backend, &e.Model, &e.PromptVersion, &e.TextHash, &e.SourceFile, &e.Response, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan parse cache: %w", err)
+		}
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate parse cache: %w", err)
+	}
+
+	return entries, nil
+}
+
+// PurgeParseCache deletes cache entries matching the filter and returns how many were removed
+func (db *DB) PurgeParseCache(filter ParseCacheFilter) (int64, error) {
+	where, args := filter.where()
+
+	result, err := db.conn.Exec(`DELETE FROM parse_cache`+where, args...)
+	if err != nil {
+		return 0, fmt.Errorf("purge parse cache: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// where builds the WHERE clause for a cache filter
+func (f ParseCacheFilter) where() (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if f.FileHash != "" {
+		conditions = append(conditions, "file_hash = ?")
+		args = append(args, f.FileHash)
+	}
+	if f.SourceFile != "" {
+		conditions = append(conditions, "source_file = ?")
+		args = append(args, f.SourceFile)
+	}
+	if f.Model != "" {
+		conditions = append(conditions, "model = ?")
+		args = append(args, f.Model)
+	}
+	if f.OlderThan != nil {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, f.OlderThan.UTC().Format("2006-01-02 15:04:05"))
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
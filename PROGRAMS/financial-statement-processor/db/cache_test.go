@@ -0,0 +1,62 @@
+package db
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseCache(t *testing.T) {
+	dbPath := "./test_parse_cache.db"
+	defer os.Remove(dbPath)
+
+	db, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	key := ParseCacheKey{FileHash: "abc123", PageNumber: 1, Backend: "ollama", Model: "dolphin3", PromptVersion: 2, TextHash: "def456"}
+
+	if _, ok, err := db.GetParseCache(key); err != nil || ok {
+		t.Fatalf("Expected cache miss, got ok=%v err=%v", ok, err)
+	}
+
+	if err := db.PutParseCache(key, "statement.pdf", `{"transactions":[]}`); err != nil {
+		t.Fatalf("Failed to store cache entry: %v", err)
+	}
+	if err := db.PutParseCache(key, "statement.pdf", `{"transactions":[1]}`); err != nil {
+		t.Fatalf("Failed to replace cache entry: %v", err)
+	}
+
+	response, ok, err := db.GetParseCache(key)
+	if err != nil || !ok || response != `{"transactions":[1]}` {
+		t.Fatalf("Expected replaced response, got %q ok=%v err=%v", response, ok, err)
+	}
+
+	// A different prompt version is a different entry
+	other := key
+	other.PromptVersion = 3
+	if _, ok, _ := db.GetParseCache(other); ok {
+		t.Error("Expected cache miss for a different prompt version")
+	}
+	other = key
+	other.Backend = "openai"
+	if _, ok, _ := db.GetParseCache(other); ok {
+		t.Error("Expected cache miss for a different backend")
+	}
+	other = key
+	other.TextHash = "789abc"
+	if _, ok, _ := db.GetParseCache(other); ok {
+		t.Error("Expected cache miss for different page text")
+	}
+
+	entries, err := db.ListParseCache(ParseCacheFilter{SourceFile: "statement.pdf"}, true)
+	if err != nil || len(entries) != 1 || entries[0].Response == "" {
+		t.Fatalf("Expected 1 entry with response, got %+v err=%v", entries, err)
+	}
+
+	purged, err := db.PurgeParseCache(ParseCacheFilter{Model: "dolphin3"})
+	if err != nil || purged != 1 {
+		t.Fatalf("Expected 1 purged entry, got %d err=%v", purged, err)
+	}
+}
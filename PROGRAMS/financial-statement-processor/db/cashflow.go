@@ -0,0 +1,294 @@
+package db
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"money"
+)
+
+// CashFlowOptions selects the months and transactions of a cash-flow report
+type CashFlowOptions struct {
+	Months  int       // number of months in the report, ending with End; defaults to 12
+	End     time.Time // any day of the last month; zero for the current month
+	Account string    // account name substring or last 4 digits (optional)
+	Top     int       // categories and merchants listed per month; defaults to 5
+
+	// BaseCurrency is the currency amounts are reported in; defaults to USD
+	BaseCurrency string
+}
+
+// CashFlowItem is the spending of one category or merchant
+type CashFlowItem struct {
+	Name             string      `json:"name"`
+	Amount           money.Cents `json:"amount"` // debits minus refunds
+	TransactionCount int         `json:"transaction_count"`
+}
+
+// CashFlowChange compares a month with the one before it
+type CashFlowChange struct {
+	Income          money.Cents `json:"income"`
+	Expenses        money.Cents `json:"expenses"`
+	Net             money.Cents `json:"net"`
+	IncomePercent   *float64    `json:"income_percent,omitempty"`   // nil when the previous month had no income
+	ExpensesPercent *float64    `json:"expenses_percent,omitempty"` // nil when the previous month had no expenses
+}
+
+// CashFlowMonth is one month of income against expenses
+type CashFlowMonth struct {
+	Month            string          `json:"month"` // YYYY-MM
+	Income           money.Cents     `json:"income"`
+	Expenses         money.Cents     `json:"expenses"`
+	Net              money.Cents     `json:"net"`
+	SavingsRate      *float64        `json:"savings_rate,omitempty"` // net as a percentage of income; nil without income
+	TransactionCount int             `json:"transaction_count"`
+	TopCategories    []*CashFlowItem `json:"top_categories"`
+	TopMerchants     []*CashFlowItem `json:"top_merchants"`
+	Change           *CashFlowChange `json:"change,omitempty"` // against the previous month
+}
+
+// CashFlowTotals sums a cash-flow report over all its months
+type CashFlowTotals struct {
+	Income          money.Cents     `json:"income"`
+	Expenses        money.Cents     `json:"expenses"`
+	Net             money.Cents     `json:"net"`
+	SavingsRate     *float64        `json:"savings_rate,omitempty"`
+	AverageIncome   money.Cents     `json:"average_income"`
+	AverageExpenses money.Cents     `json:"average_expenses"`
+	TopCategories   []*CashFlowItem `json:"top_categories"`
+	TopMerchants    []*CashFlowItem `json:"top_merchants"`
+}
+
+// CashFlowReport is monthly income, expenses and savings over a range of months
+type CashFlowReport struct {
+	StartMonth   string           `json:"start_month"`
+	EndMonth     string           `json:"end_month"`
+	BaseCurrency string           `json:"base_currency"`
+	Months       []*CashFlowMonth `json:"months"`
+	Totals       *CashFlowTotals  `json:"totals"`
+
+	// Unconverted lists transactions left out because there was no exchange rate for them
+	Unconverted []*UnconvertedAmount `json:"unconverted,omitempty"`
+}
+
+// cashFlowAccumulator sums income and spending for a month or the whole report
+type cashFlowAccumulator struct {
+	income     money.Cents
+	count      int
+	categories map[string]*CashFlowItem
+	merchants  map[string]*CashFlowItem
+}
+
+func newCashFlowAccumulator() *cashFlowAccumulator {
+	return &cashFlowAccumulator{categories: make(map[string]*CashFlowItem), merchants: make(map[string]*CashFlowItem)}
+}
+
+// add counts a transaction as income or spending
+// Credits in a spending category are refunds and reduce that category's spending; other credits are income
+func (a *cashFlowAccumulator) add(tx *Transaction) {
+	a.count++
+	category := tx.Category
+	if category == "" {
+		category = "uncategorized"
+	}
+	if tx.Amount > 0 && (category == "income" || category == "uncategorized") {
+		a.income += tx.Amount
+		return
+	}
+
+	merchant := tx.MerchantName
+	if merchant == "" {
+		merchant = tx.Description
+	}
+	addCashFlowItem(a.categories, category, tx.Amount)
+	addCashFlowItem(a.merchants, merchant, tx.Amount)
+}
+
+// addCashFlowItem counts an amount as spending of the named item
+func addCashFlowItem(items map[string]*CashFlowItem, name string, amount money.Cents) {
+	item, ok := items[name]
+	if !ok {
+		item = &CashFlowItem{Name: name}
+		items[name] = item
+	}
+	item.Amount -= amount
+	item.TransactionCount++
+}
+
+// expenses is spending across all categories
+func (a *cashFlowAccumulator) expenses() money.Cents {
+	var total money.Cents
+	for _, item := range a.categories {
+		total += item.Amount
+	}
+	return total
+}
+
+// CashFlow reports income, expenses, net and savings rate per month, with the categories and
+// merchants that took the most money and the change from the month before
+// Linked transfers and transactions categorized as transfers are left out: moving money between
+// our own accounts is neither income nor spending
+func (db *DB) CashFlow(opts CashFlowOptions) (*CashFlowReport, error) {
+	if opts.Months <= 0 {
+		opts.Months = 12
+	}
+	if opts.Top <= 0 {
+		opts.Top = 5
+	}
+	end := opts.End
+	if end.IsZero() {
+		end = time.Now()
+	}
+	endMonth := time.Date(end.Year(), end.Month(), 1, 0, 0, 0, 0, time.UTC)
+	startMonth := endMonth.AddDate(0, 1-opts.Months, 0)
+	// The month before the report is only read for the first month's change
+	from := startMonth.AddDate(0, -1, 0)
+
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE transaction_date >= ? AND transaction_date < ? AND id NOT IN (` + linkedTransferIDs + `)
+			AND COALESCE(category, '') != 'transfer'`
+	args := []interface{}{from, endMonth.AddDate(0, 1, 0)}
+	if opts.Account != "" {
+		query += " AND (account_name LIKE ? OR account_last4 = ?)"
+		args = append(args, "%"+opts.Account+"%", opts.Account)
+	}
+	query += " ORDER BY transaction_date, id"
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query cash flow transactions: %w", err)
+	}
+	defer rows.Close()
+
+	transactions, err := scanTransactions(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	converter, err := db.CurrencyConverter(opts.BaseCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	months := make(map[string]*cashFlowAccumulator)
+	totals := newCashFlowAccumulator()
+	unconverted := newUnconvertedAmounts()
+	for _, tx := range transactions {
+		amount, ok := converter.Convert(tx.Amount, tx.Currency, tx.TransactionDate)
+		if !ok {
+			if !tx.TransactionDate.Before(startMonth) {
+				unconverted.add(tx.Currency, tx.Amount)
+			}
+			continue
+		}
+		tx.Amount = amount
+
+		key := tx.TransactionDate.Format(monthLayout)
+		if months[key] == nil {
+			months[key] = newCashFlowAccumulator()
+		}
+		months[key].add(tx)
+		if !tx.TransactionDate.Before(startMonth) {
+			totals.add(tx)
+		}
+	}
+
+	report := &CashFlowReport{
+		StartMonth:   startMonth.Format(monthLayout),
+		EndMonth:     endMonth.Format(monthLayout),
+		BaseCurrency: converter.Base(),
+		Months:       make([]*CashFlowMonth, 0, opts.Months),
+		Unconverted:  unconverted.list(),
+	}
+	previous := cashFlowMonth(from.Format(monthLayout), months[from.Format(monthLayout)], opts.Top)
+	for m := startMonth; !m.After(endMonth); m = m.AddDate(0, 1, 0) {
+		month := cashFlowMonth(m.Format(monthLayout), months[m.Format(monthLayout)], opts.Top)
+		if previous.TransactionCount > 0 {
+			month.Change = &CashFlowChange{
+				Income:          month.Income - previous.Income,
+				Expenses:        month.Expenses - previous.Expenses,
+				Net:             month.Net - previous.Net,
+				IncomePercent:   percentChange(previous.Income, month.Income),
+				ExpensesPercent: percentChange(previous.Expenses, month.Expenses),
+			}
+		}
+		report.Months = append(report.Months, month)
+		previous = month
+	}
+
+	income := totals.income
+	expenses := totals.expenses()
+	report.Totals = &CashFlowTotals{
+		Income:          income,
+		Expenses:        expenses,
+		Net:             income - expenses,
+		SavingsRate:     savingsRate(income, expenses),
+		AverageIncome:   income.Div(opts.Months),
+		AverageExpenses: expenses.Div(opts.Months),
+		TopCategories:   topCashFlowItems(totals.categories, opts.Top),
+		TopMerchants:    topCashFlowItems(totals.merchants, opts.Top),
+	}
+
+	return report, nil
+}
+
+// cashFlowMonth builds a month of the report; months without transactions are all zero
+func cashFlowMonth(key string, a *cashFlowAccumulator, top int) *CashFlowMonth {
+	if a == nil {
+		a = newCashFlowAccumulator()
+	}
+	income := a.income
+	expenses := a.expenses()
+	return &CashFlowMonth{
+		Month:            key,
+		Income:           income,
+		Expenses:         expenses,
+		Net:              income - expenses,
+		SavingsRate:      savingsRate(income, expenses),
+		TransactionCount: a.count,
+		TopCategories:    topCashFlowItems(a.categories, top),
+		TopMerchants:     topCashFlowItems(a.merchants, top),
+	}
+}
+
+// topCashFlowItems returns the n items with the most spending; items refunded in full are left out
+func topCashFlowItems(items map[string]*CashFlowItem, n int) []*CashFlowItem {
+	top := make([]*CashFlowItem, 0, len(items))
+	for _, item := range items {
+		if item.Amount > 0 {
+			top = append(top, item)
+		}
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Amount != top[j].Amount {
+			return top[i].Amount > top[j].Amount
+		}
+		return top[i].Name < top[j].Name
+	})
+	if len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
+
+// savingsRate is the share of income not spent, as a percentage
+func savingsRate(income, expenses money.Cents) *float64 {
+	if income <= 0 {
+		return nil
+	}
+	rate := math.Round((income-expenses).Float64()/income.Float64()*1000) / 10
+	return &rate
+}
+
+// percentChange is the change from before to after as a percentage of before
+func percentChange(before, after money.Cents) *float64 {
+	if before == 0 {
+		return nil
+	}
+	change := math.Round((after-before).Float64()/before.Abs().Float64()*1000) / 10
+	return &change
+}
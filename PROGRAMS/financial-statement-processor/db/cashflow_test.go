@@ -0,0 +1,94 @@
+package db
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"money"
+)
+
+func TestCashFlow(t *testing.T) {
+	dbPath := "./test_cashflow.db"
+	defer os.Remove(dbPath)
+
+	db, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	date := func(m time.Month, d int) time.Time { return time.Date(2024, m, d, 0, 0, 0, 0, time.UTC) }
+	tx := func(account, last4 string, d time.Time, description, category string, amount float64) *Transaction {
+		txType := "debit"
+		if amount > 0 {
+			txType = "credit"
+		}
+		return &Transaction{AccountName: account, AccountLast4: last4, TransactionDate: d, Description: description,
+			Amount: money.FromFloat(amount), TransactionType: txType, Category: category, StatementDate: d, SourceFile: "statement.pdf"}
+	}
+	transactions := []*Transaction{
+		// April only feeds May's change
+		tx("Checking", "1111", date(time.April, 1), "PAYROLL", "income", 3000),
+		tx("Checking", "1111", date(time.April, 5), "RENT", "housing", -1500),
+		// May
+		tx("Checking", "1111", date(time.May, 1), "PAYROLL", "income", 3000),
+		tx("Checking", "1111", date(time.May, 5), "RENT", "housing", -1500),
+		tx("Checking", "1111", date(time.May, 9), "GROCER", "groceries", -400),
+		tx("Checking", "1111", date(time.May, 12), "SHOE STORE", "shopping", -120),
+		tx("Checking", "1111", date(time.May, 20), "SHOE STORE", "shopping", 120), // refund
+		tx("Checking", "1111", date(time.May, 25), "CARD PAYMENT", "transfer", -600),
+		// June: more spending than income
+		tx("Checking", "1111", date(time.June, 1), "PAYROLL", "", 3000),
+		tx("Checking", "1111", date(time.June, 5), "RENT", "housing", -1500),
+		tx("Checking", "1111", date(time.June, 14), "AIRLINE", "travel", -2000),
+		tx("Savings", "2222", date(time.June, 30), "INTEREST", "income", 10),
+	}
+	if _, _, err := db.InsertTransactions(transactions); err != nil {
+		t.Fatalf("Failed to insert transactions: %v", err)
+	}
+
+	report, err := db.CashFlow(CashFlowOptions{Months: 3, End: date(time.June, 15), Top: 2})
+	if err != nil {
+		t.Fatalf("Failed to get cash flow: %v", err)
+	}
+	if report.StartMonth != "2024-04" || report.EndMonth != "2024-06" || len(report.Months) != 3 {
+		t.Fatalf("Unexpected report range: %s to %s, %d months", report.StartMonth, report.EndMonth, len(report.Months))
+	}
+
+	april, may, june := report.Months[0], report.Months[1], report.Months[2]
+	if april.Change != nil {
+		t.Error("Expected no change for a month without a previous month")
+	}
+	// The refund cancels the shoes and the card payment is a transfer
+	if may.Income != 300000 || may.Expenses != 190000 || may.Net != 110000 || may.SavingsRate == nil || *may.SavingsRate != 36.7 {
+		t.Errorf("Unexpected May: %+v", may)
+	}
+	if len(may.TopCategories) != 2 || may.TopCategories[0].Name != "housing" || may.TopCategories[1].Name != "groceries" {
+		t.Errorf("Unexpected May categories: %+v", may.TopCategories)
+	}
+	if may.Change == nil || may.Change.Expenses != 40000 || *may.Change.ExpensesPercent != 26.7 {
+		t.Errorf("Unexpected May change: %+v", may.Change)
+	}
+	// Uncategorized credits count as income
+	if june.Income != 301000 || june.Expenses != 350000 || june.Net != -49000 || *june.SavingsRate != -16.3 {
+		t.Errorf("Unexpected June: %+v", june)
+	}
+	if june.TopMerchants[0].Amount != 200000 || june.Change.Net != -159000 {
+		t.Errorf("Unexpected June merchants or change: %+v, %+v", june.TopMerchants[0], june.Change)
+	}
+
+	totals := report.Totals
+	if totals.Income != 901000 || totals.Expenses != 690000 || totals.AverageExpenses != 230000 || totals.TopCategories[0].Name != "housing" {
+		t.Errorf("Unexpected totals: %+v", totals)
+	}
+
+	// Account filter
+	report, err = db.CashFlow(CashFlowOptions{Months: 1, End: date(time.June, 1), Account: "Savings"})
+	if err != nil {
+		t.Fatalf("Failed to get cash flow: %v", err)
+	}
+	if report.Months[0].Income != 1000 || report.Months[0].Expenses != 0 || report.Months[0].Change != nil {
+		t.Errorf("Unexpected savings month: %+v", report.Months[0])
+	}
+}
@@ -0,0 +1,378 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"money"
+)
+
+// Category sources recorded alongside a transaction's category
+const (
+	CategorySourceRule   = "rule"
+	CategorySourceLLM    = "llm"
+	CategorySourceManual = "manual"
+)
+
+// UncategorizedFilter is the special category filter value matching transactions without a category
+const UncategorizedFilter = "uncategorized"
+
+// defaultCategories are seeded on first use so the LLM fallback has something to choose from
+var defaultCategories = []string{
+	"groceries",
+	"dining",
+	"transportation",
+	"utilities",
+	"housing",
+	"shopping",
+	"entertainment",
+	"health",
+	"insurance",
+	"subscriptions",
+	"travel",
+	"income",
+	"transfer",
+	"fees",
+	"other",
+}
+
+// Category represents a spending/income category
+type Category struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// CategoryRule maps matching transactions to a category
+// All populated criteria must match for the rule to apply
+type CategoryRule struct {
+	ID        int64        `json:"id"`
+	Category  string       `json:"category"`
+	MatchType string       `json:"match_type"` // "substring" or "regex"
+	Pattern   string       `json:"pattern"`
+	MinAmount *money.Cents `json:"min_amount,omitempty"` // compared against the absolute amount
+	MaxAmount *money.Cents `json:"max_amount,omitempty"` // compared against the absolute amount
+	Account   string       `json:"account,omitempty"`    // account name substring or last 4 digits
+	Priority  int          `json:"priority"`
+	CreatedAt time.Time    `json:"created_at"`
+
+	re *regexp.Regexp
+}
+
+// Matches reports whether the rule applies to the transaction
+func (r *CategoryRule) Matches(tx *Transaction) bool {
+	if r.Pattern != "" {
+		switch r.MatchType {
+		case "regex":
+			if r.re == nil {
+				re, err := regexp.Compile("(?i)" + r.Pattern)
+				if err != nil {
+					return false
+				}
+				r.re = re
+			}
+			if !r.re.MatchString(tx.Description) {
+				return false
+			}
+		default:
+			if !strings.Contains(strings.ToLower(tx.Description), strings.ToLower(r.Pattern)) {
+				return false
+			}
+		}
+	}
+
+	amount := tx.Amount.Abs()
+	if r.MinAmount != nil && amount < *r.MinAmount {
+		return false
+	}
+	if r.MaxAmount != nil && amount > *r.MaxAmount {
+		return false
+	}
+
+	if r.Account != "" {
+		if tx.AccountLast4 != r.Account &&
+			!strings.Contains(strings.ToLower(tx.AccountName), strings.ToLower(r.Account)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Validate checks that a rule is well-formed before it is stored
+func (r *CategoryRule) Validate() error {
+	if r.Category == "" {
+		return fmt.Errorf("category is required")
+	}
+	if r.MatchType != "substring" && r.MatchType != "regex" {
+		return fmt.Errorf("match type must be 'substring' or 'regex', got: %s", r.MatchType)
+	}
+	if r.Pattern == "" && r.MinAmount == nil && r.MaxAmount == nil && r.Account == "" {
+		return fmt.Errorf("rule must have at least one criterion (pattern, amount range or account)")
+	}
+	if r.MatchType == "regex" {
+		if _, err := regexp.Compile("(?i)" + r.Pattern); err != nil {
+			return fmt.Errorf("invalid regex pattern: %w", err)
+		}
+	}
+	if r.MinAmount != nil && r.MaxAmount != nil && *r.MinAmount > *r.MaxAmount {
+		return fmt.Errorf("min amount must not exceed max amount")
+	}
+	return nil
+}
+
+// seedCategories inserts the default categories if they don't exist
+func (db *DB) seedCategories() error {
+	for _, name := range defaultCategories {
+		if _, err := db.conn.Exec(`INSERT OR IGNORE INTO categories (name) VALUES (?)`, name); err != nil {
+			return fmt.Errorf("seed category %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// AddCategory creates a new category
+func (db *DB) AddCategory(name, description string) (int64, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return 0, fmt.Errorf("category name is required")
+	}
+
+	result, err := db.conn.Exec(
+		`INSERT INTO categories (name, description) VALUES (?, ?)`,
+		name, description,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return 0, fmt.Errorf("category already exists: %s", name)
+		}
+		return 0, fmt.Errorf("insert category: %w", err)
+	}
+
+	return result.LastInsertId()
+}
+
+// ListCategories returns all categories ordered by name
+func (db *DB) ListCategories() ([]*Category, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, name, COALESCE(description, ''), created_at
+		FROM categories
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query categories: %w", err)
+	}
+	defer rows.Close()
+
+	var categories []*Category
+	for rows.Next() {
+		c := &Category{}
+		if err := rows.Scan(&c.ID, &c.Name, &c.Description, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan category: %w", err)
+		}
+		categories = append(categories, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate categories: %w", err)
+	}
+
+	return categories, nil
+}
+
+// CategoryNames returns the names of all categories
+func (db *DB) CategoryNames() ([]string, error) {
+	categories, err := db.ListCategories()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(categories))
+	for _, c := range categories {
+		names = append(names, c.Name)
+	}
+	return names, nil
+}
+
+// AddCategoryRule stores a new categorization rule
+func (db *DB) AddCategoryRule(rule *CategoryRule) (int64, error) {
+	if err := rule.Validate(); err != nil {
+		return 0, err
+	}
+
+	var categoryID int64
+	err := db.conn.QueryRow(`SELECT id FROM categories WHERE name = ?`, strings.ToLower(rule.Category)).Scan(&categoryID)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("category not found: %s", rule.Category)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("look up category: %w", err)
+	}
+
+	result, err := db.conn.Exec(`
+		INSERT INTO category_rules (
+			category_id, match_type, pattern, min_amount, max_amount, account, priority
+		) VALUES (?, ?, ?, ?, ?, ?, ?)
+	`,
+		categoryID,
+		rule.MatchType,
+		rule.Pattern,
+		rule.MinAmount,
+		rule.MaxAmount,
+		rule.Account,
+		rule.Priority,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("insert category rule: %w", err)
+	}
+
+	return result.LastInsertId()
+}
+
+// DeleteCategoryRule removes a categorization rule
+func (db *DB) DeleteCategoryRule(id int64) error {
+	result, err := db.conn.Exec(`DELETE FROM category_rules WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("delete category rule: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("category rule not found")
+	}
+
+	return nil
+}
+
+// ListCategoryRules returns all rules in evaluation order (highest priority first)
+func (db *DB) ListCategoryRules() ([]*CategoryRule, error) {
+	rows, err := db.conn.Query(`
+		SELECT
+			r.id, c.name, r.match_type, COALESCE(r.pattern, ''),
+			r.min_amount, r.max_amount, COALESCE(r.account, ''),
+			r.priority, r.created_at
+		FROM category_rules r
+		JOIN categories c ON c.id = r.category_id
+		ORDER BY r.priority DESC, r.id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query category rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []*CategoryRule
+	for rows.Next() {
+		r := &CategoryRule{}
+		err := rows.Scan(
+			&r.ID,
+			&r.Category,
+			&r.MatchType,
+			&r.Pattern,
+			&r.MinAmount,
+			&r.MaxAmount,
+			&r.Account,
+			&r.Priority,
+			&r.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan category rule: %w", err)
+		}
+		rules = append(rules, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate category rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// ApplyCategoryRules assigns a category to each uncategorized transaction using the first matching rule
+// Returns the number of transactions that were categorized
+func (db *DB) ApplyCategoryRules(transactions []*Transaction) (int, error) {
+	rules, err := db.ListCategoryRules()
+	if err != nil {
+		return 0, err
+	}
+
+	return CategorizeByRules(rules, transactions), nil
+}
+
+// CategorizeByRules assigns categories from an already-loaded rule set
+// Transactions that already have a category are left untouched
+func CategorizeByRules(rules []*CategoryRule, transactions []*Transaction) int {
+	matched := 0
+	for _, tx := range transactions {
+		if tx.Category != "" {
+			continue
+		}
+		for _, rule := range rules {
+			if rule.Matches(tx) {
+				tx.Category = rule.Category
+				tx.CategorySource = CategorySourceRule
+				matched++
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// QueryTransactionsForCategorization returns transactions eligible for (re)categorization
+// When includeCategorized is false only uncategorized rows are returned
+// Manually categorized rows are never returned
+func (db *DB) QueryTransactionsForCategorization(includeCategorized bool) ([]*Transaction, error) {
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE COALESCE(category_source, '') != ?
+	`
+	if !includeCategorized {
+		query += " AND (category IS NULL OR category = '')"
+	}
+	query += " ORDER BY transaction_date, id"
+
+	rows, err := db.conn.Query(query, CategorySourceManual)
+	if err != nil {
+		return nil, fmt.Errorf("query transactions: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTransactions(rows)
+}
+
+// SetTransactionCategory updates the category of a stored transaction
+func (db *DB) SetTransactionCategory(id int64, category, source string) error {
+	var categoryValue, sourceValue interface{}
+	if category != "" {
+		categoryValue = category
+		sourceValue = source
+	}
+
+	result, err := db.conn.Exec(
+		`UPDATE transactions SET category = ?, category_source = ? WHERE id = ?`,
+		categoryValue, sourceValue, id,
+	)
+	if err != nil {
+		return fmt.Errorf("update transaction category: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("transaction not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"money"
+)
+
+func TestCategoryRuleMatches(t *testing.T) {
+	min := money.Cents(10000)
+	max := money.Cents(50000)
+
+	tx := &Transaction{
+		AccountName:  "Checking Account",
+		AccountLast4: "1234",
+		Description:  "WHOLE FOODS MARKET #123",
+		Amount:       -25000,
+	}
+
+	tests := []struct {
+		name string
+		rule CategoryRule
+		want bool
+	}{
+		{"substring case-insensitive", CategoryRule{MatchType: "substring", Pattern: "whole foods"}, true},
+		{"substring miss", CategoryRule{MatchType: "substring", Pattern: "safeway"}, false},
+		{"regex", CategoryRule{MatchType: "regex", Pattern: `^whole\s+foods`}, true},
+		{"regex miss", CategoryRule{MatchType: "regex", Pattern: `^market`}, false},
+		{"amount range uses absolute value", CategoryRule{MatchType: "substring", MinAmount: &min, MaxAmount: &max}, true},
+		{"amount below min", CategoryRule{MatchType: "substring", MinAmount: &max}, false},
+		{"account last4", CategoryRule{MatchType: "substring", Account: "1234"}, true},
+		{"account name", CategoryRule{MatchType: "substring", Account: "checking"}, true},
+		{"account miss", CategoryRule{MatchType: "substring", Account: "9999"}, false},
+		{"all criteria", CategoryRule{MatchType: "substring", Pattern: "foods", MinAmount: &min, Account: "1234"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.Matches(tx); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInsertTransactionsAppliesCategoryRules(t *testing.T) {
+	dbPath := "./test_categories.db"
+	defer os.Remove(dbPath)
+
+	db, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	_, err = db.AddCategoryRule(&CategoryRule{Category: "groceries", MatchType: "substring", Pattern: "whole foods"})
+	if err != nil {
+		t.Fatalf("Failed to add rule: %v", err)
+	}
+
+	date := time.Date(2024, 10, 15, 0, 0, 0, 0, time.UTC)
+	transactions := []*Transaction{
+		{AccountName: "Checking", AccountLast4: "1234", TransactionDate: date, Description: "WHOLE FOODS #12", Amount: -5234, TransactionType: "debit", StatementDate: date, SourceFile: "test.pdf"},
+		{AccountName: "Checking", AccountLast4: "1234", TransactionDate: date, Description: "SHELL OIL", Amount: -3000, TransactionType: "debit", StatementDate: date, SourceFile: "test.pdf"},
+	}
+
+	inserted, _, err := db.InsertTransactions(transactions)
+	if err != nil {
+		t.Fatalf("Failed to insert transactions: %v", err)
+	}
+	if inserted != 2 {
+		t.Fatalf("Expected 2 inserted, got %d", inserted)
+	}
+
+	groceries, err := db.QueryTransactionsWithType(date, date, "", "all", "groceries", "", false)
+	if err != nil {
+		t.Fatalf("Failed to query transactions: %v", err)
+	}
+	if len(groceries) != 1 || groceries[0].CategorySource != CategorySourceRule {
+		t.Errorf("Expected 1 rule-categorized grocery transaction, got %+v", groceries)
+	}
+
+	uncategorized, err := db.QueryTransactionsWithType(date, date, "", "all", UncategorizedFilter, "", false)
+	if err != nil {
+		t.Fatalf("Failed to query transactions: %v", err)
+	}
+	if len(uncategorized) != 1 || uncategorized[0].Description != "SHELL OIL" {
+		t.Errorf("Expected SHELL OIL to be uncategorized, got %+v", uncategorized)
+	}
+}
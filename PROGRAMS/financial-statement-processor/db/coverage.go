@@ -0,0 +1,273 @@
+package db
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Coverage statuses
+const (
+	CoverageStatusCurrent = "current"
+	CoverageStatusOverdue = "overdue" // the next statement is past its expected date plus the grace period
+)
+
+// defaultCoverageGraceDays is how long after the expected statement date an account counts as overdue;
+// statements are usually available a few days after they close
+const defaultCoverageGraceDays = 10
+
+// statementCadences are the accepted gaps between statements, shortest first
+var statementCadences = []cadence{
+	{name: CadenceMonthly, minDays: 20, maxDays: 45, months: 1},
+	{name: CadenceQuarterly, minDays: 75, maxDays: 110, months: 3},
+	{name: CadenceAnnual, minDays: 330, maxDays: 400, months: 12},
+}
+
+// StatementCoverage describes which statements of an account have been imported
+type StatementCoverage struct {
+	AccountName               string         `json:"account_name"`
+	AccountLast4              string         `json:"account_last4"`
+	Cadence                   string         `json:"cadence"` // monthly unless the statement dates show otherwise
+	Statements                int            `json:"statements"`
+	FirstStatementDate        time.Time      `json:"first_statement_date"`
+	LastStatementDate         time.Time      `json:"last_statement_date"`
+	NextExpectedDate          time.Time      `json:"next_expected_date"`
+	MonthsPresent             []string       `json:"months_present"` // YYYY-MM of each imported statement
+	Gaps                      []*CoverageGap `json:"gaps"`
+	MissingStatements         int            `json:"missing_statements"`
+	LastSuccessfulImport      *time.Time     `json:"last_successful_import,omitempty"`
+	LastImportedFile          string         `json:"last_imported_file,omitempty"`
+	FailedImportsSinceSuccess int            `json:"failed_imports_since_success"`
+	Status                    string         `json:"status"`
+	DaysOverdue               int            `json:"days_overdue"`
+}
+
+// CoverageGap is a run of missing statements between two imported ones
+type CoverageGap struct {
+	After          time.Time `json:"after"`           // statement date before the gap
+	Before         time.Time `json:"before"`          // statement date after the gap
+	Missing        int       `json:"missing"`         // number of statements missing
+	ExpectedMonths []string  `json:"expected_months"` // YYYY-MM the missing statements should have closed in
+}
+
+// CoverageOptions controls the coverage report
+type CoverageOptions struct {
+	Account   string    // account name substring or last 4 digits; empty for all accounts
+	AsOf      time.Time // date overdue statements are judged against; defaults to today
+	GraceDays int       // days past the expected date before a statement is overdue
+}
+
+// StatementCoverage reports, per account, which statements have been imported, the gaps between
+// them and whether the next one is overdue
+// Statement dates come from imported transactions and successful processing_log entries;
+// manually entered transactions are ignored
+func (db *DB) StatementCoverage(opts CoverageOptions) ([]*StatementCoverage, error) {
+	if opts.AsOf.IsZero() {
+		opts.AsOf = time.Now()
+	}
+	if opts.GraceDays <= 0 {
+		opts.GraceDays = defaultCoverageGraceDays
+	}
+
+	query := `
+		SELECT DISTINCT account_name, account_last4, date(statement_date)
+		FROM transactions
+		WHERE source_file != ?`
+	args := []interface{}{ManualSourceFile}
+	if opts.Account != "" {
+		query += " AND (account_name LIKE ? OR account_last4 = ?)"
+		args = append(args, "%"+opts.Account+"%", opts.Account)
+	}
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query statement dates: %w", err)
+	}
+	defer rows.Close()
+
+	byAccount := make(map[string]*StatementCoverage)
+	dates := make(map[string]map[string]bool)
+	var keys []string
+	for rows.Next() {
+		var name, last4, day string
+		if err := rows.Scan(&name, &last4, &day); err != nil {
+			return nil, fmt.Errorf("scan statement date: %w", err)
+		}
+		key := name + "\x00" + last4
+		if _, ok := byAccount[key]; !ok {
+			byAccount[key] = &StatementCoverage{AccountName: name, AccountLast4: last4}
+			dates[key] = make(map[string]bool)
+			keys = append(keys, key)
+		}
+		dates[key][day] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate statement dates: %w", err)
+	}
+	rows.Close()
+	sort.Strings(keys)
+
+	var coverage []*StatementCoverage
+	for _, key := range keys {
+		c := byAccount[key]
+		if err := db.addImportHistory(c, dates[key]); err != nil {
+			return nil, err
+		}
+
+		var statementDates []time.Time
+		for day := range dates[key] {
+			d, err := time.Parse("2006-01-02", day)
+			if err != nil {
+				continue
+			}
+			statementDates = append(statementDates, d)
+		}
+		if len(statementDates) == 0 {
+			continue
+		}
+		sort.Slice(statementDates, func(i, j int) bool { return statementDates[i].Before(statementDates[j]) })
+
+		analyzeCoverage(c, statementDates, opts)
+		coverage = append(coverage, c)
+	}
+
+	return coverage, nil
+}
+
+// addImportHistory fills in the account's last successful import and the failures since, and adds
+// the statement dates of successful imports that left no transactions behind
+// processing_log records the account name as spelled on the statement, so entries are matched
+// through the account's aliases
+func (db *DB) addImportHistory(c *StatementCoverage, dates map[string]bool) error {
+	names := map[string]bool{accountKey(c.AccountName): true}
+	aliasRows, err := db.conn.Query(`
+		SELECT account_aliases.name_key
+		FROM account_aliases
+		JOIN accounts ON accounts.id = account_aliases.account_id
+		WHERE accounts.nickname = ? AND accounts.last4 = ?
+	`, c.AccountName, c.AccountLast4)
+	if err != nil {
+		return fmt.Errorf("query account aliases: %w", err)
+	}
+	defer aliasRows.Close()
+	for aliasRows.Next() {
+		var nameKey string
+		if err := aliasRows.Scan(&nameKey); err != nil {
+			return fmt.Errorf("scan account alias: %w", err)
+		}
+		names[nameKey] = true
+	}
+	if err := aliasRows.Err(); err != nil {
+		return fmt.Errorf("iterate account aliases: %w", err)
+	}
+	aliasRows.Close()
+
+	rows, err := db.conn.Query(`
+		SELECT COALESCE(account_name, ''), source_file, COALESCE(date(statement_date), ''), status, processed_at
+		FROM processing_log
+		ORDER BY processed_at, id
+	`)
+	if err != nil {
+		return fmt.Errorf("query processing log: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var accountName, sourceFile, day, status string
+		var processedAt time.Time
+		if err := rows.Scan(&accountName, &sourceFile, &day, &status, &processedAt); err != nil {
+			return fmt.Errorf("scan processing log: %w", err)
+		}
+		if !names[accountKey(accountName)] {
+			continue
+		}
+		if status != "success" {
+			c.FailedImportsSinceSuccess++
+			continue
+		}
+		at := processedAt
+		c.LastSuccessfulImport = &at
+		c.LastImportedFile = sourceFile
+		c.FailedImportsSinceSuccess = 0
+		if day != "" {
+			dates[day] = true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate processing log: %w", err)
+	}
+
+	return nil
+}
+
+// analyzeCoverage works out the cadence, gaps and overdue status from an account's sorted statement dates
+func analyzeCoverage(c *StatementCoverage, statementDates []time.Time, opts CoverageOptions) {
+	first, last := statementDates[0], statementDates[len(statementDates)-1]
+	c.Statements = len(statementDates)
+	c.FirstStatementDate = first
+	c.LastStatementDate = last
+
+	c.MonthsPresent = []string{}
+	seen := make(map[string]bool)
+	for _, d := range statementDates {
+		month := d.Format("2006-01")
+		if !seen[month] {
+			seen[month] = true
+			c.MonthsPresent = append(c.MonthsPresent, month)
+		}
+	}
+
+	// The cadence is the shortest one that fits the smallest gaps between statements; missing
+	// statements only ever make gaps longer
+	period := statementCadences[0]
+	if len(statementDates) >= 2 {
+		shortest := math.MaxFloat64
+		for i := 1; i < len(statementDates); i++ {
+			shortest = math.Min(shortest, statementDates[i].Sub(statementDates[i-1]).Hours()/24)
+		}
+		for _, sc := range statementCadences {
+			period = sc
+			if shortest <= sc.maxDays {
+				break
+			}
+		}
+	}
+	c.Cadence = period.name
+	periodDays := float64(period.months) * 365.25 / 12
+
+	c.Gaps = []*CoverageGap{}
+	for i := 1; i < len(statementDates); i++ {
+		after, before := statementDates[i-1], statementDates[i]
+		missing := int(math.Round(before.Sub(after).Hours()/24/periodDays)) - 1
+		if missing < 1 {
+			continue
+		}
+		gap := &CoverageGap{After: after, Before: before, Missing: missing, ExpectedMonths: []string{}}
+		for k := 1; k <= missing; k++ {
+			gap.ExpectedMonths = append(gap.ExpectedMonths, addMonthsClamped(after, k*period.months).Format("2006-01"))
+		}
+		c.Gaps = append(c.Gaps, gap)
+		c.MissingStatements += missing
+	}
+
+	c.NextExpectedDate = addMonthsClamped(last, period.months)
+	c.Status = CoverageStatusCurrent
+	due := c.NextExpectedDate.AddDate(0, 0, opts.GraceDays)
+	if opts.AsOf.After(due) {
+		c.Status = CoverageStatusOverdue
+		c.DaysOverdue = int(opts.AsOf.Sub(c.NextExpectedDate).Hours() / 24)
+	}
+}
+
+// addMonthsClamped adds months to a date, clamping the day to the end of shorter months
+// (Jan 31 + 1 month is Feb 29 in 2024 rather than Mar 2)
+func addMonthsClamped(d time.Time, months int) time.Time {
+	firstOfMonth := time.Date(d.Year(), d.Month(), 1, 0, 0, 0, 0, d.Location()).AddDate(0, months, 0)
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	day := d.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(firstOfMonth.Year(), firstOfMonth.Month(), day, 0, 0, 0, 0, d.Location())
+}
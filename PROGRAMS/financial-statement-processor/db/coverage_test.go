@@ -0,0 +1,85 @@
+package db
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestStatementCoverage(t *testing.T) {
+	dbPath := "./test_coverage.db"
+	defer os.Remove(dbPath)
+
+	db, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	date := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+	var transactions []*Transaction
+	statement := func(account, last4 string, d time.Time) {
+		transactions = append(transactions, &Transaction{AccountName: account, AccountLast4: last4, TransactionDate: d.AddDate(0, 0, -3),
+			Description: "COFFEE", Amount: -400, TransactionType: "debit", StatementDate: d, SourceFile: account + d.Format("_2006_01.pdf")})
+	}
+
+	// Monthly card statements closing at month end, missing March and April
+	for _, m := range []time.Month{time.January, time.February, time.May, time.June} {
+		statement("Visa", "2222", date(2024, m+1, 0))
+	}
+	// Quarterly brokerage statements, up to date
+	for _, m := range []time.Month{time.January, time.April, time.July} {
+		statement("Brokerage", "9999", date(2024, m, 1))
+	}
+	if _, _, err := db.InsertTransactions(transactions); err != nil {
+		t.Fatalf("Failed to insert transactions: %v", err)
+	}
+
+	// A manual cash entry isn't a statement
+	if _, err := db.AddManualTransaction(&Transaction{AccountName: "Cash", TransactionDate: date(2024, 7, 4), Description: "Market", Amount: -1000}); err != nil {
+		t.Fatalf("Failed to add transaction: %v", err)
+	}
+
+	june := date(2024, 6, 30)
+	if err := db.LogProcessing(&ProcessingLog{SourceFile: "visa_2024_06.pdf", StatementDate: &june, AccountName: "Visa", Status: "success"}); err != nil {
+		t.Fatalf("Failed to log processing: %v", err)
+	}
+	if err := db.LogProcessing(&ProcessingLog{SourceFile: "visa_2024_07.pdf", AccountName: "Visa", Status: "parse_error"}); err != nil {
+		t.Fatalf("Failed to log processing: %v", err)
+	}
+
+	coverage, err := db.StatementCoverage(CoverageOptions{AsOf: date(2024, 8, 20)})
+	if err != nil {
+		t.Fatalf("Failed to get coverage: %v", err)
+	}
+	if len(coverage) != 2 {
+		t.Fatalf("Expected 2 accounts (manual entries ignored), got %d", len(coverage))
+	}
+
+	brokerage, visa := coverage[0], coverage[1]
+	if brokerage.Cadence != CadenceQuarterly || len(brokerage.Gaps) != 0 || brokerage.Status != CoverageStatusCurrent {
+		t.Errorf("Unexpected brokerage coverage: %+v", brokerage)
+	}
+	if !brokerage.NextExpectedDate.Equal(date(2024, 10, 1)) {
+		t.Errorf("Expected the next brokerage statement on 2024-10-01, got %v", brokerage.NextExpectedDate)
+	}
+
+	if visa.Cadence != CadenceMonthly || visa.Statements != 4 || visa.MissingStatements != 2 || len(visa.Gaps) != 1 {
+		t.Fatalf("Unexpected visa coverage: %+v", visa)
+	}
+	if gap := visa.Gaps[0]; len(gap.ExpectedMonths) != 2 || gap.ExpectedMonths[0] != "2024-03" || gap.ExpectedMonths[1] != "2024-04" {
+		t.Errorf("Expected March and April to be missing, got %+v", gap)
+	}
+	if visa.Status != CoverageStatusOverdue || visa.DaysOverdue != 21 {
+		t.Errorf("Expected the July visa statement to be 21 days overdue, got %s (%d days)", visa.Status, visa.DaysOverdue)
+	}
+	if visa.LastImportedFile != "visa_2024_06.pdf" || visa.LastSuccessfulImport == nil || visa.FailedImportsSinceSuccess != 1 {
+		t.Errorf("Unexpected visa import history: %+v", visa)
+	}
+
+	// Within the grace period the visa account is current
+	coverage, err = db.StatementCoverage(CoverageOptions{Account: "2222", AsOf: date(2024, 8, 5)})
+	if err != nil || len(coverage) != 1 || coverage[0].Status != CoverageStatusCurrent {
+		t.Errorf("Expected the visa account to be current on 2024-08-05, got %+v (err=%v)", coverage, err)
+	}
+}
@@ -0,0 +1,467 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"money"
+)
+
+// Actions recorded in transactions.manual_changes
+const (
+	ManualActionAdded  = "added"
+	ManualActionEdited = "edited"
+	ManualActionSplit  = "split"
+)
+
+// ManualSourceFile is the source_file of transactions entered by hand
+const ManualSourceFile = "manual"
+
+// ManualChange is one audit entry for a change made by hand rather than by parsing a statement
+type ManualChange struct {
+	Action  string         `json:"action"`
+	At      time.Time      `json:"at"`
+	Changes []*FieldChange `json:"changes,omitempty"`
+}
+
+// FieldChange records a field's value before and after a manual change
+type FieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// TransactionEdit holds the transaction fields to change; nil fields are left as they are
+type TransactionEdit struct {
+	TransactionDate *time.Time
+	Description     *string
+	Amount          *money.Cents
+	Currency        *string
+	TransactionType *string
+	Category        *string
+	MerchantName    *string
+	Notes           *string
+}
+
+// SplitLine is one part of a split transaction
+// Empty fields are copied from the original transaction
+type SplitLine struct {
+	Amount      money.Cents `json:"amount"`
+	Description string      `json:"description,omitempty"`
+	Category    string      `json:"category,omitempty"`
+	Notes       string      `json:"notes,omitempty"`
+}
+
+// GetTransaction returns a single stored transaction
+func (db *DB) GetTransaction(id int64) (*Transaction, error) {
+	rows, err := db.conn.Query(`SELECT `+transactionColumns+` FROM transactions WHERE id = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("query transaction: %w", err)
+	}
+	defer rows.Close()
+
+	transactions, err := scanTransactions(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(transactions) == 0 {
+		return nil, fmt.Errorf("transaction %d not found", id)
+	}
+	return transactions[0], nil
+}
+
+// AddManualTransaction stores a transaction entered by hand, such as a cash purchase
+// The type is derived from the amount's sign when empty; merchant and category rules apply
+// as they do for parsed transactions unless a category is given
+func (db *DB) AddManualTransaction(t *Transaction) (int64, error) {
+	if strings.TrimSpace(t.AccountName) == "" {
+		return 0, fmt.Errorf("account name is required")
+	}
+	if t.TransactionDate.IsZero() {
+		return 0, fmt.Errorf("transaction date is required")
+	}
+	if err := validateManualFields(t); err != nil {
+		return 0, err
+	}
+
+	t.Category = strings.ToLower(strings.TrimSpace(t.Category))
+	if err := db.checkCategoryExists(t.Category); err != nil {
+		return 0, err
+	}
+	if t.Category != "" {
+		t.CategorySource = CategorySourceManual
+	}
+	if t.StatementDate.IsZero() {
+		t.StatementDate = t.TransactionDate
+	}
+	if t.SourceFile == "" {
+		t.SourceFile = ManualSourceFile
+	}
+
+	if _, err := db.AssignAccounts([]*Transaction{t}); err != nil {
+		return 0, fmt.Errorf("assign account: %w", err)
+	}
+	if _, err := db.ApplyMerchantRules([]*Transaction{t}); err != nil {
+		return 0, fmt.Errorf("apply merchant rules: %w", err)
+	}
+	if _, err := db.ApplyCategoryRules([]*Transaction{t}); err != nil {
+		return 0, fmt.Errorf("apply category rules: %w", err)
+	}
+
+	t.ManualChanges = []*ManualChange{{Action: ManualActionAdded, At: auditTime()}}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	id, err := insertManualTransaction(tx, t)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	t.ID = id
+	return id, nil
+}
+
+// EditTransaction corrects a stored transaction and records the changed fields in its audit trail
+// Changing the description re-derives the merchant name unless a merchant name is given too
+func (db *DB) EditTransaction(id int64, edit TransactionEdit) (*Transaction, error) {
+	t, err := db.GetTransaction(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []*FieldChange
+	record := func(field, from, to string) {
+		if from != to {
+			changes = append(changes, &FieldChange{Field: field, From: from, To: to})
+		}
+	}
+
+	if edit.TransactionDate != nil {
+		record("transaction_date", t.TransactionDate.Format("2006-01-02"), edit.TransactionDate.Format("2006-01-02"))
+		t.TransactionDate = *edit.TransactionDate
+	}
+	if edit.Description != nil {
+		description := strings.TrimSpace(*edit.Description)
+		record("description", t.Description, description)
+		if description != t.Description && edit.MerchantName == nil {
+			previous := t.MerchantName
+			t.Description = description
+			t.MerchantName = ""
+			if _, err := db.ApplyMerchantRules([]*Transaction{t}); err != nil {
+				return nil, fmt.Errorf("apply merchant rules: %w", err)
+			}
+			record("merchant_name", previous, t.MerchantName)
+		}
+		t.Description = description
+	}
+	if edit.MerchantName != nil {
+		merchant := strings.TrimSpace(*edit.MerchantName)
+		record("merchant_name", t.MerchantName, merchant)
+		t.MerchantName = merchant
+	}
+	if edit.Amount != nil {
+		record("amount", t.Amount.String(), edit.Amount.String())
+		t.Amount = *edit.Amount
+		if edit.TransactionType == nil {
+			previous := t.TransactionType
+			t.TransactionType = TransactionTypeFor(t.Amount)
+			record("transaction_type", previous, t.TransactionType)
+		}
+	}
+	if edit.Currency != nil {
+		currency := strings.ToUpper(strings.TrimSpace(*edit.Currency))
+		// An empty currency puts the transaction back in its account's currency
+		if currency == "" {
+			if t.AccountID == nil {
+				return nil, fmt.Errorf("currency is required for a transaction without an account")
+			}
+			account, err := db.GetAccount(*t.AccountID)
+			if err != nil {
+				return nil, err
+			}
+			currency = account.Currency
+		}
+		record("currency", t.Currency, currency)
+		t.Currency = currency
+	}
+	if edit.TransactionType != nil {
+		record("transaction_type", t.TransactionType, *edit.TransactionType)
+		t.TransactionType = *edit.TransactionType
+	}
+	if edit.Category != nil {
+		category := strings.ToLower(strings.TrimSpace(*edit.Category))
+		if err := db.checkCategoryExists(category); err != nil {
+			return nil, err
+		}
+		record("category", t.Category, category)
+		t.Category = category
+		t.CategorySource = CategorySourceManual
+		if category == "" {
+			t.CategorySource = ""
+		}
+	}
+	if edit.Notes != nil {
+		record("notes", t.Notes, *edit.Notes)
+		t.Notes = *edit.Notes
+	}
+
+	if err := validateManualFields(t); err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		return t, nil
+	}
+
+	t.ManualChanges = append(t.ManualChanges, &ManualChange{Action: ManualActionEdited, At: auditTime(), Changes: changes})
+	audit, err := json.Marshal(t.ManualChanges)
+	if err != nil {
+		return nil, fmt.Errorf("encode manual changes: %w", err)
+	}
+
+	_, err = db.conn.Exec(`
+		UPDATE transactions
+		SET transaction_date = ?, description = ?, amount = ?, currency = ?, transaction_type = ?,
+			category = ?, category_source = ?, merchant_name = ?, notes = ?, manual_changes = ?
+		WHERE id = ?
+	`, t.TransactionDate, t.Description, t.Amount, t.Currency, t.TransactionType,
+		nullString(t.Category), nullString(t.CategorySource), nullString(t.MerchantName),
+		nullString(t.Notes), string(audit), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, fmt.Errorf("an identical transaction already exists")
+		}
+		return nil, fmt.Errorf("update transaction: %w", err)
+	}
+
+	return db.GetTransaction(id)
+}
+
+// DeleteTransaction removes a stored transaction; a transfer it was part of is removed with it
+// Re-importing the statement it came from will insert it again
+func (db *DB) DeleteTransaction(id int64) error {
+	result, err := db.conn.Exec(`DELETE FROM transactions WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("delete transaction: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("transaction not found")
+	}
+
+	return nil
+}
+
+// SplitTransaction divides a transaction into lines whose amounts add up to the original
+// The first line replaces the original row (keeping its ID); the others are inserted with
+// split_from_id pointing at it and inherit its account, dates, source and merchant
+func (db *DB) SplitTransaction(id int64, lines []*SplitLine) ([]*Transaction, error) {
+	if len(lines) < 2 {
+		return nil, fmt.Errorf("a split needs at least two lines")
+	}
+
+	original, err := db.GetTransaction(id)
+	if err != nil {
+		return nil, err
+	}
+	if original.TransferID != nil {
+		return nil, fmt.Errorf("transaction %d is part of transfer %d; unlink it before splitting", id, *original.TransferID)
+	}
+
+	var total money.Cents
+	for i, line := range lines {
+		if line.Amount == 0 || (line.Amount > 0) != (original.Amount > 0) {
+			return nil, fmt.Errorf("line %d: amount must be non-zero with the same sign as the original (%s)", i+1, original.Amount)
+		}
+		line.Category = strings.ToLower(strings.TrimSpace(line.Category))
+		if err := db.checkCategoryExists(line.Category); err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		total += line.Amount
+	}
+	if total != original.Amount {
+		return nil, fmt.Errorf("split lines add up to %s, not the original %s", total, original.Amount)
+	}
+
+	now := auditTime()
+	parts := make([]*Transaction, len(lines))
+	for i, line := range lines {
+		part := *original
+		part.ID = 0
+		part.TransferID = nil
+		part.ManualChanges = nil
+		part.Amount = line.Amount
+		if line.Description != "" {
+			part.Description = strings.TrimSpace(line.Description)
+		}
+		if line.Category != "" {
+			part.Category = line.Category
+			part.CategorySource = CategorySourceManual
+		}
+		if line.Notes != "" {
+			part.Notes = line.Notes
+		}
+		parts[i] = &part
+	}
+
+	// The first line keeps the original row; its audit trail records what changed
+	first := parts[0]
+	var changes []*FieldChange
+	for _, c := range []*FieldChange{
+		{Field: "amount", From: original.Amount.String(), To: first.Amount.String()},
+		{Field: "description", From: original.Description, To: first.Description},
+		{Field: "category", From: original.Category, To: first.Category},
+		{Field: "notes", From: original.Notes, To: first.Notes},
+	} {
+		if c.From != c.To {
+			changes = append(changes, c)
+		}
+	}
+	first.ID = id
+	first.SplitFromID = original.SplitFromID
+	first.ManualChanges = append(original.ManualChanges, &ManualChange{Action: ManualActionSplit, At: now, Changes: changes})
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	audit, err := json.Marshal(first.ManualChanges)
+	if err != nil {
+		return nil, fmt.Errorf("encode manual changes: %w", err)
+	}
+	_, err = tx.Exec(`
+		UPDATE transactions
+		SET description = ?, amount = ?, category = ?, category_source = ?, notes = ?, manual_changes = ?
+		WHERE id = ?
+	`, first.Description, first.Amount, nullString(first.Category), nullString(first.CategorySource),
+		nullString(first.Notes), string(audit), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return nil, fmt.Errorf("split lines must differ from each other in description or amount")
+		}
+		return nil, fmt.Errorf("update transaction: %w", err)
+	}
+
+	ids := []int64{id}
+	for _, part := range parts[1:] {
+		splitFrom := id
+		part.SplitFromID = &splitFrom
+		part.ManualChanges = []*ManualChange{{
+			Action:  ManualActionSplit,
+			At:      now,
+			Changes: []*FieldChange{{Field: "split_from_id", To: fmt.Sprintf("%d", id)}},
+		}}
+		partID, err := insertManualTransaction(tx, part)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, partID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	split := make([]*Transaction, 0, len(ids))
+	for _, partID := range ids {
+		t, err := db.GetTransaction(partID)
+		if err != nil {
+			return nil, err
+		}
+		split = append(split, t)
+	}
+	return split, nil
+}
+
+// insertManualTransaction inserts a hand-entered or split transaction with its audit trail
+func insertManualTransaction(tx *sql.Tx, t *Transaction) (int64, error) {
+	audit, err := json.Marshal(t.ManualChanges)
+	if err != nil {
+		return 0, fmt.Errorf("encode manual changes: %w", err)
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO transactions (
+			account_id, account_name, account_last4, transaction_date, post_date,
+			description, amount, transaction_type, balance,
+			statement_date, source_file, category, category_source, merchant_name,
+			notes, split_from_id, manual_changes, currency
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		t.AccountID,
+		t.AccountName,
+		t.AccountLast4,
+		t.TransactionDate,
+		t.PostDate,
+		t.Description,
+		t.Amount,
+		t.TransactionType,
+		t.Balance,
+		t.StatementDate,
+		t.SourceFile,
+		nullString(t.Category),
+		nullString(t.CategorySource),
+		nullString(t.MerchantName),
+		nullString(t.Notes),
+		t.SplitFromID,
+		string(audit),
+		t.Currency,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return 0, fmt.Errorf("an identical transaction already exists (%s %s %s)",
+				t.TransactionDate.Format("2006-01-02"), t.Description, t.Amount)
+		}
+		return 0, fmt.Errorf("insert transaction: %w", err)
+	}
+
+	return result.LastInsertId()
+}
+
+// validateManualFields checks the fields a manual add or edit can set, deriving a missing type
+func validateManualFields(t *Transaction) error {
+	t.Description = strings.TrimSpace(t.Description)
+	if t.Description == "" {
+		return fmt.Errorf("description is required")
+	}
+	if t.Amount == 0 {
+		return fmt.Errorf("amount must be non-zero")
+	}
+	if t.TransactionType == "" {
+		t.TransactionType = TransactionTypeFor(t.Amount)
+	}
+	if t.TransactionType != "debit" && t.TransactionType != "credit" {
+		return fmt.Errorf("transaction type must be 'debit' or 'credit', got: %s", t.TransactionType)
+	}
+	if t.SignMismatch() {
+		return fmt.Errorf("amount %s contradicts transaction type %s (debits are negative, credits positive)", t.Amount, t.TransactionType)
+	}
+	// Without a currency a new transaction takes its account's
+	t.Currency = strings.ToUpper(strings.TrimSpace(t.Currency))
+	if t.Currency != "" && !currencyCode.MatchString(t.Currency) {
+		return fmt.Errorf("invalid currency %q (use a 3-letter code such as USD)", t.Currency)
+	}
+	return nil
+}
+
+// auditTime is the timestamp recorded for a manual change
+func auditTime() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
@@ -0,0 +1,108 @@
+package db
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"money"
+)
+
+func TestManualTransactions(t *testing.T) {
+	dbPath := "./test_manual.db"
+	defer os.Remove(dbPath)
+
+	db, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	date := time.Date(2024, 10, 12, 0, 0, 0, 0, time.UTC)
+
+	if _, err := db.AddManualTransaction(&Transaction{AccountName: "Cash", TransactionDate: date, Description: "Refund", Amount: 1000, TransactionType: "debit"}); err == nil {
+		t.Error("Expected an error for a positive debit")
+	}
+
+	cash := &Transaction{AccountName: "Cash", TransactionDate: date, Description: "FARMERS MARKET", Amount: -4000, Category: "Groceries"}
+	id, err := db.AddManualTransaction(cash)
+	if err != nil {
+		t.Fatalf("Failed to add transaction: %v", err)
+	}
+
+	stored, err := db.GetTransaction(id)
+	if err != nil {
+		t.Fatalf("Failed to get transaction: %v", err)
+	}
+	if stored.TransactionType != "debit" || stored.SourceFile != ManualSourceFile || stored.Category != "groceries" ||
+		stored.CategorySource != CategorySourceManual || stored.MerchantName != "Farmers Market" {
+		t.Errorf("Unexpected stored transaction: %+v", stored)
+	}
+	if len(stored.ManualChanges) != 1 || stored.ManualChanges[0].Action != ManualActionAdded {
+		t.Errorf("Expected an 'added' audit entry, got %+v", stored.ManualChanges)
+	}
+
+	if _, err := db.AddManualTransaction(&Transaction{AccountName: "Cash", TransactionDate: date, Description: "FARMERS MARKET", Amount: -4000}); err == nil {
+		t.Error("Expected an error adding an identical transaction")
+	}
+
+	amount := money.Cents(-4500)
+	notes := "corrected from receipt"
+	edited, err := db.EditTransaction(id, TransactionEdit{Amount: &amount, Notes: &notes})
+	if err != nil {
+		t.Fatalf("Failed to edit transaction: %v", err)
+	}
+	if edited.Amount != -4500 || edited.Notes != notes || len(edited.ManualChanges) != 2 {
+		t.Fatalf("Unexpected edited transaction: %+v", edited)
+	}
+	audit := edited.ManualChanges[1]
+	if audit.Action != ManualActionEdited || len(audit.Changes) != 2 || audit.Changes[0].Field != "amount" ||
+		audit.Changes[0].From != "-40.00" || audit.Changes[0].To != "-45.00" {
+		t.Errorf("Unexpected edit audit entry: %+v", audit)
+	}
+
+	// An empty currency puts the transaction back in its account's
+	euro, none := "eur", ""
+	if edited, err = db.EditTransaction(id, TransactionEdit{Currency: &euro}); err != nil || edited.Currency != "EUR" {
+		t.Fatalf("Expected the currency to change to EUR, got %+v (err=%v)", edited, err)
+	}
+	if edited, err = db.EditTransaction(id, TransactionEdit{Currency: &none}); err != nil || edited.Currency != "USD" {
+		t.Errorf("Expected an empty currency to restore the account's USD, got %+v (err=%v)", edited, err)
+	}
+
+	if _, err := db.SplitTransaction(id, []*SplitLine{{Amount: -3000}, {Amount: -1000}}); err == nil {
+		t.Error("Expected an error when the lines don't add up to the original")
+	}
+	if _, err := db.SplitTransaction(id, []*SplitLine{{Amount: -5000}, {Amount: 500}}); err == nil {
+		t.Error("Expected an error for a line with the wrong sign")
+	}
+
+	split, err := db.SplitTransaction(id, []*SplitLine{
+		{Amount: -3000, Category: "groceries"},
+		{Amount: -1500, Description: "Flowers", Category: "shopping", Notes: "birthday"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to split transaction: %v", err)
+	}
+	if len(split) != 2 || split[0].ID != id || split[0].Amount != -3000 || split[0].Description != "FARMERS MARKET" {
+		t.Fatalf("Unexpected first split line: %+v", split[0])
+	}
+	flowers := split[1]
+	if flowers.SplitFromID == nil || *flowers.SplitFromID != id || flowers.Amount != -1500 || flowers.Category != "shopping" ||
+		flowers.Notes != "birthday" || flowers.AccountName != "Cash" || flowers.MerchantName != "Farmers Market" {
+		t.Errorf("Unexpected second split line: %+v", flowers)
+	}
+	if last := split[0].ManualChanges[len(split[0].ManualChanges)-1]; last.Action != ManualActionSplit {
+		t.Errorf("Expected a 'split' audit entry, got %+v", last)
+	}
+
+	if err := db.DeleteTransaction(flowers.ID); err != nil {
+		t.Fatalf("Failed to delete transaction: %v", err)
+	}
+	if _, err := db.GetTransaction(flowers.ID); err == nil {
+		t.Error("Expected deleted transaction to be gone")
+	}
+	if err := db.DeleteTransaction(flowers.ID); err == nil {
+		t.Error("Expected an error deleting a missing transaction")
+	}
+}
@@ -0,0 +1,316 @@
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"money"
+)
+
+// processorPrefix matches card processor and POS prefixes put in front of the merchant,
+// e.g. "SQ *COFFEE SHOP", "TST* PIZZA PLACE" or "PAYPAL *SELLER"
+var processorPrefix = regexp.MustCompile(`^(?:(?:SQ|TST|SP|PY|PP|PAYPAL|IC|DD|BT|CKO|FS|WPY|GOOGLE|LS|EB) ?\* ?|POS (?:PURCHASE |DEBIT )?|DEBIT CARD PURCHASE |CHECKCARD |PURCHASE )`)
+
+// referenceSuffix matches "*REF123" style order references, e.g. "AMZN MKTP US*2K4LM"
+var referenceSuffix = regexp.MustCompile(`\*\S*`)
+
+// usStates are the two-letter codes stripped from the end of a description with the city before them
+var usStates = map[string]bool{
+	"AL": true, "AK": true, "AZ": true, "AR": true, "CA": true, "CO": true, "CT": true, "DE": true,
+	"DC": true, "FL": true, "GA": true, "HI": true, "ID": true, "IL": true, "IN": true, "IA": true,
+	"KS": true, "KY": true, "LA": true, "ME": true, "MD": true, "MA": true, "MI": true, "MN": true,
+	"MS": true, "MO": true, "MT": true, "NE": true, "NV": true, "NH": true, "NJ": true, "NM": true,
+	"NY": true, "NC": true, "ND": true, "OH": true, "OK": true, "OR": true, "PA": true, "RI": true,
+	"SC": true, "SD": true, "TN": true, "TX": true, "UT": true, "VT": true, "VA": true, "WA": true,
+	"WV": true, "WI": true, "WY": true,
+}
+
+// cityPrefixes start two-word city names ("SAN JOSE", "NEW YORK") so both words are stripped
+var cityPrefixes = map[string]bool{
+	"SAN": true, "SANTA": true, "LOS": true, "LAS": true, "NEW": true, "ST": true, "FORT": true,
+	"FT": true, "EL": true, "SALT": true, "PALO": true, "CEDAR": true, "GRAND": true,
+}
+
+// MerchantRule maps descriptions matching a pattern to a canonical merchant name
+type MerchantRule struct {
+	ID        int64     `json:"id"`
+	Merchant  string    `json:"merchant"`
+	MatchType string    `json:"match_type"` // "substring" or "regex"
+	Pattern   string    `json:"pattern"`
+	Priority  int       `json:"priority"`
+	CreatedAt time.Time `json:"created_at"`
+
+	re *regexp.Regexp
+}
+
+// Matches reports whether the rule applies to a raw description
+func (r *MerchantRule) Matches(description string) bool {
+	if r.MatchType == "regex" {
+		if r.re == nil {
+			re, err := regexp.Compile("(?i)" + r.Pattern)
+			if err != nil {
+				return false
+			}
+			r.re = re
+		}
+		return r.re.MatchString(description)
+	}
+	return strings.Contains(strings.ToLower(description), strings.ToLower(r.Pattern))
+}
+
+// Validate checks that a rule is well-formed before it is stored
+func (r *MerchantRule) Validate() error {
+	if strings.TrimSpace(r.Merchant) == "" {
+		return fmt.Errorf("merchant name is required")
+	}
+	if r.Pattern == "" {
+		return fmt.Errorf("pattern is required")
+	}
+	if r.MatchType != "substring" && r.MatchType != "regex" {
+		return fmt.Errorf("match type must be 'substring' or 'regex', got: %s", r.MatchType)
+	}
+	if r.MatchType == "regex" {
+		if _, err := regexp.Compile("(?i)" + r.Pattern); err != nil {
+			return fmt.Errorf("invalid regex pattern: %w", err)
+		}
+	}
+	return nil
+}
+
+// CleanMerchantName derives a merchant name from a raw description by stripping card processor
+// prefixes, order references, store numbers and a trailing "CITY ST" location
+// "SQ *COFFEE SHOP 1234 SEATTLE WA" becomes "Coffee Shop"
+func CleanMerchantName(description string) string {
+	s := strings.ToUpper(strings.Join(strings.Fields(description), " "))
+	s = processorPrefix.ReplaceAllString(s, "")
+	s = referenceSuffix.ReplaceAllString(s, " ")
+
+	words := strings.Fields(s)
+
+	// Everything from the first store number, phone number or date on is location or reference
+	for i, w := range words {
+		if i > 0 && (strings.IndexFunc(w, unicode.IsDigit) >= 0 || strings.HasPrefix(w, "#")) {
+			words = words[:i]
+			break
+		}
+	}
+
+	// Drop a trailing state code and the city before it, as long as a name is left
+	if n := len(words); n >= 3 && usStates[words[n-1]] {
+		cut := n - 2
+		if cut >= 2 && cityPrefixes[words[cut-1]] {
+			cut--
+		}
+		words = words[:cut]
+	}
+
+	for i, w := range words {
+		words[i] = titleWord(strings.Trim(w, "-,.#"))
+	}
+
+	return strings.Join(strings.Fields(strings.Join(words, " ")), " ")
+}
+
+// titleWord capitalizes the first letter of each hyphenated part of a word and lowercases the rest
+func titleWord(w string) string {
+	parts := strings.Split(strings.ToLower(w), "-")
+	for i, part := range parts {
+		for j, r := range part {
+			if unicode.IsLetter(r) {
+				parts[i] = part[:j] + string(unicode.ToUpper(r)) + part[j+len(string(r)):]
+				break
+			}
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// ApplyMerchantRules sets the merchant name of each transaction that doesn't have one yet,
+// using the first matching rule or, failing that, the cleaned description
+// Returns the number of transactions a rule matched
+func (db *DB) ApplyMerchantRules(transactions []*Transaction) (int, error) {
+	rules, err := db.ListMerchantRules()
+	if err != nil {
+		return 0, err
+	}
+
+	return NormalizeMerchants(rules, transactions), nil
+}
+
+// NormalizeMerchants assigns merchant names from an already-loaded rule set
+// Transactions that already have a merchant name are left untouched
+func NormalizeMerchants(rules []*MerchantRule, transactions []*Transaction) int {
+	matched := 0
+	for _, tx := range transactions {
+		if tx.MerchantName != "" {
+			continue
+		}
+		for _, rule := range rules {
+			if rule.Matches(tx.Description) {
+				tx.MerchantName = rule.Merchant
+				matched++
+				break
+			}
+		}
+		if tx.MerchantName == "" {
+			tx.MerchantName = CleanMerchantName(tx.Description)
+		}
+	}
+	return matched
+}
+
+// AddMerchantRule stores a new merchant normalization rule
+func (db *DB) AddMerchantRule(rule *MerchantRule) (int64, error) {
+	if err := rule.Validate(); err != nil {
+		return 0, err
+	}
+
+	result, err := db.conn.Exec(`
+		INSERT INTO merchant_rules (merchant, match_type, pattern, priority) VALUES (?, ?, ?, ?)
+	`, strings.TrimSpace(rule.Merchant), rule.MatchType, rule.Pattern, rule.Priority)
+	if err != nil {
+		return 0, fmt.Errorf("insert merchant rule: %w", err)
+	}
+
+	return result.LastInsertId()
+}
+
+// DeleteMerchantRule removes a merchant normalization rule
+func (db *DB) DeleteMerchantRule(id int64) error {
+	result, err := db.conn.Exec(`DELETE FROM merchant_rules WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("delete merchant rule: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("merchant rule not found")
+	}
+
+	return nil
+}
+
+// ListMerchantRules returns all merchant rules in evaluation order (highest priority first)
+func (db *DB) ListMerchantRules() ([]*MerchantRule, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, merchant, match_type, pattern, priority, created_at
+		FROM merchant_rules
+		ORDER BY priority DESC, id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query merchant rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []*MerchantRule
+	for rows.Next() {
+		r := &MerchantRule{}
+		if err := rows.Scan(&r.ID, &r.Merchant, &r.MatchType, &r.Pattern, &r.Priority, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan merchant rule: %w", err)
+		}
+		rules = append(rules, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate merchant rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// QueryTransactionsForMerchants returns transactions whose merchant name should be (re)computed
+// When all is false only rows without a merchant name are returned
+func (db *DB) QueryTransactionsForMerchants(all bool) ([]*Transaction, error) {
+	query := `SELECT ` + transactionColumns + ` FROM transactions`
+	if !all {
+		query += " WHERE merchant_name IS NULL OR merchant_name = ''"
+	}
+	query += " ORDER BY transaction_date, id"
+
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("query transactions: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTransactions(rows)
+}
+
+// SetTransactionMerchant updates the merchant name of a stored transaction
+func (db *DB) SetTransactionMerchant(id int64, merchant string) error {
+	result, err := db.conn.Exec(`UPDATE transactions SET merchant_name = ? WHERE id = ?`, nullString(merchant), id)
+	if err != nil {
+		return fmt.Errorf("update transaction merchant: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("transaction not found")
+	}
+
+	return nil
+}
+
+// GetMerchantSummary totals transactions per canonical merchant, largest spend first
+// With excludeTransfers set, linked transfers don't count towards the totals
+func (db *DB) GetMerchantSummary(excludeTransfers bool) ([]map[string]interface{}, error) {
+	where := ""
+	if excludeTransfers {
+		where = "WHERE id NOT IN (" + linkedTransferIDs + ")"
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT
+			COALESCE(NULLIF(merchant_name, ''), description) AS merchant,
+			COUNT(*) AS transaction_count,
+			SUM(CASE WHEN transaction_type = 'debit' THEN amount ELSE 0 END) AS total_debits,
+			SUM(CASE WHEN transaction_type = 'credit' THEN amount ELSE 0 END) AS total_credits,
+			date(MIN(transaction_date)) AS first_transaction,
+			date(MAX(transaction_date)) AS last_transaction
+		FROM transactions
+		` + where + `
+		GROUP BY merchant
+		ORDER BY total_debits, merchant
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query merchant summary: %w", err)
+	}
+	defer rows.Close()
+
+	var summaries []map[string]interface{}
+	for rows.Next() {
+		var merchant string
+		var count int
+		var totalDebits, totalCredits money.Cents
+		var firstTx, lastTx string
+		if err := rows.Scan(&merchant, &count, &totalDebits, &totalCredits, &firstTx, &lastTx); err != nil {
+			return nil, fmt.Errorf("scan merchant summary: %w", err)
+		}
+
+		summaries = append(summaries, map[string]interface{}{
+			"merchant_name":     merchant,
+			"transaction_count": count,
+			"total_debits":      totalDebits,
+			"total_credits":     totalCredits,
+			"first_transaction": firstTx,
+			"last_transaction":  lastTx,
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate merchant summary: %w", err)
+	}
+
+	return summaries, nil
+}
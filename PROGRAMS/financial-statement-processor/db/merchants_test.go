@@ -0,0 +1,110 @@
+package db
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"money"
+)
+
+func TestCleanMerchantName(t *testing.T) {
+	tests := map[string]string{
+		"SQ *COFFEE SHOP 1234 SEATTLE WA":  "Coffee Shop",
+		"TST* PIZZA PLACE":                 "Pizza Place",
+		"PAYPAL *SPOTIFY":                  "Spotify",
+		"AMZN Mktp US*2K4LM":               "Amzn Mktp Us",
+		"WHOLE FOODS MKT SAN FRANCISCO CA": "Whole Foods Mkt",
+		"NETFLIX.COM 866-579-7172":         "Netflix.com",
+		"7-ELEVEN 38012":                   "7-Eleven",
+		"POS PURCHASE SHELL OIL #5521":     "Shell Oil",
+		"TARGET":                           "Target",
+	}
+	for in, want := range tests {
+		if got := CleanMerchantName(in); got != want {
+			t.Errorf("CleanMerchantName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestMerchantNormalization(t *testing.T) {
+	dbPath := "./test_merchants.db"
+	defer os.Remove(dbPath)
+
+	db, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.AddMerchantRule(&MerchantRule{Merchant: "Amazon", MatchType: "regex", Pattern: `^(AMZN|AMAZON)`}); err != nil {
+		t.Fatalf("Failed to add rule: %v", err)
+	}
+	if _, err := db.AddMerchantRule(&MerchantRule{Merchant: "Coffee", MatchType: "substring"}); err == nil {
+		t.Error("Expected a rule without a pattern to be rejected")
+	}
+
+	date := time.Date(2024, 10, 15, 0, 0, 0, 0, time.UTC)
+	tx := func(description string, amount float64) *Transaction {
+		return &Transaction{AccountName: "Visa", AccountLast4: "2222", TransactionDate: date, Description: description,
+			Amount: money.FromFloat(amount), TransactionType: "debit", StatementDate: date}
+	}
+
+	inserted, skipped, err := db.InsertTransactions([]*Transaction{
+		tx("AMZN Mktp US*2K4LM", -25.00),
+		tx("AMAZON.COM*RT55X", -12.00),
+		tx("SQ *COFFEE SHOP 1234 SEATTLE WA", -4.50),
+	})
+	if err != nil || inserted != 3 || skipped != 0 {
+		t.Fatalf("Expected 3 inserted, got %d inserted, %d skipped (err=%v)", inserted, skipped, err)
+	}
+
+	// A row that only shares the merchant may be the same purchase from a CSV download or a second
+	// one at another store of the chain, so it is held for review instead of being dropped
+	csv := tx("COFFEE SHOP SEATTLE WA", -4.50)
+	flagged, err := db.FlagSuspectedDuplicates([]*Transaction{csv})
+	if err != nil || flagged != 1 || !csv.HasReviewFlag(ReviewFlagSuspectedDuplicate) || csv.DuplicateOf == nil {
+		t.Errorf("Expected the same-merchant row to be flagged as a suspected duplicate, got %d (err=%v)", flagged, err)
+	}
+	inserted, skipped, err = db.InsertTransactions([]*Transaction{tx("SQ *COFFEE SHOP 1234 SEATTLE WA", -4.50)})
+	if err != nil || inserted != 0 || skipped != 1 {
+		t.Errorf("Expected the exact duplicate to be skipped, got %d inserted, %d skipped (err=%v)", inserted, skipped, err)
+	}
+
+	amazon, err := db.QueryTransactionsWithType(date, date, "", "all", "", "amazon", false)
+	if err != nil || len(amazon) != 2 {
+		t.Fatalf("Expected 2 Amazon transactions, got %d (err=%v)", len(amazon), err)
+	}
+
+	summary, err := db.GetMerchantSummary(false)
+	if err != nil || len(summary) != 2 {
+		t.Fatalf("Expected 2 merchants in the summary, got %d (err=%v)", len(summary), err)
+	}
+	if summary[0]["merchant_name"] != "Amazon" || summary[0]["total_debits"] != money.Cents(-3700) {
+		t.Errorf("Expected Amazon with -37.00 first, got %v", summary[0])
+	}
+
+	// Rules added later are applied when back-filling
+	if _, err := db.AddMerchantRule(&MerchantRule{Merchant: "Seattle Coffee Co", MatchType: "substring", Pattern: "COFFEE SHOP", Priority: 10}); err != nil {
+		t.Fatalf("Failed to add rule: %v", err)
+	}
+	transactions, err := db.QueryTransactionsForMerchants(true)
+	if err != nil || len(transactions) != 3 {
+		t.Fatalf("Expected 3 transactions to back-fill, got %d (err=%v)", len(transactions), err)
+	}
+	for _, t := range transactions {
+		t.MerchantName = ""
+	}
+	if matched, err := db.ApplyMerchantRules(transactions); err != nil || matched != 3 {
+		t.Fatalf("Expected 3 rule matches, got %d (err=%v)", matched, err)
+	}
+	for _, tx := range transactions {
+		if err := db.SetTransactionMerchant(tx.ID, tx.MerchantName); err != nil {
+			t.Fatalf("SetTransactionMerchant failed: %v", err)
+		}
+	}
+	coffee, err := db.QueryTransactionsWithType(date, date, "", "all", "", "Seattle Coffee Co", false)
+	if err != nil || len(coffee) != 1 {
+		t.Errorf("Expected 1 Seattle Coffee Co transaction, got %d (err=%v)", len(coffee), err)
+	}
+}
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"embed"
+	"fmt"
+	"os"
+
+	"sqlite-migrate/migrate"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// migrator returns the schema migrator for this database
+func (db *DB) migrator() (*migrate.Migrator, error) {
+	m, err := migrate.New(db.conn, db.path, migrations, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	m.Legacy = db.upgradeLegacySchema
+	return m, nil
+}
+
+// Migrate applies pending schema migrations, backing up the database file first
+// Databases created before versioned migrations are upgraded in place to the baseline first
+func (db *DB) Migrate() (*migrate.Result, error) {
+	m, err := db.migrator()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := m.Up()
+	if err != nil {
+		return result, fmt.Errorf("migrate schema: %w", err)
+	}
+
+	return result, nil
+}
+
+// RunMigrateCLI runs the migrate subcommand (status or up) on this database, printing JSON
+func (db *DB) RunMigrateCLI(args []string) error {
+	m, err := db.migrator()
+	if err != nil {
+		return err
+	}
+	return m.Run(args, os.Stdout, true)
+}
@@ -0,0 +1,309 @@
+-- Financial Statement Processor Database Schema
+-- SQLite 3
+--
+-- Baseline migration: the schema as of the first versioned release. Later changes go in new
+-- numbered files next to this one; never edit a migration that has been released
+
+-- Transactions table
+CREATE TABLE IF NOT EXISTS transactions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+
+    -- Account information (account_name is the account's nickname once attached)
+    account_id INTEGER,
+    account_name TEXT NOT NULL,
+    account_last4 TEXT NOT NULL,
+
+    -- Transaction details
+    transaction_date DATE NOT NULL,
+    post_date DATE,
+    description TEXT NOT NULL,
+    amount REAL NOT NULL,
+    transaction_type TEXT NOT NULL CHECK (transaction_type IN ('debit', 'credit')),
+    balance REAL,
+
+    -- Statement metadata
+    statement_date DATE NOT NULL,
+    source_file TEXT,
+
+    -- Categorization ('rule', 'llm' or 'manual')
+    category TEXT,
+    category_source TEXT,
+    merchant_name TEXT,  -- canonical merchant from merchant_rules or the cleaned description
+
+    -- Manual entry and corrections
+    notes TEXT,
+    split_from_id INTEGER,  -- transaction this line was split from
+    manual_changes TEXT,    -- JSON array auditing manual adds, edits and splits
+
+    -- Timestamps
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+
+    -- Prevent duplicate transactions
+    UNIQUE(account_last4, transaction_date, description, amount)
+);
+
+-- Indexes for common queries
+CREATE INDEX IF NOT EXISTS idx_transactions_account
+    ON transactions(account_name, account_last4);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_date
+    ON transactions(transaction_date);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_post_date
+    ON transactions(post_date);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_statement_date
+    ON transactions(statement_date);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_type
+    ON transactions(transaction_type);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_account_date
+    ON transactions(account_name, transaction_date DESC);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_category
+    ON transactions(category);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_merchant
+    ON transactions(merchant_name);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_account_id
+    ON transactions(account_id);
+
+-- Processing log table (tracks statement processing history)
+CREATE TABLE IF NOT EXISTS processing_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    source_file TEXT NOT NULL,
+    statement_date DATE,
+    account_name TEXT,
+    transactions_inserted INTEGER DEFAULT 0,
+    transactions_skipped INTEGER DEFAULT 0,
+    status TEXT NOT NULL CHECK (status IN ('success', 'parse_error', 'db_error', 'reconcile_error')),
+    error_message TEXT,
+    processed_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_processing_log_file
+    ON processing_log(source_file);
+
+CREATE INDEX IF NOT EXISTS idx_processing_log_status
+    ON processing_log(status);
+
+-- Categories table (default categories are seeded automatically)
+CREATE TABLE IF NOT EXISTS categories (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Categorization rules (evaluated highest priority first, first match wins)
+-- Amount range is compared against the absolute transaction amount
+CREATE TABLE IF NOT EXISTS category_rules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    category_id INTEGER NOT NULL,
+    match_type TEXT NOT NULL DEFAULT 'substring' CHECK (match_type IN ('substring', 'regex')),
+    pattern TEXT,
+    min_amount REAL,
+    max_amount REAL,
+    account TEXT,
+    priority INTEGER DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_category_rules_priority
+    ON category_rules(priority DESC);
+
+-- Merchant normalization rules (evaluated highest priority first, first match wins)
+-- Descriptions no rule matches get processor prefixes, store numbers and locations stripped
+CREATE TABLE IF NOT EXISTS merchant_rules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    merchant TEXT NOT NULL,
+    match_type TEXT NOT NULL DEFAULT 'substring' CHECK (match_type IN ('substring', 'regex')),
+    pattern TEXT NOT NULL,
+    priority INTEGER DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Monthly budgets; a transaction counts towards a budget when it is in the budget's category
+-- or matches one of its patterns (linked transfers never count)
+CREATE TABLE IF NOT EXISTS budgets (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    category TEXT,
+    monthly_limit REAL NOT NULL CHECK (monthly_limit > 0),
+    rollover TEXT NOT NULL DEFAULT 'none' CHECK (rollover IN ('none', 'surplus', 'full')),
+    start_month TEXT NOT NULL,  -- YYYY-MM; rollover is accumulated from here
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Description or merchant name patterns that count towards a budget
+CREATE TABLE IF NOT EXISTS budget_patterns (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    budget_id INTEGER NOT NULL,
+    match_type TEXT NOT NULL DEFAULT 'substring' CHECK (match_type IN ('substring', 'regex')),
+    pattern TEXT NOT NULL,
+    FOREIGN KEY (budget_id) REFERENCES budgets(id) ON DELETE CASCADE
+);
+
+-- Review queue for parsed transactions that need a human to look at them
+CREATE TABLE IF NOT EXISTS pending_transactions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    account_name TEXT NOT NULL,
+    account_last4 TEXT NOT NULL,
+    transaction_date DATE,
+    raw_date TEXT,
+    post_date DATE,
+    description TEXT NOT NULL,
+    amount REAL NOT NULL,
+    transaction_type TEXT NOT NULL,
+    balance REAL,
+    statement_date DATE NOT NULL,
+    source_file TEXT,
+    category TEXT,
+    category_source TEXT,
+    flags TEXT NOT NULL DEFAULT '',
+    duplicate_of INTEGER,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    note TEXT,
+    transaction_id INTEGER,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    reviewed_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_pending_transactions_status
+    ON pending_transactions(status);
+
+CREATE INDEX IF NOT EXISTS idx_pending_transactions_source_file
+    ON pending_transactions(source_file);
+
+-- Transfers between accounts (a debit on one account paired with the matching credit on another)
+-- Rejected links are kept so the matcher doesn't pair the same transactions again
+CREATE TABLE IF NOT EXISTS transfers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    from_transaction_id INTEGER NOT NULL,
+    to_transaction_id INTEGER NOT NULL,
+    amount REAL NOT NULL,
+    method TEXT NOT NULL DEFAULT 'auto' CHECK (method IN ('auto', 'manual')),
+    status TEXT NOT NULL DEFAULT 'linked' CHECK (status IN ('linked', 'rejected')),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (from_transaction_id) REFERENCES transactions(id) ON DELETE CASCADE,
+    FOREIGN KEY (to_transaction_id) REFERENCES transactions(id) ON DELETE CASCADE
+);
+
+-- A transaction can be one side of at most one linked transfer
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transfers_from
+    ON transfers(from_transaction_id) WHERE status = 'linked';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transfers_to
+    ON transfers(to_transaction_id) WHERE status = 'linked';
+
+-- Merchants that charge an account on a schedule, rebuilt by each detection run
+CREATE TABLE IF NOT EXISTS recurring_series (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    merchant_key TEXT NOT NULL,
+    description TEXT NOT NULL,
+    account_name TEXT NOT NULL,
+    account_last4 TEXT NOT NULL,
+    cadence TEXT NOT NULL CHECK (cadence IN ('weekly', 'biweekly', 'monthly', 'quarterly', 'annual')),
+    interval_days REAL NOT NULL,
+    occurrences INTEGER NOT NULL,
+    first_date DATE NOT NULL,
+    last_date DATE NOT NULL,
+    next_expected_date DATE NOT NULL,
+    typical_amount REAL NOT NULL,
+    last_amount REAL NOT NULL,
+    amount_drift REAL NOT NULL DEFAULT 0,
+    status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'stopped')),
+    flags TEXT NOT NULL DEFAULT '',  -- comma-separated: new, price_changed
+    detected_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(merchant_key, account_name, account_last4)
+);
+
+CREATE INDEX IF NOT EXISTS idx_recurring_series_status
+    ON recurring_series(status);
+
+-- Parse cache (raw LLM JSON per statement page; also an audit trail of model output)
+-- Keyed by SHA-256 of the file, page number, model and prompt version
+CREATE TABLE IF NOT EXISTS parse_cache (
+    file_hash TEXT NOT NULL,
+    page_number INTEGER NOT NULL,
+    model TEXT NOT NULL,
+    prompt_version INTEGER NOT NULL,
+    source_file TEXT,
+    response TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (file_hash, page_number, model, prompt_version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_parse_cache_source_file
+    ON parse_cache(source_file);
+
+-- Accounts that statements and transactions are attached to
+CREATE TABLE IF NOT EXISTS accounts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    institution TEXT,
+    nickname TEXT NOT NULL,
+    account_type TEXT CHECK (account_type IN ('checking', 'savings', 'credit')),
+    last4 TEXT NOT NULL DEFAULT '',
+    currency TEXT NOT NULL DEFAULT 'USD',
+    opened_on DATE,
+    closed_on DATE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(nickname, last4)
+);
+
+-- Account names as spelled on statements; name_key is the name reduced to uppercase words
+-- A statement matches an alias with the same name_key and last4, then the only open account
+-- with its last4, then (without last4) the only account with that name_key
+CREATE TABLE IF NOT EXISTS account_aliases (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    account_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    name_key TEXT NOT NULL,
+    last4 TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(name_key, last4),
+    FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_aliases_account
+    ON account_aliases(account_id);
+
+-- Trigger to automatically update updated_at timestamp
+CREATE TRIGGER IF NOT EXISTS update_transactions_updated_at
+    AFTER UPDATE ON transactions
+    FOR EACH ROW
+BEGIN
+    UPDATE transactions SET updated_at = CURRENT_TIMESTAMP
+    WHERE id = NEW.id;
+END;
+
+-- Full-text search (transactions_fts and its triggers) isn't a migration: it depends on SQLite being
+-- built with FTS5, so the processor creates or drops it each time it opens the database (db/search.go)
+
+-- Note: SQLite doesn't support views in the same way as PostgreSQL,
+-- but you can query directly for summaries:
+--
+-- Account Summary:
+--   SELECT account_name, account_last4, COUNT(*) as transaction_count,
+--          SUM(CASE WHEN transaction_type = 'debit' THEN amount ELSE 0 END) as total_debits,
+--          SUM(CASE WHEN transaction_type = 'credit' THEN amount ELSE 0 END) as total_credits,
+--          MIN(transaction_date) as first_transaction,
+--          MAX(transaction_date) as last_transaction,
+--          MAX(statement_date) as latest_statement
+--   FROM transactions
+--   GROUP BY account_name, account_last4;
+--
+-- Monthly Summary:
+--   SELECT account_name, account_last4,
+--          strftime('%Y-%m', transaction_date) as month,
+--          COUNT(*) as transaction_count,
+--          SUM(CASE WHEN transaction_type = 'debit' THEN amount ELSE 0 END) as total_debits,
+--          SUM(CASE WHEN transaction_type = 'credit' THEN amount ELSE 0 END) as total_credits
+--   FROM transactions
+--   GROUP BY account_name, account_last4, strftime('%Y-%m', transaction_date)
+--   ORDER BY month DESC, account_name;
@@ -0,0 +1,25 @@
+-- Currencies: every transaction records the currency of its amount, and exchange rates convert
+-- amounts into a base currency for reports
+
+-- Transactions take their account's currency; statements don't always say which one they are in
+ALTER TABLE transactions ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
+
+UPDATE transactions
+SET currency = (SELECT currency FROM accounts WHERE accounts.id = transactions.account_id)
+WHERE account_id IN (SELECT id FROM accounts);
+
+-- Dated exchange rates: one unit of currency is worth rate units of base_currency on rate_date
+-- An amount is converted with the latest rate on or before its date
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    currency TEXT NOT NULL,
+    base_currency TEXT NOT NULL,
+    rate_date DATE NOT NULL,
+    rate REAL NOT NULL CHECK (rate > 0),
+    source TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(currency, base_currency, rate_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_exchange_rates_lookup
+    ON exchange_rates(currency, base_currency, rate_date);
@@ -0,0 +1,258 @@
+-- Money as integer cents: amounts stored as REAL drifted by fractions of a cent when summed and
+-- made duplicate checks on amounts unreliable. Every money column becomes INTEGER cents
+-- (money.Cents in Go); SQLite can't change a column's type, so the tables are rebuilt
+--
+-- transactions and budgets are referenced by transfers and budget_patterns, whose ON DELETE
+-- CASCADE would empty them when the old tables are dropped
+-- migrate:foreign-keys-off
+
+-- Transactions
+CREATE TABLE transactions_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+
+    -- Account information (account_name is the account's nickname once attached)
+    account_id INTEGER,
+    account_name TEXT NOT NULL,
+    account_last4 TEXT NOT NULL,
+
+    -- Transaction details (amount and balance in cents of currency)
+    transaction_date DATE NOT NULL,
+    post_date DATE,
+    description TEXT NOT NULL,
+    amount INTEGER NOT NULL,
+    transaction_type TEXT NOT NULL CHECK (transaction_type IN ('debit', 'credit')),
+    balance INTEGER,
+    currency TEXT NOT NULL DEFAULT 'USD',
+
+    -- Statement metadata
+    statement_date DATE NOT NULL,
+    source_file TEXT,
+
+    -- Categorization ('rule', 'llm' or 'manual')
+    category TEXT,
+    category_source TEXT,
+    merchant_name TEXT,  -- canonical merchant from merchant_rules or the cleaned description
+
+    -- Manual entry and corrections
+    notes TEXT,
+    split_from_id INTEGER,  -- transaction this line was split from
+    manual_changes TEXT,    -- JSON array auditing manual adds, edits and splits
+
+    -- Timestamps
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+
+    -- Prevent duplicate transactions
+    UNIQUE(account_last4, transaction_date, description, amount)
+);
+
+INSERT INTO transactions_new (
+    id, account_id, account_name, account_last4, transaction_date, post_date, description,
+    amount, transaction_type, balance, currency, statement_date, source_file,
+    category, category_source, merchant_name, notes, split_from_id, manual_changes,
+    created_at, updated_at
+)
+SELECT
+    id, account_id, account_name, account_last4, transaction_date, post_date, description,
+    CAST(ROUND(amount * 100) AS INTEGER), transaction_type, CAST(ROUND(balance * 100) AS INTEGER),
+    currency, statement_date, source_file,
+    category, category_source, merchant_name, notes, split_from_id, manual_changes,
+    created_at, updated_at
+FROM transactions;
+
+DROP TABLE transactions;
+ALTER TABLE transactions_new RENAME TO transactions;
+
+CREATE INDEX idx_transactions_account
+    ON transactions(account_name, account_last4);
+
+CREATE INDEX idx_transactions_date
+    ON transactions(transaction_date);
+
+CREATE INDEX idx_transactions_post_date
+    ON transactions(post_date);
+
+CREATE INDEX idx_transactions_statement_date
+    ON transactions(statement_date);
+
+CREATE INDEX idx_transactions_type
+    ON transactions(transaction_type);
+
+CREATE INDEX idx_transactions_account_date
+    ON transactions(account_name, transaction_date DESC);
+
+CREATE INDEX idx_transactions_category
+    ON transactions(category);
+
+CREATE INDEX idx_transactions_merchant
+    ON transactions(merchant_name);
+
+CREATE INDEX idx_transactions_account_id
+    ON transactions(account_id);
+
+CREATE TRIGGER update_transactions_updated_at
+    AFTER UPDATE ON transactions
+    FOR EACH ROW
+BEGIN
+    UPDATE transactions SET updated_at = CURRENT_TIMESTAMP
+    WHERE id = NEW.id;
+END;
+
+-- The full-text index keeps its rows (ids are unchanged); the processor recreates its sync
+-- triggers the next time it opens the database
+
+-- Categorization rules (amount range in cents)
+CREATE TABLE category_rules_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    category_id INTEGER NOT NULL,
+    match_type TEXT NOT NULL DEFAULT 'substring' CHECK (match_type IN ('substring', 'regex')),
+    pattern TEXT,
+    min_amount INTEGER,
+    max_amount INTEGER,
+    account TEXT,
+    priority INTEGER DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE
+);
+
+INSERT INTO category_rules_new (id, category_id, match_type, pattern, min_amount, max_amount, account, priority, created_at)
+SELECT id, category_id, match_type, pattern,
+       CAST(ROUND(min_amount * 100) AS INTEGER), CAST(ROUND(max_amount * 100) AS INTEGER),
+       account, priority, created_at
+FROM category_rules;
+
+DROP TABLE category_rules;
+ALTER TABLE category_rules_new RENAME TO category_rules;
+
+CREATE INDEX idx_category_rules_priority
+    ON category_rules(priority DESC);
+
+-- Monthly budgets (monthly_limit in cents)
+CREATE TABLE budgets_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    category TEXT,
+    monthly_limit INTEGER NOT NULL CHECK (monthly_limit > 0),
+    rollover TEXT NOT NULL DEFAULT 'none' CHECK (rollover IN ('none', 'surplus', 'full')),
+    start_month TEXT NOT NULL,  -- YYYY-MM; rollover is accumulated from here
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO budgets_new (id, name, category, monthly_limit, rollover, start_month, created_at)
+SELECT id, name, category, CAST(ROUND(monthly_limit * 100) AS INTEGER), rollover, start_month, created_at
+FROM budgets;
+
+DROP TABLE budgets;
+ALTER TABLE budgets_new RENAME TO budgets;
+
+-- Review queue (amount and balance in cents)
+CREATE TABLE pending_transactions_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    account_name TEXT NOT NULL,
+    account_last4 TEXT NOT NULL,
+    transaction_date DATE,
+    raw_date TEXT,
+    post_date DATE,
+    description TEXT NOT NULL,
+    amount INTEGER NOT NULL,
+    transaction_type TEXT NOT NULL,
+    balance INTEGER,
+    statement_date DATE NOT NULL,
+    source_file TEXT,
+    category TEXT,
+    category_source TEXT,
+    flags TEXT NOT NULL DEFAULT '',
+    duplicate_of INTEGER,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    note TEXT,
+    transaction_id INTEGER,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    reviewed_at DATETIME
+);
+
+INSERT INTO pending_transactions_new (
+    id, account_name, account_last4, transaction_date, raw_date, post_date, description,
+    amount, transaction_type, balance, statement_date, source_file, category, category_source,
+    flags, duplicate_of, status, note, transaction_id, created_at, reviewed_at
+)
+SELECT
+    id, account_name, account_last4, transaction_date, raw_date, post_date, description,
+    CAST(ROUND(amount * 100) AS INTEGER), transaction_type, CAST(ROUND(balance * 100) AS INTEGER),
+    statement_date, source_file, category, category_source,
+    flags, duplicate_of, status, note, transaction_id, created_at, reviewed_at
+FROM pending_transactions;
+
+DROP TABLE pending_transactions;
+ALTER TABLE pending_transactions_new RENAME TO pending_transactions;
+
+CREATE INDEX idx_pending_transactions_status
+    ON pending_transactions(status);
+
+CREATE INDEX idx_pending_transactions_source_file
+    ON pending_transactions(source_file);
+
+-- Transfers (amount in cents)
+CREATE TABLE transfers_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    from_transaction_id INTEGER NOT NULL,
+    to_transaction_id INTEGER NOT NULL,
+    amount INTEGER NOT NULL,
+    method TEXT NOT NULL DEFAULT 'auto' CHECK (method IN ('auto', 'manual')),
+    status TEXT NOT NULL DEFAULT 'linked' CHECK (status IN ('linked', 'rejected')),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (from_transaction_id) REFERENCES transactions(id) ON DELETE CASCADE,
+    FOREIGN KEY (to_transaction_id) REFERENCES transactions(id) ON DELETE CASCADE
+);
+
+INSERT INTO transfers_new (id, from_transaction_id, to_transaction_id, amount, method, status, created_at)
+SELECT id, from_transaction_id, to_transaction_id, CAST(ROUND(amount * 100) AS INTEGER), method, status, created_at
+FROM transfers;
+
+DROP TABLE transfers;
+ALTER TABLE transfers_new RENAME TO transfers;
+
+CREATE UNIQUE INDEX idx_transfers_from
+    ON transfers(from_transaction_id) WHERE status = 'linked';
+
+CREATE UNIQUE INDEX idx_transfers_to
+    ON transfers(to_transaction_id) WHERE status = 'linked';
+
+-- Recurring charges (amounts in cents; interval_days stays a fractional number of days)
+CREATE TABLE recurring_series_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    merchant_key TEXT NOT NULL,
+    description TEXT NOT NULL,
+    account_name TEXT NOT NULL,
+    account_last4 TEXT NOT NULL,
+    cadence TEXT NOT NULL CHECK (cadence IN ('weekly', 'biweekly', 'monthly', 'quarterly', 'annual')),
+    interval_days REAL NOT NULL,
+    occurrences INTEGER NOT NULL,
+    first_date DATE NOT NULL,
+    last_date DATE NOT NULL,
+    next_expected_date DATE NOT NULL,
+    typical_amount INTEGER NOT NULL,
+    last_amount INTEGER NOT NULL,
+    amount_drift INTEGER NOT NULL DEFAULT 0,
+    status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'stopped')),
+    flags TEXT NOT NULL DEFAULT '',  -- comma-separated: new, price_changed
+    detected_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(merchant_key, account_name, account_last4)
+);
+
+INSERT INTO recurring_series_new (
+    id, merchant_key, description, account_name, account_last4, cadence, interval_days,
+    occurrences, first_date, last_date, next_expected_date,
+    typical_amount, last_amount, amount_drift, status, flags, detected_at
+)
+SELECT
+    id, merchant_key, description, account_name, account_last4, cadence, interval_days,
+    occurrences, first_date, last_date, next_expected_date,
+    CAST(ROUND(typical_amount * 100) AS INTEGER), CAST(ROUND(last_amount * 100) AS INTEGER),
+    CAST(ROUND(amount_drift * 100) AS INTEGER), status, flags, detected_at
+FROM recurring_series;
+
+DROP TABLE recurring_series;
+ALTER TABLE recurring_series_new RENAME TO recurring_series;
+
+CREATE INDEX idx_recurring_series_status
+    ON recurring_series(status);
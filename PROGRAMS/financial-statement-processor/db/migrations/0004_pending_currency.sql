@@ -0,0 +1,3 @@
+-- Currency of rows held for review: approved rows take it, or their account's currency when the
+-- statement didn't give one, like imported rows do
+ALTER TABLE pending_transactions ADD COLUMN currency TEXT;
@@ -0,0 +1,21 @@
+-- Parse cache keyed by backend and page text too: a different OCR engine, DPI or LLM backend gives
+-- a different answer for the same file and model, so those must not replay each other's responses
+--
+-- Entries cached under the old key can't say which backend or page text produced them; they are
+-- dropped and the pages are sent to the LLM again on their next import
+DROP TABLE parse_cache;
+
+CREATE TABLE parse_cache (
+    file_hash TEXT NOT NULL,
+    page_number INTEGER NOT NULL,
+    backend TEXT NOT NULL,
+    model TEXT NOT NULL,
+    prompt_version INTEGER NOT NULL,
+    text_hash TEXT NOT NULL,
+    source_file TEXT,
+    response TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (file_hash, page_number, backend, model, prompt_version, text_hash)
+);
+
+CREATE INDEX idx_parse_cache_source_file ON parse_cache(source_file);
@@ -0,0 +1,161 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ProcessingStatusFailed selects every processing_log status other than success
+const ProcessingStatusFailed = "failed"
+
+// ProcessingLogEntry is a processing_log row as reported by the log query
+type ProcessingLogEntry struct {
+	ID                   int64      `json:"id"`
+	SourceFile           string     `json:"source_file"`
+	StatementDate        *time.Time `json:"statement_date,omitempty"`
+	AccountName          string     `json:"account_name,omitempty"`
+	TransactionsInserted int        `json:"transactions_inserted"`
+	TransactionsSkipped  int        `json:"transactions_skipped"`
+	Status               string     `json:"status"`
+	ErrorMessage         string     `json:"error_message,omitempty"`
+	ProcessedAt          time.Time  `json:"processed_at"`
+	Resolved             bool       `json:"resolved"`             // a later import of the same file succeeded
+	RetryHint            string     `json:"retry_hint,omitempty"` // what to do about an unresolved failure
+}
+
+// ProcessingLogSummary counts the entries matching the log query's filters
+type ProcessingLogSummary struct {
+	Total      int            `json:"total"`
+	ByStatus   map[string]int `json:"by_status"`
+	Failed     int            `json:"failed"`
+	Unresolved int            `json:"unresolved"` // failed files that haven't been imported since
+}
+
+// ProcessingLogOptions filters the processing log
+type ProcessingLogOptions struct {
+	Status    string    // a processing status, "failed" for any error, or empty for all
+	StartDate time.Time // processed on or after this day; zero for no lower bound
+	EndDate   time.Time // processed on or before this day; zero for no upper bound
+	File      string    // source file name substring
+	Limit     int       // maximum entries returned (the summary counts all matches); 0 for no limit
+}
+
+// QueryProcessingLog returns processing_log entries matching the filters, newest first, with a
+// summary of every match
+// A failed entry is resolved when the same file was imported successfully afterwards; unresolved
+// failures get a retry hint
+func (db *DB) QueryProcessingLog(opts ProcessingLogOptions) ([]*ProcessingLogEntry, *ProcessingLogSummary, error) {
+	query := `
+		SELECT p.id, p.source_file, p.statement_date, COALESCE(p.account_name, ''),
+			COALESCE(p.transactions_inserted, 0), COALESCE(p.transactions_skipped, 0),
+			p.status, COALESCE(p.error_message, ''), p.processed_at,
+			EXISTS (
+				SELECT 1 FROM processing_log later
+				WHERE later.source_file = p.source_file AND later.status = 'success'
+					AND (later.processed_at > p.processed_at OR (later.processed_at = p.processed_at AND later.id > p.id))
+			)
+		FROM processing_log p
+		WHERE 1 = 1`
+	var args []interface{}
+
+	switch opts.Status {
+	case "":
+	case ProcessingStatusFailed:
+		query += " AND p.status != 'success'"
+	default:
+		if !contains(processingLogStatuses, opts.Status) {
+			return nil, nil, fmt.Errorf("invalid status %q: must be %s or %s", opts.Status, strings.Join(processingLogStatuses, ", "), ProcessingStatusFailed)
+		}
+		query += " AND p.status = ?"
+		args = append(args, opts.Status)
+	}
+	if !opts.StartDate.IsZero() {
+		query += " AND date(p.processed_at) >= ?"
+		args = append(args, opts.StartDate.Format("2006-01-02"))
+	}
+	if !opts.EndDate.IsZero() {
+		query += " AND date(p.processed_at) <= ?"
+		args = append(args, opts.EndDate.Format("2006-01-02"))
+	}
+	if opts.File != "" {
+		query += " AND p.source_file LIKE ?"
+		args = append(args, "%"+opts.File+"%")
+	}
+	query += " ORDER BY p.processed_at DESC, p.id DESC"
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("query processing log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []*ProcessingLogEntry{}
+	summary := &ProcessingLogSummary{ByStatus: make(map[string]int)}
+	for rows.Next() {
+		e := &ProcessingLogEntry{}
+		var statementDate *time.Time
+		if err := rows.Scan(&e.ID, &e.SourceFile, &statementDate, &e.AccountName,
+			&e.TransactionsInserted, &e.TransactionsSkipped,
+			&e.Status, &e.ErrorMessage, &e.ProcessedAt, &e.Resolved); err != nil {
+			return nil, nil, fmt.Errorf("scan processing log: %w", err)
+		}
+		e.StatementDate = statementDate
+
+		summary.Total++
+		summary.ByStatus[e.Status]++
+		if e.Status != "success" {
+			summary.Failed++
+			if !e.Resolved {
+				summary.Unresolved++
+				e.RetryHint = retryHint(e.Status, e.ErrorMessage, e.SourceFile)
+			}
+		} else {
+			e.Resolved = false
+		}
+
+		if opts.Limit <= 0 || len(entries) < opts.Limit {
+			entries = append(entries, e)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("iterate processing log: %w", err)
+	}
+
+	return entries, summary, nil
+}
+
+// retryHint suggests how to get a failed statement imported
+// processing_log only keeps the file name, so the hint can't include the full path
+func retryHint(status, message, file string) string {
+	rerun := fmt.Sprintf("financial-statement-processor <path to %s>", file)
+	skipReconcile := fmt.Sprintf("financial-statement-processor --skip-reconcile <path to %s>", file)
+	lower := strings.ToLower(message)
+
+	switch status {
+	case "reconcile_error":
+		return "Parsed rows don't add up to the statement balances; compare the suspect rows in the error " +
+			"against the statement, then re-run with --refresh-cache (or a different model), or import as parsed: " +
+			skipReconcile
+	case "db_error":
+		if strings.Contains(lower, "locked") || strings.Contains(lower, "busy") {
+			return "The database was locked by another process; re-run once it finishes: " + rerun
+		}
+		return "Check the database file is writable and the disk isn't full, then re-run: " + rerun
+	}
+
+	switch {
+	case strings.Contains(lower, "connection refused") || strings.Contains(lower, "no such host") ||
+		strings.Contains(lower, "timeout") || strings.Contains(lower, "deadline exceeded"):
+		return "The LLM server couldn't be reached; check it is running at LLM_HOST, then re-run: " + rerun
+	case strings.Contains(lower, "unsupported"):
+		return "The file format isn't supported; download the statement as PDF, CSV, OFX/QFX or QIF and process that instead"
+	case strings.Contains(lower, "tesseract") || strings.Contains(lower, "pdftoppm"):
+		return "OCR tools are missing or failed; install tesseract and poppler-utils (or set OCR_ENGINE), then re-run: " + rerun
+	case strings.Contains(lower, "validation failed"), strings.Contains(lower, "invalid"):
+		return "The parsed statement was incomplete; re-run with --refresh-cache, or try a different LLM_MODEL: " + rerun
+	case strings.Contains(lower, "csv") || strings.Contains(lower, "column"):
+		return "The CSV columns weren't recognized; pass --csv-profile or add a profile to CSV_PROFILES_PATH, then re-run: " + rerun
+	}
+	return "Check the error message, then re-run: " + rerun
+}
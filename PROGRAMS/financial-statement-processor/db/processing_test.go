@@ -0,0 +1,88 @@
+package db
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueryProcessingLog(t *testing.T) {
+	dbPath := "./test_processing.db"
+	defer os.Remove(dbPath)
+
+	db, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	logs := []struct {
+		log         ProcessingLog
+		processedAt string
+	}{
+		{ProcessingLog{SourceFile: "visa_2024_05.pdf", AccountName: "Visa", TransactionsInserted: 12, Status: "success"}, "2024-06-02 09:00:00"},
+		{ProcessingLog{SourceFile: "visa_2024_06.pdf", Status: "parse_error", ErrorMessage: "LLM request failed: dial tcp: connection refused"}, "2024-07-01 09:00:00"},
+		{ProcessingLog{SourceFile: "visa_2024_06.pdf", AccountName: "Visa", TransactionsInserted: 10, Status: "success"}, "2024-07-01 10:00:00"},
+		{ProcessingLog{SourceFile: "checking_2024_06.pdf", AccountName: "Checking", Status: "reconcile_error", ErrorMessage: "closing balance off by 12.50"}, "2024-07-02 09:00:00"},
+		{ProcessingLog{SourceFile: "savings_2024_06.pdf", Status: "db_error", ErrorMessage: "insert transactions: database is locked"}, "2024-07-03 09:00:00"},
+	}
+	for _, l := range logs {
+		if err := db.LogProcessing(&l.log); err != nil {
+			t.Fatalf("Failed to log processing: %v", err)
+		}
+		if _, err := db.conn.Exec(`UPDATE processing_log SET processed_at = ? WHERE id = (SELECT MAX(id) FROM processing_log)`, l.processedAt); err != nil {
+			t.Fatalf("Failed to set processed_at: %v", err)
+		}
+	}
+
+	entries, summary, err := db.QueryProcessingLog(ProcessingLogOptions{})
+	if err != nil {
+		t.Fatalf("Failed to query processing log: %v", err)
+	}
+	if len(entries) != 5 || entries[0].SourceFile != "savings_2024_06.pdf" {
+		t.Fatalf("Expected 5 entries newest first, got %d", len(entries))
+	}
+	if summary.Total != 5 || summary.Failed != 3 || summary.Unresolved != 2 || summary.ByStatus["success"] != 2 {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+
+	// The June Visa parse error was fixed by the later import
+	entries, _, err = db.QueryProcessingLog(ProcessingLogOptions{Status: "parse_error"})
+	if err != nil {
+		t.Fatalf("Failed to query processing log: %v", err)
+	}
+	if len(entries) != 1 || !entries[0].Resolved || entries[0].RetryHint != "" {
+		t.Errorf("Expected a resolved parse error without a hint, got %+v", entries)
+	}
+
+	// Unresolved failures get a hint matching their cause
+	entries, summary, err = db.QueryProcessingLog(ProcessingLogOptions{Status: ProcessingStatusFailed, StartDate: time.Date(2024, 7, 2, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("Failed to query processing log: %v", err)
+	}
+	if len(entries) != 2 || summary.Unresolved != 2 {
+		t.Fatalf("Expected 2 unresolved failures since July 2, got %d", len(entries))
+	}
+	if !strings.Contains(entries[0].RetryHint, "locked") || !strings.Contains(entries[1].RetryHint, "--skip-reconcile") {
+		t.Errorf("Unexpected retry hints: %q, %q", entries[0].RetryHint, entries[1].RetryHint)
+	}
+
+	// Date range, file filter and limit
+	entries, summary, err = db.QueryProcessingLog(ProcessingLogOptions{
+		StartDate: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+		File:      "visa",
+		Limit:     1,
+	})
+	if err != nil {
+		t.Fatalf("Failed to query processing log: %v", err)
+	}
+	if len(entries) != 1 || summary.Total != 2 || entries[0].Status != "success" {
+		t.Errorf("Expected the latest of 2 July 1 Visa entries, got %d of %d", len(entries), summary.Total)
+	}
+
+	if _, _, err := db.QueryProcessingLog(ProcessingLogOptions{Status: "broken"}); err == nil {
+		t.Error("Expected an error for an unknown status")
+	}
+}
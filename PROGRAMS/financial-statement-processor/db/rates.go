@@ -0,0 +1,310 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"money"
+)
+
+// ExchangeRate is the value of one unit of Currency in BaseCurrency on RateDate
+type ExchangeRate struct {
+	ID           int64     `json:"id"`
+	Currency     string    `json:"currency"`
+	BaseCurrency string    `json:"base_currency"`
+	RateDate     time.Time `json:"rate_date"`
+	Rate         float64   `json:"rate"`
+	Source       string    `json:"source,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// normalizeExchangeRate uppercases the currency codes and checks the rate
+func normalizeExchangeRate(r *ExchangeRate) error {
+	r.Currency = strings.ToUpper(strings.TrimSpace(r.Currency))
+	r.BaseCurrency = strings.ToUpper(strings.TrimSpace(r.BaseCurrency))
+	if r.BaseCurrency == "" {
+		r.BaseCurrency = defaultAccountCurrency
+	}
+	if !currencyCode.MatchString(r.Currency) {
+		return fmt.Errorf("invalid currency %q (use a 3-letter code such as EUR)", r.Currency)
+	}
+	if !currencyCode.MatchString(r.BaseCurrency) {
+		return fmt.Errorf("invalid base currency %q (use a 3-letter code such as USD)", r.BaseCurrency)
+	}
+	if r.Currency == r.BaseCurrency {
+		return fmt.Errorf("currency and base currency are both %s", r.Currency)
+	}
+	if r.Rate <= 0 {
+		return fmt.Errorf("rate must be positive, got %g", r.Rate)
+	}
+	if r.RateDate.IsZero() {
+		return fmt.Errorf("rate date is required")
+	}
+	r.RateDate = time.Date(r.RateDate.Year(), r.RateDate.Month(), r.RateDate.Day(), 0, 0, 0, 0, time.UTC)
+	return nil
+}
+
+// SetExchangeRate stores a rate, replacing any rate for the same currencies and date
+func (db *DB) SetExchangeRate(r *ExchangeRate) (int64, error) {
+	if err := normalizeExchangeRate(r); err != nil {
+		return 0, err
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	id, err := setExchangeRate(tx, r)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit exchange rate: %w", err)
+	}
+	return id, nil
+}
+
+// setExchangeRate upserts a normalized rate
+func setExchangeRate(tx *sql.Tx, r *ExchangeRate) (int64, error) {
+	_, err := tx.Exec(`
+		INSERT INTO exchange_rates (currency, base_currency, rate_date, rate, source)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(currency, base_currency, rate_date) DO UPDATE SET rate = excluded.rate, source = excluded.source
+	`, r.Currency, r.BaseCurrency, r.RateDate, r.Rate, nullString(r.Source))
+	if err != nil {
+		return 0, fmt.Errorf("set exchange rate: %w", err)
+	}
+
+	// LastInsertId isn't reliable after an upsert updated an existing row
+	var id int64
+	err = tx.QueryRow(`SELECT id FROM exchange_rates WHERE currency = ? AND base_currency = ? AND rate_date = ?`,
+		r.Currency, r.BaseCurrency, r.RateDate).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("get exchange rate id: %w", err)
+	}
+	r.ID = id
+	return id, nil
+}
+
+// ListExchangeRates returns stored rates, newest first
+// Currency and base currency filter the rates when not empty
+func (db *DB) ListExchangeRates(currency, baseCurrency string) ([]*ExchangeRate, error) {
+	query := `
+		SELECT id, currency, base_currency, rate_date, rate, COALESCE(source, ''), created_at
+		FROM exchange_rates
+		WHERE 1=1`
+	var args []interface{}
+	if currency != "" {
+		query += " AND currency = ?"
+		args = append(args, strings.ToUpper(currency))
+	}
+	if baseCurrency != "" {
+		query += " AND base_currency = ?"
+		args = append(args, strings.ToUpper(baseCurrency))
+	}
+	query += " ORDER BY rate_date DESC, currency, base_currency"
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query exchange rates: %w", err)
+	}
+	defer rows.Close()
+
+	var rates []*ExchangeRate
+	for rows.Next() {
+		r := &ExchangeRate{}
+		if err := rows.Scan(&r.ID, &r.Currency, &r.BaseCurrency, &r.RateDate, &r.Rate, &r.Source, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan exchange rate: %w", err)
+		}
+		rates = append(rates, r)
+	}
+	return rates, rows.Err()
+}
+
+// DeleteExchangeRate removes a stored rate
+func (db *DB) DeleteExchangeRate(id int64) error {
+	result, err := db.conn.Exec(`DELETE FROM exchange_rates WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("delete exchange rate: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("exchange rate not found")
+	}
+
+	return nil
+}
+
+// ImportExchangeRates reads rates from CSV with a header row of date, currency and rate columns
+// and an optional base_currency column; rows without a base currency use baseCurrency
+// Dates are YYYY-MM-DD. Either every row is stored or, on the first bad row, none are
+func (db *DB) ImportExchangeRates(r io.Reader, baseCurrency, source string) (int, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return 0, fmt.Errorf("read exchange rate header: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"date", "currency", "rate"} {
+		if _, ok := columns[required]; !ok {
+			return 0, fmt.Errorf("exchange rate CSV is missing the %s column", required)
+		}
+	}
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	imported := 0
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("read exchange rates line %d: %w", line, err)
+		}
+
+		date, err := time.Parse("2006-01-02", field(record, "date"))
+		if err != nil {
+			return 0, fmt.Errorf("line %d: invalid date (use YYYY-MM-DD): %w", line, err)
+		}
+		rate, err := strconv.ParseFloat(field(record, "rate"), 64)
+		if err != nil {
+			return 0, fmt.Errorf("line %d: invalid rate: %w", line, err)
+		}
+		base := field(record, "base_currency")
+		if base == "" {
+			base = baseCurrency
+		}
+
+		r := &ExchangeRate{Currency: field(record, "currency"), BaseCurrency: base, RateDate: date, Rate: rate, Source: source}
+		if err := normalizeExchangeRate(r); err != nil {
+			return 0, fmt.Errorf("line %d: %w", line, err)
+		}
+		if _, err := setExchangeRate(tx, r); err != nil {
+			return 0, fmt.Errorf("line %d: %w", line, err)
+		}
+		imported++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit exchange rates: %w", err)
+	}
+	return imported, nil
+}
+
+// CurrencyConverter converts amounts into a base currency with the stored exchange rates
+type CurrencyConverter struct {
+	base  string
+	rates map[string][]*ExchangeRate // by currency, oldest first
+}
+
+// CurrencyConverter loads the rates into baseCurrency
+func (db *DB) CurrencyConverter(baseCurrency string) (*CurrencyConverter, error) {
+	baseCurrency = strings.ToUpper(strings.TrimSpace(baseCurrency))
+	if baseCurrency == "" {
+		baseCurrency = defaultAccountCurrency
+	}
+	rates, err := db.ListExchangeRates("", baseCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &CurrencyConverter{base: baseCurrency, rates: make(map[string][]*ExchangeRate)}
+	for _, r := range rates {
+		c.rates[r.Currency] = append(c.rates[r.Currency], r)
+	}
+	for _, list := range c.rates {
+		sort.Slice(list, func(i, j int) bool { return list[i].RateDate.Before(list[j].RateDate) })
+	}
+	return c, nil
+}
+
+// Base returns the currency amounts are converted into
+func (c *CurrencyConverter) Base() string {
+	return c.base
+}
+
+// Convert converts an amount in currency on date into the base currency with the latest rate on
+// or before that date; ok is false when there is no such rate
+// Amounts already in the base currency (or without a currency) are returned as they are
+func (c *CurrencyConverter) Convert(amount money.Cents, currency string, date time.Time) (money.Cents, bool) {
+	if currency == "" || currency == c.base {
+		return amount, true
+	}
+	list := c.rates[currency]
+	// Index of the first rate after the date
+	i := sort.Search(len(list), func(i int) bool { return list[i].RateDate.After(date) })
+	if i == 0 {
+		return 0, false
+	}
+	return amount.Mul(list[i-1].Rate), true
+}
+
+// UnconvertedAmount is the total of the amounts in a currency that could not be converted into
+// the base currency for want of an exchange rate
+type UnconvertedAmount struct {
+	Currency         string      `json:"currency"`
+	Amount           money.Cents `json:"amount"`
+	TransactionCount int         `json:"transaction_count"`
+}
+
+// unconvertedAmounts sums unconverted amounts per currency
+type unconvertedAmounts map[string]*UnconvertedAmount
+
+func newUnconvertedAmounts() unconvertedAmounts {
+	return make(unconvertedAmounts)
+}
+
+func (u unconvertedAmounts) add(currency string, amount money.Cents) {
+	item, ok := u[currency]
+	if !ok {
+		item = &UnconvertedAmount{Currency: currency}
+		u[currency] = item
+	}
+	item.Amount += amount
+	item.TransactionCount++
+}
+
+// list returns the amounts ordered by currency; nil when everything was converted
+func (u unconvertedAmounts) list() []*UnconvertedAmount {
+	if len(u) == 0 {
+		return nil
+	}
+	list := make([]*UnconvertedAmount, 0, len(u))
+	for _, item := range u {
+		list = append(list, item)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Currency < list[j].Currency })
+	return list
+}
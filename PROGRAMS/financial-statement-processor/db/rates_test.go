@@ -0,0 +1,109 @@
+package db
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"money"
+)
+
+func TestExchangeRates(t *testing.T) {
+	dbPath := "./test_rates.db"
+	defer os.Remove(dbPath)
+
+	db, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	csvData := `date,currency,rate
+2024-05-01,eur,1.08
+2024-06-01,EUR,1.10
+2024-06-01,USD,0.79,GBP
+`
+	// The third row has a base_currency value without a header column and falls back to USD
+	if _, err := db.ImportExchangeRates(strings.NewReader(csvData), "USD", "test"); err == nil {
+		t.Error("Expected an error for a rate from USD into USD")
+	}
+	if rates, _ := db.ListExchangeRates("", ""); len(rates) != 0 {
+		t.Errorf("Expected a failed import to store nothing, got %d rates", len(rates))
+	}
+
+	csvData = "date,currency,rate,base_currency\n2024-05-01,eur,1.08,\n2024-06-01,EUR,1.10,\n2024-06-01,USD,0.79,GBP\n"
+	imported, err := db.ImportExchangeRates(strings.NewReader(csvData), "USD", "test")
+	if err != nil {
+		t.Fatalf("Failed to import exchange rates: %v", err)
+	}
+	if imported != 3 {
+		t.Errorf("Expected 3 imported rates, got %d", imported)
+	}
+
+	// Setting a rate for an existing date replaces it
+	date := func(m time.Month, d int) time.Time { return time.Date(2024, m, d, 0, 0, 0, 0, time.UTC) }
+	if _, err := db.SetExchangeRate(&ExchangeRate{Currency: "EUR", RateDate: date(time.June, 1), Rate: 1.2}); err != nil {
+		t.Fatalf("Failed to set exchange rate: %v", err)
+	}
+	rates, err := db.ListExchangeRates("EUR", "USD")
+	if err != nil {
+		t.Fatalf("Failed to list exchange rates: %v", err)
+	}
+	if len(rates) != 2 || rates[0].Rate != 1.2 || rates[0].Source != "" {
+		t.Errorf("Unexpected EUR rates: %+v", rates)
+	}
+
+	converter, err := db.CurrencyConverter("usd")
+	if err != nil {
+		t.Fatalf("Failed to load converter: %v", err)
+	}
+	cases := []struct {
+		currency string
+		date     time.Time
+		want     money.Cents
+		ok       bool
+	}{
+		{"USD", date(time.January, 1), 10000, true},
+		{"EUR", date(time.April, 30), 0, false}, // before the first rate
+		{"EUR", date(time.May, 20), 10800, true},
+		{"EUR", date(time.June, 1), 12000, true},
+		{"GBP", date(time.June, 1), 0, false}, // only stored against GBP as the base
+	}
+	for _, c := range cases {
+		got, ok := converter.Convert(10000, c.currency, c.date)
+		if got != c.want || ok != c.ok {
+			t.Errorf("Convert(100, %s, %s) = %s, %v; want %s, %v", c.currency, c.date.Format("2006-01-02"), got, ok, c.want, c.ok)
+		}
+	}
+
+	if err := db.DeleteExchangeRate(rates[0].ID); err != nil {
+		t.Fatalf("Failed to delete exchange rate: %v", err)
+	}
+	if err := db.DeleteExchangeRate(rates[0].ID); err == nil {
+		t.Error("Expected an error deleting a missing rate")
+	}
+
+	// Cash flow converts into the base currency and reports what it couldn't convert
+	transactions := []*Transaction{
+		{AccountName: "Checking", AccountLast4: "1111", TransactionDate: date(time.May, 1), Description: "PAYROLL",
+			Amount: 100000, TransactionType: "credit", Category: "income", StatementDate: date(time.May, 31), SourceFile: "a.pdf"},
+		{AccountName: "Euro Card", AccountLast4: "3333", Currency: "eur", TransactionDate: date(time.May, 10), Description: "HOTEL",
+			Amount: -10000, TransactionType: "debit", Category: "travel", StatementDate: date(time.May, 31), SourceFile: "b.pdf"},
+		{AccountName: "Pound Card", AccountLast4: "4444", Currency: "GBP", TransactionDate: date(time.May, 12), Description: "TAXI",
+			Amount: -2000, TransactionType: "debit", Category: "travel", StatementDate: date(time.May, 31), SourceFile: "c.pdf"},
+	}
+	if _, _, err := db.InsertTransactions(transactions); err != nil {
+		t.Fatalf("Failed to insert transactions: %v", err)
+	}
+	report, err := db.CashFlow(CashFlowOptions{Months: 1, End: date(time.May, 1)})
+	if err != nil {
+		t.Fatalf("Failed to get cash flow: %v", err)
+	}
+	if report.BaseCurrency != "USD" || report.Months[0].Income != 100000 || report.Months[0].Expenses != 10800 {
+		t.Errorf("Unexpected converted cash flow: %s %+v", report.BaseCurrency, report.Months[0])
+	}
+	if len(report.Unconverted) != 1 || report.Unconverted[0].Currency != "GBP" || report.Unconverted[0].Amount != -2000 {
+		t.Errorf("Unexpected unconverted amounts: %+v", report.Unconverted)
+	}
+}
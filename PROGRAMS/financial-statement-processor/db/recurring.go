@@ -0,0 +1,384 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"money"
+)
+
+// Recurring cadences
+const (
+	CadenceWeekly    = "weekly"
+	CadenceBiweekly  = "biweekly"
+	CadenceMonthly   = "monthly"
+	CadenceQuarterly = "quarterly"
+	CadenceAnnual    = "annual"
+)
+
+// Recurring series statuses
+const (
+	RecurringStatusActive  = "active"
+	RecurringStatusStopped = "stopped" // the next charge is overdue by more than half a period
+)
+
+// Recurring series flags
+const (
+	RecurringFlagNew          = "new"           // just started: the account has older history without it
+	RecurringFlagPriceChanged = "price_changed" // the latest charge differs from a previously steady amount
+)
+
+// Detection defaults
+const (
+	defaultRecurringMinOccurrences = 3
+	defaultRecurringPriceTolerance = 0.02 // fraction of the typical amount
+	recurringIntervalShare         = 0.75 // share of gaps that must match the cadence
+	recurringAmountShare           = 0.75 // share of amounts that must be near the typical amount
+	recurringAmountSpread          = 0.25 // how far (as a fraction) an amount may be from the typical amount
+)
+
+// cadence describes an accepted gap between charges
+type cadence struct {
+	name             string
+	minDays, maxDays float64
+	months, days     int // added to the last date to get the next expected date
+}
+
+var cadences = []cadence{
+	{name: CadenceWeekly, minDays: 6, maxDays: 8, days: 7},
+	{name: CadenceBiweekly, minDays: 13, maxDays: 16, days: 14},
+	{name: CadenceMonthly, minDays: 27, maxDays: 33, months: 1},
+	{name: CadenceQuarterly, minDays: 85, maxDays: 97, months: 3},
+	{name: CadenceAnnual, minDays: 355, maxDays: 375, months: 12},
+}
+
+// RecurringSeries is a merchant that charges an account on a schedule
+type RecurringSeries struct {
+	ID               int64       `json:"id"`
+	MerchantKey      string      `json:"merchant_key"` // canonical merchant the charges were grouped by
+	Description      string      `json:"description"`  // description of the latest charge
+	AccountName      string      `json:"account_name"`
+	AccountLast4     string      `json:"account_last4"`
+	Cadence          string      `json:"cadence"`
+	IntervalDays     float64     `json:"interval_days"` // median gap between charges
+	Occurrences      int         `json:"occurrences"`
+	FirstDate        time.Time   `json:"first_date"`
+	LastDate         time.Time   `json:"last_date"`
+	NextExpectedDate time.Time   `json:"next_expected_date"`
+	TypicalAmount    money.Cents `json:"typical_amount"` // median of the charges before the latest one
+	LastAmount       money.Cents `json:"last_amount"`
+	AmountDrift      money.Cents `json:"amount_drift"` // last amount minus typical amount
+	Status           string      `json:"status"`
+	Flags            []string    `json:"flags"`
+	DetectedAt       time.Time   `json:"detected_at"`
+}
+
+// RecurringOptions controls recurring charge detection
+type RecurringOptions struct {
+	MinOccurrences int       // charges needed before a series is reported (annual series need 2)
+	PriceTolerance float64   // fractional change of a steady amount that counts as a price change
+	AsOf           time.Time // date stopped series are judged against; defaults to the newest transaction
+}
+
+// DetectRecurring groups debits by account and canonical merchant, keeps the groups charged
+// on a weekly to annual cadence and replaces the recurring_series table with the result
+func (db *DB) DetectRecurring(opts RecurringOptions) ([]*RecurringSeries, error) {
+	if opts.MinOccurrences <= 0 {
+		opts.MinOccurrences = defaultRecurringMinOccurrences
+	}
+	if opts.PriceTolerance <= 0 {
+		opts.PriceTolerance = defaultRecurringPriceTolerance
+	}
+
+	transactions, err := db.unlinkedTransactions("amount < 0")
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.AsOf.IsZero() {
+		for _, tx := range transactions {
+			if tx.TransactionDate.After(opts.AsOf) {
+				opts.AsOf = tx.TransactionDate
+			}
+		}
+	}
+
+	groups := make(map[string][]*Transaction)
+	var keys []string
+	accountStart := make(map[string]time.Time)
+	for _, tx := range transactions {
+		account := tx.AccountName + "\x00" + tx.AccountLast4
+		if start, ok := accountStart[account]; !ok || tx.TransactionDate.Before(start) {
+			accountStart[account] = tx.TransactionDate
+		}
+
+		merchant := tx.MerchantName
+		if merchant == "" {
+			merchant = CleanMerchantName(tx.Description)
+		}
+		if merchant == "" {
+			continue
+		}
+		key := merchant + "\x00" + account
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], tx)
+	}
+	sort.Strings(keys)
+
+	var series []*RecurringSeries
+	for _, key := range keys {
+		txs := groups[key]
+		merchant := key[:strings.IndexByte(key, 0)]
+		start := accountStart[txs[0].AccountName+"\x00"+txs[0].AccountLast4]
+		if s := analyzeRecurring(merchant, txs, start, opts); s != nil {
+			series = append(series, s)
+		}
+	}
+
+	if err := db.saveRecurringSeries(series); err != nil {
+		return nil, err
+	}
+
+	return series, nil
+}
+
+// ListRecurringSeries returns stored series with the given status ("all" for every status)
+// and, if flag is set, only those carrying that flag
+func (db *DB) ListRecurringSeries(status, flag string) ([]*RecurringSeries, error) {
+	var conditions []string
+	var args []interface{}
+	if status != "all" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, status)
+	}
+	if flag != "" {
+		conditions = append(conditions, "(',' || flags || ',') LIKE ?")
+		args = append(args, "%,"+flag+",%")
+	}
+
+	query := `
+		SELECT id, merchant_key, description, account_name, account_last4, cadence, interval_days,
+			occurrences, first_date, last_date, next_expected_date, typical_amount, last_amount,
+			amount_drift, status, flags, detected_at
+		FROM recurring_series`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY next_expected_date, merchant_key"
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query recurring series: %w", err)
+	}
+	defer rows.Close()
+
+	var series []*RecurringSeries
+	for rows.Next() {
+		s := &RecurringSeries{}
+		var flags string
+		if err := rows.Scan(&s.ID, &s.MerchantKey, &s.Description, &s.AccountName, &s.AccountLast4, &s.Cadence,
+			&s.IntervalDays, &s.Occurrences, &s.FirstDate, &s.LastDate, &s.NextExpectedDate, &s.TypicalAmount,
+			&s.LastAmount, &s.AmountDrift, &s.Status, &flags, &s.DetectedAt); err != nil {
+			return nil, fmt.Errorf("scan recurring series: %w", err)
+		}
+		s.Flags = []string{}
+		if flags != "" {
+			s.Flags = strings.Split(flags, ",")
+		}
+		series = append(series, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate recurring series: %w", err)
+	}
+
+	return series, nil
+}
+
+// analyzeRecurring returns the series for a group of same-merchant charges, or nil if they
+// don't follow a cadence with a consistent amount
+// accountStart is the account's earliest charge, used to tell a new series from the start of history
+func analyzeRecurring(merchant string, txs []*Transaction, accountStart time.Time, opts RecurringOptions) *RecurringSeries {
+	if len(txs) < 2 {
+		return nil
+	}
+
+	sort.SliceStable(txs, func(i, j int) bool {
+		return txs[i].TransactionDate.Before(txs[j].TransactionDate)
+	})
+
+	gaps := make([]float64, 0, len(txs)-1)
+	for i := 1; i < len(txs); i++ {
+		gaps = append(gaps, txs[i].TransactionDate.Sub(txs[i-1].TransactionDate).Hours()/24)
+	}
+	interval := median(gaps)
+
+	var c *cadence
+	for i := range cadences {
+		if interval >= cadences[i].minDays && interval <= cadences[i].maxDays {
+			c = &cadences[i]
+			break
+		}
+	}
+	if c == nil {
+		return nil
+	}
+
+	minOccurrences := opts.MinOccurrences
+	if c.name == CadenceAnnual && minOccurrences > 2 {
+		minOccurrences = 2
+	}
+	if len(txs) < minOccurrences {
+		return nil
+	}
+
+	matching := 0
+	for _, gap := range gaps {
+		if gap >= c.minDays && gap <= c.maxDays {
+			matching++
+		}
+	}
+	if float64(matching) < recurringIntervalShare*float64(len(gaps)) {
+		return nil
+	}
+
+	amounts := make([]money.Cents, len(txs))
+	for i, tx := range txs {
+		amounts[i] = tx.Amount
+	}
+	typical := medianAmount(amounts)
+	near := 0
+	for _, a := range amounts {
+		if withinFraction(a, typical, recurringAmountSpread) {
+			near++
+		}
+	}
+	if float64(near) < recurringAmountShare*float64(len(amounts)) {
+		return nil
+	}
+
+	first, last := txs[0], txs[len(txs)-1]
+	s := &RecurringSeries{
+		MerchantKey:      merchant,
+		Description:      last.Description,
+		AccountName:      last.AccountName,
+		AccountLast4:     last.AccountLast4,
+		Cadence:          c.name,
+		IntervalDays:     interval,
+		Occurrences:      len(txs),
+		FirstDate:        first.TransactionDate,
+		LastDate:         last.TransactionDate,
+		NextExpectedDate: last.TransactionDate.AddDate(0, c.months, c.days),
+		TypicalAmount:    typical,
+		LastAmount:       last.Amount,
+		Status:           RecurringStatusActive,
+		Flags:            []string{},
+	}
+
+	// A price change is a new amount after a run of steady ones; usage-based bills that vary
+	// every period aren't flagged
+	previous := amounts[:len(amounts)-1]
+	s.TypicalAmount = medianAmount(previous)
+	s.AmountDrift = s.LastAmount - s.TypicalAmount
+	steady := true
+	for _, a := range previous {
+		if !withinFraction(a, s.TypicalAmount, opts.PriceTolerance) {
+			steady = false
+			break
+		}
+	}
+	if steady && len(previous) >= 2 && !withinFraction(s.LastAmount, s.TypicalAmount, opts.PriceTolerance) {
+		s.Flags = append(s.Flags, RecurringFlagPriceChanged)
+	}
+
+	// A series that only just has enough charges to be detected is new, unless the account's
+	// history begins with it
+	if len(txs) <= minOccurrences && first.TransactionDate.Sub(accountStart).Hours()/24 >= c.minDays {
+		s.Flags = append(s.Flags, RecurringFlagNew)
+	}
+
+	grace := time.Duration(c.maxDays/2*24) * time.Hour
+	if opts.AsOf.After(s.NextExpectedDate.Add(grace)) {
+		s.Status = RecurringStatusStopped
+	}
+
+	return s
+}
+
+// saveRecurringSeries replaces the stored series
+func (db *DB) saveRecurringSeries(series []*RecurringSeries) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM recurring_series`); err != nil {
+		return fmt.Errorf("clear recurring series: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO recurring_series (
+			merchant_key, description, account_name, account_last4, cadence, interval_days,
+			occurrences, first_date, last_date, next_expected_date, typical_amount, last_amount,
+			amount_drift, status, flags
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now().UTC()
+	for _, s := range series {
+		result, err := stmt.Exec(
+			s.MerchantKey, s.Description, s.AccountName, s.AccountLast4, s.Cadence, s.IntervalDays,
+			s.Occurrences, s.FirstDate, s.LastDate, s.NextExpectedDate, s.TypicalAmount, s.LastAmount,
+			s.AmountDrift, s.Status, strings.Join(s.Flags, ","),
+		)
+		if err != nil {
+			return fmt.Errorf("insert recurring series: %w", err)
+		}
+		if s.ID, err = result.LastInsertId(); err != nil {
+			return fmt.Errorf("insert recurring series: %w", err)
+		}
+		s.DetectedAt = now
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// median returns the middle value of a non-empty slice without reordering it
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// medianAmount returns the middle amount of a non-empty slice without reordering it
+func medianAmount(amounts []money.Cents) money.Cents {
+	sorted := append([]money.Cents(nil), amounts...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]).Div(2)
+}
+
+// withinFraction reports whether a is within fraction of b, to the nearest cent
+func withinFraction(a, b money.Cents, fraction float64) bool {
+	return (a - b).Abs() <= b.Abs().Mul(fraction)
+}
@@ -0,0 +1,108 @@
+package db
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"money"
+)
+
+func TestDetectRecurring(t *testing.T) {
+	dbPath := "./test_recurring.db"
+	defer os.Remove(dbPath)
+
+	db, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	date := func(month time.Month, day int) time.Time { return time.Date(2024, month, day, 0, 0, 0, 0, time.UTC) }
+	var transactions []*Transaction
+	add := func(d time.Time, description string, amount float64) {
+		transactions = append(transactions, &Transaction{AccountName: "Visa", AccountLast4: "2222", TransactionDate: d,
+			Description: description, Amount: money.FromFloat(amount), TransactionType: "debit", StatementDate: d})
+	}
+
+	// Steady monthly subscription whose price went up in June
+	for m := time.January; m <= time.May; m++ {
+		add(date(m, 15), "NETFLIX.COM 866-579-7172", -15.49)
+	}
+	add(date(time.June, 14), "NETFLIX.COM 866-579-7172", -17.99)
+
+	// Weekly charge that stopped in March
+	for d := 0; d < 6; d++ {
+		add(date(time.February, 1).AddDate(0, 0, 7*d), "GYM CLASS #44", -12.00)
+	}
+
+	// Monthly charge that only started in April
+	for m := time.April; m <= time.June; m++ {
+		add(date(m, 3), "CLOUD STORAGE REF8812", -2.99)
+	}
+
+	// Irregular shopping is not recurring
+	add(date(time.January, 3), "HARDWARE STORE", -40.12)
+	add(date(time.March, 20), "HARDWARE STORE", -112.50)
+	add(date(time.June, 1), "HARDWARE STORE", -8.99)
+
+	if _, _, err := db.InsertTransactions(transactions); err != nil {
+		t.Fatalf("Failed to insert transactions: %v", err)
+	}
+
+	series, err := db.DetectRecurring(RecurringOptions{})
+	if err != nil {
+		t.Fatalf("DetectRecurring failed: %v", err)
+	}
+
+	byMerchant := make(map[string]*RecurringSeries)
+	for _, s := range series {
+		byMerchant[s.MerchantKey] = s
+	}
+	if len(byMerchant) != 3 {
+		t.Fatalf("Expected 3 series, got %d: %v", len(byMerchant), byMerchant)
+	}
+
+	netflix := byMerchant["Netflix.com"]
+	if netflix == nil || netflix.Cadence != CadenceMonthly || netflix.Status != RecurringStatusActive {
+		t.Fatalf("Unexpected Netflix series: %+v", netflix)
+	}
+	if !contains(netflix.Flags, RecurringFlagPriceChanged) || contains(netflix.Flags, RecurringFlagNew) {
+		t.Errorf("Expected Netflix to be flagged price_changed only, got %v", netflix.Flags)
+	}
+	if netflix.TypicalAmount != -1549 || netflix.AmountDrift != -250 {
+		t.Errorf("Expected typical -15.49 and drift -2.50, got %s and %s", netflix.TypicalAmount, netflix.AmountDrift)
+	}
+	if !netflix.NextExpectedDate.Equal(date(time.July, 14)) {
+		t.Errorf("Expected next Netflix charge on 2024-07-14, got %s", netflix.NextExpectedDate.Format("2006-01-02"))
+	}
+
+	gym := byMerchant["Gym Class"]
+	if gym == nil || gym.Cadence != CadenceWeekly || gym.Status != RecurringStatusStopped {
+		t.Errorf("Expected a stopped weekly gym series, got %+v", gym)
+	}
+
+	cloud := byMerchant["Cloud Storage"]
+	if cloud == nil || !contains(cloud.Flags, RecurringFlagNew) {
+		t.Errorf("Expected the cloud storage series to be flagged new, got %+v", cloud)
+	}
+
+	// Detection replaces the stored series, so running it again doesn't duplicate them
+	if _, err := db.DetectRecurring(RecurringOptions{}); err != nil {
+		t.Fatalf("DetectRecurring failed: %v", err)
+	}
+	stored, err := db.ListRecurringSeries("all", "")
+	if err != nil || len(stored) != 3 {
+		t.Fatalf("Expected 3 stored series, got %d (err=%v)", len(stored), err)
+	}
+
+	stopped, err := db.ListRecurringSeries(RecurringStatusStopped, "")
+	if err != nil || len(stopped) != 1 || stopped[0].MerchantKey != "Gym Class" {
+		t.Errorf("Expected only the gym series to be stopped, got %v (err=%v)", stopped, err)
+	}
+
+	changed, err := db.ListRecurringSeries("all", RecurringFlagPriceChanged)
+	if err != nil || len(changed) != 1 || changed[0].MerchantKey != "Netflix.com" {
+		t.Errorf("Expected only Netflix to be flagged price_changed, got %v (err=%v)", changed, err)
+	}
+}
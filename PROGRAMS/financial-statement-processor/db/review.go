@@ -0,0 +1,505 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"money"
+)
+
+// Review flags explain why a parsed transaction was held for review
+const (
+	ReviewFlagUnparseableDate    = "unparseable_date"
+	ReviewFlagSignMismatch       = "sign_mismatch"
+	ReviewFlagSuspectedDuplicate = "suspected_duplicate"
+)
+
+// Pending transaction statuses
+const (
+	ReviewStatusPending  = "pending"
+	ReviewStatusApproved = "approved"
+	ReviewStatusRejected = "rejected"
+)
+
+// duplicateWindowDays is how far apart two same-amount transactions can be and still look like duplicates
+const duplicateWindowDays = 1
+
+// PendingTransaction is a parsed transaction held in the review queue
+type PendingTransaction struct {
+	ID            int64        `json:"id"`
+	Transaction   *Transaction `json:"transaction"`
+	RawDate       string       `json:"raw_date,omitempty"` // date text as returned by the parser
+	Flags         []string     `json:"flags"`
+	DuplicateOf   *int64       `json:"duplicate_of,omitempty"` // existing transaction that looks like the same one
+	Status        string       `json:"status"`
+	Note          string       `json:"note,omitempty"`
+	TransactionID *int64       `json:"transaction_id,omitempty"` // set once approved
+	CreatedAt     time.Time    `json:"created_at"`
+	ReviewedAt    *time.Time   `json:"reviewed_at,omitempty"`
+}
+
+// NeedsReview reports whether a parsed transaction has any review flags
+func (tx *Transaction) NeedsReview() bool {
+	return len(tx.ReviewFlags) > 0
+}
+
+// HasReviewFlag reports whether a parsed transaction carries the given review flag
+func (tx *Transaction) HasReviewFlag(flag string) bool {
+	return contains(tx.ReviewFlags, flag)
+}
+
+// AddReviewFlag adds a review flag to a parsed transaction once
+func (tx *Transaction) AddReviewFlag(flag string) {
+	if !tx.HasReviewFlag(flag) {
+		tx.ReviewFlags = append(tx.ReviewFlags, flag)
+	}
+}
+
+// TransactionTypeFor returns the transaction type implied by an amount's sign
+func TransactionTypeFor(amount money.Cents) string {
+	if amount < 0 {
+		return "debit"
+	}
+	return "credit"
+}
+
+// SignMismatch reports whether the amount's sign contradicts the transaction type
+// Debits should be negative and credits positive
+func (tx *Transaction) SignMismatch() bool {
+	return (tx.TransactionType == "debit" && tx.Amount > 0) ||
+		(tx.TransactionType == "credit" && tx.Amount < 0)
+}
+
+// FlagSuspectedDuplicates flags transactions that look like an already stored transaction:
+// same account and amount within a day but a different description or date
+// This includes the same purchase under another description (a PDF statement and a CSV download
+// of the same month) and a second real purchase at the same merchant; exact duplicates (same date
+// and description) are skipped on insert and are not flagged
+func (db *DB) FlagSuspectedDuplicates(transactions []*Transaction) (int, error) {
+	flagged := 0
+	for _, tx := range transactions {
+		if tx.TransactionDate.IsZero() {
+			continue
+		}
+
+		id, err := db.findSuspectedDuplicate(tx)
+		if err != nil {
+			return flagged, err
+		}
+		if id != nil {
+			tx.AddReviewFlag(ReviewFlagSuspectedDuplicate)
+			tx.DuplicateOf = id
+			flagged++
+		}
+	}
+	return flagged, nil
+}
+
+// findSuspectedDuplicate returns the ID of a stored transaction that looks like tx, if any
+func (db *DB) findSuspectedDuplicate(tx *Transaction) (*int64, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, transaction_date, description
+		FROM transactions
+		WHERE account_last4 = ? AND amount = ?
+	`, tx.AccountLast4, tx.Amount)
+	if err != nil {
+		return nil, fmt.Errorf("query suspected duplicates: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int64
+		var date time.Time
+		var description string
+		if err := rows.Scan(&id, &date, &description); err != nil {
+			return nil, fmt.Errorf("scan suspected duplicate: %w", err)
+		}
+
+		days := tx.TransactionDate.Sub(date).Hours() / 24
+		if days < -duplicateWindowDays || days > duplicateWindowDays {
+			continue
+		}
+		if sameDay(date, tx.TransactionDate) && description == tx.Description {
+			continue // exact duplicate, skipped on insert
+		}
+		return &id, nil
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate suspected duplicates: %w", err)
+	}
+
+	return nil, nil
+}
+
+// QueuePendingTransactions stores flagged transactions in the review queue and returns how many
+// were queued
+// Rows already queued from the same source file (same account, date, description and amount, in
+// any status) are skipped, so processing a statement again doesn't queue its rows twice
+func (db *DB) QueuePendingTransactions(transactions []*Transaction) (int, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	existing, err := tx.Prepare(`
+		SELECT COUNT(*) FROM pending_transactions
+		WHERE source_file IS ? AND account_last4 IS ? AND transaction_date IS ? AND raw_date IS ?
+			AND description = ? AND amount = ?
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("prepare statement: %w", err)
+	}
+	defer existing.Close()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO pending_transactions (
+			account_name, account_last4, transaction_date, raw_date, post_date,
+			description, amount, transaction_type, balance,
+			statement_date, source_file, category, category_source,
+			flags, duplicate_of, currency
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	queued := 0
+	for _, t := range transactions {
+		var date interface{}
+		if !t.TransactionDate.IsZero() {
+			date = t.TransactionDate
+		}
+
+		var count int
+		err := existing.QueryRow(t.SourceFile, t.AccountLast4, date, nullString(t.RawDate), t.Description, t.Amount).Scan(&count)
+		if err != nil {
+			return queued, fmt.Errorf("check pending transaction: %w", err)
+		}
+		if count > 0 {
+			continue
+		}
+
+		_, err = stmt.Exec(
+			t.AccountName,
+			t.AccountLast4,
+			date,
+			nullString(t.RawDate),
+			t.PostDate,
+			t.Description,
+			t.Amount,
+			t.TransactionType,
+			t.Balance,
+			t.StatementDate,
+			t.SourceFile,
+			nullString(t.Category),
+			nullString(t.CategorySource),
+			strings.Join(t.ReviewFlags, ","),
+			t.DuplicateOf,
+			nullString(t.Currency),
+		)
+		if err != nil {
+			return queued, fmt.Errorf("queue pending transaction: %w", err)
+		}
+		queued++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return queued, nil
+}
+
+const pendingTransactionColumns = `
+	id, account_name, account_last4, transaction_date, COALESCE(raw_date, ''), post_date,
+	description, amount, transaction_type, balance,
+	statement_date, COALESCE(source_file, ''), COALESCE(category, ''), COALESCE(category_source, ''),
+	flags, duplicate_of, status, COALESCE(note, ''), transaction_id, created_at, reviewed_at,
+	COALESCE(currency, '')`
+
+// ListPendingTransactions returns queued transactions, optionally filtered by status and source file
+// An empty status or "all" returns every status
+func (db *DB) ListPendingTransactions(status, sourceFile string) ([]*PendingTransaction, error) {
+	query := `SELECT ` + pendingTransactionColumns + ` FROM pending_transactions WHERE 1=1`
+	var args []interface{}
+
+	if status != "" && status != "all" {
+		query += " AND status = ?"
+		args = append(args, status)
+	}
+	if sourceFile != "" {
+		query += " AND source_file = ?"
+		args = append(args, sourceFile)
+	}
+	query += " ORDER BY id"
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query pending transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var pending []*PendingTransaction
+	for rows.Next() {
+		p, err := scanPendingTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		pending = append(pending, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate pending transactions: %w", err)
+	}
+
+	return pending, nil
+}
+
+// GetPendingTransaction returns a queued transaction by ID
+func (db *DB) GetPendingTransaction(id int64) (*PendingTransaction, error) {
+	rows, err := db.conn.Query(`SELECT `+pendingTransactionColumns+` FROM pending_transactions WHERE id = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("query pending transaction: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("query pending transaction: %w", err)
+		}
+		return nil, fmt.Errorf("pending transaction %d not found", id)
+	}
+
+	return scanPendingTransaction(rows)
+}
+
+// scanPendingTransaction scans a row selected with pendingTransactionColumns
+func scanPendingTransaction(rows *sql.Rows) (*PendingTransaction, error) {
+	p := &PendingTransaction{Transaction: &Transaction{}}
+	tx := p.Transaction
+
+	var date sql.NullTime
+	var flags string
+	var duplicateOf, transactionID sql.NullInt64
+	var reviewedAt sql.NullTime
+
+	err := rows.Scan(
+		&p.ID,
+		&tx.AccountName,
+		&tx.AccountLast4,
+		&date,
+		&p.RawDate,
+		&tx.PostDate,
+		&tx.Description,
+		&tx.Amount,
+		&tx.TransactionType,
+		&tx.Balance,
+		&tx.StatementDate,
+		&tx.SourceFile,
+		&tx.Category,
+		&tx.CategorySource,
+		&flags,
+		&duplicateOf,
+		&p.Status,
+		&p.Note,
+		&transactionID,
+		&p.CreatedAt,
+		&reviewedAt,
+		&tx.Currency,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("scan pending transaction: %w", err)
+	}
+
+	if date.Valid {
+		tx.TransactionDate = date.Time
+	}
+	p.Flags = []string{}
+	if flags != "" {
+		p.Flags = strings.Split(flags, ",")
+	}
+	tx.ReviewFlags = p.Flags
+	if duplicateOf.Valid {
+		p.DuplicateOf = &duplicateOf.Int64
+	}
+	if transactionID.Valid {
+		p.TransactionID = &transactionID.Int64
+	}
+	if reviewedAt.Valid {
+		p.ReviewedAt = &reviewedAt.Time
+	}
+
+	return p, nil
+}
+
+// PendingEdit holds the fields a reviewer can correct; nil fields are left unchanged
+type PendingEdit struct {
+	TransactionDate *time.Time
+	Description     *string
+	Amount          *money.Cents
+	TransactionType *string
+	Category        *string
+}
+
+// EditPendingTransaction corrects a queued transaction and re-evaluates the flags the edit can fix
+func (db *DB) EditPendingTransaction(id int64, edit PendingEdit) (*PendingTransaction, error) {
+	p, err := db.GetPendingTransaction(id)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status != ReviewStatusPending {
+		return nil, fmt.Errorf("pending transaction %d is already %s", id, p.Status)
+	}
+
+	tx := p.Transaction
+	if edit.TransactionDate != nil {
+		tx.TransactionDate = *edit.TransactionDate
+	}
+	if edit.Description != nil {
+		tx.Description = *edit.Description
+	}
+	if edit.Amount != nil {
+		tx.Amount = *edit.Amount
+	}
+	if edit.TransactionType != nil {
+		if *edit.TransactionType != "debit" && *edit.TransactionType != "credit" {
+			return nil, fmt.Errorf("transaction type must be 'debit' or 'credit', got: %s", *edit.TransactionType)
+		}
+		tx.TransactionType = *edit.TransactionType
+	}
+	if edit.Category != nil {
+		tx.Category = *edit.Category
+		tx.CategorySource = CategorySourceManual
+		if tx.Category == "" {
+			tx.CategorySource = ""
+		}
+	}
+
+	// Drop flags the edit resolved; a suspected duplicate stays flagged until approved or rejected
+	var flags []string
+	for _, flag := range p.Flags {
+		switch {
+		case flag == ReviewFlagUnparseableDate && !tx.TransactionDate.IsZero():
+		case flag == ReviewFlagSignMismatch && !tx.SignMismatch():
+		default:
+			flags = append(flags, flag)
+		}
+	}
+	if tx.SignMismatch() && !contains(flags, ReviewFlagSignMismatch) {
+		flags = append(flags, ReviewFlagSignMismatch)
+	}
+
+	var date interface{}
+	if !tx.TransactionDate.IsZero() {
+		date = tx.TransactionDate
+	}
+
+	_, err = db.conn.Exec(`
+		UPDATE pending_transactions
+		SET transaction_date = ?, description = ?, amount = ?, transaction_type = ?,
+			category = ?, category_source = ?, flags = ?
+		WHERE id = ?
+	`, date, tx.Description, tx.Amount, tx.TransactionType,
+		nullString(tx.Category), nullString(tx.CategorySource), strings.Join(flags, ","), id)
+	if err != nil {
+		return nil, fmt.Errorf("update pending transaction: %w", err)
+	}
+
+	return db.GetPendingTransaction(id)
+}
+
+// ApprovePendingTransaction moves a queued transaction into transactions
+// Returns the new transaction ID, or nil if an identical transaction already existed
+func (db *DB) ApprovePendingTransaction(id int64) (*int64, error) {
+	p, err := db.GetPendingTransaction(id)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status != ReviewStatusPending {
+		return nil, fmt.Errorf("pending transaction %d is already %s", id, p.Status)
+	}
+
+	tx := p.Transaction
+	if tx.TransactionDate.IsZero() {
+		return nil, fmt.Errorf("pending transaction %d has no valid date (raw: %q); edit it first", id, p.RawDate)
+	}
+	if tx.TransactionType != "debit" && tx.TransactionType != "credit" {
+		return nil, fmt.Errorf("pending transaction %d has invalid type %q; edit it first", id, tx.TransactionType)
+	}
+
+	// Approved rows go through the same account, merchant and category steps as imported ones
+	if err := db.prepareTransactions([]*Transaction{tx}); err != nil {
+		return nil, err
+	}
+
+	sqlTx, err := db.conn.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer sqlTx.Rollback()
+
+	var transactionID *int64
+	inserted, _, err := insertTransactions(sqlTx, []*Transaction{tx})
+	if err != nil {
+		return nil, err
+	}
+	if inserted > 0 {
+		transactionID = &tx.ID
+	}
+
+	_, err = sqlTx.Exec(`
+		UPDATE pending_transactions
+		SET status = ?, transaction_id = ?, reviewed_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, ReviewStatusApproved, transactionID, id)
+	if err != nil {
+		return nil, fmt.Errorf("update pending transaction: %w", err)
+	}
+
+	if err := sqlTx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return transactionID, nil
+}
+
+// RejectPendingTransaction discards a queued transaction, keeping it for the record
+func (db *DB) RejectPendingTransaction(id int64, note string) error {
+	result, err := db.conn.Exec(`
+		UPDATE pending_transactions
+		SET status = ?, note = ?, reviewed_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND status = ?
+	`, ReviewStatusRejected, nullString(note), id, ReviewStatusPending)
+	if err != nil {
+		return fmt.Errorf("reject pending transaction: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("pending transaction %d not found or already reviewed", id)
+	}
+
+	return nil
+}
+
+// sameDay reports whether two times fall on the same calendar date
+func sameDay(a, b time.Time) bool {
+	return a.Format("2006-01-02") == b.Format("2006-01-02")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"money"
+)
+
+func TestReviewQueue(t *testing.T) {
+	dbPath := "./test_review.db"
+	defer os.Remove(dbPath)
+
+	db, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	date := time.Date(2024, 10, 15, 0, 0, 0, 0, time.UTC)
+	stored := &Transaction{AccountName: "Checking", AccountLast4: "1234", TransactionDate: date, Description: "WHOLE FOODS #12", Amount: -5234, TransactionType: "debit", StatementDate: date}
+	if _, _, err := db.InsertTransactions([]*Transaction{stored}); err != nil {
+		t.Fatalf("Failed to insert transaction: %v", err)
+	}
+
+	parsed := []*Transaction{
+		{AccountName: "Checking", AccountLast4: "1234", TransactionDate: date.AddDate(0, 0, 1), Description: "WHOLE FOODS MKT", Amount: -5234, TransactionType: "debit", StatementDate: date, Currency: "EUR"},
+		{AccountName: "Checking", AccountLast4: "1234", TransactionDate: date, Description: "WHOLE FOODS #12", Amount: -5234, TransactionType: "debit", StatementDate: date},
+		{AccountName: "Checking", AccountLast4: "1234", RawDate: "10/3?/24", Description: "REFUND", Amount: -2000, TransactionType: "credit", StatementDate: date,
+			ReviewFlags: []string{ReviewFlagUnparseableDate, ReviewFlagSignMismatch}},
+	}
+
+	flagged, err := db.FlagSuspectedDuplicates(parsed)
+	if err != nil {
+		t.Fatalf("FlagSuspectedDuplicates failed: %v", err)
+	}
+	if flagged != 1 || !parsed[0].HasReviewFlag(ReviewFlagSuspectedDuplicate) || parsed[1].NeedsReview() {
+		t.Fatalf("Expected only the near-duplicate to be flagged, got %d", flagged)
+	}
+
+	if queued, err := db.QueuePendingTransactions([]*Transaction{parsed[0], parsed[2]}); err != nil || queued != 2 {
+		t.Fatalf("QueuePendingTransactions failed: queued=%d err=%v", queued, err)
+	}
+	// The same statement processed again queues nothing new
+	if queued, err := db.QueuePendingTransactions([]*Transaction{parsed[0], parsed[2]}); err != nil || queued != 0 {
+		t.Fatalf("Expected nothing queued the second time, got %d (err=%v)", queued, err)
+	}
+
+	pending, err := db.ListPendingTransactions(ReviewStatusPending, "")
+	if err != nil || len(pending) != 2 {
+		t.Fatalf("Expected 2 pending transactions, got %d (err=%v)", len(pending), err)
+	}
+	refund := pending[1]
+	if pending[0].Transaction.Currency != "EUR" {
+		t.Errorf("Expected the queued currency to be kept, got %q", pending[0].Transaction.Currency)
+	}
+	if refund.RawDate != "10/3?/24" || len(refund.Flags) != 2 {
+		t.Fatalf("Unexpected pending refund: %+v", refund)
+	}
+
+	// Rows without a valid date can't be approved until edited
+	if _, err := db.ApprovePendingTransaction(refund.ID); err == nil {
+		t.Fatal("Expected approval without a date to fail")
+	}
+
+	fixedDate := time.Date(2024, 10, 30, 0, 0, 0, 0, time.UTC)
+	fixedAmount := money.Cents(2000)
+	edited, err := db.EditPendingTransaction(refund.ID, PendingEdit{TransactionDate: &fixedDate, Amount: &fixedAmount})
+	if err != nil {
+		t.Fatalf("EditPendingTransaction failed: %v", err)
+	}
+	if len(edited.Flags) != 0 {
+		t.Errorf("Expected edit to clear flags, got %v", edited.Flags)
+	}
+
+	if _, err := db.AddMerchantRule(&MerchantRule{Merchant: "Whole Foods", MatchType: "substring", Pattern: "REFUND"}); err != nil {
+		t.Fatalf("Failed to add merchant rule: %v", err)
+	}
+
+	transactionID, err := db.ApprovePendingTransaction(refund.ID)
+	if err != nil || transactionID == nil {
+		t.Fatalf("ApprovePendingTransaction failed: id=%v err=%v", transactionID, err)
+	}
+	approved, err := db.GetTransaction(*transactionID)
+	if err != nil || approved.AccountID == nil || *approved.AccountID != *stored.AccountID || approved.AccountName != "Checking" ||
+		approved.MerchantName != "Whole Foods" || approved.Currency != "USD" {
+		t.Errorf("Expected the approved refund on the imported account, got %+v (err=%v)", approved, err)
+	}
+
+	if err := db.RejectPendingTransaction(pending[0].ID, "same purchase"); err != nil {
+		t.Fatalf("RejectPendingTransaction failed: %v", err)
+	}
+	if err := db.RejectPendingTransaction(pending[0].ID, ""); err == nil {
+		t.Error("Expected rejecting an already reviewed row to fail")
+	}
+
+	remaining, _ := db.ListPendingTransactions(ReviewStatusPending, "")
+	if len(remaining) != 0 {
+		t.Errorf("Expected empty queue, got %d rows", len(remaining))
+	}
+
+	refunds, err := db.QueryTransactionsWithType(fixedDate, fixedDate, "", "credit", "", "", false)
+	if err != nil || len(refunds) != 1 || refunds[0].Amount != 2000 {
+		t.Errorf("Expected the approved refund in transactions, got %+v (err=%v)", refunds, err)
+	}
+}
@@ -0,0 +1,269 @@
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Search modes reported with results
+const (
+	SearchModeFTS  = "fts5" // ranked full-text search
+	SearchModeLike = "like" // substring fallback when SQLite was built without FTS5
+)
+
+// searchTable is the full-text index over transactions; it stores no text of its own
+// (content='transactions') and is kept in sync by the searchTriggers
+const searchTable = `
+	CREATE VIRTUAL TABLE IF NOT EXISTS transactions_fts USING fts5(
+		description, merchant_name, account_name, source_file, notes,
+		content='transactions', content_rowid='id',
+		tokenize='unicode61 remove_diacritics 2'
+	)`
+
+const searchTriggers = `
+	CREATE TRIGGER IF NOT EXISTS transactions_fts_insert AFTER INSERT ON transactions BEGIN
+		INSERT INTO transactions_fts(rowid, description, merchant_name, account_name, source_file, notes)
+		VALUES (new.id, new.description, new.merchant_name, new.account_name, new.source_file, new.notes);
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS transactions_fts_delete AFTER DELETE ON transactions BEGIN
+		INSERT INTO transactions_fts(transactions_fts, rowid, description, merchant_name, account_name, source_file, notes)
+		VALUES ('delete', old.id, old.description, old.merchant_name, old.account_name, old.source_file, old.notes);
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS transactions_fts_update
+		AFTER UPDATE OF description, merchant_name, account_name, source_file, notes ON transactions BEGIN
+		INSERT INTO transactions_fts(transactions_fts, rowid, description, merchant_name, account_name, source_file, notes)
+		VALUES ('delete', old.id, old.description, old.merchant_name, old.account_name, old.source_file, old.notes);
+		INSERT INTO transactions_fts(rowid, description, merchant_name, account_name, source_file, notes)
+		VALUES (new.id, new.description, new.merchant_name, new.account_name, new.source_file, new.notes);
+	END;`
+
+const dropSearchTriggers = `
+	DROP TRIGGER IF EXISTS transactions_fts_insert;
+	DROP TRIGGER IF EXISTS transactions_fts_delete;
+	DROP TRIGGER IF EXISTS transactions_fts_update;`
+
+// searchWeights are the bm25 weights of the indexed columns, in table order
+const searchWeights = "10.0, 10.0, 2.0, 1.0, 5.0"
+
+// SearchOptions narrows a full-text search; zero values don't filter
+type SearchOptions struct {
+	StartDate       time.Time
+	EndDate         time.Time
+	Account         string // account name substring or last 4 digits
+	TransactionType string // "debit", "credit" or "" for both
+	Category        string
+	Limit           int // default 50
+}
+
+// SearchResult is a transaction matching a search, best match first
+type SearchResult struct {
+	Transaction *Transaction `json:"transaction"`
+	Score       float64      `json:"score"`   // higher is a better match; 0 in like mode
+	Snippet     string       `json:"snippet"` // matched text with terms in [brackets]
+}
+
+// ensureSearchIndex creates the full-text index and its sync triggers when SQLite has FTS5
+// Without FTS5 the triggers are dropped so writes keep working; the index is rebuilt the next
+// time the database is opened by a binary built with FTS5
+func (db *DB) ensureSearchIndex() error {
+	var available bool
+	if err := db.conn.QueryRow(`SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&available); err != nil {
+		return fmt.Errorf("check fts5 support: %w", err)
+	}
+
+	if !available {
+		if _, err := db.conn.Exec(dropSearchTriggers); err != nil {
+			return fmt.Errorf("drop search triggers: %w", err)
+		}
+		return nil
+	}
+
+	if _, err := db.conn.Exec(searchTable); err != nil {
+		return fmt.Errorf("create search index: %w", err)
+	}
+	db.fts = true
+
+	var triggers int
+	err := db.conn.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name = 'transactions_fts_insert'`).Scan(&triggers)
+	if err != nil {
+		return fmt.Errorf("inspect search triggers: %w", err)
+	}
+	if triggers > 0 {
+		return nil
+	}
+
+	// New index, or one that missed writes while the triggers were gone
+	if _, err := db.conn.Exec(searchTriggers); err != nil {
+		return fmt.Errorf("create search triggers: %w", err)
+	}
+	if _, err := db.conn.Exec(`INSERT INTO transactions_fts(transactions_fts) VALUES ('rebuild')`); err != nil {
+		return fmt.Errorf("rebuild search index: %w", err)
+	}
+
+	return nil
+}
+
+// SearchMode reports whether searches are ranked full-text searches or the substring fallback
+func (db *DB) SearchMode() string {
+	if db.fts {
+		return SearchModeFTS
+	}
+	return SearchModeLike
+}
+
+// SearchTransactions finds transactions whose description, merchant, account, source file or
+// notes contain every word of the query (as a word prefix in fts5 mode), best match first
+func (db *DB) SearchTransactions(query string, opts SearchOptions) ([]*SearchResult, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("search query has no words")
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = 50
+	}
+
+	filter, args := searchFilter(opts)
+
+	var sqlQuery string
+	var queryArgs []interface{}
+	if db.fts {
+		matches := make([]string, len(terms))
+		for i, term := range terms {
+			matches[i] = `"` + term + `"*`
+		}
+		sqlQuery = `
+			SELECT transactions.id, -bm25(transactions_fts, ` + searchWeights + `) AS score,
+				snippet(transactions_fts, -1, '[', ']', '...', 12)
+			FROM transactions_fts
+			JOIN transactions ON transactions.id = transactions_fts.rowid
+			WHERE transactions_fts MATCH ?` + filter + `
+			ORDER BY score DESC, transactions.transaction_date DESC
+			LIMIT ?`
+		queryArgs = append([]interface{}{strings.Join(matches, " ")}, args...)
+	} else {
+		var conditions []string
+		for _, term := range terms {
+			conditions = append(conditions, `(transactions.description LIKE ? OR transactions.merchant_name LIKE ?
+				OR transactions.account_name LIKE ? OR transactions.source_file LIKE ? OR transactions.notes LIKE ?)`)
+			like := "%" + term + "%"
+			queryArgs = append(queryArgs, like, like, like, like, like)
+		}
+		sqlQuery = `
+			SELECT transactions.id, 0, ''
+			FROM transactions
+			WHERE ` + strings.Join(conditions, " AND ") + filter + `
+			ORDER BY transactions.transaction_date DESC, transactions.id DESC
+			LIMIT ?`
+		queryArgs = append(queryArgs, args...)
+	}
+	queryArgs = append(queryArgs, opts.Limit)
+
+	rows, err := db.conn.Query(sqlQuery, queryArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("search transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*SearchResult
+	var ids []interface{}
+	for rows.Next() {
+		var id int64
+		r := &SearchResult{}
+		if err := rows.Scan(&id, &r.Score, &r.Snippet); err != nil {
+			return nil, fmt.Errorf("scan search result: %w", err)
+		}
+		r.Transaction = &Transaction{ID: id}
+		results = append(results, r)
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate search results: %w", err)
+	}
+	rows.Close()
+
+	if len(results) == 0 {
+		return results, nil
+	}
+
+	// Load the matched transactions in full, keeping the ranking order
+	txRows, err := db.conn.Query(`
+		SELECT `+transactionColumns+`
+		FROM transactions
+		WHERE id IN (?`+strings.Repeat(", ?", len(ids)-1)+`)
+	`, ids...)
+	if err != nil {
+		return nil, fmt.Errorf("query search results: %w", err)
+	}
+	defer txRows.Close()
+
+	transactions, err := scanTransactions(txRows)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]*Transaction, len(transactions))
+	for _, tx := range transactions {
+		byID[tx.ID] = tx
+	}
+
+	highlight := highlightPattern(terms)
+	for _, r := range results {
+		r.Transaction = byID[r.Transaction.ID]
+		if r.Snippet == "" {
+			r.Snippet = highlight.ReplaceAllString(r.Transaction.Description, "[$0]")
+		}
+	}
+
+	return results, nil
+}
+
+// searchTerms splits a query into lowercase words, dropping punctuation and FTS syntax
+func searchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// searchFilter builds the extra WHERE conditions for the search options
+func searchFilter(opts SearchOptions) (string, []interface{}) {
+	var filter string
+	var args []interface{}
+
+	if !opts.StartDate.IsZero() {
+		filter += " AND transactions.transaction_date >= ?"
+		args = append(args, opts.StartDate)
+	}
+	if !opts.EndDate.IsZero() {
+		filter += " AND transactions.transaction_date <= ?"
+		args = append(args, opts.EndDate)
+	}
+	if opts.Account != "" {
+		filter += " AND (transactions.account_name LIKE ? OR transactions.account_last4 = ?)"
+		args = append(args, "%"+opts.Account+"%", opts.Account)
+	}
+	if opts.TransactionType != "" {
+		filter += " AND transactions.transaction_type = ?"
+		args = append(args, opts.TransactionType)
+	}
+	if opts.Category == UncategorizedFilter {
+		filter += " AND (transactions.category IS NULL OR transactions.category = '')"
+	} else if opts.Category != "" {
+		filter += " AND transactions.category = ?"
+		args = append(args, strings.ToLower(opts.Category))
+	}
+
+	return filter, args
+}
+
+// highlightPattern matches any of the search terms, ignoring case
+func highlightPattern(terms []string) *regexp.Regexp {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = regexp.QuoteMeta(term)
+	}
+	return regexp.MustCompile(`(?i)` + strings.Join(quoted, "|"))
+}
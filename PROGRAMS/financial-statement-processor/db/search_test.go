@@ -0,0 +1,94 @@
+package db
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"money"
+)
+
+// TestSearchTransactions runs against whichever search mode the test binary was built with;
+// run it with -tags sqlite_fts5 to cover the full-text index
+func TestSearchTransactions(t *testing.T) {
+	dbPath := "./test_search.db"
+	defer os.Remove(dbPath)
+
+	db, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	date := func(m time.Month, d int) time.Time { return time.Date(2024, m, d, 0, 0, 0, 0, time.UTC) }
+	tx := func(d time.Time, description string, amount float64) *Transaction {
+		txType := "debit"
+		if amount > 0 {
+			txType = "credit"
+		}
+		return &Transaction{AccountName: "Visa", AccountLast4: "2222", TransactionDate: d, Description: description,
+			Amount: money.FromFloat(amount), TransactionType: txType, StatementDate: d, SourceFile: "visa_2024.pdf"}
+	}
+
+	transactions := []*Transaction{
+		tx(date(4, 2), "AMAZON.COM*2K4LM REFUND", 23.99),
+		tx(date(4, 1), "AMAZON.COM*9XQ1Z", -23.99),
+		tx(date(9, 10), "AMAZON MKTP US REFUND", 12.00),
+		tx(date(5, 20), "WHOLE FOODS MARKET", -80.15),
+	}
+	if _, _, err := db.InsertTransactions(transactions); err != nil {
+		t.Fatalf("Failed to insert transactions: %v", err)
+	}
+	if _, err := db.AddManualTransaction(&Transaction{AccountName: "Cash", TransactionDate: date(5, 1), Description: "Garage sale",
+		Amount: -1500, Notes: "old amazon kindle"}); err != nil {
+		t.Fatalf("Failed to add transaction: %v", err)
+	}
+
+	t.Logf("search mode: %s", db.SearchMode())
+
+	spring := SearchOptions{StartDate: date(3, 1), EndDate: date(5, 31)}
+	results, err := db.SearchTransactions("amazon refund", spring)
+	if err != nil {
+		t.Fatalf("Failed to search: %v", err)
+	}
+	if len(results) != 1 || results[0].Transaction.Description != "AMAZON.COM*2K4LM REFUND" {
+		t.Fatalf("Expected the spring Amazon refund, got %+v", results)
+	}
+	if !strings.Contains(strings.ToLower(results[0].Snippet), "[refund]") {
+		t.Errorf("Expected the snippet to highlight the match, got %q", results[0].Snippet)
+	}
+
+	// Notes are searched too, and word prefixes match
+	results, err = db.SearchTransactions("kindl", SearchOptions{})
+	if err != nil || len(results) != 1 || results[0].Transaction.Description != "Garage sale" {
+		t.Errorf("Expected to find the transaction by its notes, got %+v (err=%v)", results, err)
+	}
+
+	results, err = db.SearchTransactions("amazon", SearchOptions{TransactionType: "debit", Account: "2222"})
+	if err != nil || len(results) != 1 {
+		t.Errorf("Expected 1 Amazon debit on the Visa, got %d (err=%v)", len(results), err)
+	}
+
+	// Edits and deletes keep the index in sync
+	description := "CORNER GROCER"
+	if _, err := db.EditTransaction(4, TransactionEdit{Description: &description}); err != nil {
+		t.Fatalf("Failed to edit transaction: %v", err)
+	}
+	if results, _ := db.SearchTransactions("whole foods", SearchOptions{}); len(results) != 0 {
+		t.Errorf("Expected the old description to be gone from the index, got %d results", len(results))
+	}
+	if results, _ := db.SearchTransactions("grocer", SearchOptions{}); len(results) != 1 {
+		t.Errorf("Expected the new description to be indexed, got %d results", len(results))
+	}
+	if err := db.DeleteTransaction(3); err != nil {
+		t.Fatalf("Failed to delete transaction: %v", err)
+	}
+	if results, _ := db.SearchTransactions("refund", SearchOptions{}); len(results) != 1 {
+		t.Errorf("Expected 1 refund after delete, got %d", len(results))
+	}
+
+	if _, err := db.SearchTransactions(`"*"`, SearchOptions{}); err == nil {
+		t.Error("Expected an error for a query without words")
+	}
+}
@@ -2,33 +2,51 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+	"money"
 )
 
 // DB wraps the SQLite database connection
 type DB struct {
 	conn *sql.DB
+	path string
+	fts  bool // SQLite was built with FTS5 and transactions_fts is in sync
 }
 
 // Transaction represents a financial transaction
 type Transaction struct {
-	ID              int64      `json:"id"`
-	AccountName     string     `json:"account_name"`
-	AccountLast4    string     `json:"account_last4"`
-	TransactionDate time.Time  `json:"transaction_date"`
-	PostDate        *time.Time `json:"post_date,omitempty"`
-	Description     string     `json:"description"`
-	Amount          float64    `json:"amount"`
-	TransactionType string     `json:"transaction_type"` // "debit" or "credit"
-	Balance         *float64   `json:"balance,omitempty"`
-	StatementDate   time.Time  `json:"statement_date"`
-	SourceFile      string     `json:"source_file"`
-	CreatedAt       time.Time  `json:"created_at,omitempty"`
-	UpdatedAt       time.Time  `json:"updated_at,omitempty"`
+	ID              int64           `json:"id"`
+	AccountID       *int64          `json:"account_id,omitempty"` // accounts row the statement was attached to
+	AccountName     string          `json:"account_name"`
+	AccountLast4    string          `json:"account_last4"`
+	TransactionDate time.Time       `json:"transaction_date"`
+	PostDate        *time.Time      `json:"post_date,omitempty"`
+	Description     string          `json:"description"`
+	Amount          money.Cents     `json:"amount"`
+	Currency        string          `json:"currency"`         // code of the amount's currency; the account's currency when not given
+	TransactionType string          `json:"transaction_type"` // "debit" or "credit"
+	Balance         *money.Cents    `json:"balance,omitempty"`
+	StatementDate   time.Time       `json:"statement_date"`
+	SourceFile      string          `json:"source_file"`
+	Category        string          `json:"category,omitempty"`
+	CategorySource  string          `json:"category_source,omitempty"` // "rule", "llm" or "manual"
+	MerchantName    string          `json:"merchant_name,omitempty"`   // canonical merchant from merchant rules or the cleaned description
+	TransferID      *int64          `json:"transfer_id,omitempty"`     // linked transfer this is one side of
+	Notes           string          `json:"notes,omitempty"`
+	SplitFromID     *int64          `json:"split_from_id,omitempty"` // transaction this line was split from
+	ManualChanges   []*ManualChange `json:"manual_changes,omitempty"`
+	CreatedAt       time.Time       `json:"created_at,omitempty"`
+	UpdatedAt       time.Time       `json:"updated_at,omitempty"`
+
+	// Set while parsing for rows that need human review; stored in pending_transactions
+	ReviewFlags []string `json:"-"`
+	RawDate     string   `json:"-"` // date text that couldn't be parsed
+	DuplicateOf *int64   `json:"-"` // stored transaction this one looks like
 }
 
 // ProcessingLog represents a statement processing record
@@ -44,8 +62,54 @@ type ProcessingLog struct {
 	ProcessedAt          time.Time
 }
 
-// New creates a new database connection and initializes schema
+// processingLogStatuses are the allowed processing_log.status values
+var processingLogStatuses = []string{"success", "parse_error", "db_error", "reconcile_error"}
+
+// processingLogTable creates the processing log table; it matches the baseline migration and is
+// used to rebuild the table of legacy databases
+var processingLogTable = `CREATE TABLE IF NOT EXISTS processing_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		source_file TEXT NOT NULL,
+		statement_date DATE,
+		account_name TEXT,
+		transactions_inserted INTEGER DEFAULT 0,
+		transactions_skipped INTEGER DEFAULT 0,
+		status TEXT NOT NULL CHECK (status IN ('` + strings.Join(processingLogStatuses, "', '") + `')),
+		error_message TEXT,
+		processed_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`
+
+const processingLogIndexes = `
+	CREATE INDEX IF NOT EXISTS idx_processing_log_file
+		ON processing_log(source_file);
+
+	CREATE INDEX IF NOT EXISTS idx_processing_log_status
+		ON processing_log(status);
+	`
+
+// New creates a new database connection, applies pending schema migrations and prepares the
+// parts of the database that depend on this build
 func New(dbPath string) (*DB, error) {
+	db, err := Open(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.Migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if err := db.initData(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("initialize database: %w", err)
+	}
+
+	return db, nil
+}
+
+// Open creates a new database connection without touching the schema
+func Open(dbPath string) (*DB, error) {
 	conn, err := sql.Open("sqlite3", dbPath)
 	if err != nil {
 		return nil, fmt.Errorf("open database: %w", err)
@@ -64,15 +128,7 @@ func New(dbPath string) (*DB, error) {
 		return nil, fmt.Errorf("enable foreign keys: %w", err)
 	}
 
-	db := &DB{conn: conn}
-
-	// Initialize schema
-	if err := db.initSchema(); err != nil {
-		conn.Close()
-		return nil, fmt.Errorf("initialize schema: %w", err)
-	}
-
-	return db, nil
+	return &DB{conn: conn, path: dbPath}, nil
 }
 
 // Close closes the database connection
@@ -80,88 +136,144 @@ func (db *DB) Close() error {
 	return db.conn.Close()
 }
 
-// initSchema creates the necessary tables if they don't exist
-func (db *DB) initSchema() error {
-	schema := `
-	-- Transactions table
-	CREATE TABLE IF NOT EXISTS transactions (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
+// initData brings rows and build-dependent tables in line with the migrated schema
+func (db *DB) initData() error {
+	// Transactions stored before the accounts table existed are attached to accounts by name
+	if err := db.ensureTransactionAccounts(); err != nil {
+		return err
+	}
 
-		-- Account information
-		account_name TEXT NOT NULL,
-		account_last4 TEXT NOT NULL,
+	// Full-text search depends on SQLite being built with FTS5, so it isn't a migration
+	if err := db.ensureSearchIndex(); err != nil {
+		return err
+	}
 
-		-- Transaction details
-		transaction_date DATE NOT NULL,
-		post_date DATE,
-		description TEXT NOT NULL,
-		amount REAL NOT NULL,
-		transaction_type TEXT NOT NULL CHECK (transaction_type IN ('debit', 'credit')),
-		balance REAL,
+	if err := db.seedCategories(); err != nil {
+		return err
+	}
 
-		-- Statement metadata
-		statement_date DATE NOT NULL,
-		source_file TEXT,
+	return nil
+}
 
-		-- Timestamps
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+// upgradeLegacySchema brings a database created before versioned migrations up to the baseline
+// migration; older releases added columns and statuses to existing tables in place, and the
+// baseline indexes those columns
+func (db *DB) upgradeLegacySchema() error {
+	var transactionsTable, logTable int
+	err := db.conn.QueryRow(`
+		SELECT
+			COUNT(CASE WHEN name = 'transactions' THEN 1 END),
+			COUNT(CASE WHEN name = 'processing_log' THEN 1 END)
+		FROM sqlite_master WHERE type = 'table'
+	`).Scan(&transactionsTable, &logTable)
+	if err != nil {
+		return fmt.Errorf("inspect legacy schema: %w", err)
+	}
 
-		-- Prevent duplicate transactions
-		UNIQUE(account_last4, transaction_date, description, amount)
-	);
+	if transactionsTable > 0 {
+		for _, column := range []string{"category", "category_source", "merchant_name", "notes", "manual_changes"} {
+			if err := db.ensureColumn("transactions", column, "TEXT"); err != nil {
+				return err
+			}
+		}
+		for _, column := range []string{"split_from_id", "account_id"} {
+			if err := db.ensureColumn("transactions", column, "INTEGER"); err != nil {
+				return err
+			}
+		}
+	}
 
-	-- Indexes for common queries
-	CREATE INDEX IF NOT EXISTS idx_transactions_account
-		ON transactions(account_name, account_last4);
+	// New processing statuses need the CHECK constraint widened
+	if logTable > 0 {
+		if err := db.ensureProcessingLogStatuses(); err != nil {
+			return err
+		}
+	}
 
-	CREATE INDEX IF NOT EXISTS idx_transactions_date
-		ON transactions(transaction_date);
+	return nil
+}
 
-	CREATE INDEX IF NOT EXISTS idx_transactions_post_date
-		ON transactions(post_date);
+// ensureProcessingLogStatuses rebuilds processing_log if its CHECK constraint predates a status
+// SQLite can't alter constraints in place, so the table is copied into a new one
+func (db *DB) ensureProcessingLogStatuses() error {
+	var tableSQL string
+	err := db.conn.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'processing_log'`).Scan(&tableSQL)
+	if err != nil {
+		return fmt.Errorf("inspect processing_log: %w", err)
+	}
 
-	CREATE INDEX IF NOT EXISTS idx_transactions_statement_date
-		ON transactions(statement_date);
+	upToDate := true
+	for _, status := range processingLogStatuses {
+		if !strings.Contains(tableSQL, "'"+status+"'") {
+			upToDate = false
+			break
+		}
+	}
+	if upToDate {
+		return nil
+	}
 
-	CREATE INDEX IF NOT EXISTS idx_transactions_type
-		ON transactions(transaction_type);
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("begin processing_log rebuild: %w", err)
+	}
+	defer tx.Rollback()
 
-	CREATE INDEX IF NOT EXISTS idx_transactions_account_date
-		ON transactions(account_name, transaction_date DESC);
+	statements := []string{
+		`ALTER TABLE processing_log RENAME TO processing_log_old`,
+		processingLogTable,
+		`INSERT INTO processing_log (
+			id, source_file, statement_date, account_name,
+			transactions_inserted, transactions_skipped,
+			status, error_message, processed_at
+		)
+		SELECT id, source_file, statement_date, account_name,
+			transactions_inserted, transactions_skipped,
+			status, error_message, processed_at
+		FROM processing_log_old`,
+		`DROP TABLE processing_log_old`,
+		processingLogIndexes,
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("rebuild processing_log: %w", err)
+		}
+	}
 
-	-- Processing log table
-	CREATE TABLE IF NOT EXISTS processing_log (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		source_file TEXT NOT NULL,
-		statement_date DATE,
-		account_name TEXT,
-		transactions_inserted INTEGER DEFAULT 0,
-		transactions_skipped INTEGER DEFAULT 0,
-		status TEXT NOT NULL CHECK (status IN ('success', 'parse_error', 'db_error')),
-		error_message TEXT,
-		processed_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit processing_log rebuild: %w", err)
+	}
 
-	CREATE INDEX IF NOT EXISTS idx_processing_log_file
-		ON processing_log(source_file);
+	return nil
+}
 
-	CREATE INDEX IF NOT EXISTS idx_processing_log_status
-		ON processing_log(status);
+// ensureColumn adds a column to a table if it doesn't exist yet
+func (db *DB) ensureColumn(table, column, definition string) error {
+	rows, err := db.conn.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("inspect table %s: %w", table, err)
+	}
+	defer rows.Close()
 
-	-- Trigger to update updated_at
-	CREATE TRIGGER IF NOT EXISTS update_transactions_updated_at
-		AFTER UPDATE ON transactions
-		FOR EACH ROW
-	BEGIN
-		UPDATE transactions SET updated_at = CURRENT_TIMESTAMP
-		WHERE id = NEW.id;
-	END;
-	`
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return fmt.Errorf("scan table info: %w", err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate table info: %w", err)
+	}
+	rows.Close()
 
-	_, err := db.conn.Exec(schema)
+	_, err = db.conn.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
 	if err != nil {
-		return fmt.Errorf("create schema: %w", err)
+		return fmt.Errorf("add column %s.%s: %w", table, column, err)
 	}
 
 	return nil
@@ -170,16 +282,21 @@ func (db *DB) initSchema() error {
 // InsertTransaction inserts a transaction into the database
 // Returns true if inserted, false if skipped (duplicate)
 func (db *DB) InsertTransaction(tx *Transaction) (bool, error) {
+	if _, err := db.AssignAccounts([]*Transaction{tx}); err != nil {
+		return false, fmt.Errorf("assign account: %w", err)
+	}
+
 	query := `
 		INSERT INTO transactions (
-			account_name, account_last4, transaction_date, post_date,
+			account_id, account_name, account_last4, transaction_date, post_date,
 			description, amount, transaction_type, balance,
-			statement_date, source_file
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			statement_date, source_file, category, category_source, merchant_name, currency
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	result, err := db.conn.Exec(
 		query,
+		tx.AccountID,
 		tx.AccountName,
 		tx.AccountLast4,
 		tx.TransactionDate,
@@ -190,6 +307,10 @@ func (db *DB) InsertTransaction(tx *Transaction) (bool, error) {
 		tx.Balance,
 		tx.StatementDate,
 		tx.SourceFile,
+		nullString(tx.Category),
+		nullString(tx.CategorySource),
+		nullString(tx.MerchantName),
+		tx.Currency,
 	)
 
 	if err != nil {
@@ -209,19 +330,56 @@ func (db *DB) InsertTransaction(tx *Transaction) (bool, error) {
 }
 
 // InsertTransactions inserts multiple transactions in a single transaction
+// Merchant and category rules are applied to any transaction that doesn't already have a
+// merchant name or category
+// A transaction is skipped as a duplicate when the same account already has one on the same
+// date with the same description and amount; rows that only share the merchant are inserted, so
+// FlagSuspectedDuplicates should hold those for review first
 func (db *DB) InsertTransactions(transactions []*Transaction) (inserted int, skipped int, err error) {
+	if err := db.prepareTransactions(transactions); err != nil {
+		return 0, 0, err
+	}
+
 	tx, err := db.conn.Begin()
 	if err != nil {
 		return 0, 0, fmt.Errorf("begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
+	inserted, skipped, err = insertTransactions(tx, transactions)
+	if err != nil {
+		return inserted, skipped, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return inserted, skipped, nil
+}
+
+// prepareTransactions assigns accounts and applies merchant and category rules before insert
+func (db *DB) prepareTransactions(transactions []*Transaction) error {
+	if _, err := db.AssignAccounts(transactions); err != nil {
+		return fmt.Errorf("assign accounts: %w", err)
+	}
+	if _, err := db.ApplyMerchantRules(transactions); err != nil {
+		return fmt.Errorf("apply merchant rules: %w", err)
+	}
+	if _, err := db.ApplyCategoryRules(transactions); err != nil {
+		return fmt.Errorf("apply category rules: %w", err)
+	}
+	return nil
+}
+
+// insertTransactions inserts prepared transactions within tx, setting the ID of each one inserted
+func insertTransactions(tx *sql.Tx, transactions []*Transaction) (inserted int, skipped int, err error) {
 	stmt, err := tx.Prepare(`
 		INSERT INTO transactions (
-			account_name, account_last4, transaction_date, post_date,
+			account_id, account_name, account_last4, transaction_date, post_date,
 			description, amount, transaction_type, balance,
-			statement_date, source_file
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			statement_date, source_file, category, category_source, merchant_name, currency
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`)
 	if err != nil {
 		return 0, 0, fmt.Errorf("prepare statement: %w", err)
@@ -230,6 +388,7 @@ func (db *DB) InsertTransactions(transactions []*Transaction) (inserted int, ski
 
 	for _, t := range transactions {
 		result, err := stmt.Exec(
+			t.AccountID,
 			t.AccountName,
 			t.AccountLast4,
 			t.TransactionDate,
@@ -240,6 +399,10 @@ func (db *DB) InsertTransactions(transactions []*Transaction) (inserted int, ski
 			t.Balance,
 			t.StatementDate,
 			t.SourceFile,
+			nullString(t.Category),
+			nullString(t.CategorySource),
+			nullString(t.MerchantName),
+			t.Currency,
 		)
 
 		if err != nil {
@@ -256,15 +419,17 @@ func (db *DB) InsertTransactions(transactions []*Transaction) (inserted int, ski
 			return inserted, skipped, fmt.Errorf("get rows affected: %w", err)
 		}
 
-		if rowsAffected > 0 {
-			inserted++
-		} else {
+		if rowsAffected == 0 {
 			skipped++
+			continue
 		}
-	}
 
-	if err := tx.Commit(); err != nil {
-		return 0, 0, fmt.Errorf("commit transaction: %w", err)
+		id, err := result.LastInsertId()
+		if err != nil {
+			return inserted, skipped, fmt.Errorf("get transaction id: %w", err)
+		}
+		t.ID = id
+		inserted++
 	}
 
 	return inserted, skipped, nil
@@ -298,13 +463,13 @@ func (db *DB) LogProcessing(log *ProcessingLog) error {
 	return nil
 }
 
-// QueryTransactionsWithType queries transactions with optional transaction type filter
-func (db *DB) QueryTransactionsWithType(startDate, endDate time.Time, accountFilter, transactionType string) ([]*Transaction, error) {
+// QueryTransactionsWithType queries transactions with optional transaction type, category and merchant filters
+// A category of "uncategorized" matches transactions without a category
+// The merchant filter matches the canonical merchant name, ignoring case
+// With excludeTransfers set, both sides of linked transfers between accounts are left out
+func (db *DB) QueryTransactionsWithType(startDate, endDate time.Time, accountFilter, transactionType, category, merchant string, excludeTransfers bool) ([]*Transaction, error) {
 	query := `
-		SELECT
-			id, account_name, account_last4, transaction_date, post_date,
-			description, amount, transaction_type, balance,
-			statement_date, source_file, created_at, updated_at
+		SELECT ` + transactionColumns + `
 		FROM transactions
 		WHERE transaction_date >= ? AND transaction_date <= ?
 	`
@@ -322,6 +487,22 @@ func (db *DB) QueryTransactionsWithType(startDate, endDate time.Time, accountFil
 		args = append(args, transactionType)
 	}
 
+	if category == UncategorizedFilter {
+		query += " AND (category IS NULL OR category = '')"
+	} else if category != "" {
+		query += " AND category = ?"
+		args = append(args, strings.ToLower(category))
+	}
+
+	if merchant != "" {
+		query += " AND merchant_name = ? COLLATE NOCASE"
+		args = append(args, strings.TrimSpace(merchant))
+	}
+
+	if excludeTransfers {
+		query += " AND id NOT IN (" + linkedTransferIDs + ")"
+	}
+
 	query += " ORDER BY transaction_date DESC, id DESC"
 
 	rows, err := db.conn.Query(query, args...)
@@ -330,27 +511,67 @@ func (db *DB) QueryTransactionsWithType(startDate, endDate time.Time, accountFil
 	}
 	defer rows.Close()
 
+	return scanTransactions(rows)
+}
+
+// transactionColumns is the column list read by scanTransactions
+const transactionColumns = `
+	id, account_id, account_name, account_last4, transaction_date, post_date,
+	description, amount, currency, transaction_type, balance,
+	statement_date, COALESCE(source_file, ''), category, category_source,
+	COALESCE(merchant_name, ''), COALESCE(notes, ''), split_from_id, manual_changes,
+	created_at, updated_at,
+	(SELECT id FROM transfers WHERE status = 'linked'
+		AND transactions.id IN (from_transaction_id, to_transaction_id)) AS transfer_id`
+
+// scanTransactions scans rows selected with transactionColumns
+func scanTransactions(rows *sql.Rows) ([]*Transaction, error) {
 	var transactions []*Transaction
 	for rows.Next() {
 		tx := &Transaction{}
+		var category, categorySource, manualChanges sql.NullString
+		var accountID, transferID, splitFromID sql.NullInt64
 		err := rows.Scan(
 			&tx.ID,
+			&accountID,
 			&tx.AccountName,
 			&tx.AccountLast4,
 			&tx.TransactionDate,
 			&tx.PostDate,
 			&tx.Description,
 			&tx.Amount,
+			&tx.Currency,
 			&tx.TransactionType,
 			&tx.Balance,
 			&tx.StatementDate,
 			&tx.SourceFile,
+			&category,
+			&categorySource,
+			&tx.MerchantName,
+			&tx.Notes,
+			&splitFromID,
+			&manualChanges,
 			&tx.CreatedAt,
 			&tx.UpdatedAt,
+			&transferID,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("scan transaction: %w", err)
 		}
+		tx.Category = category.String
+		tx.CategorySource = categorySource.String
+		tx.TransferID = scanTransferID(transferID)
+		if accountID.Valid {
+			tx.AccountID = &accountID.Int64
+		}
+		if splitFromID.Valid {
+			tx.SplitFromID = &splitFromID.Int64
+		}
+		if manualChanges.Valid {
+			if err := json.Unmarshal([]byte(manualChanges.String), &tx.ManualChanges); err != nil {
+				return nil, fmt.Errorf("decode manual changes: %w", err)
+			}
+		}
 		transactions = append(transactions, tx)
 	}
 
@@ -361,12 +582,28 @@ func (db *DB) QueryTransactionsWithType(startDate, endDate time.Time, accountFil
 	return transactions, nil
 }
 
+// nullString converts an empty string to NULL for storage
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
 // GetAccountSummary retrieves account summary information
-func (db *DB) GetAccountSummary() ([]map[string]interface{}, error) {
+// With excludeTransfers set, linked transfers don't count towards the totals
+// An account with transactions in more than one currency has a summary per currency
+func (db *DB) GetAccountSummary(excludeTransfers bool) ([]map[string]interface{}, error) {
+	where := ""
+	if excludeTransfers {
+		where = "WHERE id NOT IN (" + linkedTransferIDs + ")"
+	}
+
 	query := `
 		SELECT
 			account_name,
 			account_last4,
+			currency,
 			COUNT(*) as transaction_count,
 			SUM(CASE WHEN transaction_type = 'debit' THEN amount ELSE 0 END) as total_debits,
 			SUM(CASE WHEN transaction_type = 'credit' THEN amount ELSE 0 END) as total_credits,
@@ -374,8 +611,9 @@ func (db *DB) GetAccountSummary() ([]map[string]interface{}, error) {
 			MAX(transaction_date) as last_transaction,
 			MAX(statement_date) as latest_statement
 		FROM transactions
-		GROUP BY account_name, account_last4
-		ORDER BY account_name
+		` + where + `
+		GROUP BY account_name, account_last4, currency
+		ORDER BY account_name, currency
 	`
 
 	rows, err := db.conn.Query(query)
@@ -386,14 +624,15 @@ func (db *DB) GetAccountSummary() ([]map[string]interface{}, error) {
 
 	var summaries []map[string]interface{}
 	for rows.Next() {
-		var accountName, accountLast4 string
+		var accountName, accountLast4, currency string
 		var transactionCount int
-		var totalDebits, totalCredits float64
+		var totalDebits, totalCredits money.Cents
 		var firstTx, lastTx, latestStmt time.Time
 
 		err := rows.Scan(
 			&accountName,
 			&accountLast4,
+			&currency,
 			&transactionCount,
 			&totalDebits,
 			&totalCredits,
@@ -408,6 +647,7 @@ func (db *DB) GetAccountSummary() ([]map[string]interface{}, error) {
 		summaries = append(summaries, map[string]interface{}{
 			"account_name":      accountName,
 			"account_last4":     accountLast4,
+			"currency":          currency,
 			"transaction_count": transactionCount,
 			"total_debits":      totalDebits,
 			"total_credits":     totalCredits,
@@ -0,0 +1,208 @@
+package db
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"sqlite-migrate/migrate"
+)
+
+// removeDatabase deletes a test database and the backups migrations took of it
+func removeDatabase(dbPath string) {
+	os.Remove(dbPath)
+	backups, _ := filepath.Glob(dbPath + ".v*.bak")
+	for _, backup := range backups {
+		os.Remove(backup)
+	}
+}
+
+func TestProcessingLogStatusUpgrade(t *testing.T) {
+	dbPath := "./test_processing_log.db"
+	defer removeDatabase(dbPath)
+
+	// Create a processing_log table with the original CHECK constraint
+	conn, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	_, err = conn.Exec(`
+		CREATE TABLE processing_log (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			source_file TEXT NOT NULL,
+			statement_date DATE,
+			account_name TEXT,
+			transactions_inserted INTEGER DEFAULT 0,
+			transactions_skipped INTEGER DEFAULT 0,
+			status TEXT NOT NULL CHECK (status IN ('success', 'parse_error', 'db_error')),
+			error_message TEXT,
+			processed_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+		INSERT INTO processing_log (source_file, status) VALUES ('old.pdf', 'success');
+	`)
+	conn.Close()
+	if err != nil {
+		t.Fatalf("Failed to create legacy table: %v", err)
+	}
+
+	db, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.LogProcessing(&ProcessingLog{SourceFile: "new.pdf", Status: "reconcile_error", ErrorMessage: "totals off by 20.00"}); err != nil {
+		t.Fatalf("Failed to log reconcile_error: %v", err)
+	}
+
+	var count int
+	if err := db.conn.QueryRow(`SELECT COUNT(*) FROM processing_log`).Scan(&count); err != nil {
+		t.Fatalf("Failed to count rows: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected existing rows to be kept, got %d rows", count)
+	}
+}
+
+func TestLegacyDatabaseMigration(t *testing.T) {
+	dbPath := "./test_legacy.db"
+	defer removeDatabase(dbPath)
+
+	// A transactions table from before categorization, merchants, manual entries and accounts
+	conn, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	_, err = conn.Exec(`
+		CREATE TABLE transactions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			account_name TEXT NOT NULL,
+			account_last4 TEXT NOT NULL,
+			transaction_date DATE NOT NULL,
+			post_date DATE,
+			description TEXT NOT NULL,
+			amount REAL NOT NULL,
+			transaction_type TEXT NOT NULL CHECK (transaction_type IN ('debit', 'credit')),
+			balance REAL,
+			statement_date DATE NOT NULL,
+			source_file TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(account_last4, transaction_date, description, amount)
+		);
+		INSERT INTO transactions (account_name, account_last4, transaction_date, description, amount, transaction_type, statement_date)
+		VALUES ('Checking', '1234', '2024-03-05', 'RENT', -1500, 'debit', '2024-03-31');
+	`)
+	conn.Close()
+	if err != nil {
+		t.Fatalf("Failed to create legacy table: %v", err)
+	}
+
+	db, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open legacy database: %v", err)
+	}
+	defer db.Close()
+
+	m, err := db.migrator()
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	statuses, err := m.Status()
+	if err != nil {
+		t.Fatalf("Failed to get migration status: %v", err)
+	}
+	for _, s := range statuses {
+		if !s.Applied {
+			t.Errorf("Expected migration %d_%s to be applied", s.Version, s.Name)
+		}
+	}
+	backups, _ := filepath.Glob(dbPath + ".v0-*.bak")
+	if len(backups) != 1 {
+		t.Errorf("Expected one backup of the legacy database, got %v", backups)
+	}
+
+	// The upgraded rows can be categorized and are attached to an account
+	date := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	transactions, err := db.QueryTransactionsWithType(date.AddDate(0, 0, -1), date.AddDate(0, 0, 1), "", "all", "", "", false)
+	if err != nil || len(transactions) != 1 {
+		t.Fatalf("Expected the legacy transaction, got %d (err=%v)", len(transactions), err)
+	}
+	if transactions[0].AccountID == nil {
+		t.Error("Expected the legacy transaction to be attached to an account")
+	}
+	if _, err := db.conn.Exec(`UPDATE transactions SET category = 'housing', merchant_name = 'Landlord'`); err != nil {
+		t.Errorf("Expected the added columns to exist: %v", err)
+	}
+
+	// Opening it again applies nothing and takes no further backup
+	result, err := db.Migrate()
+	if err != nil || len(result.Applied) != 0 || result.Backup != "" {
+		t.Errorf("Expected nothing to migrate, got %+v (err=%v)", result, err)
+	}
+}
+
+func TestMoneyCentsMigration(t *testing.T) {
+	dbPath := "./test_money_cents.db"
+	defer removeDatabase(dbPath)
+
+	// A database at version 2, when amounts were still REAL
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	before := fstest.MapFS{}
+	for _, name := range []string{"0001_initial.sql", "0002_currencies.sql"} {
+		contents, err := migrations.ReadFile("migrations/" + name)
+		if err != nil {
+			t.Fatalf("Failed to read migration: %v", err)
+		}
+		before["migrations/"+name] = &fstest.MapFile{Data: contents}
+	}
+	m, err := migrate.New(db.conn, dbPath, before, "migrations")
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatalf("Failed to migrate to version 2: %v", err)
+	}
+	_, err = db.conn.Exec(`
+		INSERT INTO transactions (id, account_name, account_last4, transaction_date, description, amount, transaction_type, balance, statement_date)
+		VALUES (1, 'Checking', '1111', '2024-03-05', 'TRANSFER TO SAVINGS', -250.1, 'debit', 1049.95, '2024-03-31'),
+		       (2, 'Savings', '2222', '2024-03-05', 'TRANSFER FROM CHECKING', 250.1, 'credit', NULL, '2024-03-31');
+		INSERT INTO transfers (from_transaction_id, to_transaction_id, amount) VALUES (1, 2, 250.1);
+		INSERT INTO budgets (id, name, monthly_limit, start_month) VALUES (1, 'Coffee', 60.5, '2024-03');
+		INSERT INTO budget_patterns (budget_id, pattern) VALUES (1, 'coffee');
+	`)
+	db.Close()
+	if err != nil {
+		t.Fatalf("Failed to insert version 2 rows: %v", err)
+	}
+
+	db, err = New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	defer db.Close()
+
+	tx, err := db.GetTransaction(1)
+	if err != nil {
+		t.Fatalf("Failed to get transaction: %v", err)
+	}
+	if tx.Amount != -25010 || tx.Balance == nil || *tx.Balance != 104995 {
+		t.Errorf("Expected -250.10 with balance 1049.95 in cents, got %s and %v", tx.Amount, tx.Balance)
+	}
+
+	// Rebuilding the referenced tables must not cascade into the rows that point at them
+	transfers, err := db.ListTransfers("all")
+	if err != nil || len(transfers) != 1 || transfers[0].Amount != 25010 {
+		t.Errorf("Expected the transfer of 250.10 to survive, got %+v (err=%v)", transfers, err)
+	}
+	budgets, err := db.ListBudgets()
+	if err != nil || len(budgets) != 1 || budgets[0].MonthlyLimit != 6050 || len(budgets[0].Patterns) != 1 {
+		t.Errorf("Expected the budget and its pattern to survive, got %+v (err=%v)", budgets, err)
+	}
+}
@@ -0,0 +1,352 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"money"
+)
+
+// Transfer statuses
+const (
+	TransferStatusLinked   = "linked"
+	TransferStatusRejected = "rejected" // unlinked by hand; the matcher won't pair the two again
+)
+
+// Transfer match methods
+const (
+	TransferMethodAuto   = "auto"
+	TransferMethodManual = "manual"
+)
+
+// linkedTransferIDs selects every transaction that is one side of a linked transfer
+const linkedTransferIDs = `
+	SELECT from_transaction_id FROM transfers WHERE status = 'linked'
+	UNION
+	SELECT to_transaction_id FROM transfers WHERE status = 'linked'`
+
+// Transfer links the outgoing and incoming sides of money moving between two of our accounts,
+// e.g. a checking debit and the matching credit card payment
+type Transfer struct {
+	ID                int64        `json:"id"`
+	FromTransactionID int64        `json:"from_transaction_id"` // negative amount, money leaving an account
+	ToTransactionID   int64        `json:"to_transaction_id"`   // positive amount, money arriving
+	Amount            money.Cents  `json:"amount"`
+	Method            string       `json:"method"` // "auto" or "manual"
+	Status            string       `json:"status"`
+	CreatedAt         time.Time    `json:"created_at"`
+	From              *Transaction `json:"from,omitempty"`
+	To                *Transaction `json:"to,omitempty"`
+}
+
+// transferDescription matches descriptions banks use for money moving between accounts
+var transferDescription = regexp.MustCompile(`(?i)transfer|\bxfer\b|payment|\bpymt\b|autopay|thank you`)
+
+// TransferMatchOptions controls how far apart the two sides of a transfer may be
+type TransferMatchOptions struct {
+	WindowDays int         // maximum days between the two transaction dates
+	Tolerance  money.Cents // maximum difference between the two amounts
+
+	// TransferLike only pairs transactions that both look like transfers (see LooksLikeTransfer);
+	// set for the unattended pass after every import
+	TransferLike bool
+}
+
+// transferCandidate is a possible pairing found by the matcher
+type transferCandidate struct {
+	from, to   *Transaction
+	dayGap     float64
+	amountDiff money.Cents
+}
+
+// MatchTransfers pairs unlinked debits with credits on a different account for the same amount
+// (within the tolerance) in the same currency and close in date, closest pairs first
+// With dryRun set the pairs are returned without being stored
+func (db *DB) MatchTransfers(opts TransferMatchOptions, dryRun bool) ([]*Transfer, error) {
+	debits, err := db.unlinkedTransactions("amount < 0")
+	if err != nil {
+		return nil, err
+	}
+	credits, err := db.unlinkedTransactions("amount > 0")
+	if err != nil {
+		return nil, err
+	}
+
+	rejected, err := db.rejectedTransferPairs()
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []transferCandidate
+	for _, from := range debits {
+		for _, to := range credits {
+			if from.AccountLast4 == to.AccountLast4 && from.AccountName == to.AccountName {
+				continue
+			}
+			// Amounts in different currencies aren't comparable; such transfers are linked by hand
+			if from.Currency != to.Currency {
+				continue
+			}
+			if opts.TransferLike && !(from.LooksLikeTransfer() && to.LooksLikeTransfer()) {
+				continue
+			}
+
+			amountDiff := (to.Amount + from.Amount).Abs()
+			if amountDiff > opts.Tolerance {
+				continue
+			}
+
+			dayGap := math.Abs(to.TransactionDate.Sub(from.TransactionDate).Hours() / 24)
+			if dayGap > float64(opts.WindowDays) {
+				continue
+			}
+
+			if rejected[[2]int64{from.ID, to.ID}] {
+				continue
+			}
+
+			candidates = append(candidates, transferCandidate{from: from, to: to, dayGap: dayGap, amountDiff: amountDiff})
+		}
+	}
+
+	// Closest in date, then in amount; IDs keep the order deterministic
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.dayGap != b.dayGap {
+			return a.dayGap < b.dayGap
+		}
+		if a.amountDiff != b.amountDiff {
+			return a.amountDiff < b.amountDiff
+		}
+		if a.from.ID != b.from.ID {
+			return a.from.ID < b.from.ID
+		}
+		return a.to.ID < b.to.ID
+	})
+
+	used := make(map[int64]bool)
+	var transfers []*Transfer
+	for _, c := range candidates {
+		if used[c.from.ID] || used[c.to.ID] {
+			continue
+		}
+		used[c.from.ID] = true
+		used[c.to.ID] = true
+
+		transfer := &Transfer{
+			FromTransactionID: c.from.ID,
+			ToTransactionID:   c.to.ID,
+			Amount:            c.to.Amount,
+			Method:            TransferMethodAuto,
+			Status:            TransferStatusLinked,
+			From:              c.from,
+			To:                c.to,
+		}
+		if !dryRun {
+			if err := db.insertTransfer(transfer); err != nil {
+				return transfers, err
+			}
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	return transfers, nil
+}
+
+// LooksLikeTransfer reports whether a transaction is categorized as a transfer or its description
+// reads like one (a transfer, card payment or autopay)
+func (tx *Transaction) LooksLikeTransfer() bool {
+	return strings.EqualFold(tx.Category, "transfer") || transferDescription.MatchString(tx.Description)
+}
+
+// LinkTransfer links two transactions by hand, regardless of date window and tolerance
+// The sides are ordered by sign, so the IDs may be given either way round
+func (db *DB) LinkTransfer(firstID, secondID int64) (*Transfer, error) {
+	first, err := db.getTransaction(firstID)
+	if err != nil {
+		return nil, err
+	}
+	second, err := db.getTransaction(secondID)
+	if err != nil {
+		return nil, err
+	}
+
+	from, to := first, second
+	if from.Amount > 0 {
+		from, to = second, first
+	}
+	if from.Amount >= 0 || to.Amount <= 0 {
+		return nil, fmt.Errorf("a transfer needs one negative and one positive transaction")
+	}
+
+	linked, err := db.unlinkedTransactions("id IN (?, ?)", from.ID, to.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(linked) != 2 {
+		return nil, fmt.Errorf("transaction %d or %d is already part of a transfer", from.ID, to.ID)
+	}
+
+	transfer := &Transfer{
+		FromTransactionID: from.ID,
+		ToTransactionID:   to.ID,
+		Amount:            to.Amount,
+		Method:            TransferMethodManual,
+		Status:            TransferStatusLinked,
+		From:              from,
+		To:                to,
+	}
+	if err := db.insertTransfer(transfer); err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+// UnlinkTransfer marks a transfer as rejected so both transactions count again
+func (db *DB) UnlinkTransfer(id int64) error {
+	result, err := db.conn.Exec(`
+		UPDATE transfers SET status = 'rejected' WHERE id = ? AND status = 'linked'
+	`, id)
+	if err != nil {
+		return fmt.Errorf("unlink transfer: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("unlink transfer: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("linked transfer %d not found", id)
+	}
+
+	return nil
+}
+
+// ListTransfers returns transfers with the given status ("all" for every status), newest first
+func (db *DB) ListTransfers(status string) ([]*Transfer, error) {
+	query := `
+		SELECT id, from_transaction_id, to_transaction_id, amount, method, status, created_at
+		FROM transfers`
+	var args []interface{}
+	if status != "all" {
+		query += " WHERE status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY id DESC"
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query transfers: %w", err)
+	}
+	defer rows.Close()
+
+	var transfers []*Transfer
+	for rows.Next() {
+		t := &Transfer{}
+		if err := rows.Scan(&t.ID, &t.FromTransactionID, &t.ToTransactionID, &t.Amount, &t.Method, &t.Status, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan transfer: %w", err)
+		}
+		transfers = append(transfers, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate transfers: %w", err)
+	}
+
+	for _, t := range transfers {
+		if t.From, err = db.getTransaction(t.FromTransactionID); err != nil {
+			return nil, err
+		}
+		if t.To, err = db.getTransaction(t.ToTransactionID); err != nil {
+			return nil, err
+		}
+	}
+
+	return transfers, nil
+}
+
+// insertTransfer stores a transfer and sets its ID
+func (db *DB) insertTransfer(t *Transfer) error {
+	result, err := db.conn.Exec(`
+		INSERT INTO transfers (from_transaction_id, to_transaction_id, amount, method, status)
+		VALUES (?, ?, ?, ?, ?)
+	`, t.FromTransactionID, t.ToTransactionID, t.Amount, t.Method, t.Status)
+	if err != nil {
+		return fmt.Errorf("insert transfer: %w", err)
+	}
+
+	t.ID, err = result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("insert transfer: %w", err)
+	}
+
+	return nil
+}
+
+// unlinkedTransactions returns transactions matching a condition that aren't part of a transfer
+func (db *DB) unlinkedTransactions(condition string, args ...interface{}) ([]*Transaction, error) {
+	rows, err := db.conn.Query(`
+		SELECT `+transactionColumns+`
+		FROM transactions
+		WHERE `+condition+` AND id NOT IN (`+linkedTransferIDs+`)
+		ORDER BY transaction_date, id
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query unlinked transactions: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTransactions(rows)
+}
+
+// getTransaction returns a single stored transaction
+func (db *DB) getTransaction(id int64) (*Transaction, error) {
+	rows, err := db.conn.Query(`SELECT `+transactionColumns+` FROM transactions WHERE id = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("query transaction: %w", err)
+	}
+	defer rows.Close()
+
+	transactions, err := scanTransactions(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(transactions) == 0 {
+		return nil, fmt.Errorf("transaction %d not found", id)
+	}
+
+	return transactions[0], nil
+}
+
+// rejectedTransferPairs returns the (from, to) pairs that were unlinked by hand
+func (db *DB) rejectedTransferPairs() (map[[2]int64]bool, error) {
+	rows, err := db.conn.Query(`SELECT from_transaction_id, to_transaction_id FROM transfers WHERE status = 'rejected'`)
+	if err != nil {
+		return nil, fmt.Errorf("query rejected transfers: %w", err)
+	}
+	defer rows.Close()
+
+	pairs := make(map[[2]int64]bool)
+	for rows.Next() {
+		var from, to int64
+		if err := rows.Scan(&from, &to); err != nil {
+			return nil, fmt.Errorf("scan rejected transfer: %w", err)
+		}
+		pairs[[2]int64{from, to}] = true
+	}
+
+	return pairs, rows.Err()
+}
+
+// scanTransferID reads the transfer_id column of transactionColumns
+func scanTransferID(id sql.NullInt64) *int64 {
+	if !id.Valid {
+		return nil
+	}
+	return &id.Int64
+}
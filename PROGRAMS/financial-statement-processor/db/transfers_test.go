@@ -0,0 +1,119 @@
+package db
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"money"
+)
+
+func TestMatchTransfers(t *testing.T) {
+	dbPath := "./test_transfers.db"
+	defer os.Remove(dbPath)
+
+	db, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	day := func(d int) time.Time { return time.Date(2024, 10, d, 0, 0, 0, 0, time.UTC) }
+	tx := func(account, last4 string, date time.Time, description string, amount float64) *Transaction {
+		txType := "debit"
+		if amount > 0 {
+			txType = "credit"
+		}
+		return &Transaction{AccountName: account, AccountLast4: last4, TransactionDate: date, Description: description,
+			Amount: money.FromFloat(amount), TransactionType: txType, StatementDate: day(31)}
+	}
+
+	transactions := []*Transaction{
+		tx("Checking", "1111", day(5), "PAYMENT TO VISA", -500),       // 1: pairs with 2
+		tx("Visa", "2222", day(7), "PAYMENT THANK YOU", 500),          // 2
+		tx("Checking", "1111", day(10), "TRANSFER TO SAVINGS", -200),  // 3: pairs with 5 (closer than 4)
+		tx("Savings", "3333", day(16), "TRANSFER FROM CHECKING", 200), // 4: outside the window
+		tx("Savings", "3333", day(11), "TRANSFER FROM CHECKING", 200), // 5
+		tx("Checking", "1111", day(12), "GROCERIES", -80),             // 6: no other side
+		tx("Checking", "1111", day(12), "REFUND", 80),                 // 7: same account, not a transfer
+		tx("Wise", "4444", day(5), "TOP UP", 500),                     // 8: same amount as 1 but in euros
+	}
+	transactions[7].Currency = "EUR"
+	if _, _, err := db.InsertTransactions(transactions); err != nil {
+		t.Fatalf("Failed to insert transactions: %v", err)
+	}
+
+	opts := TransferMatchOptions{WindowDays: 3}
+
+	preview, err := db.MatchTransfers(opts, true)
+	if err != nil || len(preview) != 2 {
+		t.Fatalf("Expected 2 transfers in dry run, got %d (err=%v)", len(preview), err)
+	}
+	if linked, _ := db.ListTransfers(TransferStatusLinked); len(linked) != 0 {
+		t.Fatalf("Dry run stored %d transfers", len(linked))
+	}
+
+	transfers, err := db.MatchTransfers(opts, false)
+	if err != nil || len(transfers) != 2 {
+		t.Fatalf("Expected 2 transfers, got %d (err=%v)", len(transfers), err)
+	}
+	pairs := map[int64]int64{}
+	for _, tr := range transfers {
+		pairs[tr.FromTransactionID] = tr.ToTransactionID
+	}
+	if pairs[1] != 2 || pairs[3] != 5 {
+		t.Errorf("Unexpected pairs: %v", pairs)
+	}
+
+	// Matching again finds nothing new
+	if again, _ := db.MatchTransfers(opts, false); len(again) != 0 {
+		t.Errorf("Expected no new transfers, got %d", len(again))
+	}
+
+	all, err := db.QueryTransactionsWithType(day(1), day(31), "", "all", "", "", false)
+	if err != nil || len(all) != 8 {
+		t.Fatalf("Expected 8 transactions, got %d (err=%v)", len(all), err)
+	}
+	withoutTransfers, err := db.QueryTransactionsWithType(day(1), day(31), "", "all", "", "", true)
+	if err != nil || len(withoutTransfers) != 4 {
+		t.Fatalf("Expected 4 transactions without transfers, got %d (err=%v)", len(withoutTransfers), err)
+	}
+
+	// Unlinking a wrong match frees both sides and keeps the pair from being matched again
+	savings := transfers[1]
+	if savings.FromTransactionID != 3 {
+		savings = transfers[0]
+	}
+	if err := db.UnlinkTransfer(savings.ID); err != nil {
+		t.Fatalf("UnlinkTransfer failed: %v", err)
+	}
+	if again, _ := db.MatchTransfers(TransferMatchOptions{WindowDays: 10}, false); len(again) != 1 || again[0].ToTransactionID != 4 {
+		t.Errorf("Expected transaction 3 to pair with 4 after unlinking, got %+v", again)
+	}
+
+	// Manual links ignore the window but not the sign or existing links
+	if _, err := db.LinkTransfer(6, 7); err != nil {
+		t.Errorf("LinkTransfer failed: %v", err)
+	}
+	if _, err := db.LinkTransfer(1, 7); err == nil {
+		t.Error("Expected linking an already linked transaction to fail")
+	}
+
+	// The pass after an import only pairs rows that both look like transfers
+	more := []*Transaction{
+		tx("Checking", "1111", day(20), "AMAZON MKTPLACE", -60),        // 9: same amount as 10 by chance
+		tx("Visa", "2222", day(20), "SHELL OIL REFUND", 60),            // 10
+		tx("Checking", "1111", day(22), "ONLINE PAYMENT TO VISA", -75), // 11: pairs with 12
+		tx("Visa", "2222", day(23), "AUTOPAY THANK YOU", 75),           // 12
+	}
+	if _, _, err := db.InsertTransactions(more); err != nil {
+		t.Fatalf("Failed to insert transactions: %v", err)
+	}
+	if loose, _ := db.MatchTransfers(opts, true); len(loose) != 2 {
+		t.Errorf("Expected 2 pairs without the transfer check, got %d", len(loose))
+	}
+	auto, err := db.MatchTransfers(TransferMatchOptions{WindowDays: 3, TransferLike: true}, true)
+	if err != nil || len(auto) != 1 || auto[0].FromTransactionID != 11 || auto[0].ToTransactionID != 12 {
+		t.Errorf("Expected only the card payment to pair, got %+v (err=%v)", auto, err)
+	}
+}
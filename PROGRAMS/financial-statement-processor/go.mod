@@ -6,4 +6,12 @@ require github.com/joho/godotenv v1.5.1
 
 require github.com/mattn/go-sqlite3 v1.14.32
 
-require github.com/ledongthuc/pdf v0.0.0-20250511090121-5959a4027728
+require (
+	github.com/ledongthuc/pdf v0.0.0-20250511090121-5959a4027728
+	money v0.0.0
+	sqlite-migrate v0.0.0
+)
+
+replace sqlite-migrate => ../sqlite-migrate
+
+replace money => ../money
@@ -0,0 +1,216 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// LLM backends
+const (
+	LLMBackendOllama     = "ollama"      // Ollama /api/generate
+	LLMBackendOllamaChat = "ollama-chat" // Ollama /api/chat
+	LLMBackendOpenAI     = "openai"      // OpenAI-compatible /v1/chat/completions (llama.cpp, vLLM, LM Studio)
+	LLMBackendFake       = "fake"        // replays fixture responses, for offline tests
+)
+
+// LLMBackend sends prompts to a language model that answers in JSON
+type LLMBackend interface {
+	// GenerateJSON returns the model's raw JSON reply to a prompt
+	// A non-nil schema is sent as a structured-output constraint; nil only requests JSON
+	GenerateJSON(prompt string, schema json.RawMessage) (string, error)
+	// HealthCheck verifies the server is reachable before any pages are sent
+	HealthCheck() error
+	// Name names the backend (one of the LLMBackend constants); it is part of the parse cache key
+	Name() string
+	// Model names the model; it is part of the parse cache key
+	Model() string
+}
+
+// LLMConfig selects and configures an LLM backend
+type LLMConfig struct {
+	Backend  string // "ollama" (default), "ollama-chat", "openai" or "fake"
+	Host     string // server base URL
+	Model    string
+	APIKey   string // bearer token for OpenAI-compatible servers (optional)
+	Fixtures string // fixture file for the fake backend
+}
+
+// NewLLMBackend creates the backend described by cfg
+func NewLLMBackend(cfg LLMConfig) (LLMBackend, error) {
+	switch cfg.Backend {
+	case LLMBackendOllama, "":
+		return NewOllamaClient(cfg.Host, cfg.Model), nil
+	case LLMBackendOllamaChat:
+		return NewOllamaChatClient(cfg.Host, cfg.Model), nil
+	case LLMBackendOpenAI:
+		return NewOpenAIClient(cfg.Host, cfg.Model, cfg.APIKey), nil
+	case LLMBackendFake:
+		if cfg.Fixtures == "" {
+			return nil, fmt.Errorf("fake LLM backend needs a fixture file (set LLM_FIXTURES)")
+		}
+		return LoadFakeBackend(cfg.Fixtures)
+	default:
+		return nil, fmt.Errorf("unknown LLM backend: %s (supported: ollama, ollama-chat, openai, fake)", cfg.Backend)
+	}
+}
+
+// llmBackend returns the configured backend, defaulting to Ollama generate
+func (opts Options) llmBackend() LLMBackend {
+	if opts.LLM != nil {
+		return opts.LLM
+	}
+	return NewOllamaClient(opts.OllamaHost, opts.OllamaModel)
+}
+
+// OpenAIClient talks to any server implementing the OpenAI chat completions API
+type OpenAIClient struct {
+	baseURL string
+	model   string
+	apiKey  string
+	client  *http.Client
+}
+
+// OpenAIChatRequest represents a chat completions request
+type OpenAIChatRequest struct {
+	Model          string                `json:"model"`
+	Messages       []ChatMessage         `json:"messages"`
+	Temperature    float64               `json:"temperature"`
+	ResponseFormat *OpenAIResponseFormat `json:"response_format,omitempty"`
+}
+
+// OpenAIResponseFormat constrains the reply format
+type OpenAIResponseFormat struct {
+	Type       string            `json:"type"` // "json_object" or "json_schema"
+	JSONSchema *OpenAIJSONSchema `json:"json_schema,omitempty"`
+}
+
+// OpenAIJSONSchema names the schema a "json_schema" reply must follow
+type OpenAIJSONSchema struct {
+	Name   string          `json:"name"`
+	Schema json.RawMessage `json:"schema"`
+}
+
+// OpenAIChatResponse represents a chat completions response
+type OpenAIChatResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message      ChatMessage `json:"message"`
+		FinishReason string      `json:"finish_reason"`
+	} `json:"choices"`
+}
+
+// NewOpenAIClient creates a client for an OpenAI-compatible server
+// The base URL may be given with or without the trailing /v1
+func NewOpenAIClient(baseURL, model, apiKey string) *OpenAIClient {
+	baseURL = strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/v1")
+	return &OpenAIClient{
+		baseURL: baseURL,
+		model:   model,
+		apiKey:  apiKey,
+		client: &http.Client{
+			Timeout: 120 * time.Second,
+		},
+	}
+}
+
+// Name returns the backend name
+func (c *OpenAIClient) Name() string {
+	return LLMBackendOpenAI
+}
+
+// Model returns the model name sent with each request
+func (c *OpenAIClient) Model() string {
+	return c.model
+}
+
+// GenerateJSON sends a prompt as a single user message and returns the JSON reply
+func (c *OpenAIClient) GenerateJSON(prompt string, schema json.RawMessage) (string, error) {
+	format := &OpenAIResponseFormat{Type: "json_object"}
+	if schema != nil {
+		format = &OpenAIResponseFormat{Type: "json_schema", JSONSchema: &OpenAIJSONSchema{Name: "response", Schema: schema}}
+	}
+
+	var chatResp OpenAIChatResponse
+	err := postJSON(c.client, c.baseURL+"/v1/chat/completions", c.apiKey, OpenAIChatRequest{
+		Model:          c.model,
+		Messages:       []ChatMessage{{Role: "user", Content: prompt}},
+		Temperature:    0,
+		ResponseFormat: format,
+	}, &chatResp)
+	if err != nil {
+		return "", err
+	}
+
+	if len(chatResp.Choices) == 0 {
+		return "", fmt.Errorf("response has no choices")
+	}
+
+	return chatResp.Choices[0].Message.Content, nil
+}
+
+// HealthCheck verifies the server is accessible
+func (c *OpenAIClient) HealthCheck() error {
+	req, err := http.NewRequest("GET", c.baseURL+"/v1/models", nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("server not accessible: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// postJSON posts a JSON request body and decodes the JSON response into out
+func postJSON(client *http.Client, url, bearerToken string, body, out interface{}) error {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+bearerToken)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+
+	return nil
+}
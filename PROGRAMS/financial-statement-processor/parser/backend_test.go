@@ -0,0 +1,121 @@
+package parser
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFakeBackendReplaysFixtures(t *testing.T) {
+	backend, err := LoadFakeBackend("testdata/fake_statement.json")
+	if err != nil {
+		t.Fatalf("LoadFakeBackend failed: %v", err)
+	}
+
+	pages := []string{"PAGE ONE text", "PAGE TWO text", "PAGE THREE text"}
+	data, err := parseMultiPageWithLLM(pages, "statement.pdf", Options{LLM: backend, Workers: 2})
+	if err != nil {
+		t.Fatalf("parseMultiPageWithLLM failed: %v", err)
+	}
+
+	if data.AccountLast4 != "4321" || data.StatementDate.Format("2006-01-02") != "2024-10-31" {
+		t.Errorf("Unexpected account info: %s %s", data.AccountLast4, data.StatementDate)
+	}
+	if len(data.Transactions) != 3 {
+		t.Fatalf("Expected 3 transactions (page three is unreadable), got %d", len(data.Transactions))
+	}
+	if data.Transactions[1].PostDate == nil || data.Transactions[1].PostDate.Format("2006-01-02") != "2024-10-06" {
+		t.Errorf("Expected post date 2024-10-06, got %v", data.Transactions[1].PostDate)
+	}
+
+	result := Reconcile(data)
+	if !result.TotalsChecked || !result.OK() {
+		t.Errorf("Expected fixture statement to reconcile: %s", result.Summary())
+	}
+
+	if _, err := backend.GenerateJSON("something else", nil); err == nil {
+		t.Error("Expected an error for a prompt no fixture matches")
+	}
+}
+
+func TestOpenAIClientGenerateJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v1/models":
+			w.Write([]byte(`{"data":[]}`))
+		case "/v1/chat/completions":
+			var req OpenAIChatRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if req.Model != "qwen2.5" || req.ResponseFormat == nil || req.ResponseFormat.Type != "json_schema" ||
+				req.ResponseFormat.JSONSchema == nil || string(req.ResponseFormat.JSONSchema.Schema) != `{"type":"object"}` ||
+				len(req.Messages) != 1 || req.Messages[0].Content != "prompt" {
+				t.Errorf("Unexpected request: %+v", req)
+			}
+			w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"{\"ok\":true}"},"finish_reason":"stop"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewOpenAIClient(server.URL+"/v1/", "qwen2.5", "secret")
+	if err := client.HealthCheck(); err != nil {
+		t.Fatalf("HealthCheck failed: %v", err)
+	}
+
+	response, err := client.GenerateJSON("prompt", json.RawMessage(`{"type":"object"}`))
+	if err != nil {
+		t.Fatalf("GenerateJSON failed: %v", err)
+	}
+	if response != `{"ok":true}` {
+		t.Errorf("Unexpected response: %s", response)
+	}
+}
+
+func TestOllamaChatClientGenerateJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		var req OllamaChatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if string(req.Format) != `"json"` || req.Stream || len(req.Messages) != 1 || req.Messages[0].Role != "user" {
+			t.Errorf("Unexpected request: %+v", req)
+		}
+		json.NewEncoder(w).Encode(OllamaChatResponse{Message: ChatMessage{Role: "assistant", Content: `{"ok":true}`}, Done: true})
+	}))
+	defer server.Close()
+
+	response, err := NewOllamaChatClient(server.URL, "llama3").GenerateJSON("prompt", nil)
+	if err != nil {
+		t.Fatalf("GenerateJSON failed: %v", err)
+	}
+	if response != `{"ok":true}` {
+		t.Errorf("Unexpected response: %s", response)
+	}
+}
+
+func TestNewLLMBackend(t *testing.T) {
+	if _, err := NewLLMBackend(LLMConfig{Backend: "gpt"}); err == nil {
+		t.Error("Expected an error for an unknown backend")
+	}
+	if _, err := NewLLMBackend(LLMConfig{Backend: LLMBackendFake}); err == nil {
+		t.Error("Expected an error for the fake backend without fixtures")
+	}
+
+	backend, err := NewLLMBackend(LLMConfig{Backend: LLMBackendOpenAI, Host: "http://localhost:8000", Model: "mistral"})
+	if err != nil {
+		t.Fatalf("NewLLMBackend failed: %v", err)
+	}
+	if backend.Model() != "mistral" {
+		t.Errorf("Expected model mistral, got %s", backend.Model())
+	}
+}
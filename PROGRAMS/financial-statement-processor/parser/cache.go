@@ -0,0 +1,83 @@
+package parser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"financial-statement-processor/db"
+)
+
+// ParseCache stores raw LLM responses per statement page (implemented by *db.DB)
+type ParseCache interface {
+	GetParseCache(key db.ParseCacheKey) (string, bool, error)
+	PutParseCache(key db.ParseCacheKey, sourceFile, response string) error
+}
+
+// FileSHA256 returns the hex SHA-256 digest of a file's contents
+func FileSHA256(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("open file: %w", err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("hash file: %w", err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// parseCacheKeys returns one cache key per page, or nil when caching is disabled or unavailable
+// Each key holds the backend and a hash of the page text, so a change of backend, OCR engine or DPI
+// sends the page to the LLM again rather than replaying a response to different text
+func parseCacheKeys(sourceFile string, pages []string, backend LLMBackend, opts Options) []db.ParseCacheKey {
+	if opts.Cache == nil {
+		return nil
+	}
+
+	hash, err := FileSHA256(sourceFile)
+	if err != nil {
+		log.Printf("WARNING: Parse cache disabled for this file: %v", err)
+		return nil
+	}
+
+	keys := make([]db.ParseCacheKey, len(pages))
+	for i, page := range pages {
+		textHash := sha256.Sum256([]byte(page))
+		keys[i] = db.ParseCacheKey{
+			FileHash:      hash,
+			PageNumber:    i + 1,
+			Backend:       backend.Name(),
+			Model:         backend.Model(),
+			PromptVersion: StatementPromptVersion,
+			TextHash:      hex.EncodeToString(textHash[:]),
+		}
+	}
+	return keys
+}
+
+// loadCachedPage returns the parsed page from a cached LLM response
+func loadCachedPage(cache ParseCache, key db.ParseCacheKey, sourceFile, invalidRows string) (*StatementData, bool) {
+	response, ok, err := cache.GetParseCache(key)
+	if err != nil {
+		log.Printf("WARNING: Failed to read parse cache for page %d: %v", key.PageNumber, err)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+
+	data, err := statementFromLLMResponse(response, sourceFile, invalidRows)
+	if err != nil {
+		log.Printf("WARNING: Ignoring unreadable cached response for page %d: %v", key.PageNumber, err)
+		return nil, false
+	}
+
+	return data, true
+}
@@ -0,0 +1,128 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"financial-statement-processor/db"
+)
+
+// categorizeBatchSize limits how many transactions are sent to the LLM in one prompt
+const categorizeBatchSize = 40
+
+// LLMCategoryResponse represents the JSON structure expected from the LLM when categorizing
+type LLMCategoryResponse struct {
+	Categories []LLMCategoryAssignment `json:"categories"`
+}
+
+// LLMCategoryAssignment assigns a category to the transaction at Index
+type LLMCategoryAssignment struct {
+	Index    int    `json:"index"`
+	Category string `json:"category"`
+}
+
+// CategorizeWithLLM asks the LLM to categorize transactions that have no category yet
+// Only categories from the allowed list are accepted; anything else is left uncategorized
+// Returns the number of transactions that were categorized
+func CategorizeWithLLM(transactions []*db.Transaction, categories []string, backend LLMBackend) (int, error) {
+	var pending []*db.Transaction
+	for _, tx := range transactions {
+		if tx.Category == "" {
+			pending = append(pending, tx)
+		}
+	}
+
+	if len(pending) == 0 {
+		return 0, nil
+	}
+
+	if len(categories) == 0 {
+		return 0, fmt.Errorf("no categories defined")
+	}
+
+	if err := backend.HealthCheck(); err != nil {
+		return 0, fmt.Errorf("LLM health check failed: %w", err)
+	}
+
+	allowed := make(map[string]bool, len(categories))
+	for _, c := range categories {
+		allowed[strings.ToLower(c)] = true
+	}
+
+	categorized := 0
+	for start := 0; start < len(pending); start += categorizeBatchSize {
+		end := start + categorizeBatchSize
+		if end > len(pending) {
+			end = len(pending)
+		}
+		batch := pending[start:end]
+
+		log.Printf("Categorizing transactions %d-%d of %d with LLM", start+1, end, len(pending))
+
+		responseJSON, err := backend.GenerateJSON(buildCategorizePrompt(batch, categories), nil)
+		if err != nil {
+			return categorized, fmt.Errorf("LLM categorization failed: %w", err)
+		}
+
+		var resp LLMCategoryResponse
+		if err := json.Unmarshal([]byte(responseJSON), &resp); err != nil {
+			log.Printf("WARNING: Failed to parse LLM categorization response: %v", err)
+			continue
+		}
+
+		for _, a := range resp.Categories {
+			if a.Index < 0 || a.Index >= len(batch) {
+				continue
+			}
+			category := strings.ToLower(strings.TrimSpace(a.Category))
+			if !allowed[category] {
+				continue
+			}
+			tx := batch[a.Index]
+			if tx.Category != "" {
+				continue
+			}
+			tx.Category = category
+			tx.CategorySource = db.CategorySourceLLM
+			categorized++
+		}
+	}
+
+	return categorized, nil
+}
+
+// buildCategorizePrompt creates the LLM prompt for categorizing a batch of transactions
+func buildCategorizePrompt(transactions []*db.Transaction, categories []string) string {
+	var lines strings.Builder
+	for i, tx := range transactions {
+		fmt.Fprintf(&lines, "%d | %s | %s | %s | %s\n",
+			i,
+			tx.TransactionDate.Format("2006-01-02"),
+			tx.Description,
+			tx.Amount,
+			tx.TransactionType)
+	}
+
+	return fmt.Sprintf(`You are a personal finance assistant. Assign exactly one category to each bank transaction below and return ONLY valid JSON (no markdown, no code blocks, no explanations).
+
+Allowed categories:
+%s
+
+Required JSON structure:
+{
+  "categories": [
+    {"index": 0, "category": "groceries"}
+  ]
+}
+
+Rules:
+- category MUST be one of the allowed categories, spelled exactly as listed
+- use "other" if nothing else fits
+- include one entry per transaction, using the index from the first column
+
+Transactions (index | date | description | amount | type):
+%s
+Return ONLY the JSON object, nothing else.`, strings.Join(categories, ", "), lines.String())
+}
@@ -0,0 +1,362 @@
+package parser
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"financial-statement-processor/db"
+	"money"
+)
+
+// CSVProfile maps a bank's CSV export columns onto transaction fields
+// Column names are matched case-insensitively against the header row
+type CSVProfile struct {
+	Name        string `json:"name"`
+	FilePattern string `json:"file_pattern,omitempty"` // glob matched against the file name, e.g. "Chase*.csv"
+
+	DateColumn        string `json:"date_column"`
+	PostDateColumn    string `json:"post_date_column,omitempty"`
+	DescriptionColumn string `json:"description_column"`
+	AmountColumn      string `json:"amount_column,omitempty"` // single signed amount column
+	DebitColumn       string `json:"debit_column,omitempty"`  // or separate debit/credit columns
+	CreditColumn      string `json:"credit_column,omitempty"`
+	BalanceColumn     string `json:"balance_column,omitempty"`
+
+	// RequiredColumns are extra headers that must be present for auto-detection
+	RequiredColumns []string `json:"required_columns,omitempty"`
+
+	DateFormat    string `json:"date_format,omitempty"`    // Go layout; common formats are tried when empty
+	NegateAmounts bool   `json:"negate_amounts,omitempty"` // card exports that list purchases as positive
+	Delimiter     string `json:"delimiter,omitempty"`      // defaults to ","
+
+	AccountName  string `json:"account_name,omitempty"`
+	AccountLast4 string `json:"account_last4,omitempty"`
+}
+
+// builtinCSVProfiles are tried in order during auto-detection, most specific first
+var builtinCSVProfiles = []CSVProfile{
+	{
+		Name:              "chase-checking",
+		DateColumn:        "Posting Date",
+		DescriptionColumn: "Description",
+		AmountColumn:      "Amount",
+		BalanceColumn:     "Balance",
+		RequiredColumns:   []string{"Details"},
+	},
+	{
+		Name:              "chase-credit",
+		DateColumn:        "Transaction Date",
+		PostDateColumn:    "Post Date",
+		DescriptionColumn: "Description",
+		AmountColumn:      "Amount",
+	},
+	{
+		Name:              "capital-one",
+		DateColumn:        "Transaction Date",
+		PostDateColumn:    "Posted Date",
+		DescriptionColumn: "Description",
+		DebitColumn:       "Debit",
+		CreditColumn:      "Credit",
+	},
+	{
+		Name:              "amex",
+		DateColumn:        "Date",
+		DescriptionColumn: "Description",
+		AmountColumn:      "Amount",
+		RequiredColumns:   []string{"Card Member"},
+		NegateAmounts:     true,
+	},
+	{
+		Name:              "bofa-checking",
+		DateColumn:        "Date",
+		DescriptionColumn: "Description",
+		AmountColumn:      "Amount",
+		BalanceColumn:     "Running Bal.",
+	},
+	{
+		Name:              "generic",
+		DateColumn:        "Date",
+		DescriptionColumn: "Description",
+		AmountColumn:      "Amount",
+	},
+}
+
+// LoadCSVProfiles returns the built-in profiles plus any defined in the JSON file at path
+// Profiles from the file take precedence over built-in profiles with the same name
+func LoadCSVProfiles(path string) ([]CSVProfile, error) {
+	if path == "" {
+		return builtinCSVProfiles, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read CSV profiles: %w", err)
+	}
+
+	var custom []CSVProfile
+	if err := json.Unmarshal(data, &custom); err != nil {
+		return nil, fmt.Errorf("parse CSV profiles JSON: %w", err)
+	}
+
+	overridden := make(map[string]bool, len(custom))
+	for i, p := range custom {
+		if p.Name == "" {
+			return nil, fmt.Errorf("CSV profile %d: name is required", i)
+		}
+		if p.DateColumn == "" || p.DescriptionColumn == "" {
+			return nil, fmt.Errorf("CSV profile %s: date_column and description_column are required", p.Name)
+		}
+		if p.AmountColumn == "" && p.DebitColumn == "" && p.CreditColumn == "" {
+			return nil, fmt.Errorf("CSV profile %s: amount_column or debit_column/credit_column is required", p.Name)
+		}
+		overridden[p.Name] = true
+	}
+
+	profiles := custom
+	for _, p := range builtinCSVProfiles {
+		if !overridden[p.Name] {
+			profiles = append(profiles, p)
+		}
+	}
+
+	return profiles, nil
+}
+
+// parseCSV imports a CSV transaction export using a column mapping profile
+func parseCSV(filePath string, opts Options) (*StatementData, error) {
+	profiles, err := LoadCSVProfiles(opts.CSVProfilesPath)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("open CSV: %w", err)
+	}
+	defer f.Close()
+
+	// The delimiter depends on the profile, so pick the profile by name/pattern first
+	// and fall back to header detection with the default delimiter
+	profile := selectCSVProfileByName(profiles, opts.CSVProfile, filePath)
+	if opts.CSVProfile != "" && profile == nil {
+		return nil, fmt.Errorf("unknown CSV profile: %s", opts.CSVProfile)
+	}
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if profile != nil && profile.Delimiter != "" {
+		reader.Comma = []rune(profile.Delimiter)[0]
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read CSV header: %w", err)
+	}
+	columns := indexColumns(header)
+
+	if profile == nil {
+		profile = detectCSVProfile(profiles, columns)
+		if profile == nil {
+			return nil, fmt.Errorf("no CSV profile matches header %q (use --csv-profile or add a profile to CSV_PROFILES_PATH)", strings.Join(header, ","))
+		}
+	}
+	log.Printf("Importing CSV with profile: %s", profile.Name)
+
+	if missing := profile.missingColumns(columns); len(missing) > 0 {
+		return nil, fmt.Errorf("CSV profile %s: missing columns %s", profile.Name, strings.Join(missing, ", "))
+	}
+
+	data := &StatementData{
+		AccountName:  profile.AccountName,
+		AccountLast4: profile.AccountLast4,
+		Transactions: make([]*db.Transaction, 0),
+	}
+
+	line := 1
+	for {
+		record, err := reader.Read()
+		line++
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read CSV line %d: %w", line, err)
+		}
+
+		if isBlankRecord(record) {
+			continue
+		}
+
+		tx, err := profile.transaction(record, columns)
+		if err != nil {
+			return nil, fmt.Errorf("CSV line %d: %w", line, err)
+		}
+		data.Transactions = append(data.Transactions, tx)
+	}
+
+	log.Printf("Imported %d transactions from CSV", len(data.Transactions))
+	return finalizeImport(data, filePath, opts), nil
+}
+
+// selectCSVProfileByName picks a profile by explicit name, or by file pattern when no name is given
+func selectCSVProfileByName(profiles []CSVProfile, name, filePath string) *CSVProfile {
+	for i := range profiles {
+		if name != "" && strings.EqualFold(profiles[i].Name, name) {
+			return &profiles[i]
+		}
+	}
+	if name != "" {
+		return nil
+	}
+
+	base := filepath.Base(filePath)
+	for i := range profiles {
+		if profiles[i].FilePattern == "" {
+			continue
+		}
+		if ok, _ := filepath.Match(profiles[i].FilePattern, base); ok {
+			return &profiles[i]
+		}
+	}
+	return nil
+}
+
+// detectCSVProfile returns the first profile whose columns are all present in the header
+func detectCSVProfile(profiles []CSVProfile, columns map[string]int) *CSVProfile {
+	for i := range profiles {
+		if len(profiles[i].missingColumns(columns)) == 0 {
+			return &profiles[i]
+		}
+	}
+	return nil
+}
+
+// missingColumns lists the profile's columns that are absent from the header
+func (p *CSVProfile) missingColumns(columns map[string]int) []string {
+	wanted := []string{p.DateColumn, p.DescriptionColumn, p.AmountColumn, p.DebitColumn,
+		p.CreditColumn, p.PostDateColumn, p.BalanceColumn}
+	wanted = append(wanted, p.RequiredColumns...)
+
+	var missing []string
+	for _, name := range wanted {
+		if name == "" {
+			continue
+		}
+		if _, ok := columns[normalizeColumn(name)]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// transaction converts a CSV record into a transaction using the profile mapping
+func (p *CSVProfile) transaction(record []string, columns map[string]int) (*db.Transaction, error) {
+	field := func(name string) string {
+		if name == "" {
+			return ""
+		}
+		idx, ok := columns[normalizeColumn(name)]
+		if !ok || idx >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[idx])
+	}
+
+	date, err := p.parseDate(field(p.DateColumn))
+	if err != nil {
+		return nil, err
+	}
+
+	var amount money.Cents
+	if p.AmountColumn != "" {
+		amount, err = parseAmount(field(p.AmountColumn))
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		if debit := field(p.DebitColumn); debit != "" {
+			d, err := parseAmount(debit)
+			if err != nil {
+				return nil, err
+			}
+			amount -= d.Abs()
+		}
+		if credit := field(p.CreditColumn); credit != "" {
+			c, err := parseAmount(credit)
+			if err != nil {
+				return nil, err
+			}
+			amount += c.Abs()
+		}
+	}
+	if p.NegateAmounts {
+		amount = -amount
+	}
+
+	description := field(p.DescriptionColumn)
+	if description == "" {
+		return nil, fmt.Errorf("description is empty")
+	}
+
+	tx := newImportedTransaction(date, description, amount)
+
+	if postDate := field(p.PostDateColumn); postDate != "" {
+		if pd, err := p.parseDate(postDate); err == nil {
+			tx.PostDate = &pd
+		}
+	}
+
+	if balance := field(p.BalanceColumn); balance != "" {
+		if b, err := parseAmount(balance); err == nil {
+			tx.Balance = &b
+		}
+	}
+
+	return tx, nil
+}
+
+// parseDate parses a date with the profile's layout, falling back to common formats
+func (p *CSVProfile) parseDate(s string) (time.Time, error) {
+	if p.DateFormat != "" {
+		t, err := time.Parse(p.DateFormat, s)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("parse date '%s' with format %s: %w", s, p.DateFormat, err)
+		}
+		return t, nil
+	}
+	return parseDate(s)
+}
+
+// indexColumns maps normalized header names to their column index
+func indexColumns(header []string) map[string]int {
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		// Strip a UTF-8 BOM some banks prepend to the first column
+		name = strings.TrimPrefix(name, "\ufeff")
+		columns[normalizeColumn(name)] = i
+	}
+	return columns
+}
+
+// normalizeColumn normalizes a header name for case- and whitespace-insensitive matching
+func normalizeColumn(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// isBlankRecord reports whether every field of a record is empty
+func isBlankRecord(record []string) bool {
+	for _, f := range record {
+		if strings.TrimSpace(f) != "" {
+			return false
+		}
+	}
+	return true
+}
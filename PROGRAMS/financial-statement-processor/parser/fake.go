@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// FakeBackend is a deterministic LLMBackend that replays fixture responses
+// Each prompt gets the first response whose Match text it contains, so results don't depend
+// on the order concurrent pages are sent in
+type FakeBackend struct {
+	model     string
+	responses []FakeResponse
+}
+
+// FakeResponse is a canned reply; an empty Match matches every prompt
+type FakeResponse struct {
+	Match    string
+	Response string
+}
+
+// fakeFixtureFile is the on-disk fixture format
+// A response may be a JSON object (returned verbatim) or a string (returned as-is, which
+// allows replaying malformed replies)
+type fakeFixtureFile struct {
+	Model     string `json:"model"`
+	Responses []struct {
+		Match    string          `json:"match"`
+		Response json.RawMessage `json:"response"`
+	} `json:"responses"`
+}
+
+// NewFakeBackend creates a fake backend from in-memory responses
+func NewFakeBackend(model string, responses ...FakeResponse) *FakeBackend {
+	return &FakeBackend{model: model, responses: responses}
+}
+
+// LoadFakeBackend creates a fake backend from a JSON fixture file
+func LoadFakeBackend(path string) (*FakeBackend, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read LLM fixtures: %w", err)
+	}
+
+	var file fakeFixtureFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse LLM fixtures %s: %w", path, err)
+	}
+
+	model := file.Model
+	if model == "" {
+		model = LLMBackendFake
+	}
+
+	responses := make([]FakeResponse, 0, len(file.Responses))
+	for i, r := range file.Responses {
+		response := string(r.Response)
+		if strings.HasPrefix(strings.TrimSpace(response), `"`) {
+			if err := json.Unmarshal(r.Response, &response); err != nil {
+				return nil, fmt.Errorf("parse LLM fixtures %s: response %d: %w", path, i+1, err)
+			}
+		}
+		responses = append(responses, FakeResponse{Match: r.Match, Response: response})
+	}
+
+	return NewFakeBackend(model, responses...), nil
+}
+
+// Name returns the backend name
+func (f *FakeBackend) Name() string {
+	return LLMBackendFake
+}
+
+// Model returns the fixture's model name
+func (f *FakeBackend) Model() string {
+	return f.model
+}
+
+// GenerateJSON returns the first fixture response matching the prompt; the schema is ignored
+func (f *FakeBackend) GenerateJSON(prompt string, schema json.RawMessage) (string, error) {
+	for _, r := range f.responses {
+		if strings.Contains(prompt, r.Match) {
+			return r.Response, nil
+		}
+	}
+	return "", fmt.Errorf("no fixture response matches the prompt")
+}
+
+// HealthCheck always succeeds
+func (f *FakeBackend) HealthCheck() error {
+	return nil
+}
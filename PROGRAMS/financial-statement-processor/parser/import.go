@@ -0,0 +1,101 @@
+package parser
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"financial-statement-processor/db"
+	"money"
+)
+
+// parseAmount parses a monetary amount as written in bank exports
+// Handles currency symbols, thousands separators, trailing minus signs and (parenthesised) negatives
+func parseAmount(s string) (money.Cents, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("empty amount")
+	}
+
+	negative := false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative = true
+		s = s[1 : len(s)-1]
+	}
+	if strings.HasSuffix(s, "-") {
+		negative = true
+		s = strings.TrimSuffix(s, "-")
+	}
+
+	s = strings.NewReplacer("$", "", ",", "", " ", "", "USD", "").Replace(s)
+	if strings.HasPrefix(s, "-") {
+		negative = !negative
+		s = s[1:]
+	}
+	s = strings.TrimPrefix(s, "+")
+
+	amount, err := money.Parse(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+// lastFour returns the last four digits of an account identifier
+func lastFour(accountID string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, accountID)
+
+	if len(digits) <= 4 {
+		return digits
+	}
+	return digits[len(digits)-4:]
+}
+
+// finalizeImport fills in account overrides, a missing statement date and per-transaction metadata
+func finalizeImport(data *StatementData, filePath string, opts Options) *StatementData {
+	if opts.AccountName != "" {
+		data.AccountName = opts.AccountName
+	}
+	if opts.AccountLast4 != "" {
+		data.AccountLast4 = opts.AccountLast4
+	}
+
+	// Without an explicit statement date use the latest transaction date
+	if data.StatementDate.IsZero() {
+		for _, tx := range data.Transactions {
+			if tx.TransactionDate.After(data.StatementDate) {
+				data.StatementDate = tx.TransactionDate
+			}
+		}
+	}
+
+	for _, tx := range data.Transactions {
+		tx.AccountName = data.AccountName
+		tx.AccountLast4 = data.AccountLast4
+		tx.StatementDate = data.StatementDate
+		tx.SourceFile = filepath.Base(filePath)
+	}
+
+	data.Transactions = deduplicateTransactions(data.Transactions)
+	return data
+}
+
+// newImportedTransaction builds a transaction from structured import fields
+func newImportedTransaction(date time.Time, description string, amount money.Cents) *db.Transaction {
+	return &db.Transaction{
+		TransactionDate: date,
+		Description:     strings.Join(strings.Fields(description), " "),
+		Amount:          amount,
+		TransactionType: db.TransactionTypeFor(amount),
+	}
+}
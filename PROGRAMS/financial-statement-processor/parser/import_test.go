@@ -0,0 +1,198 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"money"
+)
+
+func writeTestFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	return path
+}
+
+func TestParseCSVAutoDetectsProfile(t *testing.T) {
+	path := writeTestFile(t, "activity.csv", `Transaction Date,Post Date,Description,Category,Type,Amount,Memo
+10/15/2024,10/16/2024,WHOLE FOODS #123,Groceries,Sale,-52.34,
+10/20/2024,10/20/2024,Payment Thank You,,Payment,"1,200.00",
+`)
+
+	data, err := ParseFile(path, Options{AccountName: "Chase Sapphire", AccountLast4: "1234"})
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	if err := ValidateStatementData(data); err != nil {
+		t.Fatalf("ValidateStatementData failed: %v", err)
+	}
+
+	if len(data.Transactions) != 2 {
+		t.Fatalf("Expected 2 transactions, got %d", len(data.Transactions))
+	}
+
+	first := data.Transactions[0]
+	if first.Amount != -5234 || first.TransactionType != "debit" {
+		t.Errorf("Expected debit of -52.34, got %s %s", first.TransactionType, first.Amount)
+	}
+	if first.PostDate == nil || first.PostDate.Format("2006-01-02") != "2024-10-16" {
+		t.Errorf("Expected post date 2024-10-16, got %v", first.PostDate)
+	}
+	if data.Transactions[1].Amount != 120000 || data.Transactions[1].TransactionType != "credit" {
+		t.Errorf("Expected credit of 1200, got %+v", data.Transactions[1])
+	}
+
+	// Statement date defaults to the latest transaction date
+	if got := data.StatementDate.Format("2006-01-02"); got != "2024-10-20" {
+		t.Errorf("Expected statement date 2024-10-20, got %s", got)
+	}
+	if first.SourceFile != "activity.csv" || first.AccountLast4 != "1234" {
+		t.Errorf("Expected account and source metadata on transactions, got %+v", first)
+	}
+}
+
+func TestParseCSVDebitCreditColumns(t *testing.T) {
+	path := writeTestFile(t, "capone.csv", `Transaction Date,Posted Date,Card No.,Description,Category,Debit,Credit
+2024-10-03,2024-10-04,5678,SHELL OIL,Gas/Automotive,45.10,
+2024-10-05,2024-10-05,5678,CAPITAL ONE AUTOPAY,Payment/Credit,,300.00
+`)
+
+	data, err := ParseFile(path, Options{CSVProfile: "capital-one", AccountName: "Quicksilver", AccountLast4: "5678"})
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	if data.Transactions[0].Amount != -4510 {
+		t.Errorf("Expected debit -45.10, got %s", data.Transactions[0].Amount)
+	}
+	if data.Transactions[1].Amount != 30000 {
+		t.Errorf("Expected credit 300, got %s", data.Transactions[1].Amount)
+	}
+}
+
+func TestParseOFX(t *testing.T) {
+	path := writeTestFile(t, "checking.qfx", `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<SIGNONMSGSRSV1><SONRS><FI><ORG>MYBANK<FID>1234</FI></SONRS></SIGNONMSGSRSV1>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<CURDEF>USD
+<BANKACCTFROM><BANKID>123456789<ACCTID>000123459876<ACCTTYPE>CHECKING</BANKACCTFROM>
+<BANKTRANLIST>
+<DTSTART>20241001
+<DTEND>20241031120000.000[-5:EST]
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20241015
+<TRNAMT>-52.34
+<FITID>1
+<NAME>WHOLE FOODS
+<MEMO>STORE 123
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20241020
+<TRNAMT>2500.00
+<FITID>2
+<NAME>PAYROLL
+</STMTTRN>
+</BANKTRANLIST>
+<LEDGERBAL><BALAMT>3447.66<DTASOF>20241031</LEDGERBAL>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`)
+
+	data, err := ParseFile(path, Options{})
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	if err := ValidateStatementData(data); err != nil {
+		t.Fatalf("ValidateStatementData failed: %v", err)
+	}
+
+	if data.AccountName != "MYBANK Checking" || data.AccountLast4 != "9876" {
+		t.Errorf("Unexpected account: %s ...%s", data.AccountName, data.AccountLast4)
+	}
+	if got := data.StatementDate.Format("2006-01-02"); got != "2024-10-31" {
+		t.Errorf("Expected statement date 2024-10-31, got %s", got)
+	}
+	if len(data.Transactions) != 2 {
+		t.Fatalf("Expected 2 transactions, got %d", len(data.Transactions))
+	}
+	if data.Transactions[0].Description != "WHOLE FOODS STORE 123" {
+		t.Errorf("Unexpected description: %s", data.Transactions[0].Description)
+	}
+	if data.Transactions[1].Currency != "USD" {
+		t.Errorf("Expected the statement's currency on every transaction, got %q", data.Transactions[1].Currency)
+	}
+}
+
+func TestParseQIF(t *testing.T) {
+	path := writeTestFile(t, "export.qif", `!Account
+NChecking 4321
+TBank
+^
+!Type:Bank
+D10/15'24
+T-52.34
+PWHOLE FOODS
+^
+D10/20/2024
+T2,500.00
+PPAYROLL
+MOctober
+^
+`)
+
+	data, err := ParseFile(path, Options{})
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	if err := ValidateStatementData(data); err != nil {
+		t.Fatalf("ValidateStatementData failed: %v", err)
+	}
+
+	if data.AccountLast4 != "4321" {
+		t.Errorf("Expected last4 4321, got %s", data.AccountLast4)
+	}
+	if len(data.Transactions) != 2 {
+		t.Fatalf("Expected 2 transactions, got %d", len(data.Transactions))
+	}
+	if got := data.Transactions[0].TransactionDate.Format("2006-01-02"); got != "2024-10-15" {
+		t.Errorf("Expected 2024-10-15, got %s", got)
+	}
+	if data.Transactions[1].Amount != 250000 || data.Transactions[1].Description != "PAYROLL October" {
+		t.Errorf("Unexpected second transaction: %+v", data.Transactions[1])
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := map[string]money.Cents{
+		"52.34":      5234,
+		"-52.34":     -5234,
+		"$1,234.56":  123456,
+		"(45.00)":    -4500,
+		"45.00-":     -4500,
+		"-$1,000.00": -100000,
+	}
+
+	for input, want := range tests {
+		got, err := parseAmount(input)
+		if err != nil {
+			t.Errorf("parseAmount(%q) failed: %v", input, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("parseAmount(%q) = %v, want %v", input, got, want)
+		}
+	}
+}
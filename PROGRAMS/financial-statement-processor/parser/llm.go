@@ -1,14 +1,17 @@
 package parser
 
 import (
-	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"time"
 )
 
+// StatementPromptVersion identifies the statement prompt in the parse cache
+// Bump it whenever buildStatementPrompt changes so cached responses aren't reused
+const StatementPromptVersion = 3
+
 // OllamaClient handles communication with Ollama LLM
 type OllamaClient struct {
 	host   string
@@ -18,10 +21,11 @@ type OllamaClient struct {
 
 // OllamaRequest represents the request to Ollama API
 type OllamaRequest struct {
-	Model  string `json:"model"`
-	Prompt string `json:"prompt"`
-	Stream bool   `json:"stream"`
-	Format string `json:"format,omitempty"`
+	Model  string          `json:"model"`
+	Prompt string          `json:"prompt"`
+	Stream bool            `json:"stream"`
+	Format json.RawMessage `json:"format,omitempty"` // "json" or a JSON schema
+	Images []string        `json:"images,omitempty"` // base64-encoded images for vision models
 }
 
 // OllamaResponse represents the response from Ollama API
@@ -43,68 +47,152 @@ func NewOllamaClient(host, model string) *OllamaClient {
 	}
 }
 
-// ParseStatementText sends extracted PDF text to LLM for structured parsing
-func (c *OllamaClient) ParseStatementText(text string) (string, error) {
-	prompt := buildStatementPrompt(text)
+// Name returns the backend name
+func (c *OllamaClient) Name() string {
+	return LLMBackendOllama
+}
+
+// Model returns the Ollama model name
+func (c *OllamaClient) Model() string {
+	return c.model
+}
+
+// TranscribeImage asks a vision model to transcribe all text in an image
+func (c *OllamaClient) TranscribeImage(image []byte) (string, error) {
+	return c.generate(OllamaRequest{
+		Model:  c.model,
+		Prompt: buildTranscribePrompt(),
+		Stream: false,
+		Images: []string{base64.StdEncoding.EncodeToString(image)},
+	})
+}
 
-	reqBody := OllamaRequest{
+// GenerateJSON sends a prompt to Ollama and returns the raw JSON-formatted response
+func (c *OllamaClient) GenerateJSON(prompt string, schema json.RawMessage) (string, error) {
+	return c.generate(OllamaRequest{
 		Model:  c.model,
 		Prompt: prompt,
 		Stream: false,
-		Format: "json",
-	}
+		Format: ollamaFormat(schema),
+	})
+}
 
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return "", fmt.Errorf("marshal request: %w", err)
+// generate sends a request to Ollama's generate endpoint and returns the response text
+func (c *OllamaClient) generate(reqBody OllamaRequest) (string, error) {
+	var ollamaResp OllamaResponse
+	if err := postJSON(c.client, fmt.Sprintf("%s/api/generate", c.host), "", reqBody, &ollamaResp); err != nil {
+		return "", err
 	}
 
-	url := fmt.Sprintf("%s/api/generate", c.host)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("create request: %w", err)
-	}
+	return ollamaResp.Response, nil
+}
+
+// HealthCheck verifies Ollama is accessible
+func (c *OllamaClient) HealthCheck() error {
+	return ollamaHealthCheck(c.client, c.host)
+}
 
-	req.Header.Set("Content-Type", "application/json")
+// ollamaFormat returns the format field for a request: the schema itself (Ollama 0.5+
+// structured outputs) or plain JSON mode
+func ollamaFormat(schema json.RawMessage) json.RawMessage {
+	if schema != nil {
+		return schema
+	}
+	return json.RawMessage(`"json"`)
+}
 
-	resp, err := c.client.Do(req)
+// ollamaHealthCheck verifies an Ollama server answers on its model list endpoint
+func ollamaHealthCheck(client *http.Client, host string) error {
+	url := fmt.Sprintf("%s/api/tags", host)
+	resp, err := client.Get(url)
 	if err != nil {
-		return "", fmt.Errorf("send request: %w", err)
+		return fmt.Errorf("ollama not accessible: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("ollama returned status %d: %s", resp.StatusCode, string(body))
+		return fmt.Errorf("ollama returned status %d", resp.StatusCode)
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("read response: %w", err)
-	}
+	return nil
+}
 
-	var ollamaResp OllamaResponse
-	if err := json.Unmarshal(body, &ollamaResp); err != nil {
-		return "", fmt.Errorf("unmarshal response: %w", err)
+// OllamaChatClient talks to Ollama's /api/chat endpoint, which applies the model's chat
+// template (some instruction-tuned models follow the prompt better this way)
+type OllamaChatClient struct {
+	host   string
+	model  string
+	client *http.Client
+}
+
+// OllamaChatRequest represents a request to Ollama's chat API
+type OllamaChatRequest struct {
+	Model    string          `json:"model"`
+	Messages []ChatMessage   `json:"messages"`
+	Stream   bool            `json:"stream"`
+	Format   json.RawMessage `json:"format,omitempty"`
+}
+
+// OllamaChatResponse represents the response from Ollama's chat API
+type OllamaChatResponse struct {
+	Model   string      `json:"model"`
+	Message ChatMessage `json:"message"`
+	Done    bool        `json:"done"`
+}
+
+// ChatMessage is a single message in a chat completion request or response
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// NewOllamaChatClient creates a new Ollama chat client
+func NewOllamaChatClient(host, model string) *OllamaChatClient {
+	return &OllamaChatClient{
+		host:  host,
+		model: model,
+		client: &http.Client{
+			Timeout: 120 * time.Second,
+		},
 	}
+}
 
-	return ollamaResp.Response, nil
+// Name returns the backend name
+func (c *OllamaChatClient) Name() string {
+	return LLMBackendOllamaChat
 }
 
-// HealthCheck verifies Ollama is accessible
-func (c *OllamaClient) HealthCheck() error {
-	url := fmt.Sprintf("%s/api/tags", c.host)
-	resp, err := c.client.Get(url)
+// Model returns the Ollama model name
+func (c *OllamaChatClient) Model() string {
+	return c.model
+}
+
+// GenerateJSON sends a prompt as a single user message and returns the JSON reply
+func (c *OllamaChatClient) GenerateJSON(prompt string, schema json.RawMessage) (string, error) {
+	var chatResp OllamaChatResponse
+	err := postJSON(c.client, fmt.Sprintf("%s/api/chat", c.host), "", OllamaChatRequest{
+		Model:    c.model,
+		Messages: []ChatMessage{{Role: "user", Content: prompt}},
+		Stream:   false,
+		Format:   ollamaFormat(schema),
+	}, &chatResp)
 	if err != nil {
-		return fmt.Errorf("ollama not accessible: %w", err)
+		return "", err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("ollama returned status %d", resp.StatusCode)
-	}
+	return chatResp.Message.Content, nil
+}
 
-	return nil
+// HealthCheck verifies Ollama is accessible
+func (c *OllamaChatClient) HealthCheck() error {
+	return ollamaHealthCheck(c.client, c.host)
+}
+
+// buildTranscribePrompt creates the vision model prompt for OCR
+func buildTranscribePrompt() string {
+	return `Transcribe ALL text in this image of a bank or credit card statement exactly as it appears.
+Keep each table row on its own line and keep dates, descriptions and amounts on the same line.
+Do not summarize, interpret or add commentary. Return only the transcribed text.`
 }
 
 // buildStatementPrompt creates the LLM prompt for parsing bank statements
@@ -116,6 +204,8 @@ Required JSON structure:
   "account_name": "Account holder name or account type",
   "account_last4": "Last 4 digits of account number",
   "statement_date": "ISO 8601 date in YYYY-MM-DD format (e.g., 2025-10-01, NOT 25-10-01)",
+  "opening_balance": 1000.00,
+  "closing_balance": 1234.56,
   "transactions": [
     {
       "transaction_date": "ISO 8601 date in YYYY-MM-DD format (e.g., 2025-10-15, NOT 25-10-15)",
@@ -133,6 +223,7 @@ Rules:
 - amount should be negative for debits (money out), positive for credits (money in)
 - dates MUST be in YYYY-MM-DD format with 4-digit year (e.g., 2025-10-01). Never use 2-digit years like 25-10-01.
 - balance can be null if not shown
+- opening_balance is the beginning/previous balance and closing_balance the ending/new balance of the statement period; use null if not shown in this text
 - post_date can be null if not shown
 - Extract ALL transactions you can find
 
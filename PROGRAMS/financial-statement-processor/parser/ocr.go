@@ -0,0 +1,184 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// OCR engines
+const (
+	OCREngineTesseract = "tesseract"
+	OCREngineOllama    = "ollama"
+	OCREngineNone      = "none"
+)
+
+// OCROptions controls how text is extracted from images and scanned PDF pages
+type OCROptions struct {
+	Engine        string // "tesseract" (default), "ollama" (vision model) or "none"
+	TesseractPath string // tesseract binary
+	Language      string // tesseract language(s), e.g. "eng" or "eng+spa"
+	DPI           int    // resolution for rasterizing PDF pages and the tesseract --dpi hint
+	PDFToPPMPath  string // pdftoppm binary (poppler-utils) used to rasterize scanned PDF pages
+	VisionModel   string // Ollama vision model for the "ollama" engine, e.g. "llava"
+}
+
+// ocrImage extracts text from an image file, returning one entry per page
+// Multi-page TIFFs produce several pages when tesseract is used
+func ocrImage(imagePath string, opts Options) ([]string, error) {
+	switch opts.OCR.Engine {
+	case OCREngineTesseract, "":
+		text, err := runTesseract(imagePath, opts.OCR)
+		if err != nil {
+			return nil, err
+		}
+		// tesseract separates pages with a form feed
+		return strings.Split(text, "\f"), nil
+	case OCREngineOllama:
+		text, err := transcribeWithVisionModel(imagePath, opts)
+		if err != nil {
+			return nil, err
+		}
+		return []string{text}, nil
+	case OCREngineNone:
+		return nil, fmt.Errorf("OCR is disabled (OCR_ENGINE=none)")
+	default:
+		return nil, fmt.Errorf("unknown OCR engine: %s (supported: tesseract, ollama, none)", opts.OCR.Engine)
+	}
+}
+
+// runTesseract shells out to the tesseract binary and returns the recognized text
+func runTesseract(imagePath string, opts OCROptions) (string, error) {
+	binary := opts.TesseractPath
+	if binary == "" {
+		binary = "tesseract"
+	}
+
+	args := []string{imagePath, "stdout"}
+	if opts.Language != "" {
+		args = append(args, "-l", opts.Language)
+	}
+	if opts.DPI > 0 {
+		args = append(args, "--dpi", strconv.Itoa(opts.DPI))
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(binary, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("run tesseract: %w (%s)", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
+}
+
+// transcribeWithVisionModel sends an image to an Ollama vision model and returns the transcription
+func transcribeWithVisionModel(imagePath string, opts Options) (string, error) {
+	image, err := os.ReadFile(imagePath)
+	if err != nil {
+		return "", fmt.Errorf("read image: %w", err)
+	}
+
+	if opts.OCR.VisionModel == "" {
+		return "", fmt.Errorf("no Ollama vision model configured (set OLLAMA_VISION_MODEL)")
+	}
+
+	client := NewOllamaClient(opts.OllamaHost, opts.OCR.VisionModel)
+	return client.TranscribeImage(image)
+}
+
+// rasterizePDFPage renders a single PDF page to a PNG with pdftoppm and returns its path
+// The caller is responsible for removing the returned file's directory
+func rasterizePDFPage(pdfPath string, pageNum int, opts OCROptions) (string, error) {
+	binary := opts.PDFToPPMPath
+	if binary == "" {
+		binary = "pdftoppm"
+	}
+
+	dpi := opts.DPI
+	if dpi <= 0 {
+		dpi = 300
+	}
+
+	dir, err := os.MkdirTemp("", "statement-ocr-")
+	if err != nil {
+		return "", fmt.Errorf("create temp directory: %w", err)
+	}
+
+	prefix := filepath.Join(dir, "page")
+	page := strconv.Itoa(pageNum)
+	cmd := exec.Command(binary, "-f", page, "-l", page, "-r", strconv.Itoa(dpi), "-png", "-singlefile", pdfPath, prefix)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("run pdftoppm: %w (%s)", err, strings.TrimSpace(string(output)))
+	}
+
+	return prefix + ".png", nil
+}
+
+// ocrPDFPage rasterizes a scanned PDF page and runs OCR on it
+func ocrPDFPage(pdfPath string, pageNum int, opts Options) (string, error) {
+	imagePath, err := rasterizePDFPage(pdfPath, pageNum, opts.OCR)
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(filepath.Dir(imagePath))
+
+	pages, err := ocrImage(imagePath, opts)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join(pages, "\n"), nil
+}
+
+// fillScannedPages runs OCR on PDF pages that had no extractable text
+func fillScannedPages(pdfPath string, pages []string, opts Options) {
+	if opts.OCR.Engine == OCREngineNone {
+		return
+	}
+
+	for i, text := range pages {
+		if strings.TrimSpace(text) != "" {
+			continue
+		}
+
+		pageNum := i + 1
+		log.Printf("Page %d has no text layer, running OCR (%s)", pageNum, ocrEngineName(opts.OCR))
+
+		ocrText, err := ocrPDFPage(pdfPath, pageNum, opts)
+		if err != nil {
+			log.Printf("WARNING: OCR failed for page %d: %v", pageNum, err)
+			continue
+		}
+
+		log.Printf("OCR extracted page %d (%d characters)", pageNum, len(ocrText))
+		pages[i] = ocrText
+	}
+}
+
+// ocrEngineName returns the effective engine name for logging
+func ocrEngineName(opts OCROptions) string {
+	if opts.Engine == "" {
+		return OCREngineTesseract
+	}
+	return opts.Engine
+}
+
+// nonEmptyPages drops pages without any text
+func nonEmptyPages(pages []string) []string {
+	result := make([]string, 0, len(pages))
+	for _, p := range pages {
+		if strings.TrimSpace(p) != "" {
+			result = append(result, p)
+		}
+	}
+	return result
+}
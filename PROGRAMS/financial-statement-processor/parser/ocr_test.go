@@ -0,0 +1,49 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestOCRImageWithTesseract(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake tesseract is a shell script")
+	}
+
+	// Fake tesseract that records its arguments and prints two form-feed separated pages
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	script := "#!/bin/sh\necho \"$@\" > " + argsFile + "\nprintf 'page one\\fpage two'\n"
+	binary := filepath.Join(dir, "tesseract")
+	if err := os.WriteFile(binary, []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write fake tesseract: %v", err)
+	}
+
+	pages, err := ocrImage("statement.tif", Options{
+		OCR: OCROptions{TesseractPath: binary, Language: "eng+spa", DPI: 200},
+	})
+	if err != nil {
+		t.Fatalf("ocrImage failed: %v", err)
+	}
+
+	if len(pages) != 2 || pages[0] != "page one" || pages[1] != "page two" {
+		t.Errorf("Unexpected pages: %q", pages)
+	}
+
+	args, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatalf("Failed to read recorded args: %v", err)
+	}
+	if got := strings.TrimSpace(string(args)); got != "statement.tif stdout -l eng+spa --dpi 200" {
+		t.Errorf("Unexpected tesseract args: %s", got)
+	}
+}
+
+func TestOCRDisabled(t *testing.T) {
+	if _, err := ocrImage("statement.png", Options{OCR: OCROptions{Engine: OCREngineNone}}); err == nil {
+		t.Error("Expected error when OCR is disabled")
+	}
+}
@@ -0,0 +1,205 @@
+package parser
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"financial-statement-processor/db"
+	"money"
+)
+
+// ofxTagPattern matches an OFX tag and the text that follows it
+// Works for both SGML (OFX 1.x, unclosed leaf tags) and XML (OFX 2.x) files
+var ofxTagPattern = regexp.MustCompile(`<(/?[A-Za-z0-9.]+)>([^<]*)`)
+
+// OFXStatement holds the statement-level values read from an OFX/QFX file
+type OFXStatement struct {
+	Org           string
+	AccountID     string
+	AccountType   string
+	Currency      string // CURDEF, the currency of every amount in the statement
+	StartDate     time.Time
+	EndDate       time.Time
+	LedgerBalance *money.Cents
+	Transactions  []*db.Transaction
+}
+
+// parseOFX imports an OFX or QFX (Quicken) download
+func parseOFX(filePath string, opts Options) (*StatementData, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("read OFX: %w", err)
+	}
+
+	stmt, err := parseOFXContent(string(content))
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Imported %d transactions from OFX (account ...%s)", len(stmt.Transactions), lastFour(stmt.AccountID))
+
+	accountName := strings.TrimSpace(strings.Join([]string{stmt.Org, ofxAccountTypeName(stmt.AccountType)}, " "))
+	for _, tx := range stmt.Transactions {
+		tx.Currency = stmt.Currency
+	}
+
+	data := &StatementData{
+		AccountName:    accountName,
+		AccountLast4:   lastFour(stmt.AccountID),
+		StatementDate:  stmt.EndDate,
+		Transactions:   stmt.Transactions,
+		ClosingBalance: stmt.LedgerBalance,
+	}
+
+	return finalizeImport(data, filePath, opts), nil
+}
+
+// parseOFXContent extracts the account and transactions from OFX markup
+func parseOFXContent(content string) (*OFXStatement, error) {
+	stmt := &OFXStatement{}
+
+	var current map[string]string
+	inLedger := false
+
+	for _, m := range ofxTagPattern.FindAllStringSubmatch(content, -1) {
+		tag := strings.ToUpper(m[1])
+		value := strings.TrimSpace(m[2])
+
+		switch tag {
+		case "STMTTRN":
+			current = make(map[string]string)
+			continue
+		case "/STMTTRN":
+			if current != nil {
+				tx, err := ofxTransaction(current)
+				if err != nil {
+					return nil, err
+				}
+				stmt.Transactions = append(stmt.Transactions, tx)
+			}
+			current = nil
+			continue
+		case "LEDGERBAL":
+			inLedger = true
+			continue
+		case "/LEDGERBAL":
+			inLedger = false
+			continue
+		}
+
+		if strings.HasPrefix(tag, "/") || value == "" {
+			continue
+		}
+
+		if current != nil {
+			current[tag] = value
+			continue
+		}
+
+		switch tag {
+		case "ORG":
+			stmt.Org = value
+		case "ACCTID":
+			stmt.AccountID = value
+		case "ACCTTYPE":
+			stmt.AccountType = value
+		case "CURDEF":
+			stmt.Currency = strings.ToUpper(value)
+		case "DTSTART":
+			if t, err := parseOFXDate(value); err == nil {
+				stmt.StartDate = t
+			}
+		case "DTEND":
+			if t, err := parseOFXDate(value); err == nil {
+				stmt.EndDate = t
+			}
+		case "BALAMT":
+			if inLedger {
+				if b, err := parseAmount(value); err == nil {
+					stmt.LedgerBalance = &b
+				}
+			}
+		}
+	}
+
+	// An unterminated final transaction is common in SGML files
+	if current != nil {
+		tx, err := ofxTransaction(current)
+		if err != nil {
+			return nil, err
+		}
+		stmt.Transactions = append(stmt.Transactions, tx)
+	}
+
+	if stmt.AccountID == "" {
+		return nil, fmt.Errorf("OFX file has no account ID")
+	}
+
+	return stmt, nil
+}
+
+// ofxTransaction converts the fields of a STMTTRN block into a transaction
+func ofxTransaction(fields map[string]string) (*db.Transaction, error) {
+	date, err := parseOFXDate(fields["DTPOSTED"])
+	if err != nil {
+		return nil, fmt.Errorf("transaction %s: %w", fields["FITID"], err)
+	}
+
+	amount, err := parseAmount(fields["TRNAMT"])
+	if err != nil {
+		return nil, fmt.Errorf("transaction %s: %w", fields["FITID"], err)
+	}
+
+	description := fields["NAME"]
+	if memo := fields["MEMO"]; memo != "" && !strings.Contains(description, memo) {
+		description = strings.TrimSpace(description + " " + memo)
+	}
+	if description == "" {
+		description = fields["TRNTYPE"]
+	}
+
+	tx := newImportedTransaction(date, description, amount)
+
+	if userDate, ok := fields["DTUSER"]; ok {
+		// DTUSER is when the transaction happened, DTPOSTED when it posted
+		if t, err := parseOFXDate(userDate); err == nil && !t.Equal(date) {
+			posted := date
+			tx.TransactionDate = t
+			tx.PostDate = &posted
+		}
+	}
+
+	return tx, nil
+}
+
+// parseOFXDate parses OFX dates: YYYYMMDD[HHMMSS[.XXX]][[+-]TZ[:name]]
+func parseOFXDate(s string) (time.Time, error) {
+	if idx := strings.Index(s, "["); idx >= 0 {
+		s = s[:idx]
+	}
+	if len(s) < 8 {
+		return time.Time{}, fmt.Errorf("invalid OFX date: %q", s)
+	}
+	return time.Parse("20060102", s[:8])
+}
+
+// ofxAccountTypeName returns a readable name for an OFX account type
+func ofxAccountTypeName(accountType string) string {
+	switch strings.ToUpper(accountType) {
+	case "CHECKING":
+		return "Checking"
+	case "SAVINGS":
+		return "Savings"
+	case "MONEYMRKT":
+		return "Money Market"
+	case "CREDITLINE":
+		return "Credit Line"
+	case "":
+		return "Credit Card" // CCACCTFROM has no ACCTTYPE
+	}
+	return accountType
+}
@@ -7,9 +7,11 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"financial-statement-processor/db"
+	"money"
 
 	"github.com/ledongthuc/pdf"
 )
@@ -20,57 +22,112 @@ type StatementData struct {
 	AccountLast4  string
 	StatementDate time.Time
 	Transactions  []*db.Transaction
+
+	// Statement balances used for reconciliation; nil when the statement doesn't show them
+	OpeningBalance *money.Cents
+	ClosingBalance *money.Cents
 }
 
 // LLMStatementResponse represents the JSON structure expected from the LLM
 type LLMStatementResponse struct {
-	AccountName   string           `json:"account_name"`
-	AccountLast4  string           `json:"account_last4"`
-	StatementDate string           `json:"statement_date"`
-	Transactions  []LLMTransaction `json:"transactions"`
+	AccountName    string           `json:"account_name"`
+	AccountLast4   string           `json:"account_last4"`
+	StatementDate  string           `json:"statement_date"`
+	OpeningBalance *money.Cents     `json:"opening_balance"`
+	ClosingBalance *money.Cents     `json:"closing_balance"`
+	Transactions   []LLMTransaction `json:"transactions"`
 }
 
 // LLMTransaction represents a transaction as returned by the LLM
 type LLMTransaction struct {
-	TransactionDate string   `json:"transaction_date"`
-	PostDate        *string  `json:"post_date"`
-	Description     string   `json:"description"`
-	Amount          float64  `json:"amount"`
-	TransactionType string   `json:"transaction_type"`
-	Balance         *float64 `json:"balance"`
+	TransactionDate string       `json:"transaction_date"`
+	PostDate        *string      `json:"post_date"`
+	Description     string       `json:"description"`
+	Amount          money.Cents  `json:"amount"`
+	TransactionType string       `json:"transaction_type"`
+	Balance         *money.Cents `json:"balance"`
+}
+
+// Options controls how statement files are parsed
+type Options struct {
+	OllamaHost  string
+	OllamaModel string
+	Workers     int // pages parsed concurrently by the LLM (default 1)
+
+	// LLM parses statement pages; nil uses Ollama's generate API at OllamaHost/OllamaModel
+	LLM LLMBackend
+
+	// Validation of LLM responses
+	PlainJSON     bool   // only request JSON instead of sending the response schema
+	RepairRetries int    // times a response with problems is sent back to the model for repair
+	InvalidRows   string // rows still invalid after repair: "skip" (default) or "fail" the page
+
+	// Cache stores raw LLM responses per page; nil disables caching
+	Cache        ParseCache
+	RefreshCache bool // ignore cached responses (new responses are still stored)
+
+	// Structured import (CSV/OFX/QFX/QIF) settings
+	CSVProfile      string // CSV profile name; selected by file pattern or header row when empty
+	CSVProfilesPath string // optional JSON file with additional CSV profiles
+	AccountName     string // account name for formats that don't carry one (overrides the file)
+	AccountLast4    string // account last 4 for formats that don't carry one (overrides the file)
+
+	// OCR settings for images and scanned PDF pages
+	OCR OCROptions
 }
 
-// ParseFile parses a bank statement file (PDF or image) using local LLM
-func ParseFile(filePath, ollamaHost, ollamaModel string) (*StatementData, error) {
+// ParseFile parses a bank statement file
+// PDFs and images go through the local LLM; CSV, OFX/QFX and QIF downloads are imported directly
+func ParseFile(filePath string, opts Options) (*StatementData, error) {
 	ext := strings.ToLower(filepath.Ext(filePath))
 
 	switch ext {
 	case ".pdf":
-		return parsePDF(filePath, ollamaHost, ollamaModel)
+		return parsePDF(filePath, opts)
 	case ".jpg", ".jpeg", ".png", ".tiff", ".tif":
-		return parseImage(filePath, ollamaHost, ollamaModel)
+		return parseImage(filePath, opts)
+	case ".csv":
+		return parseCSV(filePath, opts)
+	case ".ofx", ".qfx":
+		return parseOFX(filePath, opts)
+	case ".qif":
+		return parseQIF(filePath, opts)
 	default:
-		return nil, fmt.Errorf("unsupported file type: %s (supported: PDF, JPG, PNG, TIFF)", ext)
+		return nil, fmt.Errorf("unsupported file type: %s (supported: PDF, JPG, PNG, TIFF, CSV, OFX, QFX, QIF)", ext)
 	}
 }
 
+// IsStructuredFile reports whether a file is imported directly rather than parsed by the LLM
+func IsStructuredFile(filePath string) bool {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".csv", ".ofx", ".qfx", ".qif":
+		return true
+	}
+	return false
+}
+
 // parsePDF extracts transaction data from a PDF bank statement
-func parsePDF(filePath, ollamaHost, ollamaModel string) (*StatementData, error) {
+func parsePDF(filePath string, opts Options) (*StatementData, error) {
 	// Extract text from PDF page-by-page
 	pages, err := extractPDFTextByPage(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("extract PDF text: %w", err)
 	}
 
+	// Scanned pages have no text layer; OCR them
+	fillScannedPages(filePath, pages, opts)
+
+	pages = nonEmptyPages(pages)
 	if len(pages) == 0 {
 		return nil, fmt.Errorf("PDF contains no readable pages")
 	}
 
 	// Parse each page with LLM and merge results
-	return parseMultiPageWithLLM(pages, filePath, ollamaHost, ollamaModel)
+	return parseMultiPageWithLLM(pages, filePath, opts)
 }
 
 // extractPDFTextByPage extracts text from each page of a PDF separately
+// Pages without extractable text are returned as empty strings so they can be OCR'd
 func extractPDFTextByPage(filePath string) ([]string, error) {
 	f, err := os.Open(filePath)
 	if err != nil {
@@ -97,17 +154,20 @@ func extractPDFTextByPage(filePath string) ([]string, error) {
 		page := pdfReader.Page(pageNum)
 		if page.V.IsNull() {
 			log.Printf("WARNING: Page %d is null, skipping", pageNum)
+			pages = append(pages, "")
 			continue
 		}
 
 		text, err := page.GetPlainText(nil)
 		if err != nil {
 			log.Printf("WARNING: Failed to extract text from page %d: %v", pageNum, err)
+			pages = append(pages, "")
 			continue
 		}
 
 		if strings.TrimSpace(text) == "" {
-			log.Printf("WARNING: Page %d contains no text, skipping", pageNum)
+			log.Printf("WARNING: Page %d contains no text", pageNum)
+			pages = append(pages, "")
 			continue
 		}
 
@@ -115,34 +175,127 @@ func extractPDFTextByPage(filePath string) ([]string, error) {
 		pages = append(pages, text)
 	}
 
-	log.Printf("Successfully extracted %d/%d pages", len(pages), numPages)
+	log.Printf("Successfully extracted %d/%d pages", len(nonEmptyPages(pages)), numPages)
 	return pages, nil
 }
 
 // parseImage extracts transaction data from an image using OCR
-func parseImage(filePath, ollamaHost, ollamaModel string) (*StatementData, error) {
-	// TODO: Implement OCR parsing
-	// For now, return error suggesting PDF conversion
-	return nil, fmt.Errorf("image OCR not yet implemented - please convert to PDF or use tesseract manually")
+func parseImage(filePath string, opts Options) (*StatementData, error) {
+	log.Printf("Running OCR on image (%s)", ocrEngineName(opts.OCR))
+
+	pages, err := ocrImage(filePath, opts)
+	if err != nil {
+		return nil, fmt.Errorf("OCR image: %w", err)
+	}
+
+	pages = nonEmptyPages(pages)
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("OCR found no text in image")
+	}
+
+	log.Printf("OCR extracted %d page(s)", len(pages))
+
+	// Parse each page with LLM and merge results
+	return parseMultiPageWithLLM(pages, filePath, opts)
+}
+
+// pageResult holds the outcome of parsing a single page
+type pageResult struct {
+	data     *StatementData
+	response string // raw LLM JSON, stored in the parse cache
+	cached   bool
+	err      error
+	duration time.Duration
 }
 
-// parseMultiPageWithLLM processes each page separately with LLM and merges results
-func parseMultiPageWithLLM(pages []string, sourceFile, ollamaHost, ollamaModel string) (*StatementData, error) {
-	log.Printf("Processing %d pages with LLM (page-by-page to avoid context limits)", len(pages))
+// parseMultiPageWithLLM parses pages concurrently with a bounded worker pool and merges
+// the results in page order
+// Pages found in the parse cache are not sent to the LLM again
+func parseMultiPageWithLLM(pages []string, sourceFile string, opts Options) (*StatementData, error) {
+	started := time.Now()
+	results := make([]pageResult, len(pages))
+	backend := opts.llmBackend()
+	cacheKeys := parseCacheKeys(sourceFile, pages, backend, opts)
+
+	pending := make([]int, 0, len(pages))
+	for i := range pages {
+		if cacheKeys != nil && !opts.RefreshCache {
+			if data, ok := loadCachedPage(opts.Cache, cacheKeys[i], sourceFile, opts.InvalidRows); ok {
+				log.Printf("Page %d loaded from parse cache (%d transactions)", i+1, len(data.Transactions))
+				results[i] = pageResult{data: data, cached: true}
+				continue
+			}
+		}
+		pending = append(pending, i)
+	}
+
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(pending) {
+		workers = len(pending)
+	}
+
+	if len(pending) > 0 {
+		log.Printf("Processing %d pages with LLM (page-by-page to avoid context limits, %d workers)", len(pending), workers)
+
+		// One backend and one health check for the whole statement
+		if err := backend.HealthCheck(); err != nil {
+			return nil, fmt.Errorf("LLM health check failed: %w", err)
+		}
+
+		jobs := make(chan int)
+
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range jobs {
+					pageStart := time.Now()
+					data, response, err := parseWithLLM(backend, pages[i], sourceFile, opts)
+					results[i] = pageResult{data: data, response: response, err: err, duration: time.Since(pageStart)}
+
+					if err != nil {
+						log.Printf("WARNING: Failed to parse page %d after %s: %v", i+1, results[i].duration.Round(time.Millisecond), err)
+					} else {
+						log.Printf("Page %d parsed in %s (%d transactions)", i+1, results[i].duration.Round(time.Millisecond), len(data.Transactions))
+					}
+				}
+			}()
+		}
+
+		for _, i := range pending {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+	}
+
+	// Cache writes happen here rather than in the workers to keep SQLite writes on one goroutine
+	if cacheKeys != nil {
+		for i, result := range results {
+			if result.err != nil || result.cached {
+				continue
+			}
+			if err := opts.Cache.PutParseCache(cacheKeys[i], filepath.Base(sourceFile), result.response); err != nil {
+				log.Printf("WARNING: Failed to cache page %d: %v", i+1, err)
+			}
+		}
+	}
+
+	log.Printf("Parsed %d pages in %s (%d from cache)", len(pages), time.Since(started).Round(time.Millisecond), len(pages)-len(pending))
 
 	var allTransactions []*db.Transaction
 	var statementData *StatementData
 
-	for i, pageText := range pages {
+	for i, result := range results {
 		pageNum := i + 1
-		log.Printf("Processing page %d...", pageNum)
-
-		// Parse this page with LLM
-		pageData, err := parseWithLLM(pageText, sourceFile, ollamaHost, ollamaModel)
-		if err != nil {
-			log.Printf("WARNING: Failed to parse page %d: %v", pageNum, err)
+		if result.err != nil {
 			continue
 		}
+		pageData := result.data
 
 		// Use account info and statement date from the first successfully parsed page
 		if statementData == nil {
@@ -155,6 +308,14 @@ func parseMultiPageWithLLM(pages []string, sourceFile, ollamaHost, ollamaModel s
 			log.Printf("Using account info from page %d: %s (...%s)", pageNum, pageData.AccountName, pageData.AccountLast4)
 		}
 
+		// Opening balance comes from the first page that shows one, closing balance from the last
+		if statementData.OpeningBalance == nil && pageData.OpeningBalance != nil {
+			statementData.OpeningBalance = pageData.OpeningBalance
+		}
+		if pageData.ClosingBalance != nil {
+			statementData.ClosingBalance = pageData.ClosingBalance
+		}
+
 		// Collect transactions from this page
 		log.Printf("Page %d contributed %d transactions", pageNum, len(pageData.Transactions))
 		allTransactions = append(allTransactions, pageData.Transactions...)
@@ -180,7 +341,7 @@ func deduplicateTransactions(transactions []*db.Transaction) []*db.Transaction {
 
 	for _, tx := range transactions {
 		// Create unique key matching database UNIQUE constraint
-		key := fmt.Sprintf("%s|%s|%s|%.2f",
+		key := fmt.Sprintf("%s|%s|%s|%d",
 			tx.AccountLast4,
 			tx.TransactionDate.Format("2006-01-02"),
 			tx.Description,
@@ -196,25 +357,29 @@ func deduplicateTransactions(transactions []*db.Transaction) []*db.Transaction {
 }
 
 // parseWithLLM sends extracted text to local LLM for structured parsing
-func parseWithLLM(text, sourceFile, ollamaHost, ollamaModel string) (*StatementData, error) {
+// The raw LLM response is returned alongside the parsed data for caching
+func parseWithLLM(backend LLMBackend, text, sourceFile string, opts Options) (*StatementData, string, error) {
 	log.Printf("Sending %d characters to LLM for parsing", len(text))
 
-	// Create LLM client
-	client := NewOllamaClient(ollamaHost, ollamaModel)
-
-	// Health check
-	if err := client.HealthCheck(); err != nil {
-		return nil, fmt.Errorf("ollama health check failed: %w (make sure Ollama is running at %s)", err, ollamaHost)
+	// Send to LLM for parsing, repairing invalid responses
+	responseJSON, err := generateStatementJSON(backend, text, opts)
+	if err != nil {
+		return nil, "", fmt.Errorf("LLM parsing failed: %w", err)
 	}
 
-	// Send to LLM for parsing
-	responseJSON, err := client.ParseStatementText(text)
+	log.Printf("Received LLM response (%d characters)", len(responseJSON))
+
+	data, err := statementFromLLMResponse(responseJSON, sourceFile, opts.InvalidRows)
 	if err != nil {
-		return nil, fmt.Errorf("LLM parsing failed: %w", err)
+		return nil, "", err
 	}
 
-	log.Printf("Received LLM response (%d characters)", len(responseJSON))
+	return data, responseJSON, nil
+}
 
+// statementFromLLMResponse converts the LLM's JSON response into statement data
+// Invalid rows are dropped or fail the page depending on invalidRows
+func statementFromLLMResponse(responseJSON, sourceFile, invalidRows string) (*StatementData, error) {
 	// Parse LLM response
 	var llmResp LLMStatementResponse
 	if err := json.Unmarshal([]byte(responseJSON), &llmResp); err != nil {
@@ -230,23 +395,28 @@ func parseWithLLM(text, sourceFile, ollamaHost, ollamaModel string) (*StatementD
 	}
 
 	data := &StatementData{
-		AccountName:   llmResp.AccountName,
-		AccountLast4:  llmResp.AccountLast4,
-		StatementDate: statementDate,
-		Transactions:  make([]*db.Transaction, 0, len(llmResp.Transactions)),
+		AccountName:    llmResp.AccountName,
+		AccountLast4:   llmResp.AccountLast4,
+		StatementDate:  statementDate,
+		Transactions:   make([]*db.Transaction, 0, len(llmResp.Transactions)),
+		OpeningBalance: llmResp.OpeningBalance,
+		ClosingBalance: llmResp.ClosingBalance,
 	}
 
 	// Convert LLM transactions to db.Transaction
+	// Questionable rows are kept but flagged so they land in the review queue
 	for i, llmTx := range llmResp.Transactions {
-		txDate, err := parseDate(llmTx.TransactionDate)
-		if err != nil {
-			return nil, fmt.Errorf("transaction %d: parse date '%s': %w", i, llmTx.TransactionDate, err)
+		if problems := rowProblems(llmTx); len(problems) > 0 {
+			if invalidRows == InvalidRowsFail {
+				return nil, fmt.Errorf("transaction %d: %s", i, strings.Join(problems, ", "))
+			}
+			log.Printf("WARNING: Skipping transaction %d (%s %q %s): %s", i, llmTx.TransactionDate, llmTx.Description, llmTx.Amount, strings.Join(problems, ", "))
+			continue
 		}
 
 		tx := &db.Transaction{
 			AccountName:     llmResp.AccountName,
 			AccountLast4:    llmResp.AccountLast4,
-			TransactionDate: txDate,
 			Description:     llmTx.Description,
 			Amount:          llmTx.Amount,
 			TransactionType: llmTx.TransactionType,
@@ -255,6 +425,19 @@ func parseWithLLM(text, sourceFile, ollamaHost, ollamaModel string) (*StatementD
 			SourceFile:      filepath.Base(sourceFile),
 		}
 
+		txDate, err := parseDate(llmTx.TransactionDate)
+		if err != nil {
+			log.Printf("WARNING: Transaction %d: unparseable date '%s', flagging for review", i, llmTx.TransactionDate)
+			tx.RawDate = llmTx.TransactionDate
+			tx.AddReviewFlag(db.ReviewFlagUnparseableDate)
+		} else {
+			tx.TransactionDate = txDate
+		}
+
+		if tx.SignMismatch() {
+			tx.AddReviewFlag(db.ReviewFlagSignMismatch)
+		}
+
 		// Parse post date if provided
 		if llmTx.PostDate != nil && *llmTx.PostDate != "" {
 			postDate, err := parseDate(*llmTx.PostDate)
@@ -320,7 +503,7 @@ func ValidateStatementData(data *StatementData) error {
 		if tx.Description == "" {
 			return fmt.Errorf("transaction %d: description is required", i)
 		}
-		if tx.TransactionDate.IsZero() {
+		if tx.TransactionDate.IsZero() && !tx.HasReviewFlag(db.ReviewFlagUnparseableDate) {
 			return fmt.Errorf("transaction %d: transaction date is required", i)
 		}
 		if tx.TransactionType != "debit" && tx.TransactionType != "credit" {
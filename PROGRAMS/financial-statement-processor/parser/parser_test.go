@@ -0,0 +1,183 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"financial-statement-processor/db"
+)
+
+// memoryCache is an in-memory ParseCache
+type memoryCache struct {
+	mu      sync.Mutex
+	entries map[db.ParseCacheKey]string
+}
+
+func (c *memoryCache) GetParseCache(key db.ParseCacheKey) (string, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	response, ok := c.entries[key]
+	return response, ok, nil
+}
+
+func (c *memoryCache) PutParseCache(key db.ParseCacheKey, sourceFile, response string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = response
+	return nil
+}
+
+func TestParseMultiPageWithLLMPreservesPageOrder(t *testing.T) {
+	var healthChecks, inFlight, maxInFlight int32
+	pagePattern := regexp.MustCompile(`PAGE-(\d+)`)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/tags" {
+			atomic.AddInt32(&healthChecks, 1)
+			w.Write([]byte(`{"models":[]}`))
+			return
+		}
+
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+
+		var req OllamaRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		page, _ := strconv.Atoi(pagePattern.FindStringSubmatch(req.Prompt)[1])
+
+		// Earlier pages respond slower so they finish out of order
+		time.Sleep(time.Duration(5-page) * 10 * time.Millisecond)
+
+		statement := fmt.Sprintf(`{"account_name":"Checking","account_last4":"1234","statement_date":"2024-10-31",
+			"transactions":[{"transaction_date":"2024-10-%02d","description":"page %d","amount":-1,"transaction_type":"debit"}]}`, page, page)
+		json.NewEncoder(w).Encode(OllamaResponse{Response: statement, Done: true})
+	}))
+	defer server.Close()
+
+	pages := []string{"PAGE-1", "PAGE-2", "PAGE-3", "PAGE-4"}
+	data, err := parseMultiPageWithLLM(pages, "statement.pdf", Options{OllamaHost: server.URL, Workers: 3})
+	if err != nil {
+		t.Fatalf("parseMultiPageWithLLM failed: %v", err)
+	}
+
+	if healthChecks != 1 {
+		t.Errorf("Expected 1 health check, got %d", healthChecks)
+	}
+	if maxInFlight < 2 || maxInFlight > 3 {
+		t.Errorf("Expected 2-3 concurrent requests, got %d", maxInFlight)
+	}
+	if len(data.Transactions) != len(pages) {
+		t.Fatalf("Expected %d transactions, got %d", len(pages), len(data.Transactions))
+	}
+	for i, tx := range data.Transactions {
+		if want := fmt.Sprintf("page %d", i+1); tx.Description != want {
+			t.Errorf("Transaction %d: expected %q, got %q", i, want, tx.Description)
+		}
+	}
+}
+
+func TestParseMultiPageWithLLMUsesCache(t *testing.T) {
+	var generateCalls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/tags" {
+			w.Write([]byte(`{"models":[]}`))
+			return
+		}
+		atomic.AddInt32(&generateCalls, 1)
+		statement := `{"account_name":"Checking","account_last4":"1234","statement_date":"2024-10-31",
+			"transactions":[{"transaction_date":"2024-10-15","description":"WHOLE FOODS","amount":-52.34,"transaction_type":"debit"}]}`
+		json.NewEncoder(w).Encode(OllamaResponse{Response: statement, Done: true})
+	}))
+	defer server.Close()
+
+	sourceFile := filepath.Join(t.TempDir(), "statement.pdf")
+	if err := os.WriteFile(sourceFile, []byte("%PDF-1.4 test"), 0644); err != nil {
+		t.Fatalf("Failed to write statement: %v", err)
+	}
+
+	cache := &memoryCache{entries: make(map[db.ParseCacheKey]string)}
+	opts := Options{OllamaHost: server.URL, OllamaModel: "test-model", Workers: 2, Cache: cache}
+	pages := []string{"page one", "page two"}
+
+	if _, err := parseMultiPageWithLLM(pages, sourceFile, opts); err != nil {
+		t.Fatalf("First parse failed: %v", err)
+	}
+	if generateCalls != 2 || len(cache.entries) != 2 {
+		t.Fatalf("Expected 2 LLM calls and 2 cache entries, got %d calls and %d entries", generateCalls, len(cache.entries))
+	}
+
+	// A second run is served entirely from the cache, even with Ollama down
+	server.Close()
+	data, err := parseMultiPageWithLLM(pages, sourceFile, opts)
+	if err != nil {
+		t.Fatalf("Cached parse failed: %v", err)
+	}
+	if generateCalls != 2 {
+		t.Errorf("Expected no additional LLM calls, got %d total", generateCalls)
+	}
+	if len(data.Transactions) != 1 || data.Transactions[0].Description != "WHOLE FOODS" {
+		t.Errorf("Unexpected transactions from cache: %+v", data.Transactions)
+	}
+
+	// Different page text for the same file (another OCR engine or DPI) is not served from the cache
+	if _, err := parseMultiPageWithLLM([]string{"page one", "page 2"}, sourceFile, opts); err == nil {
+		t.Error("Expected changed page text to contact the (stopped) LLM server and fail")
+	}
+
+	// Neither is the same model behind another backend
+	chatOpts := opts
+	chatOpts.LLM = NewOllamaChatClient(server.URL, "test-model")
+	if _, err := parseMultiPageWithLLM(pages, sourceFile, chatOpts); err == nil {
+		t.Error("Expected another backend to contact the (stopped) LLM server and fail")
+	}
+
+	// Refreshing bypasses the cache
+	opts.RefreshCache = true
+	if _, err := parseMultiPageWithLLM(pages, sourceFile, opts); err == nil {
+		t.Error("Expected refresh to contact the (stopped) LLM server and fail")
+	}
+}
+
+func TestStatementFromLLMResponseFlagsQuestionableRows(t *testing.T) {
+	response := `{"account_name":"Checking","account_last4":"1234","statement_date":"2024-10-31",
+		"transactions":[
+			{"transaction_date":"2024-10-15","description":"WHOLE FOODS","amount":-52.34,"transaction_type":"debit"},
+			{"transaction_date":"Oct ??","description":"SMUDGED","amount":-10,"transaction_type":"debit"},
+			{"transaction_date":"2024-10-20","description":"REFUND","amount":-20,"transaction_type":"credit"}
+		]}`
+
+	data, err := statementFromLLMResponse(response, "statement.pdf", InvalidRowsSkip)
+	if err != nil {
+		t.Fatalf("statementFromLLMResponse failed: %v", err)
+	}
+	if err := ValidateStatementData(data); err != nil {
+		t.Fatalf("ValidateStatementData failed: %v", err)
+	}
+
+	if data.Transactions[0].NeedsReview() {
+		t.Errorf("Expected clean row to have no flags, got %v", data.Transactions[0].ReviewFlags)
+	}
+	if smudged := data.Transactions[1]; !smudged.HasReviewFlag(db.ReviewFlagUnparseableDate) || smudged.RawDate != "Oct ??" {
+		t.Errorf("Expected unparseable date flag, got %+v", smudged)
+	}
+	if !data.Transactions[2].HasReviewFlag(db.ReviewFlagSignMismatch) {
+		t.Errorf("Expected sign mismatch flag, got %v", data.Transactions[2].ReviewFlags)
+	}
+}
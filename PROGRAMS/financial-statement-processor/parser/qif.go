@@ -0,0 +1,165 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"financial-statement-processor/db"
+)
+
+// parseQIF imports a QIF (Quicken Interchange Format) file
+// Only bank/cash/credit card sections are read; investment sections are skipped
+func parseQIF(filePath string, opts Options) (*StatementData, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("open QIF: %w", err)
+	}
+	defer f.Close()
+
+	data := &StatementData{
+		Transactions: make([]*db.Transaction, 0),
+	}
+
+	var (
+		section    string
+		record     = make(map[byte]string)
+		lineNum    int
+		accountMap = make(map[byte]string)
+	)
+
+	flush := func() error {
+		defer func() { record = make(map[byte]string) }()
+
+		switch section {
+		case "account":
+			for k, v := range record {
+				accountMap[k] = v
+			}
+			return nil
+		case "bank", "cash", "ccard", "oth a", "oth l":
+		default:
+			return nil
+		}
+
+		if len(record) == 0 {
+			return nil
+		}
+
+		tx, err := qifTransaction(record)
+		if err != nil {
+			return fmt.Errorf("QIF record ending line %d: %w", lineNum, err)
+		}
+		data.Transactions = append(data.Transactions, tx)
+		return nil
+	}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "!") {
+			header := strings.ToLower(strings.TrimSpace(line[1:]))
+			switch {
+			case header == "account":
+				section = "account"
+			case strings.HasPrefix(header, "type:"):
+				section = strings.TrimSpace(strings.TrimPrefix(header, "type:"))
+			case strings.HasPrefix(header, "option:") || strings.HasPrefix(header, "clear:"):
+				// Quicken options don't change the record layout
+			default:
+				section = header
+			}
+			continue
+		}
+
+		if line == "^" {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		code := line[0]
+		value := strings.TrimSpace(line[1:])
+		// Split lines (S/E/$) repeat; the total amount is what we import
+		if _, exists := record[code]; !exists {
+			record[code] = value
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read QIF: %w", err)
+	}
+
+	// Handle a missing final "^"
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	if name, ok := accountMap['N']; ok {
+		data.AccountName = name
+		data.AccountLast4 = lastFour(name)
+	}
+
+	log.Printf("Imported %d transactions from QIF", len(data.Transactions))
+	return finalizeImport(data, filePath, opts), nil
+}
+
+// qifTransaction converts a QIF record into a transaction
+func qifTransaction(record map[byte]string) (*db.Transaction, error) {
+	date, err := parseQIFDate(record['D'])
+	if err != nil {
+		return nil, err
+	}
+
+	amountStr := record['T']
+	if amountStr == "" {
+		amountStr = record['U']
+	}
+	amount, err := parseAmount(amountStr)
+	if err != nil {
+		return nil, err
+	}
+
+	description := record['P']
+	if memo := record['M']; memo != "" {
+		if description == "" {
+			description = memo
+		} else if !strings.Contains(description, memo) {
+			description += " " + memo
+		}
+	}
+	if description == "" {
+		description = "Check " + record['N']
+	}
+
+	return newImportedTransaction(date, description, amount), nil
+}
+
+// parseQIFDate parses the date styles Quicken writes, e.g. 1/5/24, 1/ 5'24, 01/05/2024, 2024-01-05
+func parseQIFDate(s string) (time.Time, error) {
+	normalized := strings.ReplaceAll(strings.ReplaceAll(s, " ", ""), "'", "/")
+	normalized = strings.ReplaceAll(normalized, "-", "/")
+
+	formats := []string{
+		"1/2/2006",
+		"1/2/06",
+		"2006/1/2",
+		"2.1.2006",
+	}
+	for _, format := range formats {
+		if t, err := time.Parse(format, normalized); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unable to parse QIF date: %s", s)
+}
@@ -0,0 +1,165 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"money"
+)
+
+// maxReportedSuspects limits how many suspect rows are listed in a reconciliation summary
+const maxReportedSuspects = 10
+
+// ReconcileResult compares a statement's reported balances against its transactions
+type ReconcileResult struct {
+	TotalsChecked bool        // opening and closing balances were both reported
+	Opening       money.Cents // reported opening balance
+	Closing       money.Cents // reported closing balance
+	Sum           money.Cents // sum of transaction amounts
+	Delta         money.Cents // closing balance minus the balance implied by the transactions
+	BalanceChecks int         // adjacent row pairs whose running balances were compared
+	SuspectRows   []SuspectRow
+}
+
+// SuspectRow is a transaction whose running balance doesn't follow from the previous row
+type SuspectRow struct {
+	Index           int
+	Date            string
+	Description     string
+	Amount          money.Cents
+	Balance         money.Cents
+	ExpectedBalance money.Cents
+}
+
+// Reconcile checks that opening + sum(amounts) == closing and that per-row balances form
+// a consistent running total
+// Both asset (balance rises with credits) and credit card (balance owed rises with debits)
+// conventions are accepted, as are oldest-first and newest-first row orders
+func Reconcile(data *StatementData) *ReconcileResult {
+	result := &ReconcileResult{}
+
+	for _, tx := range data.Transactions {
+		result.Sum += tx.Amount
+	}
+
+	if data.OpeningBalance != nil && data.ClosingBalance != nil {
+		result.TotalsChecked = true
+		result.Opening = *data.OpeningBalance
+		result.Closing = *data.ClosingBalance
+
+		assetDelta := result.Closing - (result.Opening + result.Sum)
+		liabilityDelta := result.Closing - (result.Opening - result.Sum)
+		result.Delta = assetDelta
+		if liabilityDelta.Abs() < assetDelta.Abs() {
+			result.Delta = liabilityDelta
+		}
+	}
+
+	result.BalanceChecks, result.SuspectRows = checkRunningBalances(data)
+
+	return result
+}
+
+// Checked reports whether any reconciliation check could be performed
+func (r *ReconcileResult) Checked() bool {
+	return r.TotalsChecked || r.BalanceChecks > 0
+}
+
+// OK reports whether the statement reconciled
+func (r *ReconcileResult) OK() bool {
+	return r.Delta == 0 && len(r.SuspectRows) == 0
+}
+
+// Summary describes the reconciliation outcome for logs and processing_log
+func (r *ReconcileResult) Summary() string {
+	if !r.Checked() {
+		return "no balances reported, reconciliation skipped"
+	}
+
+	var parts []string
+	if r.TotalsChecked {
+		if r.Delta == 0 {
+			parts = append(parts, fmt.Sprintf("totals reconcile (opening %s, transactions %s, closing %s)",
+				r.Opening, r.Sum, r.Closing))
+		} else {
+			parts = append(parts, fmt.Sprintf("totals off by %s (opening %s, transactions %s, closing %s)",
+				r.Delta, r.Opening, r.Sum, r.Closing))
+		}
+	}
+
+	if r.BalanceChecks > 0 {
+		if len(r.SuspectRows) == 0 {
+			parts = append(parts, fmt.Sprintf("running balances consistent (%d rows compared)", r.BalanceChecks))
+		} else {
+			rows := make([]string, 0, len(r.SuspectRows))
+			for i, row := range r.SuspectRows {
+				if i == maxReportedSuspects {
+					rows = append(rows, fmt.Sprintf("... %d more", len(r.SuspectRows)-maxReportedSuspects))
+					break
+				}
+				rows = append(rows, fmt.Sprintf("row %d %s %q %s: balance %s, expected %s",
+					row.Index+1, row.Date, row.Description, row.Amount, row.Balance, row.ExpectedBalance))
+			}
+			parts = append(parts, fmt.Sprintf("%d suspect rows: %s", len(r.SuspectRows), strings.Join(rows, "; ")))
+		}
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+// checkRunningBalances compares each row's balance with the one implied by its neighbour
+// Every combination of sign convention and row order is tried and the best fit is reported
+func checkRunningBalances(data *StatementData) (int, []SuspectRow) {
+	var best []SuspectRow
+	checks := 0
+	first := true
+
+	for _, sign := range []money.Cents{1, -1} {
+		for _, newestFirst := range []bool{false, true} {
+			n, suspects := runningBalanceSuspects(data, sign, newestFirst)
+			checks = n
+			if first || len(suspects) < len(best) {
+				best = suspects
+				first = false
+			}
+		}
+	}
+
+	return checks, best
+}
+
+// runningBalanceSuspects returns the rows whose balance doesn't follow from the adjacent row
+func runningBalanceSuspects(data *StatementData, sign money.Cents, newestFirst bool) (int, []SuspectRow) {
+	var suspects []SuspectRow
+	checks := 0
+
+	txs := data.Transactions
+	for i := 1; i < len(txs); i++ {
+		prev, cur := txs[i-1], txs[i]
+		if prev.Balance == nil || cur.Balance == nil {
+			continue
+		}
+		checks++
+
+		// In oldest-first order each row's balance includes its own amount on top of the
+		// previous row's; newest-first order is the same relation read backwards
+		idx, earlier, later := i, prev, cur
+		if newestFirst {
+			idx, earlier, later = i-1, cur, prev
+		}
+
+		expected := *earlier.Balance + sign*later.Amount
+		if *later.Balance != expected {
+			suspects = append(suspects, SuspectRow{
+				Index:           idx,
+				Date:            later.TransactionDate.Format("2006-01-02"),
+				Description:     later.Description,
+				Amount:          later.Amount,
+				Balance:         *later.Balance,
+				ExpectedBalance: expected,
+			})
+		}
+	}
+
+	return checks, suspects
+}
@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"testing"
+	"time"
+
+	"financial-statement-processor/db"
+	"money"
+)
+
+func reconcileTransaction(day int, description string, amount money.Cents, balance *money.Cents) *db.Transaction {
+	return &db.Transaction{
+		TransactionDate: time.Date(2024, 10, day, 0, 0, 0, 0, time.UTC),
+		Description:     description,
+		Amount:          amount,
+		Balance:         balance,
+	}
+}
+
+func balance(c money.Cents) *money.Cents {
+	return &c
+}
+
+func TestReconcileBalancedStatement(t *testing.T) {
+	data := &StatementData{
+		OpeningBalance: balance(100000),
+		ClosingBalance: balance(344766),
+		Transactions: []*db.Transaction{
+			reconcileTransaction(15, "WHOLE FOODS", -5234, balance(94766)),
+			reconcileTransaction(20, "PAYROLL", 250000, balance(344766)),
+		},
+	}
+
+	result := Reconcile(data)
+	if !result.OK() {
+		t.Fatalf("Expected statement to reconcile: %s", result.Summary())
+	}
+	if !result.TotalsChecked || result.BalanceChecks != 1 {
+		t.Errorf("Expected totals and 1 balance pair to be checked, got %+v", result)
+	}
+}
+
+func TestReconcileDroppedRow(t *testing.T) {
+	// The LLM dropped a -20.00 row between the two transactions
+	data := &StatementData{
+		OpeningBalance: balance(100000),
+		ClosingBalance: balance(342766),
+		Transactions: []*db.Transaction{
+			reconcileTransaction(15, "WHOLE FOODS", -5234, balance(94766)),
+			reconcileTransaction(20, "PAYROLL", 250000, balance(342766)),
+		},
+	}
+
+	result := Reconcile(data)
+	if result.OK() {
+		t.Fatal("Expected reconciliation to fail")
+	}
+	if result.Delta != -2000 {
+		t.Errorf("Expected delta -20.00, got %s", result.Delta)
+	}
+	if len(result.SuspectRows) != 1 || result.SuspectRows[0].Description != "PAYROLL" {
+		t.Fatalf("Expected PAYROLL to be the suspect row, got %+v", result.SuspectRows)
+	}
+	if result.SuspectRows[0].ExpectedBalance != 344766 {
+		t.Errorf("Expected balance 3447.66, got %s", result.SuspectRows[0].ExpectedBalance)
+	}
+}
+
+func TestReconcileCreditCardNewestFirst(t *testing.T) {
+	// Credit card balances are amounts owed, and rows are listed newest first
+	data := &StatementData{
+		OpeningBalance: balance(50000),
+		ClosingBalance: balance(57510),
+		Transactions: []*db.Transaction{
+			reconcileTransaction(20, "SHELL OIL", -4510, balance(57510)),
+			reconcileTransaction(15, "NETFLIX", -3000, balance(53000)),
+		},
+	}
+
+	result := Reconcile(data)
+	if !result.OK() {
+		t.Fatalf("Expected statement to reconcile: %s", result.Summary())
+	}
+}
+
+func TestReconcileWithoutBalances(t *testing.T) {
+	data := &StatementData{
+		Transactions: []*db.Transaction{
+			reconcileTransaction(15, "WHOLE FOODS", -5234, nil),
+		},
+	}
+
+	result := Reconcile(data)
+	if result.Checked() || !result.OK() {
+		t.Errorf("Expected an unchecked, passing result, got %+v", result)
+	}
+}
@@ -0,0 +1,131 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// What to do with rows that still fail validation after the repair retries
+const (
+	InvalidRowsSkip = "skip" // drop the row and keep the rest of the page
+	InvalidRowsFail = "fail" // fail the whole page
+)
+
+// maxReportedProblems limits how many validation problems are fed back to the model
+const maxReportedProblems = 20
+
+// statementResponseSchema is the JSON schema of LLMStatementResponse, sent as a
+// structured-output constraint so the model can't invent keys or change types
+var statementResponseSchema = json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "account_name": {"type": "string"},
+    "account_last4": {"type": "string"},
+    "statement_date": {"type": "string"},
+    "opening_balance": {"type": ["number", "null"]},
+    "closing_balance": {"type": ["number", "null"]},
+    "transactions": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "transaction_date": {"type": "string"},
+          "post_date": {"type": ["string", "null"]},
+          "description": {"type": "string"},
+          "amount": {"type": "number"},
+          "transaction_type": {"type": "string", "enum": ["debit", "credit"]},
+          "balance": {"type": ["number", "null"]}
+        },
+        "required": ["transaction_date", "post_date", "description", "amount", "transaction_type", "balance"]
+      }
+    }
+  },
+  "required": ["account_name", "account_last4", "statement_date", "opening_balance", "closing_balance", "transactions"]
+}`)
+
+// responseProblems lists everything wrong with a statement response that the model could fix
+func responseProblems(responseJSON string) []string {
+	var resp LLMStatementResponse
+	if err := json.Unmarshal([]byte(responseJSON), &resp); err != nil {
+		return []string{fmt.Sprintf("response is not valid JSON for the required structure: %v", err)}
+	}
+
+	var problems []string
+	if _, err := parseDate(resp.StatementDate); err != nil {
+		problems = append(problems, fmt.Sprintf("statement_date %q is not a YYYY-MM-DD date", resp.StatementDate))
+	}
+
+	for i, tx := range resp.Transactions {
+		if _, err := parseDate(tx.TransactionDate); err != nil {
+			problems = append(problems, fmt.Sprintf("transactions[%d].transaction_date %q is not a YYYY-MM-DD date", i, tx.TransactionDate))
+		}
+		for _, p := range rowProblems(tx) {
+			problems = append(problems, fmt.Sprintf("transactions[%d].%s", i, p))
+		}
+	}
+
+	return problems
+}
+
+// rowProblems lists problems with a transaction row other than its date
+// Rows with only a bad date are kept and held for review rather than skipped
+func rowProblems(tx LLMTransaction) []string {
+	var problems []string
+	if strings.TrimSpace(tx.Description) == "" {
+		problems = append(problems, "description is empty")
+	}
+	if tx.TransactionType != "debit" && tx.TransactionType != "credit" {
+		problems = append(problems, fmt.Sprintf("transaction_type %q must be \"debit\" or \"credit\"", tx.TransactionType))
+	}
+	return problems
+}
+
+// generateStatementJSON asks the model to parse a page and, while the response has problems,
+// feeds them back for up to opts.RepairRetries more attempts
+// The last response is returned even if problems remain; the caller decides what to keep
+func generateStatementJSON(backend LLMBackend, text string, opts Options) (string, error) {
+	schema := statementResponseSchema
+	if opts.PlainJSON {
+		schema = nil
+	}
+
+	prompt := buildStatementPrompt(text)
+	for attempt := 0; ; attempt++ {
+		responseJSON, err := backend.GenerateJSON(prompt, schema)
+		if err != nil {
+			return "", err
+		}
+
+		problems := responseProblems(responseJSON)
+		if len(problems) == 0 {
+			return responseJSON, nil
+		}
+		if attempt >= opts.RepairRetries {
+			log.Printf("WARNING: LLM response still has %d problems after %d repair attempts", len(problems), attempt)
+			return responseJSON, nil
+		}
+
+		log.Printf("LLM response has %d problems, asking for a repair (attempt %d of %d)", len(problems), attempt+1, opts.RepairRetries)
+		prompt = buildRepairPrompt(text, responseJSON, problems)
+	}
+}
+
+// buildRepairPrompt asks the model to correct its previous response
+func buildRepairPrompt(text, previous string, problems []string) string {
+	if len(problems) > maxReportedProblems {
+		problems = append(problems[:maxReportedProblems:maxReportedProblems], fmt.Sprintf("... and %d more", len(problems)-maxReportedProblems))
+	}
+
+	return fmt.Sprintf(`%s
+
+Your previous response was:
+%s
+
+It has these problems:
+- %s
+
+Fix every problem and return the complete corrected JSON object, nothing else.`,
+		buildStatementPrompt(text), previous, strings.Join(problems, "\n- "))
+}
@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// sequenceBackend returns its responses in order and records the prompts and schemas it saw
+type sequenceBackend struct {
+	responses []string
+	prompts   []string
+	schemas   []json.RawMessage
+}
+
+func (b *sequenceBackend) GenerateJSON(prompt string, schema json.RawMessage) (string, error) {
+	if len(b.prompts) >= len(b.responses) {
+		return "", fmt.Errorf("unexpected call %d", len(b.prompts)+1)
+	}
+	b.prompts = append(b.prompts, prompt)
+	b.schemas = append(b.schemas, schema)
+	return b.responses[len(b.prompts)-1], nil
+}
+
+func (b *sequenceBackend) HealthCheck() error { return nil }
+func (b *sequenceBackend) Name() string       { return "sequence" }
+func (b *sequenceBackend) Model() string      { return "sequence" }
+
+const (
+	brokenPage = `{"account_name":"Checking","account_last4":"1234","statement_date":"2024-10-31","transactions":[
+		{"transaction_date":"2024-10-02","description":"COFFEE","amount":-4.5,"transaction_type":"debit"},
+		{"transaction_date":"10/3?","description":"","amount":-12,"transaction_type":"withdrawal"}]}`
+	repairedPage = `{"account_name":"Checking","account_last4":"1234","statement_date":"2024-10-31","transactions":[
+		{"transaction_date":"2024-10-02","description":"COFFEE","amount":-4.5,"transaction_type":"debit"},
+		{"transaction_date":"2024-10-03","description":"PARKING","amount":-12,"transaction_type":"debit"}]}`
+)
+
+func TestParseWithLLMRepairsResponse(t *testing.T) {
+	backend := &sequenceBackend{responses: []string{brokenPage, repairedPage}}
+
+	data, response, err := parseWithLLM(backend, "PAGE TEXT", "statement.pdf", Options{RepairRetries: 2})
+	if err != nil {
+		t.Fatalf("parseWithLLM failed: %v", err)
+	}
+
+	if len(backend.prompts) != 2 {
+		t.Fatalf("Expected 2 LLM calls, got %d", len(backend.prompts))
+	}
+	for _, want := range []string{"transactions[1].transaction_date", "transactions[1].description is empty", `transaction_type "withdrawal"`, brokenPage} {
+		if !strings.Contains(backend.prompts[1], want) {
+			t.Errorf("Repair prompt is missing %q", want)
+		}
+	}
+	if backend.schemas[0] == nil || !json.Valid(backend.schemas[0]) {
+		t.Error("Expected a valid response schema to be sent")
+	}
+
+	if response != repairedPage {
+		t.Error("Expected the repaired response to be returned for caching")
+	}
+	if len(data.Transactions) != 2 || data.Transactions[1].Description != "PARKING" || data.Transactions[1].NeedsReview() {
+		t.Errorf("Unexpected transactions after repair: %+v", data.Transactions)
+	}
+}
+
+func TestParseWithLLMInvalidRows(t *testing.T) {
+	// Still broken after one repair: the invalid row is skipped by default
+	backend := &sequenceBackend{responses: []string{brokenPage, brokenPage}}
+	data, _, err := parseWithLLM(backend, "PAGE TEXT", "statement.pdf", Options{RepairRetries: 1, PlainJSON: true})
+	if err != nil {
+		t.Fatalf("parseWithLLM failed: %v", err)
+	}
+	if len(backend.prompts) != 2 || backend.schemas[0] != nil {
+		t.Errorf("Expected 2 calls without a schema, got %d", len(backend.prompts))
+	}
+	if len(data.Transactions) != 1 || data.Transactions[0].Description != "COFFEE" {
+		t.Errorf("Expected only the valid row to be kept, got %+v", data.Transactions)
+	}
+
+	// Fail mode rejects the page
+	backend = &sequenceBackend{responses: []string{brokenPage}}
+	if _, _, err := parseWithLLM(backend, "PAGE TEXT", "statement.pdf", Options{InvalidRows: InvalidRowsFail}); err == nil {
+		t.Error("Expected the page to fail in fail mode")
+	}
+
+	// Without retries a response that isn't JSON at all fails the page after one call
+	backend = &sequenceBackend{responses: []string{"not json", repairedPage}}
+	if _, _, err := parseWithLLM(backend, "PAGE TEXT", "statement.pdf", Options{}); err == nil || len(backend.prompts) != 1 {
+		t.Errorf("Expected a single failed call, got %d calls (err=%v)", len(backend.prompts), err)
+	}
+}
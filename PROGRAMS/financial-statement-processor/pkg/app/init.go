@@ -29,6 +29,21 @@ func InitDatabase() (*db.DB, error) {
 	return database, nil
 }
 
+// OpenDatabase loads environment variables and config, and opens the database without applying
+// schema migrations
+// Used by the migrate command; caller is responsible for calling db.Close()
+func OpenDatabase() (*db.DB, error) {
+	// Load .env file if it exists (silently ignore if it doesn't)
+	_ = godotenv.Load()
+
+	cfg, err := config.LoadFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	return db.Open(cfg.DatabasePath())
+}
+
 // InitConfig loads environment variables and config
 // Returns the config or an error
 func InitConfig() (*config.Config, error) {
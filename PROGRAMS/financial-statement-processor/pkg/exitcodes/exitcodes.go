@@ -9,6 +9,10 @@ const (
 	DBError     = 2
 	ConfigError = 3
 
+	// ReconcileError means the parsed transactions don't add up to the statement balances;
+	// nothing was inserted and the file should be reviewed
+	ReconcileError = 4
+
 	// Query-specific exit codes
 	ArgsError = 1
 	// DBError = 2 (shared with processor)
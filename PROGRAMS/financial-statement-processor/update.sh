@@ -14,8 +14,8 @@ echo -e "${GREEN}====================================================${NC}"
 echo ""
 
 # Check if we're in the right directory
-if [ ! -f "schema.sql" ]; then
-    echo -e "${RED}Error: schema.sql not found. Please run this script from the financial-statement-processor directory.${NC}"
+if [ ! -f "db/migrations/0001_initial.sql" ]; then
+    echo -e "${RED}Error: db/migrations/0001_initial.sql not found. Please run this script from the financial-statement-processor directory.${NC}"
     exit 1
 fi
 
@@ -80,10 +80,10 @@ echo -e "${GREEN}Installing Go dependencies...${NC}"
 go mod tidy
 
 echo -e "${GREEN}Building processor binary...${NC}"
-go build -o financial-statement-processor ./cmd/processor
+go build -tags sqlite_fts5 -o financial-statement-processor ./cmd/processor
 
 echo -e "${GREEN}Building query binary...${NC}"
-go build -o financial-statement-query ./cmd/query
+go build -tags sqlite_fts5 -o financial-statement-query ./cmd/query
 
 echo -e "${GREEN}Updating binaries...${NC}"
 if [ "$INSTALL_DIR" = "/usr/local/bin" ] || [ "$INSTALL_DIR" = "/usr/bin" ]; then
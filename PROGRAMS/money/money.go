@@ -0,0 +1,188 @@
+// Package money represents amounts of money as a whole number of cents
+//
+// Amounts read, summed and compared as float64 drift by fractions of a cent, so the programs keep
+// money as Cents from the moment it's parsed: SQLite stores it in INTEGER columns, JSON carries it
+// as a decimal number with two places and command lines take it as decimal text.
+package money
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Cents is an amount of money in hundredths of the currency unit
+type Cents int64
+
+// FromFloat converts an amount in currency units, rounding to the nearest cent (halves away from zero)
+func FromFloat(f float64) Cents {
+	return Cents(math.Round(f * 100))
+}
+
+// Parse reads a decimal amount such as "1234.56", "-12.5" or "+3"; digits past the cents are
+// rounded (halves away from zero). Grouping separators and currency symbols aren't accepted
+func Parse(s string) (Cents, error) {
+	text := strings.TrimSpace(s)
+	if strings.ContainsAny(text, "eE") {
+		// Exponent notation only comes from other programs' float output
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+			return 0, fmt.Errorf("invalid amount %q", s)
+		}
+		return FromFloat(f), nil
+	}
+
+	negative := false
+	if text != "" && (text[0] == '-' || text[0] == '+') {
+		negative = text[0] == '-'
+		text = text[1:]
+	}
+	whole, fraction, _ := strings.Cut(text, ".")
+	if whole == "" && fraction == "" || !digits(whole) || !digits(fraction) {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+
+	var units int64
+	if whole != "" {
+		var err error
+		if units, err = strconv.ParseInt(whole, 10, 64); err != nil || units > math.MaxInt64/100-1 {
+			return 0, fmt.Errorf("amount %q is out of range", s)
+		}
+	}
+	cents := units * 100
+	for i, place := range []int64{10, 1} {
+		if i < len(fraction) {
+			cents += int64(fraction[i]-'0') * place
+		}
+	}
+	if len(fraction) > 2 && fraction[2] >= '5' {
+		cents++
+	}
+
+	if negative {
+		cents = -cents
+	}
+	return Cents(cents), nil
+}
+
+// digits reports whether s is made of decimal digits only (true when empty)
+func digits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Float64 returns the amount in currency units, for ratios and display
+func (c Cents) Float64() float64 {
+	return float64(c) / 100
+}
+
+// String formats the amount in currency units with two decimal places, e.g. "-1234.50"
+func (c Cents) String() string {
+	sign := ""
+	n := int64(c)
+	if n < 0 {
+		sign = "-"
+		n = -n
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, n/100, n%100)
+}
+
+// Abs returns the amount without its sign
+func (c Cents) Abs() Cents {
+	if c < 0 {
+		return -c
+	}
+	return c
+}
+
+// Mul scales the amount by a rate or fraction, rounding to the nearest cent
+func (c Cents) Mul(f float64) Cents {
+	return Cents(math.Round(float64(c) * f))
+}
+
+// Div divides the amount into n parts, rounding to the nearest cent (halves away from zero)
+func (c Cents) Div(n int) Cents {
+	if n == 0 {
+		return 0
+	}
+	d := int64(n)
+	q, r := int64(c)/d, int64(c)%d
+	if 2*abs(r) >= abs(d) {
+		if (int64(c) < 0) != (d < 0) {
+			q--
+		} else {
+			q++
+		}
+	}
+	return Cents(q)
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// MarshalJSON writes the amount as a JSON number with two decimal places
+func (c Cents) MarshalJSON() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalJSON reads a JSON number or a string holding one; null leaves the amount unchanged
+func (c *Cents) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if text == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		text = unquoted
+	}
+	amount, err := Parse(text)
+	if err != nil {
+		return err
+	}
+	*c = amount
+	return nil
+}
+
+// Value stores the amount in an INTEGER column
+func (c Cents) Value() (driver.Value, error) {
+	return int64(c), nil
+}
+
+// Scan reads an amount stored as whole cents; REAL values, such as averages of cents, are rounded
+// to whole cents
+func (c *Cents) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case int64:
+		*c = Cents(v)
+	case float64:
+		*c = Cents(math.Round(v))
+	case []byte:
+		return c.scanText(string(v))
+	case string:
+		return c.scanText(v)
+	case nil:
+		return fmt.Errorf("cannot scan NULL into an amount")
+	default:
+		return fmt.Errorf("cannot scan %T into an amount", src)
+	}
+	return nil
+}
+
+// scanText reads cents stored as text
+func (c *Cents) scanText(s string) error {
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid amount in cents %q", s)
+	}
+	*c = Cents(n)
+	return nil
+}
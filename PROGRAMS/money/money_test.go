@@ -0,0 +1,109 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Cents
+	}{
+		{"1234.56", 123456},
+		{"-12.5", -1250},
+		{"+3", 300},
+		{".07", 7},
+		{"0.125", 13},
+		{"-0.125", -13},
+		{"19.999", 2000},
+		{" 42.10 ", 4210},
+		{"1e2", 10000},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.input)
+		if err != nil || got != tt.want {
+			t.Errorf("Parse(%q) = %d, %v; want %d", tt.input, got, err, tt.want)
+		}
+	}
+
+	for _, input := range []string{"", "-", ".", "1,234.56", "$5", "1.2.3", "abc"} {
+		if got, err := Parse(input); err == nil {
+			t.Errorf("Parse(%q) = %d; want an error", input, got)
+		}
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	// Summing the same amounts as float64 drifts; cents don't
+	var total Cents
+	var float float64
+	for i := 0; i < 1000; i++ {
+		total += 10
+		float += 0.1
+	}
+	if total != 10000 || total.String() != "100.00" {
+		t.Errorf("Expected a total of 100.00, got %s", total)
+	}
+	if FromFloat(float) != total {
+		t.Errorf("Expected the float sum to round to the same total, got %v", float)
+	}
+
+	if got := Cents(-5).String(); got != "-0.05" {
+		t.Errorf("Expected -0.05, got %s", got)
+	}
+	if got := Cents(1000).Mul(1.1); got != 1100 {
+		t.Errorf("Expected 11.00 at a rate of 1.1, got %s", got)
+	}
+	if got := Cents(1000).Div(3); got != 333 {
+		t.Errorf("Expected 10.00 / 3 = 3.33, got %s", got)
+	}
+	if got := Cents(-1000).Div(6); got != -167 {
+		t.Errorf("Expected -10.00 / 6 = -1.67, got %s", got)
+	}
+	if got := Cents(-250).Abs(); got != 250 {
+		t.Errorf("Expected 2.50, got %s", got)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var v struct {
+		Amount  Cents  `json:"amount"`
+		Balance *Cents `json:"balance"`
+		Quoted  Cents  `json:"quoted"`
+	}
+	if err := json.Unmarshal([]byte(`{"amount": -19.99, "balance": 1200, "quoted": "4.5"}`), &v); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+	if v.Amount != -1999 || v.Balance == nil || *v.Balance != 120000 || v.Quoted != 450 {
+		t.Errorf("Unexpected amounts: %+v", v)
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	if string(data) != `{"amount":-19.99,"balance":1200.00,"quoted":4.50}` {
+		t.Errorf("Unexpected JSON: %s", data)
+	}
+
+	if err := json.Unmarshal([]byte(`{"amount": "lots"}`), &v); err == nil {
+		t.Error("Expected an error for a non-numeric amount")
+	}
+}
+
+func TestScan(t *testing.T) {
+	var c Cents
+	for _, src := range []interface{}{int64(1250), float64(1249.6), []byte("1250"), "1250"} {
+		c = 0
+		if err := c.Scan(src); err != nil || c != 1250 {
+			t.Errorf("Scan(%#v) = %d, %v; want 1250", src, c, err)
+		}
+	}
+	if err := c.Scan(nil); err == nil {
+		t.Error("Expected an error scanning NULL")
+	}
+	if v, err := Cents(1250).Value(); err != nil || v != int64(1250) {
+		t.Errorf("Expected Value 1250, got %v (err=%v)", v, err)
+	}
+}
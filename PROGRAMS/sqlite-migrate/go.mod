@@ -0,0 +1,5 @@
+module sqlite-migrate
+
+go 1.25.4
+
+require github.com/mattn/go-sqlite3 v1.14.32
@@ -0,0 +1,109 @@
+package migrate
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// ErrUsage is wrapped by the errors Run returns for arguments it doesn't understand
+var ErrUsage = errors.New("invalid migrate command")
+
+// Apply loads the migrations in dir of fsys and applies the pending ones to the database at
+// dbPath, as programs do whenever they open their database (see Migrator.Up)
+func Apply(conn *sql.DB, dbPath string, fsys fs.FS, dir string) (*Result, error) {
+	m, err := New(conn, dbPath, fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := m.Up()
+	if err != nil {
+		return result, fmt.Errorf("migrate schema: %w", err)
+	}
+
+	return result, nil
+}
+
+// RunCLI loads the migrations in dir of fsys and runs a program's migrate subcommand on the
+// database at dbPath, printing to stdout (see Migrator.Run)
+func RunCLI(args []string, conn *sql.DB, dbPath string, fsys fs.FS, dir string, jsonOutput bool) error {
+	m, err := New(conn, dbPath, fsys, dir)
+	if err != nil {
+		return err
+	}
+	return m.Run(args, os.Stdout, jsonOutput)
+}
+
+// Run runs the migrate subcommand every program has; args are the arguments after "migrate":
+// "status" (the default) lists every migration and whether it has been applied, "up" applies the
+// pending ones. Output is text, or JSON with jsonOutput set
+func (m *Migrator) Run(args []string, w io.Writer, jsonOutput bool) error {
+	action := "status"
+	if len(args) > 0 {
+		action = args[0]
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("%w: unexpected arguments after %s: %s", ErrUsage, action, strings.Join(args[1:], " "))
+	}
+
+	switch action {
+	case "status":
+		statuses, err := m.Status()
+		if err != nil {
+			return err
+		}
+		if jsonOutput {
+			pending := 0
+			for _, s := range statuses {
+				if !s.Applied {
+					pending++
+				}
+			}
+			return writeJSON(w, map[string]interface{}{"migrations": statuses, "pending": pending})
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.AppliedAt != nil {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			} else if s.Applied {
+				state = "applied"
+			}
+			fmt.Fprintf(w, "%04d  %-30s %s\n", s.Version, s.Name, state)
+		}
+		return nil
+
+	case "up":
+		result, err := m.Up()
+		if err != nil {
+			return err
+		}
+		if jsonOutput {
+			return writeJSON(w, map[string]interface{}{"success": true, "applied": result.Applied, "backup": result.Backup})
+		}
+		if result.Backup != "" {
+			fmt.Fprintf(w, "Backed up database to %s\n", result.Backup)
+		}
+		for _, migration := range result.Applied {
+			fmt.Fprintf(w, "Applied migration %04d_%s\n", migration.Version, migration.Name)
+		}
+		if len(result.Applied) == 0 {
+			fmt.Fprintln(w, "Database schema is up to date")
+		}
+		return nil
+	}
+
+	return fmt.Errorf("%w: unknown action %q (use status or up)", ErrUsage, action)
+}
+
+// writeJSON prints v as indented JSON
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
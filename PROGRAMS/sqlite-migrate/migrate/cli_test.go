@@ -0,0 +1,75 @@
+package migrate
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestRun(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	conn, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer conn.Close()
+
+	fsys := fstest.MapFS{
+		"migrations/0001_initial.sql":   {Data: []byte(`CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT NOT NULL);`)},
+		"migrations/0002_add_title.sql": {Data: []byte(`ALTER TABLE notes ADD COLUMN title TEXT;`)},
+	}
+	m, err := New(conn, dbPath, fsys, "migrations")
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	// Status is the default action
+	var out bytes.Buffer
+	if err := m.Run(nil, &out, false); err != nil {
+		t.Fatalf("Failed to run status: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 2 || !strings.HasSuffix(lines[0], "pending") {
+		t.Errorf("Expected 2 pending migrations, got %q", out.String())
+	}
+
+	out.Reset()
+	if err := m.Run([]string{"up"}, &out, false); err != nil {
+		t.Fatalf("Failed to run up: %v", err)
+	}
+	if !strings.Contains(out.String(), "Applied migration 0001_initial") || !strings.Contains(out.String(), "Applied migration 0002_add_title") {
+		t.Errorf("Expected both migrations to be reported, got %q", out.String())
+	}
+
+	out.Reset()
+	if err := m.Run([]string{"up"}, &out, false); err != nil || out.String() != "Database schema is up to date\n" {
+		t.Errorf("Expected nothing to do, got %q (err=%v)", out.String(), err)
+	}
+
+	out.Reset()
+	if err := m.Run([]string{"status"}, &out, true); err != nil {
+		t.Fatalf("Failed to run status: %v", err)
+	}
+	var status struct {
+		Migrations []Status `json:"migrations"`
+		Pending    int      `json:"pending"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &status); err != nil {
+		t.Fatalf("Failed to parse status JSON %q: %v", out.String(), err)
+	}
+	if len(status.Migrations) != 2 || status.Pending != 0 || !status.Migrations[1].Applied {
+		t.Errorf("Expected 2 applied migrations, got %+v", status)
+	}
+
+	for _, args := range [][]string{{"down"}, {"up", "now"}} {
+		if err := m.Run(args, &out, false); !errors.Is(err, ErrUsage) {
+			t.Errorf("Run(%q) = %v, want a usage error", args, err)
+		}
+	}
+}
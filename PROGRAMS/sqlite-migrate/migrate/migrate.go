@@ -0,0 +1,379 @@
+// Package migrate applies versioned schema migrations to the SQLite databases of the programs
+//
+// Migrations are SQL files named <version>_<name>.sql, usually embedded with embed.FS. Each one runs
+// in its own transaction and is recorded in the schema_migrations table, so a database only ever
+// gets the migrations it hasn't seen yet. Before anything is applied to a database that already
+// holds data, a copy of it is written next to the database file.
+//
+// A migration containing the line
+//
+//	-- migrate:foreign-keys-off
+//
+// runs with foreign key enforcement switched off, so it can rebuild tables other tables refer to
+// without their ON DELETE actions firing; the foreign keys are checked before it is committed.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Migration is one versioned schema change
+type Migration struct {
+	Version        int    `json:"version"`
+	Name           string `json:"name"`
+	SQL            string `json:"-"`
+	ForeignKeysOff bool   `json:"-"` // run without foreign key enforcement (see the package comment)
+}
+
+// foreignKeysOffDirective marks a migration that runs with foreign key enforcement switched off
+const foreignKeysOffDirective = "-- migrate:foreign-keys-off"
+
+// Status reports whether a migration has been applied to the database
+type Status struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// Result describes a migration run
+type Result struct {
+	Applied []Migration `json:"applied"`
+	Backup  string      `json:"backup,omitempty"` // copy of the database taken before the first migration
+}
+
+// Migrator applies a program's migrations to one database
+type Migrator struct {
+	conn       *sql.DB
+	dbPath     string
+	migrations []Migration
+
+	// Legacy, if set, runs once on a database that holds tables but predates schema_migrations,
+	// after the backup and before the first migration. It brings databases created by older
+	// releases up to the schema the first migration expects
+	Legacy func() error
+}
+
+// schemaMigrationsTable records the migrations applied to a database
+const schemaMigrationsTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+)`
+
+// New loads the migrations in dir of fsys for the database at dbPath
+func New(conn *sql.DB, dbPath string, fsys fs.FS, dir string) (*Migrator, error) {
+	migrations, err := Load(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{conn: conn, dbPath: dbPath, migrations: migrations}, nil
+}
+
+// Load reads the migrations in dir of fsys, ordered by version
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+
+	var migrations []Migration
+	seen := make(map[int]string)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		base := strings.TrimSuffix(entry.Name(), ".sql")
+		prefix, name, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: file name must be <version>_<name>.sql", entry.Name())
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", other, entry.Name(), version)
+		}
+		seen[version] = entry.Name()
+
+		contents, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", entry.Name(), err)
+		}
+		migrations = append(migrations, Migration{
+			Version:        version,
+			Name:           name,
+			SQL:            string(contents),
+			ForeignKeysOff: hasDirective(string(contents), foreignKeysOffDirective),
+		})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// Status lists every known migration and whether it has been applied, followed by any applied
+// migration this program doesn't know about (the database was migrated by a newer release)
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []Status{}
+	for _, migration := range m.migrations {
+		s := Status{Version: migration.Version, Name: migration.Name}
+		if a, ok := applied[migration.Version]; ok {
+			s.Applied = true
+			s.AppliedAt = a.AppliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, s)
+	}
+
+	var unknown []Status
+	for _, a := range applied {
+		unknown = append(unknown, a)
+	}
+	sort.Slice(unknown, func(i, j int) bool { return unknown[i].Version < unknown[j].Version })
+
+	return append(statuses, unknown...), nil
+}
+
+// Pending returns the migrations that haven't been applied yet, in order
+func (m *Migrator) Pending() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies the pending migrations in order, backing up the database file first when it already
+// holds data. Each migration runs in its own transaction; a failing migration is rolled back and
+// stops the run, leaving the earlier ones applied
+func (m *Migrator) Up() (*Result, error) {
+	result := &Result{Applied: []Migration{}}
+
+	tracked, err := m.hasTable("schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	populated, err := m.hasUserTables()
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := m.Pending()
+	if err != nil {
+		return nil, err
+	}
+	legacy := !tracked && populated && m.Legacy != nil
+	if len(pending) == 0 && !legacy {
+		return result, nil
+	}
+
+	if populated {
+		if result.Backup, err = m.Backup(); err != nil {
+			return nil, err
+		}
+	}
+
+	if legacy {
+		if err := m.Legacy(); err != nil {
+			return result, fmt.Errorf("upgrade legacy schema: %w", err)
+		}
+	}
+
+	if _, err := m.conn.Exec(schemaMigrationsTable); err != nil {
+		return result, fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	for _, migration := range pending {
+		if err := m.apply(migration); err != nil {
+			return result, err
+		}
+		result.Applied = append(result.Applied, migration)
+	}
+
+	return result, nil
+}
+
+// Backup writes a consistent copy of the database next to it and returns its path
+// In-memory databases aren't backed up
+func (m *Migrator) Backup() (string, error) {
+	if m.dbPath == "" || m.dbPath == ":memory:" || strings.Contains(m.dbPath, "mode=memory") {
+		return "", nil
+	}
+	if _, err := os.Stat(m.dbPath); err != nil {
+		return "", nil
+	}
+
+	version := 0
+	if tracked, err := m.hasTable("schema_migrations"); err != nil {
+		return "", err
+	} else if tracked {
+		if err := m.conn.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+			return "", fmt.Errorf("read schema version: %w", err)
+		}
+	}
+
+	backupPath := fmt.Sprintf("%s.v%d-%s.bak", m.dbPath, version, time.Now().Format("20060102-150405"))
+	if _, err := os.Stat(backupPath); err == nil {
+		return "", fmt.Errorf("backup %s already exists", backupPath)
+	}
+
+	// VACUUM INTO writes a clean copy through SQLite, so a WAL or an open connection can't leave it torn
+	if _, err := m.conn.Exec(`VACUUM INTO ?`, backupPath); err != nil {
+		return "", fmt.Errorf("back up database: %w", err)
+	}
+
+	return backupPath, nil
+}
+
+// apply runs one migration and records it in the same transaction
+func (m *Migrator) apply(migration Migration) error {
+	// PRAGMA foreign_keys only applies to one connection and can't change inside a transaction,
+	// so the migration holds on to a connection of its own
+	ctx := context.Background()
+	conn, err := m.conn.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("begin migration %d: %w", migration.Version, err)
+	}
+	defer conn.Close()
+
+	if migration.ForeignKeysOff {
+		var enabled bool
+		if err := conn.QueryRowContext(ctx, `PRAGMA foreign_keys`).Scan(&enabled); err != nil {
+			return fmt.Errorf("read foreign key setting: %w", err)
+		}
+		if enabled {
+			if _, err := conn.ExecContext(ctx, `PRAGMA foreign_keys = OFF`); err != nil {
+				return fmt.Errorf("disable foreign keys: %w", err)
+			}
+			defer conn.ExecContext(ctx, `PRAGMA foreign_keys = ON`)
+		}
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin migration %d: %w", migration.Version, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(migration.SQL); err != nil {
+		return fmt.Errorf("apply migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	if migration.ForeignKeysOff {
+		if err := checkForeignKeys(tx); err != nil {
+			return fmt.Errorf("apply migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+	}
+	if _, err := tx.Exec(`INSERT INTO schema_migrations (version, name) VALUES (?, ?)`, migration.Version, migration.Name); err != nil {
+		return fmt.Errorf("record migration %d: %w", migration.Version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit migration %d: %w", migration.Version, err)
+	}
+
+	return nil
+}
+
+// checkForeignKeys fails when any row refers to a row that doesn't exist
+func checkForeignKeys(tx *sql.Tx) error {
+	rows, err := tx.Query(`PRAGMA foreign_key_check`)
+	if err != nil {
+		return fmt.Errorf("check foreign keys: %w", err)
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		var table, parent string
+		var rowid sql.NullInt64
+		var fk int
+		if err := rows.Scan(&table, &rowid, &parent, &fk); err != nil {
+			return fmt.Errorf("check foreign keys: %w", err)
+		}
+		return fmt.Errorf("foreign key violation: %s row %d refers to a missing %s row", table, rowid.Int64, parent)
+	}
+	return rows.Err()
+}
+
+// hasDirective reports whether the SQL has the directive on a line of its own
+func hasDirective(contents, directive string) bool {
+	for _, line := range strings.Split(contents, "\n") {
+		if strings.TrimSpace(line) == directive {
+			return true
+		}
+	}
+	return false
+}
+
+// applied returns the migrations recorded in schema_migrations by version
+func (m *Migrator) applied() (map[int]Status, error) {
+	applied := make(map[int]Status)
+
+	tracked, err := m.hasTable("schema_migrations")
+	if err != nil || !tracked {
+		return applied, err
+	}
+
+	rows, err := m.conn.Query(`SELECT version, name, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("query schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s Status
+		var appliedAt sql.NullTime
+		if err := rows.Scan(&s.Version, &s.Name, &appliedAt); err != nil {
+			return nil, fmt.Errorf("scan schema_migrations: %w", err)
+		}
+		s.Applied = true
+		if appliedAt.Valid {
+			s.AppliedAt = &appliedAt.Time
+		}
+		applied[s.Version] = s
+	}
+
+	return applied, rows.Err()
+}
+
+// hasTable reports whether the database has a table with the given name
+func (m *Migrator) hasTable(name string) (bool, error) {
+	var count int
+	err := m.conn.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, name).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("inspect schema: %w", err)
+	}
+	return count > 0, nil
+}
+
+// hasUserTables reports whether the database holds any tables besides schema_migrations
+func (m *Migrator) hasUserTables() (bool, error) {
+	var count int
+	err := m.conn.QueryRow(`
+		SELECT COUNT(*) FROM sqlite_master
+		WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name != 'schema_migrations'
+	`).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("inspect schema: %w", err)
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,208 @@
+package migrate
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestUp(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	conn, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer conn.Close()
+
+	fsys := fstest.MapFS{
+		"migrations/0001_initial.sql": {Data: []byte(`CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT NOT NULL);`)},
+		"migrations/README.md":        {Data: []byte("not a migration")},
+	}
+	m, err := New(conn, dbPath, fsys, "migrations")
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	// A new database is migrated without a backup
+	result, err := m.Up()
+	if err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	if len(result.Applied) != 1 || result.Backup != "" {
+		t.Fatalf("Expected 1 migration and no backup, got %+v", result)
+	}
+	if _, err := conn.Exec(`INSERT INTO notes (body) VALUES ('kept')`); err != nil {
+		t.Fatalf("Failed to insert note: %v", err)
+	}
+
+	// Nothing to do the second time
+	if result, err := m.Up(); err != nil || len(result.Applied) != 0 || result.Backup != "" {
+		t.Fatalf("Expected no migrations, got %+v (err=%v)", result, err)
+	}
+
+	// A new migration is applied after backing up the populated database
+	fsys["migrations/0002_add_title.sql"] = &fstest.MapFile{Data: []byte(`ALTER TABLE notes ADD COLUMN title TEXT;`)}
+	fsys["migrations/0003_broken.sql"] = &fstest.MapFile{Data: []byte(`ALTER TABLE missing ADD COLUMN x TEXT;`)}
+	m, _ = New(conn, dbPath, fsys, "migrations")
+	result, err = m.Up()
+	if err == nil {
+		t.Fatal("Expected the broken migration to fail")
+	}
+	if len(result.Applied) != 1 || result.Applied[0].Version != 2 {
+		t.Errorf("Expected migration 2 to be applied before the failure, got %+v", result.Applied)
+	}
+	if result.Backup == "" {
+		t.Fatal("Expected a backup of the populated database")
+	}
+	backup, err := sql.Open("sqlite3", result.Backup)
+	if err != nil {
+		t.Fatalf("Failed to open backup: %v", err)
+	}
+	defer backup.Close()
+	var body string
+	if err := backup.QueryRow(`SELECT body FROM notes`).Scan(&body); err != nil || body != "kept" {
+		t.Errorf("Expected the backup to hold the note, got %q (err=%v)", body, err)
+	}
+
+	statuses, err := m.Status()
+	if err != nil {
+		t.Fatalf("Failed to get status: %v", err)
+	}
+	if len(statuses) != 3 || !statuses[0].Applied || !statuses[1].Applied || statuses[2].Applied {
+		t.Errorf("Expected migrations 1 and 2 applied and 3 pending, got %+v", statuses)
+	}
+}
+
+func TestLegacy(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "legacy.db")
+	conn, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer conn.Close()
+
+	// Created by a release that predates migrations, before the title column existed
+	if _, err := conn.Exec(`CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT NOT NULL)`); err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	fsys := fstest.MapFS{
+		"migrations/0001_initial.sql": {Data: []byte(`
+			CREATE TABLE IF NOT EXISTS notes (id INTEGER PRIMARY KEY, body TEXT NOT NULL, title TEXT);
+			CREATE INDEX IF NOT EXISTS idx_notes_title ON notes(title);`)},
+	}
+	m, err := New(conn, dbPath, fsys, "migrations")
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	legacyRuns := 0
+	m.Legacy = func() error {
+		legacyRuns++
+		_, err := conn.Exec(`ALTER TABLE notes ADD COLUMN title TEXT`)
+		return err
+	}
+
+	result, err := m.Up()
+	if err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	if legacyRuns != 1 || len(result.Applied) != 1 || result.Backup == "" {
+		t.Errorf("Expected one legacy upgrade, one migration and a backup, got %d runs and %+v", legacyRuns, result)
+	}
+	if _, err := os.Stat(result.Backup); err != nil {
+		t.Errorf("Expected the backup file to exist: %v", err)
+	}
+
+	if _, err := m.Up(); err != nil || legacyRuns != 1 {
+		t.Errorf("Expected the legacy upgrade to run once, got %d runs (err=%v)", legacyRuns, err)
+	}
+}
+
+func TestForeignKeysOff(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "fk.db")
+	conn, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer conn.Close()
+	conn.SetMaxOpenConns(1)
+	if _, err := conn.Exec(`PRAGMA foreign_keys = ON`); err != nil {
+		t.Fatalf("Failed to enable foreign keys: %v", err)
+	}
+
+	fsys := fstest.MapFS{
+		"migrations/0001_initial.sql": {Data: []byte(`
+			CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT NOT NULL);
+			CREATE TABLE tags (id INTEGER PRIMARY KEY, note_id INTEGER NOT NULL REFERENCES notes(id) ON DELETE CASCADE, tag TEXT);
+			INSERT INTO notes (id, body) VALUES (1, 'kept');
+			INSERT INTO tags (note_id, tag) VALUES (1, 'kept');`)},
+		// Rebuilding notes would cascade to its tags with foreign keys enforced
+		"migrations/0002_rebuild_notes.sql": {Data: []byte(`
+			-- migrate:foreign-keys-off
+			CREATE TABLE notes_new (id INTEGER PRIMARY KEY, body TEXT NOT NULL CHECK (body != ''));
+			INSERT INTO notes_new (id, body) SELECT id, body FROM notes;
+			DROP TABLE notes;
+			ALTER TABLE notes_new RENAME TO notes;`)},
+	}
+	m, err := New(conn, dbPath, fsys, "migrations")
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	if m.migrations[0].ForeignKeysOff || !m.migrations[1].ForeignKeysOff {
+		t.Fatalf("Expected only migration 2 to run without foreign keys, got %+v", m.migrations)
+	}
+	if _, err := m.Up(); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	var tags int
+	if err := conn.QueryRow(`SELECT COUNT(*) FROM tags`).Scan(&tags); err != nil || tags != 1 {
+		t.Errorf("Expected the tag to survive the rebuild, got %d (err=%v)", tags, err)
+	}
+	var enabled bool
+	if err := conn.QueryRow(`PRAGMA foreign_keys`).Scan(&enabled); err != nil || !enabled {
+		t.Errorf("Expected foreign keys to be enforced again, got %v (err=%v)", enabled, err)
+	}
+
+	// A rebuild that leaves dangling references is rolled back
+	fsys["migrations/0003_drop_notes.sql"] = &fstest.MapFile{Data: []byte(`
+		-- migrate:foreign-keys-off
+		DELETE FROM notes;`)}
+	m, _ = New(conn, dbPath, fsys, "migrations")
+	if _, err := m.Up(); err == nil {
+		t.Fatal("Expected the dangling tag to fail the migration")
+	}
+	var notes int
+	if err := conn.QueryRow(`SELECT COUNT(*) FROM notes`).Scan(&notes); err != nil || notes != 1 {
+		t.Errorf("Expected the failed migration to be rolled back, got %d notes (err=%v)", notes, err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/0002_second.sql": {Data: []byte(`SELECT 2;`)},
+		"migrations/0001_first.sql":  {Data: []byte(`SELECT 1;`)},
+	}
+	migrations, err := Load(fsys, "migrations")
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	if len(migrations) != 2 || migrations[0].Name != "first" || migrations[1].Version != 2 {
+		t.Errorf("Expected migrations ordered by version, got %+v", migrations)
+	}
+
+	fsys["migrations/2_duplicate.sql"] = &fstest.MapFile{Data: []byte(`SELECT 2;`)}
+	if _, err := Load(fsys, "migrations"); err == nil {
+		t.Error("Expected an error for duplicate versions")
+	}
+
+	delete(fsys, "migrations/2_duplicate.sql")
+	fsys["migrations/initial.sql"] = &fstest.MapFile{Data: []byte(`SELECT 1;`)}
+	if _, err := Load(fsys, "migrations"); err == nil {
+		t.Error("Expected an error for a file name without a version")
+	}
+}
@@ -4,4 +4,9 @@ go 1.25.4
 
 require github.com/mattn/go-sqlite3 v1.14.32
 
-require github.com/joho/godotenv v1.5.1
+require (
+	github.com/joho/godotenv v1.5.1
+	sqlite-migrate v0.0.0
+)
+
+replace sqlite-migrate => ../sqlite-migrate
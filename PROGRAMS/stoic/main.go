@@ -25,6 +25,12 @@ func main() {
 	// Environment variables set in the shell take precedence
 	_ = godotenv.Load()
 
+	// Schema migrations are managed with a subcommand: stoic-thought migrate [status|up]
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(getEnv("DB_PATH", defaultDBPath), os.Args[2:])
+		return
+	}
+
 	// CLI flags
 	regenerate := flag.Bool("regenerate", false, "Force regenerate today's thought")
 	dateFlag := flag.String("date", "", "Show thought from specific date (YYYY-MM-DD)")
@@ -134,6 +140,19 @@ func displayThought(date, thought string, createdAt time.Time, jsonFormat bool)
 	}
 }
 
+// runMigrate shows or applies the database schema migrations
+func runMigrate(dbPath string, args []string) {
+	database, err := db.Open(dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	if err := database.RunMigrateCLI(args); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+}
+
 // getEnv retrieves an environment variable or returns a default value
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
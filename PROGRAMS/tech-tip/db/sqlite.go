@@ -18,45 +18,36 @@ type Tip struct {
 
 type DB struct {
 	conn *sql.DB
+	path string
 }
 
-// New creates a new database connection and initializes the schema
+// New creates a new database connection and applies pending schema migrations
 func New(dbPath string) (*DB, error) {
-	conn, err := sql.Open("sqlite3", dbPath)
+	db, err := Open(dbPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+		return nil, err
 	}
 
-	if err := conn.Ping(); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %w", err)
-	}
-
-	db := &DB{conn: conn}
-	if err := db.initSchema(); err != nil {
-		return nil, fmt.Errorf("failed to initialize schema: %w", err)
+	if _, err := db.Migrate(); err != nil {
+		db.Close()
+		return nil, err
 	}
 
 	return db, nil
 }
 
-// initSchema creates the tips table if it doesn't exist
-func (db *DB) initSchema() error {
-	schema := `
-	CREATE TABLE IF NOT EXISTS tips (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		date TEXT UNIQUE NOT NULL,
-		tip TEXT NOT NULL,
-		category TEXT,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
-	`
-
-	_, err := db.conn.Exec(schema)
+// Open creates a new database connection without touching the schema
+func Open(dbPath string) (*DB, error) {
+	conn, err := sql.Open("sqlite3", dbPath)
 	if err != nil {
-		return fmt.Errorf("failed to create schema: %w", err)
+		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	return nil
+	if err := conn.Ping(); err != nil {
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return &DB{conn: conn, path: dbPath}, nil
 }
 
 // GetTipByDate retrieves a tip for a specific date (YYYY-MM-DD format)
@@ -25,6 +25,12 @@ func main() {
 	// Environment variables set in the shell take precedence
 	_ = godotenv.Load()
 
+	// Schema migrations are managed with a subcommand: tech-tip migrate [status|up]
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(getEnv("DB_PATH", defaultDBPath), os.Args[2:])
+		return
+	}
+
 	// CLI flags
 	regenerate := flag.Bool("regenerate", false, "Force regenerate today's tip")
 	dateFlag := flag.String("date", "", "Show tip from specific date (YYYY-MM-DD)")
@@ -162,6 +168,19 @@ func displayTip(date, tip, category string, createdAt time.Time, jsonFormat bool
 	}
 }
 
+// runMigrate shows or applies the database schema migrations
+func runMigrate(dbPath string, args []string) {
+	database, err := db.Open(dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+
+	if err := database.RunMigrateCLI(args); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+}
+
 // getEnv retrieves an environment variable or returns a default value
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {